@@ -10,17 +10,21 @@ import (
 	"time"
 
 	"github.com/hinphansa/7-solutions-challenge/api/gen/user/github.com/hinphansa/7-solutions-challenge/api/gen/user"
+	"github.com/hinphansa/7-solutions-challenge/cmd/internal/storage"
 	"github.com/hinphansa/7-solutions-challenge/config"
 	"github.com/hinphansa/7-solutions-challenge/internal/adapters/auth"
+	"github.com/hinphansa/7-solutions-challenge/internal/adapters/cache"
 	grpc_adapter "github.com/hinphansa/7-solutions-challenge/internal/adapters/grpc"
+	"github.com/hinphansa/7-solutions-challenge/internal/adapters/mailer"
 	mongo_repo "github.com/hinphansa/7-solutions-challenge/internal/adapters/mongo"
+	"github.com/hinphansa/7-solutions-challenge/internal/adapters/resilience"
 	"github.com/hinphansa/7-solutions-challenge/internal/ports"
 	"github.com/hinphansa/7-solutions-challenge/internal/services"
 	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
 	"github.com/sirupsen/logrus"
-	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -36,19 +40,64 @@ func main() {
 	if err != nil {
 		l.Fatalf("Failed to load config: %v", err)
 	}
+	settingsSchema, err := cfg.SettingsSchema()
+	if err != nil {
+		l.Fatalf("Invalid settings config: %v", err)
+	}
 
-	/* -------------------------------- Mongo DB -------------------------------- */
-	// mongo client
-	mongoClient, err := mongo.Connect(options.Client().ApplyURI(cfg.Mongo.URI))
+	/* --------------------------------- Storage -------------------------------- */
+	// repositories of the storage selected by storage.driver
+	store, err := storage.Open(ctx, cfg)
 	if err != nil {
-		l.Fatalf("Failed to connect to MongoDB: %v", err)
+		l.Fatalf("Failed to open the storage: %v", err)
 	}
-	// test connection
-	if err := mongoClient.Ping(ctx, nil); err != nil {
-		l.Fatalf("Failed to ping MongoDB: %v", err)
+	userRepo := store.Users
+
+	// timeouts, read retries and a circuit breaker around the storage, under the cache so hits are
+	// served while the circuit is open. Its state is reported by the gRPC health service and its
+	// counters are logged by the schedule job
+	healthServer := health.NewServer()
+	var userStorageStats func() resilience.Stats
+	if c := cfg.Storage.Resilience; c.Enabled {
+		guarded := resilience.NewUserRepository(userRepo,
+			resilience.WithTimeouts(time.Duration(c.ReadTimeout)*time.Millisecond, time.Duration(c.WriteTimeout)*time.Millisecond),
+			resilience.WithRetries(c.ReadAttempts, time.Duration(c.RetryBackoff)*time.Millisecond),
+			resilience.WithRetryable(store.Retryable),
+			resilience.WithBreaker(c.FailureThreshold, time.Duration(c.Cooldown)*time.Second),
+			resilience.WithStateChange(func(from, to resilience.State) {
+				l.Warnf("User storage circuit is %s, was %s", to, from)
+				status := grpc_health_v1.HealthCheckResponse_SERVING
+				if to == resilience.Open {
+					status = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+				}
+				healthServer.SetServingStatus("", status)
+			}),
+		)
+		userStorageStats = guarded.Stats
+		userRepo = guarded
+	}
+
+	// transactions of user operations, only in Mongo since the user repository must take part
+	var txManager ports.TxManager
+	if cfg.Mongo.Transactions {
+		if cfg.Storage.Driver == "mongo" {
+			txManager = mongo_repo.NewTxManager(store.MongoClient)
+		} else {
+			l.Warnf("Mongo transactions don't apply to the %s storage driver", cfg.Storage.Driver)
+		}
+	}
+
+	// cache of user lookups in front of the storage, its counters are logged by the schedule job
+	var userCacheStats func() cache.Stats
+	if c := cfg.Storage.Cache; c.Enabled {
+		userCache := cache.NewUserRepository(userRepo,
+			cache.WithSize(c.Size),
+			cache.WithTTL(time.Duration(c.TTL)*time.Second),
+			cache.WithNegativeTTL(time.Duration(c.NegativeTTL)*time.Second),
+		)
+		userCacheStats = userCache.Stats
+		userRepo = userCache
 	}
-	defer mongoClient.Disconnect(ctx)
-	mongoDB := mongoClient.Database(cfg.Mongo.DB)
 
 	/* -------------------------------- Cryptography ---------------------------- */
 	// cryptography service
@@ -57,23 +106,70 @@ func main() {
 
 	/* -------------------------------- User Service ---------------------------- */
 	// user service
-	userRepo := mongo_repo.NewUserRepository(mongoDB)
-	userService := services.NewUserService(userRepo, passwordHasher, tokenGenerator)
+	userMailer := mailer.New(cfg, l)
+	userService := services.NewUserService(userRepo, passwordHasher, tokenGenerator,
+		services.WithAttributeSchema(store.Attributes),
+		services.WithSettings(store.Settings),
+		services.WithGroups(store.Groups),
+		services.WithTransactions(txManager),
+		services.WithPageSize(cfg.Pagination.DefaultPageSize, cfg.Pagination.MaxPageSize),
+		services.WithPageTokenSecret(cfg.Pagination.TokenSecret),
+		services.WithEmailPolicy(cfg.Email.Policy()),
+		services.WithUsernames(cfg.Username.Policy(), time.Duration(cfg.Username.RedirectTTL)*time.Second),
+		services.WithRegistration(cfg.Registration.Policy()),
+		services.WithEmailChange(userMailer, services.EmailChangeConfig{
+			ConfirmURL: cfg.EmailChange.ConfirmURL,
+			RevertURL:  cfg.EmailChange.RevertURL,
+			ConfirmTTL: time.Duration(cfg.EmailChange.ConfirmTTL) * time.Second,
+			RevertTTL:  time.Duration(cfg.EmailChange.RevertTTL) * time.Second,
+		}),
+	)
+
+	// group service
+	groupService := services.NewGroupService(store.Groups, userRepo,
+		services.WithGroupPagination(cfg.Pagination.DefaultPageSize, cfg.Pagination.MaxPageSize, cfg.Pagination.TokenSecret),
+		services.WithGroupTransactions(txManager),
+	)
+
+	// invitation service
+	invitationService := services.NewInvitationService(store.Invitations, userRepo, userService, groupService, userMailer,
+		services.InvitationConfig{
+			AcceptURL: cfg.Invitations.AcceptURL,
+			TTL:       time.Duration(cfg.Invitations.TTL) * time.Second,
+		},
+		services.WithInvitationEmailPolicy(cfg.Email.Policy()),
+		services.WithInvitationPagination(cfg.Pagination.DefaultPageSize, cfg.Pagination.MaxPageSize, cfg.Pagination.TokenSecret),
+	)
 
 	// auth service
-	authService := services.NewAuthService(userRepo, passwordHasher, tokenGenerator)
+	authService := services.NewAuthService(userRepo, passwordHasher, tokenGenerator,
+		services.WithLoginEmailPolicy(cfg.Email.Policy()),
+		services.WithGroupRoles(groupService),
+	)
+
+	// organization service
+	organizationService := services.NewOrganizationService(store.Organizations, userRepo)
 
 	/* -------------------------------- gRPC Server ---------------------------- */
-	// create a new gRPC server with auth interceptor
+	// create a new gRPC server with auth interceptor, then the tenant interceptor scoping
+	// requests to the organization of their token or metadata
 	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(grpc_adapter.UnaryAuthInterceptor(tokenGenerator)),
+		grpc.ChainUnaryInterceptor(
+			grpc_adapter.UnaryAuthInterceptor(tokenGenerator, authService, cfg.Users.RequireAuthForListing),
+			grpc_adapter.UnaryTenantInterceptor(organizationService, cfg.Tenancy.DefaultOrganization),
+		),
 	)
 
 	// register reflection service
 	reflection.Register(grpcServer)
 
+	// register health service, not serving while the user storage circuit is open
+	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
+
 	// register user service
-	userServer := grpc_adapter.NewUserServer(l, userService, authService)
+	attributeService := services.NewAttributeService(store.Attributes)
+	settingsService := services.NewSettingsService(store.Settings, settingsSchema)
+	userServer := grpc_adapter.NewUserServer(l, userService, authService, attributeService, settingsService, organizationService, groupService, invitationService)
 	user.RegisterUserServiceServer(grpcServer, userServer)
 
 	// start gRPC server
@@ -92,7 +188,7 @@ func main() {
 	/* ------------------------------ Schedule Job ------------------------------ */
 	// This could be works with a cron job as well.
 	// I'm using a ticker since the job is not critical and low complexity.
-	schedule(ctx, l, userService, 10*time.Second)
+	schedule(ctx, l, userService, userCacheStats, userStorageStats, 10*time.Second)
 
 	/* ---------------------------- graceful shutdown --------------------------- */
 	quit := make(chan os.Signal, 1)
@@ -102,20 +198,22 @@ func main() {
 	l.Info("Shutting down server...")
 	time.Sleep(1 * time.Second) // just simulate some cleanup logic
 
-	// cleanup server
+	// cleanup server, health checks report it's going away first
+	healthServer.Shutdown()
 	grpcServer.GracefulStop()
 
-	// cleanup mongo client
-	if err := mongoClient.Disconnect(ctx); err != nil {
-		l.Errorf("Failed to disconnect from MongoDB: %v", err)
+	// cleanup storage
+	if err := store.Close(ctx); err != nil {
+		l.Errorf("Failed to close the storage: %v", err)
 	}
 
 	// cancel context, on this process, we could add some cleanup logic here if needed
 	cancel()
 }
 
-// Spawn a goroutine that runs every 10 seconds and logs the number of users in the DB
-func schedule(ctx context.Context, l logger.Logger, userService ports.UserService, period time.Duration) {
+// Spawn a goroutine that runs every 10 seconds and logs the number of users in the DB, across organizations,
+// and the counters of the user cache when there's one
+func schedule(ctx context.Context, l logger.Logger, userService ports.UserService, cacheStats func() cache.Stats, storageStats func() resilience.Stats, period time.Duration) {
 	ticker := time.NewTicker(period)
 	go func() {
 		defer ticker.Stop()
@@ -125,11 +223,21 @@ func schedule(ctx context.Context, l logger.Logger, userService ports.UserServic
 				l.Info("Stopping schedule")
 				return
 			case <-ticker.C:
-				users, err := userService.GetAll(ctx)
+				count, err := userService.Count(ports.AllTenants(ctx))
 				if err != nil {
-					l.Errorf("Failed to get all users: %v", err)
+					l.Errorf("Failed to count users: %v", err)
+				}
+				l.Infof("Number of users in the DB: %d", count)
+				if cacheStats != nil {
+					stats := cacheStats()
+					l.Infof("User cache: %d hits, %d negative hits, %d misses, %d loads, %d evictions, %d entries",
+						stats.Hits, stats.NegativeHits, stats.Misses, stats.Loads, stats.Evictions, stats.Entries)
+				}
+				if storageStats != nil {
+					stats := storageStats()
+					l.Infof("User storage: circuit %s, %d calls, %d failures, %d timeouts, %d retries, %d rejected, opened %d times",
+						stats.State, stats.Calls, stats.Failures, stats.Timeouts, stats.Retries, stats.Rejected, stats.Opens)
 				}
-				l.Infof("Number of users in the DB: %d", len(users))
 			}
 		}
 	}()
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"os"
 	"os/signal"
@@ -9,14 +10,20 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	fiberexpvar "github.com/gofiber/fiber/v2/middleware/expvar"
+	"github.com/gofiber/fiber/v2/middleware/healthcheck"
+	"github.com/hinphansa/7-solutions-challenge/cmd/internal/storage"
 	"github.com/hinphansa/7-solutions-challenge/config"
 	"github.com/hinphansa/7-solutions-challenge/internal/adapters/auth"
+	"github.com/hinphansa/7-solutions-challenge/internal/adapters/blob"
+	"github.com/hinphansa/7-solutions-challenge/internal/adapters/cache"
 	"github.com/hinphansa/7-solutions-challenge/internal/adapters/http"
+	"github.com/hinphansa/7-solutions-challenge/internal/adapters/mailer"
 	mongo_repo "github.com/hinphansa/7-solutions-challenge/internal/adapters/mongo"
+	"github.com/hinphansa/7-solutions-challenge/internal/adapters/resilience"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
 	"github.com/hinphansa/7-solutions-challenge/internal/ports"
 	"github.com/hinphansa/7-solutions-challenge/internal/services"
-	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 
 	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
 	"github.com/sirupsen/logrus"
@@ -34,19 +41,62 @@ func main() {
 	if err != nil {
 		l.Fatalf("Failed to load config: %v", err)
 	}
+	settingsSchema, err := cfg.SettingsSchema()
+	if err != nil {
+		l.Fatalf("Invalid settings config: %v", err)
+	}
 
-	/* -------------------------------- Mongo DB -------------------------------- */
-	// mongo client
-	mongoClient, err := mongo.Connect(options.Client().ApplyURI(cfg.Mongo.URI))
+	/* --------------------------------- Storage -------------------------------- */
+	// repositories of the storage selected by storage.driver
+	store, err := storage.Open(ctx, cfg)
 	if err != nil {
-		l.Fatalf("Failed to connect to MongoDB: %v", err)
+		l.Fatalf("Failed to open the storage: %v", err)
 	}
-	// test connection
-	if err := mongoClient.Ping(ctx, nil); err != nil {
-		l.Fatalf("Failed to ping MongoDB: %v", err)
+	userRepo := store.Users
+
+	// timeouts, read retries and a circuit breaker around the storage, under the cache so hits are
+	// served while the circuit is open. Its state is published as user_storage at /debug/vars and
+	// reported at /readyz
+	ready := func() bool { return true }
+	var userStorageStats func() resilience.Stats
+	if c := cfg.Storage.Resilience; c.Enabled {
+		guarded := resilience.NewUserRepository(userRepo,
+			resilience.WithTimeouts(time.Duration(c.ReadTimeout)*time.Millisecond, time.Duration(c.WriteTimeout)*time.Millisecond),
+			resilience.WithRetries(c.ReadAttempts, time.Duration(c.RetryBackoff)*time.Millisecond),
+			resilience.WithRetryable(store.Retryable),
+			resilience.WithBreaker(c.FailureThreshold, time.Duration(c.Cooldown)*time.Second),
+			resilience.WithStateChange(func(from, to resilience.State) {
+				l.Warnf("User storage circuit is %s, was %s", to, from)
+			}),
+		)
+		ready = guarded.Ready
+		userStorageStats = guarded.Stats
+		expvar.Publish("user_storage", expvar.Func(func() any { return guarded.Stats() }))
+		userRepo = guarded
+	}
+
+	// transactions of user operations, only in Mongo since the user repository must take part
+	var txManager ports.TxManager
+	if cfg.Mongo.Transactions {
+		if cfg.Storage.Driver == "mongo" {
+			txManager = mongo_repo.NewTxManager(store.MongoClient)
+		} else {
+			l.Warnf("Mongo transactions don't apply to the %s storage driver", cfg.Storage.Driver)
+		}
+	}
+
+	// cache of user lookups in front of the storage, its counters are published as user_cache at /debug/vars
+	var userCacheStats func() cache.Stats
+	if c := cfg.Storage.Cache; c.Enabled {
+		userCache := cache.NewUserRepository(userRepo,
+			cache.WithSize(c.Size),
+			cache.WithTTL(time.Duration(c.TTL)*time.Second),
+			cache.WithNegativeTTL(time.Duration(c.NegativeTTL)*time.Second),
+		)
+		userCacheStats = userCache.Stats
+		expvar.Publish("user_cache", expvar.Func(func() any { return userCache.Stats() }))
+		userRepo = userCache
 	}
-	defer mongoClient.Disconnect(ctx)
-	mongoDB := mongoClient.Database(cfg.Mongo.DB)
 
 	/* -------------------------------- Cryptography ---------------------------- */
 	// cryptography service
@@ -55,30 +105,118 @@ func main() {
 
 	/* -------------------------------- User Service ---------------------------- */
 	// user service
-	userRepo := mongo_repo.NewUserRepository(mongoDB)
-	userService := services.NewUserService(userRepo, passwordHasher, tokenGenerator)
+	userMailer := mailer.New(cfg, l)
+	userService := services.NewUserService(userRepo, passwordHasher, tokenGenerator,
+		services.WithAttributeSchema(store.Attributes),
+		services.WithSettings(store.Settings),
+		services.WithGroups(store.Groups),
+		services.WithTransactions(txManager),
+		services.WithPageSize(cfg.Pagination.DefaultPageSize, cfg.Pagination.MaxPageSize),
+		services.WithPageTokenSecret(cfg.Pagination.TokenSecret),
+		services.WithEmailPolicy(cfg.Email.Policy()),
+		services.WithUsernames(cfg.Username.Policy(), time.Duration(cfg.Username.RedirectTTL)*time.Second),
+		services.WithRegistration(cfg.Registration.Policy()),
+		services.WithEmailChange(userMailer, services.EmailChangeConfig{
+			ConfirmURL: cfg.EmailChange.ConfirmURL,
+			RevertURL:  cfg.EmailChange.RevertURL,
+			ConfirmTTL: time.Duration(cfg.EmailChange.ConfirmTTL) * time.Second,
+			RevertTTL:  time.Duration(cfg.EmailChange.RevertTTL) * time.Second,
+		}),
+	)
 
 	// user handler
 	userHandler := http.NewUserHandler(l, userService)
 
+	/* ------------------------------- Group Service ---------------------------- */
+	// group service
+	groupService := services.NewGroupService(store.Groups, userRepo,
+		services.WithGroupPagination(cfg.Pagination.DefaultPageSize, cfg.Pagination.MaxPageSize, cfg.Pagination.TokenSecret),
+		services.WithGroupTransactions(txManager),
+	)
+
 	/* -------------------------------- Auth Service ---------------------------- */
 	// auth service
-	authService := services.NewAuthService(userRepo, passwordHasher, tokenGenerator)
+	authService := services.NewAuthService(userRepo, passwordHasher, tokenGenerator,
+		services.WithLoginEmailPolicy(cfg.Email.Policy()),
+		services.WithGroupRoles(groupService),
+	)
 
 	// auth handler
 	authHandler := http.NewAuthHandler(l, authService, userHandler)
 
+	/* ----------------------------- Attribute Service -------------------------- */
+	// attribute service
+	attributeService := services.NewAttributeService(store.Attributes)
+
+	// attribute handler
+	attributeHandler := http.NewAttributeHandler(l, attributeService)
+
+	/* ----------------------------- Settings Service --------------------------- */
+	// settings service
+	settingsService := services.NewSettingsService(store.Settings, settingsSchema)
+
+	// settings handler
+	settingsHandler := http.NewSettingsHandler(l, settingsService)
+
+	/* ------------------------------ Avatar Service ---------------------------- */
+	// blob store
+	blobStore, err := blob.New(cfg)
+	if err != nil {
+		l.Fatalf("Failed to create blob store: %v", err)
+	}
+
+	// avatar service
+	avatarService := services.NewAvatarService(userRepo, blobStore,
+		services.WithAvatarLimits(cfg.Avatar.MaxBytes, cfg.Avatar.Sizes),
+	)
+
+	// avatar handler
+	avatarHandler := http.NewAvatarHandler(l, avatarService, cfg.Avatar.MaxBytes)
+
+	/* --------------------------- Organization Service ------------------------- */
+	// organization service
+	organizationService := services.NewOrganizationService(store.Organizations, userRepo)
+
+	// organization handler
+	organizationHandler := http.NewOrganizationHandler(l, organizationService)
+
+	// group handler
+	groupHandler := http.NewGroupHandler(l, groupService)
+
+	/* ---------------------------- Invitation Service -------------------------- */
+	// invitation service
+	invitationService := services.NewInvitationService(store.Invitations, userRepo, userService, groupService, userMailer,
+		services.InvitationConfig{
+			AcceptURL: cfg.Invitations.AcceptURL,
+			TTL:       time.Duration(cfg.Invitations.TTL) * time.Second,
+		},
+		services.WithInvitationEmailPolicy(cfg.Email.Policy()),
+		services.WithInvitationPagination(cfg.Pagination.DefaultPageSize, cfg.Pagination.MaxPageSize, cfg.Pagination.TokenSecret),
+	)
+
+	// invitation handler
+	invitationHandler := http.NewInvitationHandler(l, invitationService)
+
 	/* -------------------------------- Fiber app ------------------------------- */
 
-	// create a new fiber app
-	app := fiber.New()
+	// create a new fiber app, bodies must fit an avatar upload and its multipart framing
+	app := fiber.New(fiber.Config{
+		BodyLimit: max(fiber.DefaultBodyLimit, int(cfg.Avatar.MaxBytes)+64<<10),
+	})
 
 	// apply general middlewares
+	// serves the expvar counters at /debug/vars, to admins only
+	app.Use("/debug/vars", http.AuthMiddleware(cfg.JWT.Secret, authService), http.RequireRole(domain.RoleAdmin), fiberexpvar.New())
+	// serves /livez, and /readyz failing while the user storage circuit is open
+	app.Use(healthcheck.New(healthcheck.Config{
+		ReadinessProbe: func(*fiber.Ctx) bool { return ready() },
+	}))
 	app.Use(http.RequestIdMiddleware())
 	app.Use(http.LoggerMiddleware())
+	app.Use(http.TenantMiddleware(organizationService, cfg.Tenancy.BaseDomain, cfg.Tenancy.DefaultOrganization))
 
 	// setup routes
-	http.SetupRoutes(app, cfg, userHandler, authHandler)
+	http.SetupRoutes(app, cfg, userHandler, authHandler, attributeHandler, settingsHandler, avatarHandler, organizationHandler, groupHandler, invitationHandler)
 
 	go func() {
 		if err := app.Listen(fmt.Sprintf(":%d", cfg.HttpServer.Port)); err != nil {
@@ -89,7 +227,7 @@ func main() {
 	/* ------------------------------ Schedule Job ------------------------------ */
 	// This could be works with a cron job as well.
 	// I'm using a ticker since the job is not critical and low complexity.
-	schedule(ctx, l, userService, 10*time.Second)
+	schedule(ctx, l, userService, userCacheStats, userStorageStats, 10*time.Second)
 
 	/* ---------------------------- graceful shutdown --------------------------- */
 
@@ -106,17 +244,18 @@ func main() {
 		l.Errorf("Failed to shutdown server: %v", err)
 	}
 
-	// cleanup mongo client
-	if err := mongoClient.Disconnect(ctx); err != nil {
-		l.Errorf("Failed to disconnect from MongoDB: %v", err)
+	// cleanup storage
+	if err := store.Close(ctx); err != nil {
+		l.Errorf("Failed to close the storage: %v", err)
 	}
 
 	// cancel context, on this process, we could add some cleanup logic here if needed
 	cancel()
 }
 
-// Spawn a goroutine that runs every 10 seconds and logs the number of users in the DB
-func schedule(ctx context.Context, l logger.Logger, userService ports.UserService, period time.Duration) {
+// Spawn a goroutine that runs every 10 seconds and logs the number of users in the DB, across organizations,
+// and the counters of the user cache when there's one
+func schedule(ctx context.Context, l logger.Logger, userService ports.UserService, cacheStats func() cache.Stats, storageStats func() resilience.Stats, period time.Duration) {
 	ticker := time.NewTicker(period)
 	go func() {
 		defer ticker.Stop()
@@ -126,11 +265,21 @@ func schedule(ctx context.Context, l logger.Logger, userService ports.UserServic
 				l.Info("Stopping schedule")
 				return
 			case <-ticker.C:
-				users, err := userService.GetAll(ctx)
+				count, err := userService.Count(ports.AllTenants(ctx))
 				if err != nil {
-					l.Errorf("Failed to get all users: %v", err)
+					l.Errorf("Failed to count users: %v", err)
+				}
+				l.Infof("Number of users in the DB: %d", count)
+				if cacheStats != nil {
+					stats := cacheStats()
+					l.Infof("User cache: %d hits, %d negative hits, %d misses, %d loads, %d evictions, %d entries",
+						stats.Hits, stats.NegativeHits, stats.Misses, stats.Loads, stats.Evictions, stats.Entries)
+				}
+				if storageStats != nil {
+					stats := storageStats()
+					l.Infof("User storage: circuit %s, %d calls, %d failures, %d timeouts, %d retries, %d rejected, opened %d times",
+						stats.State, stats.Calls, stats.Failures, stats.Timeouts, stats.Retries, stats.Rejected, stats.Opens)
 				}
-				l.Infof("Number of users in the DB: %d", len(users))
 			}
 		}
 	}()
@@ -0,0 +1,103 @@
+// Package storage opens the storage the servers run on, selected by storage.driver.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/config"
+	mongo_repo "github.com/hinphansa/7-solutions-challenge/internal/adapters/mongo"
+	"github.com/hinphansa/7-solutions-challenge/internal/adapters/postgres"
+	"github.com/hinphansa/7-solutions-challenge/internal/adapters/resilience"
+	"github.com/hinphansa/7-solutions-challenge/internal/adapters/sqlite"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Storage holds the repositories of the selected storage, all kept in it
+type Storage struct {
+	Users         ports.UserRepository
+	Attributes    ports.AttributeSchemaRepository
+	Settings      ports.SettingsRepository
+	Groups        ports.GroupRepository
+	Organizations ports.OrganizationRepository
+	Invitations   ports.InvitationRepository
+
+	// Retryable reports which errors of the driver are worth retrying a read for
+	Retryable func(err error) bool
+	// MongoClient is the client of the mongo driver, nil with the others
+	MongoClient *mongo.Client
+
+	close func(ctx context.Context) error
+}
+
+// Open connects to the storage of cfg.Storage.Driver, Mongo when it's not set. Mongo is
+// only connected to with the mongo driver.
+func Open(ctx context.Context, cfg *config.Config) (*Storage, error) {
+	switch cfg.Storage.Driver {
+	case "sqlite":
+		db, err := sqlite.Open(ctx, cfg.Storage.SQLite.Path)
+		if err != nil {
+			return nil, fmt.Errorf("open SQLite: %w", err)
+		}
+		return &Storage{
+			Users:         sqlite.NewUserRepository(db),
+			Attributes:    sqlite.NewAttributeRepository(db),
+			Settings:      sqlite.NewSettingsRepository(db),
+			Groups:        sqlite.NewGroupRepository(db),
+			Organizations: sqlite.NewOrganizationRepository(db),
+			Invitations:   sqlite.NewInvitationRepository(db),
+			Retryable:     resilience.IsTransient,
+			close:         func(context.Context) error { return db.Close() },
+		}, nil
+	case "postgres":
+		pg := cfg.Storage.Postgres
+		pool, err := postgres.Open(ctx, pg.URI, postgres.PoolConfig{
+			MaxConns:        pg.MaxConns,
+			MinConns:        pg.MinConns,
+			MaxConnLifetime: time.Duration(pg.MaxConnLifetime) * time.Second,
+			MaxConnIdleTime: time.Duration(pg.MaxConnIdleTime) * time.Second,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("open PostgreSQL: %w", err)
+		}
+		return &Storage{
+			Users:         postgres.NewUserRepository(pool),
+			Attributes:    postgres.NewAttributeRepository(pool),
+			Settings:      postgres.NewSettingsRepository(pool),
+			Groups:        postgres.NewGroupRepository(pool),
+			Organizations: postgres.NewOrganizationRepository(pool),
+			Invitations:   postgres.NewInvitationRepository(pool),
+			Retryable:     postgres.IsTransient,
+			close:         func(context.Context) error { pool.Close(); return nil },
+		}, nil
+	default:
+		client, err := mongo.Connect(options.Client().ApplyURI(cfg.Mongo.URI))
+		if err != nil {
+			return nil, fmt.Errorf("connect to MongoDB: %w", err)
+		}
+		// test connection
+		if err := client.Ping(ctx, nil); err != nil {
+			return nil, fmt.Errorf("ping MongoDB: %w", err)
+		}
+		db := client.Database(cfg.Mongo.DB)
+		return &Storage{
+			Users:         mongo_repo.NewUserRepository(db),
+			Attributes:    mongo_repo.NewAttributeRepository(db),
+			Settings:      mongo_repo.NewSettingsRepository(db),
+			Groups:        mongo_repo.NewGroupRepository(db),
+			Organizations: mongo_repo.NewOrganizationRepository(db),
+			Invitations:   mongo_repo.NewInvitationRepository(db),
+			Retryable:     mongo_repo.IsTransient,
+			MongoClient:   client,
+			close:         client.Disconnect,
+		}, nil
+	}
+}
+
+// Close disconnects from the storage
+func (s *Storage) Close(ctx context.Context) error {
+	return s.close(ctx)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// reportEmailDuplicates finds users whose emails have the same canonical form, e.g. Foo@X.com
+// and foo@x.com, in the same organization, and users whose stored email isn't canonical. Those were created before
+// emails were canonicalized and can't log in or be updated until they're merged or fixed.
+// It returns the number of duplicate groups.
+func reportEmailDuplicates(ctx context.Context, log logger.Logger, db *mongo.Database, policy domain.EmailPolicy) (int, error) {
+	cursor, err := db.Collection("users").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"tenant_id": 1, "email": 1}))
+	if err != nil {
+		log.Error("Failed to read user emails")
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	type stored struct {
+		ID       bson.ObjectID `bson:"_id"`
+		TenantID bson.ObjectID `bson:"tenant_id"`
+		Email    string        `bson:"email"`
+	}
+	// emails are unique per organization
+	type key struct {
+		tenantID bson.ObjectID
+		email    domain.Email
+	}
+	var (
+		groups       = map[key][]stored{}
+		nonCanonical int
+	)
+	for cursor.Next(ctx) {
+		var user stored
+		if err := cursor.Decode(&user); err != nil {
+			return 0, err
+		}
+		email, err := domain.ParseEmail(user.Email, policy)
+		if err != nil {
+			log.WithFields(logrus.Fields{"id": user.ID.Hex(), "email": user.Email}).Warn("invalid email")
+			continue
+		}
+		if email.String() != user.Email {
+			nonCanonical++
+			log.WithFields(logrus.Fields{"id": user.ID.Hex(), "email": user.Email, "canonical": email}).Warn("email isn't canonical")
+		}
+		k := key{tenantID: user.TenantID, email: email}
+		groups[k] = append(groups[k], user)
+	}
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+
+	var duplicates []key
+	for k, users := range groups {
+		if len(users) > 1 {
+			duplicates = append(duplicates, k)
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].tenantID != duplicates[j].tenantID {
+			return duplicates[i].tenantID.Hex() < duplicates[j].tenantID.Hex()
+		}
+		return duplicates[i].email < duplicates[j].email
+	})
+
+	for _, k := range duplicates {
+		accounts := make([]string, len(groups[k]))
+		for i, user := range groups[k] {
+			accounts[i] = user.ID.Hex() + " " + user.Email
+		}
+		log.WithFields(logrus.Fields{"tenant_id": k.tenantID.Hex(), "canonical": k.email, "users": accounts}).Warn("duplicate email")
+	}
+	log.Infof("found %d duplicate emails and %d non-canonical emails", len(duplicates), nonCanonical)
+	return len(duplicates), nil
+}
+
+// canonicalizeEmails stores the canonical form of the emails of users created before emails
+// were canonicalized, so they comply with the schema. Emails that are invalid, or that are
+// the same as another one of the organization once canonicalized, are left for an operator
+// to fix, see reportEmailDuplicates, and fail the migration before the schema is applied.
+func canonicalizeEmails(ctx context.Context, log logger.Logger, db *mongo.Database, policy domain.EmailPolicy) error {
+	coll := db.Collection("users")
+	cursor, err := coll.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"tenant_id": 1, "email": 1}))
+	if err != nil {
+		log.Error("Failed to read user emails")
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	type stored struct {
+		ID        bson.ObjectID `bson:"_id"`
+		TenantID  bson.ObjectID `bson:"tenant_id"`
+		Email     string        `bson:"email"`
+		canonical domain.Email
+	}
+	type key struct {
+		tenantID bson.ObjectID
+		email    domain.Email
+	}
+	var (
+		groups     = map[key][]stored{}
+		unresolved int
+	)
+	for cursor.Next(ctx) {
+		var user stored
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		user.canonical, err = domain.ParseEmail(user.Email, policy)
+		if err != nil {
+			unresolved++
+			log.WithFields(logrus.Fields{"id": user.ID.Hex(), "email": user.Email}).Warn("invalid email")
+			continue
+		}
+		k := key{tenantID: user.TenantID, email: user.canonical}
+		groups[k] = append(groups[k], user)
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	var updated int
+	for _, users := range groups {
+		if len(users) > 1 {
+			unresolved += len(users)
+			continue
+		}
+		user := users[0]
+		if user.canonical.String() == user.Email {
+			continue
+		}
+		// the search trigrams are derived from the email, backfillSearchTrigrams recomputes them
+		_, err := coll.UpdateByID(ctx, user.ID, bson.M{
+			"$set":   bson.M{"email": user.canonical.String(), "email_domain": user.canonical.Domain()},
+			"$unset": bson.M{"search": ""},
+		})
+		if err != nil {
+			log.Error("Failed to canonicalize email")
+			return err
+		}
+		updated++
+	}
+	log.Infof("canonicalized the email of %d users", updated)
+	if unresolved > 0 {
+		return fmt.Errorf("%d users have invalid emails or emails colliding once canonicalized, list them with -report-email-duplicates and fix them before migrating", unresolved)
+	}
+	return nil
+}
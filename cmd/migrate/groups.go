@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+
+	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ensureGroups creates the groups, group_members and group_locks collections and their
+// indexes. Group names are unique per organization case-insensitively, a member is in a
+// group at most once. group_locks is created up front as transactions write it.
+func ensureGroups(ctx context.Context, log logger.Logger, db *mongo.Database) error {
+	for _, name := range []string{"groups", "group_members", "group_locks"} {
+		if err := db.CreateCollection(ctx, name); err != nil && !isNamespaceExists(err) {
+			log.Errorf("Failed to create %s collection: %v", name, err)
+			return err
+		}
+	}
+
+	_, err := db.Collection("groups").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("uniq_tenant_group_name").
+			SetCollation(&options.Collation{Locale: "en", Strength: 2}),
+	})
+	if err != nil {
+		log.Error("Failed to create group indexes")
+		return err
+	}
+
+	_, err = db.Collection("group_members").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// members of a group, listed by member id
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "group_id", Value: 1}, {Key: "member_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_group_member"),
+		},
+		{
+			// groups of a member, walked up for nested groups
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "member_id", Value: 1}},
+			Options: options.Index().SetName("tenant_member"),
+		},
+	})
+	if err != nil {
+		log.Error("Failed to create group member indexes")
+	}
+	return err
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+
+	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ensureInvitations creates the invitations collection, an email has at most one pending
+// invitation per organization
+func ensureInvitations(ctx context.Context, log logger.Logger, db *mongo.Database) error {
+	if err := db.CreateCollection(ctx, "invitations"); err != nil && !isNamespaceExists(err) {
+		log.Errorf("Failed to create invitations collection: %v", err)
+		return err
+	}
+	_, err := db.Collection("invitations").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("uniq_tenant_invitation_email"),
+	})
+	if err != nil {
+		log.Error("Failed to create invitation indexes")
+	}
+	return err
+}
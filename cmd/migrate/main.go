@@ -3,8 +3,13 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
+	"fmt"
 	"os"
 
+	"github.com/hinphansa/7-solutions-challenge/config"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/search"
 	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -13,6 +18,12 @@ import (
 )
 
 func main() {
+	reportEmails := flag.Bool("report-email-duplicates", false, "only report users whose emails are the same once canonicalized, exit 1 if any")
+	grantAdmin := flag.String("grant-admin", "", "only grant the admin role to the user with this email")
+	grantSuperAdmin := flag.String("grant-superadmin", "", "only grant the superadmin role to the user with this email")
+	tenant := flag.String("tenant", "", "organization of the user to grant a role to, by slug, defaults to tenancy.default_organization")
+	flag.Parse()
+
 	log := logger.New(logrus.DebugLevel).WithFields(logrus.Fields{
 		"service": "migrate",
 	})
@@ -27,6 +38,11 @@ func main() {
 	}
 	ctx := context.Background()
 
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	client, err := mongo.Connect(options.Client().ApplyURI(mongoURI))
 	if err != nil {
 		log.Error("Failed to connect to MongoDB")
@@ -35,10 +51,78 @@ func main() {
 	defer client.Disconnect(ctx)
 
 	db := client.Database(mongoDBName)
+	if *reportEmails {
+		duplicates, err := reportEmailDuplicates(ctx, log, db, cfg.Email.Policy())
+		if err != nil {
+			log.Fatal(err)
+		}
+		if duplicates > 0 {
+			client.Disconnect(ctx)
+			os.Exit(1)
+		}
+		return
+	}
+	if *grantAdmin != "" || *grantSuperAdmin != "" {
+		slug := *tenant
+		if slug == "" {
+			slug = cfg.Tenancy.DefaultOrganization
+		}
+		tenantID, err := organizationID(ctx, db, slug)
+		if err != nil {
+			log.Fatal(err)
+		}
+		email, role := *grantAdmin, domain.RoleAdmin
+		if *grantSuperAdmin != "" {
+			email, role = *grantSuperAdmin, domain.RoleSuperAdmin
+		}
+		if err := grantRole(ctx, log, db, tenantID, email, role, cfg.Email.Policy()); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := ensureOrganizations(ctx, log, db); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Tenancy.DefaultOrganization != "" {
+		tenantID, err := ensureDefaultOrganization(ctx, log, db, cfg.Tenancy.DefaultOrganization)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := backfillTenant(ctx, log, db, tenantID); err != nil {
+			log.Fatal(err)
+		}
+	} else if err := checkTenants(ctx, log, db); err != nil {
+		log.Fatal(err)
+	}
+
+	// every backfill runs before the schema is applied, the strict validator rejects updates
+	// of documents that don't comply with it yet
+	if err := canonicalizeEmails(ctx, log, db, cfg.Email.Policy()); err != nil {
+		log.Fatal(err)
+	}
+	if err := backfillStatus(ctx, log, db); err != nil {
+		log.Fatal(err)
+	}
+	if err := backfillUpdatedAt(ctx, log, db); err != nil {
+		log.Fatal(err)
+	}
+	if err := backfillEmailDomain(ctx, log, db); err != nil {
+		log.Fatal(err)
+	}
+	if err := backfillSearchTrigrams(ctx, log, db); err != nil {
+		log.Fatal(err)
+	}
 	if err := ensureUserCollection(ctx, log, db); err != nil {
 		log.Error("Failed to ensure user collection")
 		log.Fatal(err)
 	}
+	if err := ensureGroups(ctx, log, db); err != nil {
+		log.Fatal(err)
+	}
+	if err := ensureInvitations(ctx, log, db); err != nil {
+		log.Fatal(err)
+	}
 
 	log.Info("migration completed")
 }
@@ -77,16 +161,176 @@ func ensureUserCollection(ctx context.Context, log logger.Logger, db *mongo.Data
 		return res.Err()
 	}
 
-	// Create unique index on email
-	idx := mongo.IndexModel{
-		Keys:    bson.D{{Key: "email", Value: 1}},
-		Options: options.Index().SetUnique(true).SetName("uniq_email"),
+	// Create unique indexes on email and username, (created_at, _id) index for keyset pagination,
+	// and case-insensitive indexes backing list filters and sorts. Listing queries run with
+	// the same collation, Mongo only uses an index for string comparisons when they match.
+	// Every query is scoped to an organization, so every index starts with tenant_id.
+	caseInsensitive := &options.Collation{Locale: "en", Strength: 2}
+	tenantKeys := func(keys ...string) bson.D {
+		d := bson.D{{Key: "tenant_id", Value: 1}}
+		for _, key := range keys {
+			d = append(d, bson.E{Key: key, Value: 1})
+		}
+		return d
+	}
+	idxs := []mongo.IndexModel{
+		{
+			Keys:    tenantKeys("email"),
+			Options: options.Index().SetUnique(true).SetName("uniq_tenant_email"),
+		},
+		{
+			// usernames are optional, only users having one are indexed
+			Keys: tenantKeys("username"),
+			Options: options.Index().SetUnique(true).SetName("uniq_tenant_username").
+				SetPartialFilterExpression(bson.M{"username": bson.M{"$type": "string"}}),
+		},
+		{
+			Keys:    tenantKeys("previous_usernames.username"),
+			Options: options.Index().SetName("tenant_previous_usernames"),
+		},
+		{
+			Keys:    tenantKeys("created_at", "_id"),
+			Options: options.Index().SetName("tenant_created_at_id"),
+		},
+		{
+			Keys:    tenantKeys("name", "_id"),
+			Options: options.Index().SetName("tenant_name_ci").SetCollation(caseInsensitive),
+		},
+		{
+			Keys:    tenantKeys("email", "_id"),
+			Options: options.Index().SetName("tenant_email_ci").SetCollation(caseInsensitive),
+		},
+		{
+			Keys:    tenantKeys("email_domain", "created_at", "_id"),
+			Options: options.Index().SetName("tenant_email_domain_created_at_ci").SetCollation(caseInsensitive),
+		},
+		{
+			Keys:    tenantKeys("status", "created_at", "_id"),
+			Options: options.Index().SetName("tenant_status_created_at"),
+		},
+		{
+			Keys:    tenantKeys("search.name"),
+			Options: options.Index().SetName("tenant_search_name"),
+		},
+		{
+			Keys:    tenantKeys("search.email"),
+			Options: options.Index().SetName("tenant_search_email"),
+		},
+		{
+			// whole-word search over names and emails, ranked by text score. No language,
+			// names aren't stemmed and have no stop words.
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "name", Value: "text"}, {Key: "email", Value: "text"}},
+			Options: options.Index().SetName("tenant_search_text").SetDefaultLanguage("none"),
+		},
+	}
+	_, err = db.Collection(collectionName).Indexes().CreateMany(ctx, idxs)
+	if err != nil {
+		log.Error("Failed to create indexes")
+		return err
+	}
+	// dropped once their replacements exist, so uniqueness is enforced throughout
+	return dropIndexes(ctx, log, db.Collection(collectionName), legacyUserIndexes)
+}
+
+// backfillEmailDomain derives email_domain for users created before it was stored
+func backfillEmailDomain(ctx context.Context, log logger.Logger, db *mongo.Database) error {
+	res, err := db.Collection("users").UpdateMany(ctx,
+		bson.M{"email_domain": bson.M{"$exists": false}},
+		bson.A{bson.M{"$set": bson.M{
+			"email_domain": bson.M{"$arrayElemAt": bson.A{bson.M{"$split": bson.A{"$email", "@"}}, 1}},
+		}}},
+	)
+	if err != nil {
+		log.Error("Failed to backfill email domain")
+		return err
 	}
-	_, err = db.Collection(collectionName).Indexes().CreateOne(ctx, idx)
+	log.Infof("backfilled email domain of %d users", res.ModifiedCount)
+	return nil
+}
+
+// backfillStatus makes users created before account statuses existed active
+func backfillStatus(ctx context.Context, log logger.Logger, db *mongo.Database) error {
+	res, err := db.Collection("users").UpdateMany(ctx,
+		bson.M{"status": bson.M{"$exists": false}},
+		bson.A{bson.M{"$set": bson.M{"status": domain.StatusActive, "status_changed_at": "$created_at"}}},
+	)
 	if err != nil {
-		log.Error("Failed to create unique index on email")
+		log.Error("Failed to backfill status")
+		return err
+	}
+	log.Infof("backfilled status of %d users", res.ModifiedCount)
+	return nil
+}
+
+// backfillUpdatedAt sets updated_at of users created before it was stored to their creation time
+func backfillUpdatedAt(ctx context.Context, log logger.Logger, db *mongo.Database) error {
+	res, err := db.Collection("users").UpdateMany(ctx,
+		bson.M{"updated_at": bson.M{"$exists": false}},
+		bson.A{bson.M{"$set": bson.M{"updated_at": "$created_at"}}},
+	)
+	if err != nil {
+		log.Error("Failed to backfill updated_at")
+		return err
+	}
+	log.Infof("backfilled updated_at of %d users", res.ModifiedCount)
+	return nil
+}
+
+// grantRole adds a role to the user with email in the organization with tenantID, roles
+// are carried by tokens issued afterwards
+func grantRole(ctx context.Context, log logger.Logger, db *mongo.Database, tenantID bson.ObjectID, email, role string, policy domain.EmailPolicy) error {
+	canonical, err := domain.ParseEmail(email, policy)
+	if err != nil {
+		return err
+	}
+	res, err := db.Collection("users").UpdateOne(ctx,
+		bson.M{"tenant_id": tenantID, "email": canonical.String()},
+		bson.M{"$addToSet": bson.M{"roles": role}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("no user with email %s", canonical)
+	}
+	log.Infof("granted %s to %s, it applies from their next login", role, canonical)
+	return nil
+}
+
+// backfillSearchTrigrams stores the search trigrams of users created before search existed.
+// Trigrams are computed in Go, the same way the repository computes them on write.
+func backfillSearchTrigrams(ctx context.Context, log logger.Logger, db *mongo.Database) error {
+	coll := db.Collection("users")
+	cursor, err := coll.Find(ctx, bson.M{"search": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"name": 1, "email": 1}))
+	if err != nil {
+		log.Error("Failed to find users to backfill search trigrams")
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var updated int
+	for cursor.Next(ctx) {
+		var user struct {
+			ID    bson.ObjectID `bson:"_id"`
+			Name  string        `bson:"name"`
+			Email string        `bson:"email"`
+		}
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		trigrams := bson.M{"name": search.Trigrams(user.Name), "email": search.Trigrams(user.Email)}
+		if _, err := coll.UpdateByID(ctx, user.ID, bson.M{"$set": bson.M{"search": trigrams}}); err != nil {
+			log.Error("Failed to backfill search trigrams")
+			return err
+		}
+		updated++
+	}
+	if err := cursor.Err(); err != nil {
+		return err
 	}
-	return err
+	log.Infof("backfilled search trigrams of %d users", updated)
+	return nil
 }
 
 func isNamespaceExists(err error) bool {
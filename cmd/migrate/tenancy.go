@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// legacyUserIndexes were unique or sorted across organizations, ensureUserCollection
+// replaces them by indexes prefixed with tenant_id
+var legacyUserIndexes = []string{
+	"uniq_email", "uniq_username", "previous_usernames", "created_at_id", "name_ci", "email_ci",
+	"email_domain_created_at_ci", "status_created_at", "search_name", "search_email",
+}
+
+// ensureOrganizations creates the organizations collection and its unique slug index
+func ensureOrganizations(ctx context.Context, log logger.Logger, db *mongo.Database) error {
+	err := db.CreateCollection(ctx, "organizations")
+	if err != nil && !isNamespaceExists(err) {
+		log.Errorf("Failed to create organizations collection: %v", err)
+		return err
+	}
+	_, err = db.Collection("organizations").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "slug", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("uniq_slug"),
+	})
+	if err != nil {
+		log.Error("Failed to create organization indexes")
+	}
+	return err
+}
+
+// ensureDefaultOrganization creates the organization with slug unless it exists, and
+// returns its id. Users created before organizations are moved to it.
+func ensureDefaultOrganization(ctx context.Context, log logger.Logger, db *mongo.Database, slug string) (bson.ObjectID, error) {
+	slug, err := domain.ParseSlug(slug)
+	if err != nil {
+		return bson.ObjectID{}, err
+	}
+	now := time.Now()
+	_, err = db.Collection("organizations").UpdateOne(ctx,
+		bson.M{"slug": slug},
+		bson.M{"$setOnInsert": bson.M{"name": slug, "created_at": now, "updated_at": now}},
+		options.UpdateOne().SetUpsert(true),
+	)
+	if err != nil {
+		log.Error("Failed to create the default organization")
+		return bson.ObjectID{}, err
+	}
+	return organizationID(ctx, db, slug)
+}
+
+// organizationID returns the id of the organization with slug
+func organizationID(ctx context.Context, db *mongo.Database, slug string) (bson.ObjectID, error) {
+	var org domain.Organization
+	err := db.Collection("organizations").FindOne(ctx, bson.M{"slug": slug}).Decode(&org)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return bson.ObjectID{}, fmt.Errorf("no organization with slug %s", slug)
+	}
+	return org.ID, err
+}
+
+// backfillTenant moves users and settings created before organizations to the organization
+// with tenantID
+func backfillTenant(ctx context.Context, log logger.Logger, db *mongo.Database, tenantID bson.ObjectID) error {
+	for _, collectionName := range []string{"users", "user_settings"} {
+		res, err := db.Collection(collectionName).UpdateMany(ctx,
+			bson.M{"tenant_id": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"tenant_id": tenantID}},
+		)
+		if err != nil {
+			log.Errorf("Failed to backfill tenant of %s", collectionName)
+			return err
+		}
+		log.Infof("backfilled tenant of %d %s", res.ModifiedCount, collectionName)
+	}
+	return nil
+}
+
+// dropIndexes drops the indexes by name, skipping those already gone
+func dropIndexes(ctx context.Context, log logger.Logger, coll *mongo.Collection, names []string) error {
+	for _, name := range names {
+		err := coll.Indexes().DropOne(ctx, name)
+		var cmdErr mongo.CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Code == 27 { // IndexNotFound
+			continue
+		}
+		if err != nil {
+			log.Errorf("Failed to drop index %s", name)
+			return err
+		}
+		log.Infof("dropped index %s of %s", name, coll.Name())
+	}
+	return nil
+}
+
+// checkTenants fails when users or settings created before organizations exist and there's
+// no default organization to move them to, the schema requires every user to have one
+func checkTenants(ctx context.Context, log logger.Logger, db *mongo.Database) error {
+	for _, collectionName := range []string{"users", "user_settings"} {
+		n, err := db.Collection(collectionName).CountDocuments(ctx, bson.M{"tenant_id": bson.M{"$exists": false}})
+		if err != nil {
+			log.Errorf("Failed to count %s without a tenant", collectionName)
+			return err
+		}
+		if n > 0 {
+			return fmt.Errorf("%d %s have no organization, set tenancy.default_organization to move them to one", n, collectionName)
+		}
+	}
+	return nil
+}
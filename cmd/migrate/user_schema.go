@@ -3,16 +3,22 @@ package main
 import (
 	"regexp"
 
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 // userSchema converts the Go-struct schema into Mongo-flavoured JSON-Schema.
 func userSchema() bson.M {
-	emailRegexp := regexp.MustCompile(`^[\w\-.]+@([\w\-]+\.)+[\w\-]{2,4}$`)
+	// canonical emails: lowercase dot-atom local part, punycode domain (see domain.ParseEmail)
+	emailRegexp := regexp.MustCompile("^[a-z0-9!#$%&'*+/=?^_`{|}~.-]+@([a-z0-9-]+\\.)+[a-z0-9-]{2,63}$")
 	schema := bson.M{
 		"bsonType": "object",
-		"required": []string{"name", "email", "password", "created_at"},
+		"required": []string{"tenant_id", "name", "email", "password", "created_at", "updated_at", "status"},
 		"properties": bson.M{
+			"tenant_id": bson.M{
+				"bsonType":    "objectId",
+				"description": "organization of the user, emails and usernames are unique within it",
+			},
 			"name": bson.M{
 				"bsonType":    "string",
 				"minLength":   1,
@@ -23,6 +29,13 @@ func userSchema() bson.M {
 				"pattern":     emailRegexp.String(),
 				"description": "valid e-mail",
 			},
+			"username": bson.M{
+				"bsonType":    "string",
+				"pattern":     "^[a-z][a-z0-9_-]*[a-z0-9]$",
+				"minLength":   domain.MinUsernameLength,
+				"maxLength":   domain.MaxUsernameLength,
+				"description": "optional canonical username, see domain.ParseUsername",
+			},
 			"password": bson.M{
 				"bsonType":  "string",
 				"minLength": 60,
@@ -31,7 +44,104 @@ func userSchema() bson.M {
 			"created_at": bson.M{
 				"bsonType": "date",
 			},
+			"updated_at": bson.M{
+				"bsonType": "date",
+			},
+			"display_name": bson.M{
+				"bsonType":  "string",
+				"maxLength": domain.MaxDisplayNameLength,
+			},
+			"phone": bson.M{
+				"bsonType":    "string",
+				"pattern":     `^\+[1-9][0-9]{1,14}$`,
+				"description": "E.164 phone number",
+			},
+			"locale": bson.M{
+				"bsonType":    "string",
+				"description": "BCP 47 tag",
+			},
+			"time_zone": bson.M{
+				"bsonType":    "string",
+				"description": "IANA time zone name",
+			},
+			"avatar_url": bson.M{
+				"bsonType":  "string",
+				"pattern":   "^https?://",
+				"maxLength": domain.MaxAvatarURLLength,
+			},
+			"bio": bson.M{
+				"bsonType":  "string",
+				"maxLength": domain.MaxBioLength,
+			},
+			"avatar_hash": bson.M{
+				"bsonType":    "string",
+				"pattern":     "^[0-9a-f]{64}$",
+				"description": "sha256 of the uploaded avatar, its thumbnails are in blob storage",
+			},
+			"status": bson.M{
+				"enum":        domain.Statuses,
+				"description": "account lifecycle status",
+			},
+			"status_reason": bson.M{
+				"bsonType":  "string",
+				"maxLength": 500,
+			},
+			"status_changed_at": bson.M{
+				"bsonType": "date",
+			},
+			"roles": bson.M{
+				"bsonType": "array",
+				"items":    bson.M{"bsonType": "string"},
+			},
+			"attributes": bson.M{
+				"bsonType":      "object",
+				"description":   "custom attributes, their values are checked against the attribute schema",
+				"maxProperties": domain.MaxAttributes,
+				"additionalProperties": bson.M{
+					"bsonType": bson.A{"string", "double", "int", "long", "bool"},
+				},
+			},
+			"email_domain": bson.M{
+				"bsonType":    "string",
+				"description": "part of the email after the @, derived for filtering",
+			},
+			"pending_email": emailTokenSchema("email change waiting for the new address to confirm it"),
+			"email_revert":  emailTokenSchema("previous email and how to restore it"),
+			"previous_usernames": bson.M{
+				"bsonType":    "array",
+				"description": "replaced usernames, still redirecting to the user until they expire",
+				"items": bson.M{
+					"bsonType": "object",
+					"required": []string{"username", "renamed_at", "expires_at"},
+					"properties": bson.M{
+						"username":   bson.M{"bsonType": "string"},
+						"renamed_at": bson.M{"bsonType": "date"},
+						"expires_at": bson.M{"bsonType": "date"},
+					},
+				},
+			},
+			"search": bson.M{
+				"bsonType":    "object",
+				"description": "trigrams of name and email, derived for fuzzy search",
+				"properties": bson.M{
+					"name":  bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}},
+					"email": bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}},
+				},
+			},
 		},
 	}
 	return schema
 }
+
+func emailTokenSchema(description string) bson.M {
+	return bson.M{
+		"bsonType":    "object",
+		"description": description,
+		"required":    []string{"email", "token_hash", "expires_at"},
+		"properties": bson.M{
+			"email":      bson.M{"bsonType": "string"},
+			"token_hash": bson.M{"bsonType": "string", "description": "sha256 of the token, tokens aren't stored"},
+			"expires_at": bson.M{"bsonType": "date"},
+		},
+	}
+}
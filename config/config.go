@@ -1,6 +1,9 @@
 package config
 
 import (
+	"fmt"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
 	"github.com/hinphansa/7-solutions-challenge/pkg/utils"
 	"gopkg.in/yaml.v3"
 
@@ -23,8 +26,46 @@ type Config struct {
 	Mongo struct {
 		URI string `yaml:"uri" validate:"required,min=1"`
 		DB  string `yaml:"db" validate:"required,min=1"`
+		// runs user operations writing several documents in transactions, which need a
+		// replica set. Only applies with the mongo storage driver.
+		Transactions bool `yaml:"transactions"`
 	} `yaml:"mongo"`
 
+	Storage struct {
+		// where users are stored. sqlite and postgres keep the other data in the same
+		// database, without Mongo, the mongo driver keeps everything in Mongo.
+		Driver string `yaml:"driver" validate:"required,oneof=mongo sqlite postgres"`
+		SQLite struct {
+			Path string `yaml:"path" validate:"required"` // database file, created and migrated on start
+		} `yaml:"sqlite"`
+		Postgres struct {
+			URI             string `yaml:"uri" validate:"required"` // database URL, migrated on start
+			MaxConns        int32  `yaml:"max_conns" validate:"min=0"`
+			MinConns        int32  `yaml:"min_conns" validate:"min=0"`
+			MaxConnLifetime int    `yaml:"max_conn_lifetime" validate:"min=0"`  // in seconds
+			MaxConnIdleTime int    `yaml:"max_conn_idle_time" validate:"min=0"` // in seconds
+		} `yaml:"postgres"`
+		// in-process cache of user lookups by id and email, in front of any driver. Each
+		// instance caches on its own, so writes through another one show after the TTL.
+		Cache struct {
+			Enabled     bool `yaml:"enabled"`
+			Size        int  `yaml:"size" validate:"min=2"`         // entries, a user takes two
+			TTL         int  `yaml:"ttl" validate:"min=1"`          // in seconds
+			NegativeTTL int  `yaml:"negative_ttl" validate:"min=0"` // in seconds, 0 doesn't cache missing users
+		} `yaml:"cache"`
+		// timeouts, retries of reads and a circuit breaker around the user repository of any
+		// driver, calls fail fast with 503 while it's open
+		Resilience struct {
+			Enabled          bool `yaml:"enabled"`
+			ReadTimeout      int  `yaml:"read_timeout" validate:"min=0"`  // in milliseconds, 0 doesn't bound reads
+			WriteTimeout     int  `yaml:"write_timeout" validate:"min=0"` // in milliseconds, 0 doesn't bound writes
+			ReadAttempts     int  `yaml:"read_attempts" validate:"min=1"`
+			RetryBackoff     int  `yaml:"retry_backoff" validate:"min=0"`     // in milliseconds, doubled on every retry
+			FailureThreshold int  `yaml:"failure_threshold" validate:"min=1"` // failures in a row opening the circuit
+			Cooldown         int  `yaml:"cooldown" validate:"min=1"`          // in seconds, before probing the storage again
+		} `yaml:"resilience"`
+	} `yaml:"storage"`
+
 	HttpServer struct {
 		Port int `yaml:"port" validate:"required,min=1"`
 	} `yaml:"http_server"`
@@ -32,6 +73,155 @@ type Config struct {
 	GrpcServer struct {
 		Port int `yaml:"port"`
 	} `yaml:"grpc_server"`
+
+	Pagination struct {
+		DefaultPageSize int64  `yaml:"default_page_size" validate:"required,min=1"`
+		MaxPageSize     int64  `yaml:"max_page_size" validate:"required,gtefield=DefaultPageSize"`
+		TokenSecret     string `yaml:"token_secret" validate:"required,min=1"` // seals opaque page tokens
+	} `yaml:"pagination"`
+
+	Users struct {
+		// anonymous callers can list and search users in their public view unless set
+		RequireAuthForListing bool `yaml:"require_auth_for_listing"`
+	} `yaml:"users"`
+
+	Tenancy struct {
+		// slug of the organization of requests naming none, empty requires naming one
+		DefaultOrganization string `yaml:"default_organization"`
+		// requests to <slug>.<base_domain> are scoped to the organization with the slug, empty disables subdomains
+		BaseDomain string `yaml:"base_domain"`
+	} `yaml:"tenancy"`
+
+	Email EmailConfig `yaml:"email"`
+
+	Username UsernameConfig `yaml:"username"`
+
+	Registration RegistrationConfig `yaml:"registration"`
+
+	Mailer struct {
+		Driver string `yaml:"driver" validate:"required,oneof=log smtp"` // log only writes emails to the log
+		From   string `yaml:"from" validate:"required,email"`
+		SMTP   struct {
+			Host     string `yaml:"host" validate:"required_if=Driver smtp"`
+			Port     int    `yaml:"port"`
+			Username string `yaml:"username"`
+			Password string `yaml:"password"`
+		} `yaml:"smtp"`
+	} `yaml:"mailer"`
+
+	EmailChange struct {
+		ConfirmURL string `yaml:"confirm_url" validate:"required,contains={token}"`
+		RevertURL  string `yaml:"revert_url" validate:"required,contains={token}"`
+		ConfirmTTL int    `yaml:"confirm_ttl" validate:"required,min=1"` // in seconds
+		RevertTTL  int    `yaml:"revert_ttl" validate:"required,min=1"`  // in seconds
+	} `yaml:"email_change"`
+
+	Invitations struct {
+		AcceptURL string `yaml:"accept_url" validate:"required,contains={token}"`
+		TTL       int    `yaml:"ttl" validate:"required,min=1"` // in seconds
+	} `yaml:"invitations"`
+
+	Settings []SettingConfig `yaml:"settings" validate:"dive"`
+
+	Avatar struct {
+		MaxBytes int64 `yaml:"max_bytes" validate:"required,min=1"`
+		Sizes    []int `yaml:"sizes" validate:"required,min=1,dive,min=16,max=2048"` // thumbnail sizes in pixels
+	} `yaml:"avatar"`
+
+	Blob struct {
+		Driver string `yaml:"driver" validate:"required,oneof=local s3"`
+		Local  struct {
+			Root string `yaml:"root" validate:"required_if=Driver local"`
+		} `yaml:"local"`
+		S3 struct {
+			Endpoint  string `yaml:"endpoint" validate:"required_if=Driver s3"`
+			Region    string `yaml:"region" validate:"required_if=Driver s3"`
+			Bucket    string `yaml:"bucket" validate:"required_if=Driver s3"`
+			AccessKey string `yaml:"access_key"`
+			SecretKey string `yaml:"secret_key"`
+			PathStyle bool   `yaml:"path_style"`
+		} `yaml:"s3"`
+	} `yaml:"blob"`
+}
+
+// SettingConfig defines a user setting and its default, see domain.SettingDefinition
+type SettingConfig struct {
+	Name        string   `yaml:"name" validate:"required"`
+	Type        string   `yaml:"type" validate:"required,oneof=string number bool"`
+	Default     any      `yaml:"default"`
+	Enum        []string `yaml:"enum"`
+	Description string   `yaml:"description"`
+}
+
+// SettingsSchema returns the user settings of the config, checking each default is valid
+func (c *Config) SettingsSchema() (domain.SettingsSchema, error) {
+	schema := make(domain.SettingsSchema, 0, len(c.Settings))
+	seen := map[string]bool{}
+	for _, setting := range c.Settings {
+		def := domain.SettingDefinition{
+			Name:        setting.Name,
+			Type:        domain.AttributeType(setting.Type),
+			Default:     setting.Default,
+			Enum:        setting.Enum,
+			Description: setting.Description,
+		}
+		if err := def.Validate(); err != nil {
+			return nil, err
+		}
+		if seen[def.Name] {
+			return nil, fmt.Errorf("setting %q is defined twice", def.Name)
+		}
+		seen[def.Name] = true
+		schema = append(schema, def)
+	}
+	return schema, nil
+}
+
+// EmailConfig configures email canonicalization. Changing it on a populated database can
+// make existing users unreachable by email, check with `migrate -report-email-duplicates` first.
+type EmailConfig struct {
+	FoldPlusAddressing    bool     `yaml:"fold_plus_addressing"`
+	PlusAddressingDomains []string `yaml:"plus_addressing_domains"` // empty folds every domain
+}
+
+// Policy returns the email policy of the config
+func (c EmailConfig) Policy() domain.EmailPolicy {
+	return domain.EmailPolicy{
+		FoldPlusAddressing:    c.FoldPlusAddressing,
+		PlusAddressingDomains: c.PlusAddressingDomains,
+	}
+}
+
+// UsernameConfig configures which usernames can be claimed and how long a replaced
+// username keeps redirecting to its user.
+type UsernameConfig struct {
+	Reserved    []string `yaml:"reserved"`                               // on top of domain.ReservedUsernames
+	RedirectTTL int      `yaml:"redirect_ttl" validate:"required,min=1"` // in seconds
+}
+
+// Policy returns the username policy of the config
+func (c UsernameConfig) Policy() domain.UsernamePolicy {
+	return domain.UsernamePolicy{Reserved: c.Reserved}
+}
+
+// RegistrationConfig configures who can register, see domain.RegistrationPolicy
+type RegistrationConfig struct {
+	Mode            string   `yaml:"mode" validate:"required,oneof=open invite_only closed"`
+	AllowedDomains  []string `yaml:"allowed_domains"` // empty allows every domain
+	DeniedDomains   []string `yaml:"denied_domains"`
+	BlockDisposable bool     `yaml:"block_disposable"` // denies domain.DisposableDomains too
+	RequireApproval bool     `yaml:"require_approval"`
+}
+
+// Policy returns the registration policy of the config
+func (c RegistrationConfig) Policy() domain.RegistrationPolicy {
+	return domain.RegistrationPolicy{
+		Mode:            domain.RegistrationMode(c.Mode),
+		AllowedDomains:  c.AllowedDomains,
+		DeniedDomains:   c.DeniedDomains,
+		BlockDisposable: c.BlockDisposable,
+		RequireApproval: c.RequireApproval,
+	}
 }
 
 func Load() (*Config, error) {
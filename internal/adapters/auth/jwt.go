@@ -12,30 +12,66 @@ type JWTMaker struct {
 	ttl    time.Duration
 }
 
+// Claims are the verified claims of a token
+type Claims struct {
+	ID       bson.ObjectID
+	TenantID bson.ObjectID // zero for tokens issued before organizations
+	Email    string
+	Roles    []string
+}
+
 func NewJWT(secret string, ttl time.Duration) *JWTMaker {
 	return &JWTMaker{secret: []byte(secret), ttl: ttl}
 }
 
-func (j *JWTMaker) Generate(id bson.ObjectID, email string) (string, error) {
+func (j *JWTMaker) Generate(id bson.ObjectID, tenantID bson.ObjectID, email string, roles []string) (string, error) {
 	claims := jwt.MapClaims{
 		"sub": id.Hex(),
+		"tid": tenantID.Hex(),
 		"eml": email,
 		"exp": time.Now().Add(j.ttl).Unix(),
 	}
+	if len(roles) > 0 {
+		claims["rol"] = roles
+	}
 	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secret)
 }
 
-func (j *JWTMaker) Verify(token string) (bson.ObjectID, bool, error) {
+func (j *JWTMaker) Verify(token string) (*Claims, error) {
 	claims := jwt.MapClaims{}
 	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (any, error) {
 		return j.secret, nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
+	if err != nil {
+		return nil, err
+	}
+	sub, err := claims.GetSubject()
 	if err != nil {
-		return bson.ObjectID{}, false, err
+		return nil, err
 	}
-	id, err := bson.ObjectIDFromHex(claims["sub"].(string))
+	id, err := bson.ObjectIDFromHex(sub)
 	if err != nil {
-		return bson.ObjectID{}, false, err
+		return nil, err
 	}
-	return id, true, nil
+	email, _ := claims["eml"].(string)
+	return &Claims{ID: id, TenantID: TenantOf(claims), Email: email, Roles: RolesOf(claims)}, nil
+}
+
+// RolesOf returns the roles carried by verified claims
+func RolesOf(claims jwt.MapClaims) []string {
+	raw, _ := claims["rol"].([]any)
+	roles := make([]string, 0, len(raw))
+	for _, r := range raw {
+		if role, ok := r.(string); ok {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// TenantOf returns the organization carried by verified claims, zero when there's none
+func TenantOf(claims jwt.MapClaims) bson.ObjectID {
+	tid, _ := claims["tid"].(string)
+	id, _ := bson.ObjectIDFromHex(tid)
+	return id
 }
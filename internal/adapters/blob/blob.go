@@ -0,0 +1,22 @@
+package blob
+
+import (
+	"github.com/hinphansa/7-solutions-challenge/config"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+)
+
+// New returns the blob store selected by blob.driver in the config
+func New(cfg *config.Config) (ports.BlobStore, error) {
+	if cfg.Blob.Driver == "s3" {
+		s3 := cfg.Blob.S3
+		return NewS3Store(S3Config{
+			Endpoint:  s3.Endpoint,
+			Region:    s3.Region,
+			Bucket:    s3.Bucket,
+			AccessKey: s3.AccessKey,
+			SecretKey: s3.SecretKey,
+			PathStyle: s3.PathStyle,
+		}, nil)
+	}
+	return NewLocalStore(cfg.Blob.Local.Root)
+}
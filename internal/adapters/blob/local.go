@@ -0,0 +1,137 @@
+// Package blob implements ports.BlobStore on the local filesystem and on S3-compatible
+// object storage.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+)
+
+// compile time check to ensure localStore implements ports.BlobStore
+var _ ports.BlobStore = (*localStore)(nil)
+
+// localStore keeps each object in a file under root, with its content type in a sidecar
+// file so it's served as stored.
+type localStore struct {
+	root string
+}
+
+// NewLocalStore stores objects under the root directory, creating it if needed
+func NewLocalStore(root string) (*localStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &localStore{root: root}, nil
+}
+
+const contentTypeSuffix = ".content-type"
+
+// path returns the file of key, rejecting keys that would escape the root
+func (s *localStore) path(key string) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+func (s *localStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	// write to a temporary file and rename it, readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly once renamed
+
+	n, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("blob %s: wrote %d bytes, expected %d", key, n, size)
+	}
+	if err := os.WriteFile(name+contentTypeSuffix, []byte(contentType), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (s *localStore) Get(ctx context.Context, key string) (*ports.Blob, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: blob %s", domain.ErrNotFound, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	contentType := "application/octet-stream"
+	if b, err := os.ReadFile(name + contentTypeSuffix); err == nil {
+		contentType = string(b)
+	}
+	return &ports.Blob{Body: f, Size: info.Size(), ContentType: contentType, ModTime: info.ModTime()}, nil
+}
+
+func (s *localStore) Exists(ctx context.Context, key string) (bool, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *localStore) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.Remove(name + contentTypeSuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// checkKey accepts relative slash separated keys without empty, "." or ".." segments
+func checkKey(key string) error {
+	if key == "" || path.Clean(key) != key || strings.HasPrefix(key, "/") || strings.HasPrefix(key, "..") ||
+		strings.HasSuffix(key, contentTypeSuffix) {
+		return fmt.Errorf("%w: invalid blob key %q", domain.ErrInvalidArgument, key)
+	}
+	return nil
+}
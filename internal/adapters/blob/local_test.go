@@ -0,0 +1,67 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+)
+
+// testStore runs the BlobStore contract against store
+func testStore(t *testing.T, store ports.BlobStore) {
+	t.Helper()
+	ctx := context.Background()
+	const key = "avatars/ab/cdef/128"
+
+	if _, err := store.Get(ctx, key); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found before put, got %v", err)
+	}
+	if ok, err := store.Exists(ctx, key); ok || err != nil {
+		t.Fatalf("expected no blob before put, got %v, %v", ok, err)
+	}
+
+	if err := store.Put(ctx, key, strings.NewReader("image"), 5, "image/png"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if ok, err := store.Exists(ctx, key); !ok || err != nil {
+		t.Fatalf("expected blob after put, got %v, %v", ok, err)
+	}
+
+	blob, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	data, _ := io.ReadAll(blob.Body)
+	blob.Body.Close()
+	if string(data) != "image" || blob.Size != 5 || blob.ContentType != "image/png" {
+		t.Fatalf("unexpected blob %q, %d bytes, %s", data, blob.Size, blob.ContentType)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("delete of a missing blob: %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found after delete, got %v", err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../b", "a//b"} {
+		if err := store.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"); !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Fatalf("key %q: expected invalid argument, got %v", key, err)
+		}
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+}
@@ -0,0 +1,99 @@
+package blob
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 is a local stand-in for an S3 bucket, it checks the signature of every request
+type fakeS3 struct {
+	bucket  string
+	secret  string
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	credential, _, _ := strings.Cut(strings.TrimPrefix(auth, "AWS4-HMAC-SHA256 Credential="), ",")
+	_, scope, _ := strings.Cut(credential, "/")
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	want := signature(f.secret, scope, r.Header.Get("X-Amz-Date"), canonicalRequest(r, payloadHash))
+	if !strings.HasSuffix(auth, "Signature="+want) {
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket+"/")
+	if !ok {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, exists := f.objects[key]
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = fakeObject{data: data, contentType: r.Header.Get("Content-Type")}
+	case http.MethodGet, http.MethodHead:
+		if !exists {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Write(obj.data)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	fake := &fakeS3{bucket: "avatars", secret: "secret", objects: map[string]fakeObject{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := NewS3Store(S3Config{
+		Endpoint:  server.URL,
+		Region:    "us-east-1",
+		Bucket:    "avatars",
+		AccessKey: "key",
+		SecretKey: "secret",
+		PathStyle: true,
+	}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+}
+
+func TestS3Store_WrongSecret(t *testing.T) {
+	fake := &fakeS3{bucket: "avatars", secret: "secret", objects: map[string]fakeObject{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, _ := NewS3Store(S3Config{
+		Endpoint: server.URL, Region: "us-east-1", Bucket: "avatars",
+		AccessKey: "key", SecretKey: "wrong", PathStyle: true,
+	}, server.Client())
+	if _, err := store.Exists(t.Context(), "avatars/ab/cd/64"); err == nil {
+		t.Fatal("expected an error with a wrong secret")
+	}
+}
+
+func TestEscapeKey(t *testing.T) {
+	if got := escapeKey("a b/c+d~e"); got != "a%20b/c%2Bd~e" {
+		t.Fatalf("unexpected escaped key %q", got)
+	}
+}
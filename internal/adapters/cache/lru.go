@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+// lru is a bounded map evicting the least recently used entry once full. Entries expire
+// after their TTL and are dropped when next read. It isn't safe for concurrent use, the
+// repository guards it.
+type lru struct {
+	size    int
+	items   map[string]*list.Element
+	order   *list.List                     // front is the most recently used
+	onEvict func(key string, value cached) // called for every entry leaving, whatever the reason
+}
+
+type entry struct {
+	key     string
+	value   cached
+	expires time.Time
+}
+
+func newLRU(size int, onEvict func(key string, value cached)) *lru {
+	return &lru{
+		size:    size,
+		items:   make(map[string]*list.Element, size),
+		order:   list.New(),
+		onEvict: onEvict,
+	}
+}
+
+// get returns the live value of key, and marks it used
+func (c *lru) get(key string, now time.Time) (cached, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		return cached{}, false
+	}
+	e := el.Value.(*entry)
+	if !now.Before(e.expires) {
+		c.removeElement(el)
+		return cached{}, false
+	}
+	c.order.MoveToFront(el)
+	return e.value, true
+}
+
+// peek returns the value of key, expired or not, without marking it used
+func (c *lru) peek(key string) (cached, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		return cached{}, false
+	}
+	return el.Value.(*entry).value, true
+}
+
+// put stores value until expires, evicting the least recently used entries over the size.
+// It returns how many entries left to make room, onEvict may take others with them.
+func (c *lru) put(key string, value cached, expires time.Time) int {
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expires: expires})
+
+	before := c.order.Len()
+	for c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+	return before - c.order.Len()
+}
+
+func (c *lru) remove(key string) {
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+func (c *lru) removeElement(el *list.Element) {
+	e := c.order.Remove(el).(*entry)
+	delete(c.items, e.key)
+	c.onEvict(e.key, e.value)
+}
+
+func (c *lru) purge() {
+	for c.order.Len() > 0 {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *lru) len() int {
+	return c.order.Len()
+}
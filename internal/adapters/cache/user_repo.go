@@ -0,0 +1,365 @@
+// Package cache decorates repositories with in-process read-through caches. Each process
+// caches on its own, so in a deployment of several instances a write made by another one
+// is only seen once the entry expires.
+package cache
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"golang.org/x/sync/singleflight"
+)
+
+// compile time check to ensure userRepository implements ports.UserRepository
+var _ ports.UserRepository = (*userRepository)(nil)
+
+// cached is a cache entry, a nil user caches a domain.ErrNotFound
+type cached struct {
+	user *domain.User
+}
+
+// Stats are the counters of a cache since it was created. Misses beyond Loads joined a
+// load already in flight for the same key.
+type Stats struct {
+	Hits         uint64 `json:"hits"`          // users returned from the cache
+	NegativeHits uint64 `json:"negative_hits"` // not-found errors returned from the cache
+	Misses       uint64 `json:"misses"`
+	Loads        uint64 `json:"loads"` // reads of the wrapped repository on a miss
+	Evictions    uint64 `json:"evictions"`
+	Entries      int    `json:"entries"`
+}
+
+type Option func(*userRepository)
+
+// WithSize bounds the number of entries, users are cached once by id and once by email
+func WithSize(size int) Option {
+	return func(r *userRepository) {
+		r.size = size
+	}
+}
+
+// WithTTL sets how long a user is cached
+func WithTTL(ttl time.Duration) Option {
+	return func(r *userRepository) {
+		r.ttl = ttl
+	}
+}
+
+// WithNegativeTTL sets how long a missing user is cached, 0 doesn't cache them
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(r *userRepository) {
+		r.negativeTTL = ttl
+	}
+}
+
+// userRepository caches the users GetByID and GetByEmail load, and the ids and emails
+// they don't find. Entries are keyed by organization, contexts reading across
+// organizations bypass the cache. Every write through the repository invalidates the
+// entries of the user it touches.
+type userRepository struct {
+	ports.UserRepository // the wrapped repository, serving every other method
+
+	size        int
+	ttl         time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries *lru
+	gen     uint64 // bumped by invalidations, a load started before one isn't cached
+	group   singleflight.Group
+
+	hits, negativeHits, misses, loads, evictions atomic.Uint64
+}
+
+// NewUserRepository returns a cache in front of next, by default of 10000 entries
+// caching users for a minute and missing users for 10 seconds
+func NewUserRepository(next ports.UserRepository, opts ...Option) *userRepository {
+	r := &userRepository{
+		UserRepository: next,
+		size:           10000,
+		ttl:            time.Minute,
+		negativeTTL:    10 * time.Second,
+		now:            time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.entries = newLRU(max(r.size, 2), r.dropPair) // room for a user by id and email
+	return r
+}
+
+// Stats returns the counters of the cache, for monitoring
+func (r *userRepository) Stats() Stats {
+	r.mu.Lock()
+	entries := r.entries.len()
+	r.mu.Unlock()
+	return Stats{
+		Hits:         r.hits.Load(),
+		NegativeHits: r.negativeHits.Load(),
+		Misses:       r.misses.Load(),
+		Loads:        r.loads.Load(),
+		Evictions:    r.evictions.Load(),
+		Entries:      entries,
+	}
+}
+
+// tenantKey is the key prefix of the organization ctx is scoped to, false when ctx
+// isn't scoped to one
+func tenantKey(ctx context.Context) (string, bool) {
+	if ports.IsAllTenants(ctx) {
+		return "", false
+	}
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return "", false
+	}
+	return tenantID.Hex(), true
+}
+
+func idKey(tenant string, id bson.ObjectID) string {
+	return tenant + "/id/" + id.Hex()
+}
+
+func emailKey(tenant string, email string) string {
+	return tenant + "/email/" + email
+}
+
+// GetByID loads the whole user once and masks the fields of each caller. Reads in a
+// transaction go to the storage, they must see its own writes.
+func (r *userRepository) GetByID(ctx context.Context, id bson.ObjectID, fields ...string) (*domain.User, error) {
+	tenant, ok := tenantKey(ctx)
+	if !ok || ports.InTx(ctx) {
+		return r.UserRepository.GetByID(ctx, id, fields...)
+	}
+	user, err := r.load(ctx, tenant, idKey(tenant, id), func(ctx context.Context) (*domain.User, error) {
+		return r.UserRepository.GetByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		ports.MaskUser(user, fields)
+		user.ID = id
+	}
+	return user, nil
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	tenant, ok := tenantKey(ctx)
+	if !ok || ports.InTx(ctx) {
+		return r.UserRepository.GetByEmail(ctx, email)
+	}
+	return r.load(ctx, tenant, emailKey(tenant, email), func(ctx context.Context) (*domain.User, error) {
+		return r.UserRepository.GetByEmail(ctx, email)
+	})
+}
+
+// load returns a copy of the user cached at key, loading it with fetch on a miss.
+// Concurrent misses of a key share one load, which isn't canceled when the caller that
+// started it gives up.
+func (r *userRepository) load(ctx context.Context, tenant, key string, fetch func(ctx context.Context) (*domain.User, error)) (*domain.User, error) {
+	r.mu.Lock()
+	now := r.now()
+	value, ok := r.entries.get(key, now)
+	if ok && value.user != nil {
+		r.entries.get(pairKey(key, value.user), now) // the pair is used as one
+	}
+	gen := r.gen
+	r.mu.Unlock()
+	if ok {
+		if value.user == nil {
+			r.negativeHits.Add(1)
+			return nil, domain.ErrNotFound
+		}
+		r.hits.Add(1)
+		return cloneUser(value.user), nil
+	}
+	r.misses.Add(1)
+
+	results := r.group.DoChan(key, func() (any, error) {
+		r.loads.Add(1)
+		user, err := fetch(context.WithoutCancel(ctx))
+		switch {
+		case err == nil:
+			r.store(tenant, key, user, gen)
+		case errors.Is(err, domain.ErrNotFound):
+			r.store(tenant, key, nil, gen)
+		}
+		return user, err
+	})
+	select {
+	case res := <-results:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return cloneUser(res.Val.(*domain.User)), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// store caches a user by id and email, or a missing user at key, unless an invalidation
+// happened since gen
+func (r *userRepository) store(tenant, key string, user *domain.User, gen uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.gen != gen {
+		return
+	}
+	now := r.now()
+	if user == nil {
+		if r.negativeTTL > 0 {
+			r.evictions.Add(uint64(r.entries.put(key, cached{}, now.Add(r.negativeTTL))))
+		}
+		return
+	}
+	user = cloneUser(user)
+	expires := now.Add(r.ttl)
+	evicted := r.entries.put(idKey(tenant, user.ID), cached{user: user}, expires)
+	evicted += r.entries.put(emailKey(tenant, user.Email), cached{user: user}, expires)
+	r.evictions.Add(uint64(evicted))
+}
+
+// dropPair drops the other entry of a user leaving the cache, users are cached by id and
+// by email together so invalidating the id drops both
+func (r *userRepository) dropPair(key string, value cached) {
+	if value.user == nil {
+		return
+	}
+	// a newer load may have replaced it
+	pair := pairKey(key, value.user)
+	if other, ok := r.entries.peek(pair); ok && other.user == value.user {
+		r.entries.remove(pair)
+	}
+}
+
+// pairKey is the key of the other entry of a cached user
+func pairKey(key string, user *domain.User) string {
+	tenant, rest, _ := strings.Cut(key, "/")
+	if strings.HasPrefix(rest, "id/") {
+		return emailKey(tenant, user.Email)
+	}
+	return idKey(tenant, user.ID)
+}
+
+// invalidate drops the entries of the user with id and the missing user entries of the
+// emails, after a write. Loads in flight aren't cached, and later misses start new ones.
+// A write across organizations drops every entry. A write in a transaction invalidates
+// again once it commits, as loads until then still see the previous user.
+func (r *userRepository) invalidate(ctx context.Context, id bson.ObjectID, emails ...string) {
+	r.drop(ctx, id, emails...)
+	if ports.InTx(ctx) {
+		ports.AfterCommit(ctx, func() { r.drop(ctx, id, emails...) })
+	}
+}
+
+// drop drops the entries of invalidate
+func (r *userRepository) drop(ctx context.Context, id bson.ObjectID, emails ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gen++
+	tenant, ok := tenantKey(ctx)
+	if !ok {
+		if ports.IsAllTenants(ctx) {
+			r.entries.purge()
+		}
+		return
+	}
+
+	byID := idKey(tenant, id)
+	if value, ok := r.entries.peek(byID); ok && value.user != nil {
+		r.group.Forget(emailKey(tenant, value.user.Email))
+	}
+	r.entries.remove(byID) // and its email entry
+	r.group.Forget(byID)
+	for _, email := range emails {
+		r.entries.remove(emailKey(tenant, email))
+		r.group.Forget(emailKey(tenant, email))
+	}
+}
+
+// Create drops the missing user entries of the id and email of the user
+func (r *userRepository) Create(ctx context.Context, user *domain.User) (*bson.ObjectID, error) {
+	id, err := r.UserRepository.Create(ctx, user)
+	r.invalidate(ctx, user.ID, user.Email)
+	return id, err
+}
+
+func (r *userRepository) Update(ctx context.Context, id bson.ObjectID, update *ports.UserUpdate) error {
+	err := r.UserRepository.Update(ctx, id, update)
+	if update.Email.Op == ports.Set {
+		r.invalidate(ctx, id, update.Email.Value)
+	} else {
+		r.invalidate(ctx, id)
+	}
+	return err
+}
+
+func (r *userRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	err := r.UserRepository.Delete(ctx, id)
+	r.invalidate(ctx, id)
+	return err
+}
+
+func (r *userRepository) SetEmailChange(ctx context.Context, id bson.ObjectID, change *domain.EmailChange, revert *domain.EmailRevert) error {
+	err := r.UserRepository.SetEmailChange(ctx, id, change, revert)
+	r.invalidate(ctx, id)
+	return err
+}
+
+func (r *userRepository) ConfirmEmailChange(ctx context.Context, id bson.ObjectID, tokenHash string, email string) error {
+	err := r.UserRepository.ConfirmEmailChange(ctx, id, tokenHash, email)
+	r.invalidate(ctx, id, email)
+	return err
+}
+
+func (r *userRepository) RevertEmailChange(ctx context.Context, id bson.ObjectID, tokenHash string, email string) error {
+	err := r.UserRepository.RevertEmailChange(ctx, id, tokenHash, email)
+	r.invalidate(ctx, id, email)
+	return err
+}
+
+func (r *userRepository) SetStatus(ctx context.Context, id bson.ObjectID, change *ports.StatusChange) error {
+	err := r.UserRepository.SetStatus(ctx, id, change)
+	r.invalidate(ctx, id)
+	return err
+}
+
+func (r *userRepository) SetUsername(ctx context.Context, id bson.ObjectID, username string, redirectUntil time.Time) error {
+	err := r.UserRepository.SetUsername(ctx, id, username, redirectUntil)
+	r.invalidate(ctx, id)
+	return err
+}
+
+func (r *userRepository) SetAvatar(ctx context.Context, id bson.ObjectID, hash string) error {
+	err := r.UserRepository.SetAvatar(ctx, id, hash)
+	r.invalidate(ctx, id)
+	return err
+}
+
+// cloneUser copies a user deep enough that callers can't change the cached one
+func cloneUser(user *domain.User) *domain.User {
+	clone := *user
+	clone.Attributes = maps.Clone(user.Attributes)
+	clone.Roles = slices.Clone(user.Roles)
+	clone.PreviousUsernames = slices.Clone(user.PreviousUsernames)
+	if user.PendingEmail != nil {
+		pending := *user.PendingEmail
+		clone.PendingEmail = &pending
+	}
+	if user.EmailRevert != nil {
+		revert := *user.EmailRevert
+		clone.EmailRevert = &revert
+	}
+	return &clone
+}
@@ -0,0 +1,325 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/adapters/memory"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports/porttest"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestUserRepository(t *testing.T) {
+	porttest.TestUserRepository(t, func(t *testing.T) ports.UserRepository {
+		return NewUserRepository(memory.NewUserRepository())
+	})
+}
+
+// countingRepository counts the lookups reaching the wrapped repository. When release is
+// set, lookups hold what they read until it's closed.
+type countingRepository struct {
+	ports.UserRepository
+	lookups atomic.Int64
+	release chan struct{}
+}
+
+func (r *countingRepository) hold(user *domain.User, err error) (*domain.User, error) {
+	r.lookups.Add(1)
+	if r.release != nil {
+		<-r.release
+	}
+	return user, err
+}
+
+func (r *countingRepository) GetByID(ctx context.Context, id bson.ObjectID, fields ...string) (*domain.User, error) {
+	return r.hold(r.UserRepository.GetByID(ctx, id, fields...))
+}
+
+func (r *countingRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.hold(r.UserRepository.GetByEmail(ctx, email))
+}
+
+func newTestRepo(t *testing.T, opts ...Option) (*userRepository, *countingRepository, context.Context) {
+	t.Helper()
+	next := &countingRepository{UserRepository: memory.NewUserRepository()}
+	return NewUserRepository(next, opts...), next, ports.WithTenant(context.Background(), bson.NewObjectID())
+}
+
+func createUser(t *testing.T, ctx context.Context, repo ports.UserRepository, email string) bson.ObjectID {
+	t.Helper()
+	id, err := repo.Create(ctx, &domain.User{Name: "Jane", Email: email, Password: "hash", Status: domain.StatusActive})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	return *id
+}
+
+func TestCacheHits(t *testing.T) {
+	repo, next, ctx := newTestRepo(t)
+	id := createUser(t, ctx, repo, "jane@example.com")
+
+	for range 3 {
+		if _, err := repo.GetByID(ctx, id); err != nil {
+			t.Fatalf("get by id: %v", err)
+		}
+	}
+	// cached by email too
+	if _, err := repo.GetByEmail(ctx, "jane@example.com"); err != nil {
+		t.Fatalf("get by email: %v", err)
+	}
+	if n := next.lookups.Load(); n != 1 {
+		t.Fatalf("expected 1 lookup, got %d", n)
+	}
+	stats := repo.Stats()
+	if stats.Hits != 3 || stats.Misses != 1 || stats.Loads != 1 || stats.Entries != 2 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	// callers get copies, and masked fields don't leak into the cache
+	user, _ := repo.GetByID(ctx, id, ports.FieldName)
+	if user.Email != "" || user.ID != id {
+		t.Fatalf("expected only the name and id, got %+v", user)
+	}
+	user, _ = repo.GetByID(ctx, id)
+	user.Name = "changed"
+	if user, _ = repo.GetByID(ctx, id); user.Name != "Jane" || user.Email != "jane@example.com" {
+		t.Fatalf("expected the cached user unchanged, got %+v", user)
+	}
+
+	// another organization doesn't see the entries
+	other := ports.WithTenant(context.Background(), bson.NewObjectID())
+	if _, err := repo.GetByID(other, id); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found in another organization, got %v", err)
+	}
+}
+
+func TestCacheNegative(t *testing.T) {
+	repo, next, ctx := newTestRepo(t)
+
+	for range 2 {
+		if _, err := repo.GetByEmail(ctx, "jane@example.com"); !errors.Is(err, domain.ErrNotFound) {
+			t.Fatalf("expected not found, got %v", err)
+		}
+	}
+	if n := next.lookups.Load(); n != 1 {
+		t.Fatalf("expected 1 lookup, got %d", n)
+	}
+	if stats := repo.Stats(); stats.NegativeHits != 1 {
+		t.Fatalf("expected a negative hit, got %+v", stats)
+	}
+
+	// creating the user drops the negative entry
+	createUser(t, ctx, repo, "jane@example.com")
+	if _, err := repo.GetByEmail(ctx, "jane@example.com"); err != nil {
+		t.Fatalf("expected the created user, got %v", err)
+	}
+
+	// disabled by a zero TTL
+	repo, next, ctx = newTestRepo(t, WithNegativeTTL(0))
+	for range 2 {
+		repo.GetByEmail(ctx, "jane@example.com")
+	}
+	if n := next.lookups.Load(); n != 2 {
+		t.Fatalf("expected 2 lookups, got %d", n)
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	repo, next, ctx := newTestRepo(t, WithTTL(time.Minute))
+	now := time.Now()
+	repo.now = func() time.Time { return now }
+	id := createUser(t, ctx, repo, "jane@example.com")
+
+	repo.GetByID(ctx, id)
+	now = now.Add(59 * time.Second)
+	repo.GetByID(ctx, id)
+	if n := next.lookups.Load(); n != 1 {
+		t.Fatalf("expected 1 lookup before the TTL, got %d", n)
+	}
+	now = now.Add(time.Second)
+	repo.GetByID(ctx, id)
+	if n := next.lookups.Load(); n != 2 {
+		t.Fatalf("expected 2 lookups after the TTL, got %d", n)
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	repo, next, ctx := newTestRepo(t, WithSize(4)) // two users, by id and email
+	ids := []bson.ObjectID{
+		createUser(t, ctx, repo, "a@example.com"),
+		createUser(t, ctx, repo, "b@example.com"),
+		createUser(t, ctx, repo, "c@example.com"),
+	}
+	repo.GetByID(ctx, ids[0])
+	repo.GetByID(ctx, ids[1])
+	repo.GetByEmail(ctx, "a@example.com") // b is now the least recently used
+	repo.GetByID(ctx, ids[2])
+
+	stats := repo.Stats()
+	if stats.Entries != 4 || stats.Evictions != 2 {
+		t.Fatalf("expected b evicted by id and email, got %+v", stats)
+	}
+	lookups := next.lookups.Load()
+	repo.GetByEmail(ctx, "a@example.com")
+	if next.lookups.Load() != lookups {
+		t.Fatal("expected a to stay cached")
+	}
+	repo.GetByEmail(ctx, "b@example.com")
+	if next.lookups.Load() != lookups+1 {
+		t.Fatal("expected b to be loaded again")
+	}
+}
+
+func TestCacheCoalesces(t *testing.T) {
+	repo, next, ctx := newTestRepo(t)
+	id := createUser(t, ctx, repo, "jane@example.com")
+	next.release = make(chan struct{})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.GetByID(ctx, id)
+			errs <- err
+		}()
+	}
+	// every caller missed before the load returns
+	for repo.Stats().Misses < 10 {
+		time.Sleep(time.Millisecond)
+	}
+	close(next.release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+	}
+	if n := next.lookups.Load(); n != 1 {
+		t.Fatalf("expected 1 lookup, got %d", n)
+	}
+}
+
+func TestCacheCanceledCaller(t *testing.T) {
+	repo, next, ctx := newTestRepo(t)
+	id := createUser(t, ctx, repo, "jane@example.com")
+	next.release = make(chan struct{})
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := repo.GetByID(canceled, id); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	// the load goes on for the others
+	close(next.release)
+	if _, err := repo.GetByID(ctx, id); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if n := next.lookups.Load(); n != 1 {
+		t.Fatalf("expected 1 lookup, got %d", n)
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	repo, _, ctx := newTestRepo(t)
+	id := createUser(t, ctx, repo, "jane@example.com")
+	repo.GetByID(ctx, id)
+
+	err := repo.Update(ctx, id, &ports.UserUpdate{
+		Name:  ports.FieldUpdate[string]{Op: ports.Set, Value: "Janet"},
+		Email: ports.FieldUpdate[string]{Op: ports.Set, Value: "janet@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if user, _ := repo.GetByID(ctx, id); user.Name != "Janet" {
+		t.Fatalf("expected the updated name, got %q", user.Name)
+	}
+	if _, err := repo.GetByEmail(ctx, "jane@example.com"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected the previous email gone, got %v", err)
+	}
+	if _, err := repo.GetByEmail(ctx, "janet@example.com"); err != nil {
+		t.Fatalf("get by the new email: %v", err)
+	}
+
+	err = repo.SetStatus(ctx, id, &ports.StatusChange{From: domain.StatusActive, To: domain.StatusSuspended, At: time.Now()})
+	if err != nil {
+		t.Fatalf("set status: %v", err)
+	}
+	if user, _ := repo.GetByEmail(ctx, "janet@example.com"); user.Status != domain.StatusSuspended {
+		t.Fatalf("expected suspended, got %q", user.Status)
+	}
+
+	if err := repo.Delete(ctx, id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, id); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found after delete, got %v", err)
+	}
+}
+
+// A load that started before a write doesn't cache what it read
+func TestCacheStaleLoad(t *testing.T) {
+	repo, next, ctx := newTestRepo(t)
+	id := createUser(t, ctx, repo, "jane@example.com")
+	next.release = make(chan struct{})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		repo.GetByID(ctx, id)
+	}()
+	for next.lookups.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// the load holds the name, the update lands before it returns
+	err := repo.Update(ctx, id, &ports.UserUpdate{Name: ports.FieldUpdate[string]{Op: ports.Set, Value: "Janet"}})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	close(next.release)
+	<-done
+
+	if user, _ := repo.GetByID(ctx, id); user.Name != "Janet" {
+		t.Fatalf("expected the updated name, got %q", user.Name)
+	}
+}
+
+// Reads in a transaction skip the cache, and its writes invalidate again on commit
+func TestCacheTransaction(t *testing.T) {
+	repo, next, ctx := newTestRepo(t)
+	id := createUser(t, ctx, repo, "jane@example.com")
+	repo.GetByID(ctx, id)
+
+	txCtx, uow := ports.NewUnitOfWork(ctx)
+	for range 2 {
+		if _, err := repo.GetByID(txCtx, id); err != nil {
+			t.Fatalf("get in transaction: %v", err)
+		}
+	}
+	if n := next.lookups.Load(); n != 3 {
+		t.Fatalf("expected 3 lookups, got %d", n)
+	}
+
+	err := repo.Update(txCtx, id, &ports.UserUpdate{Name: ports.FieldUpdate[string]{Op: ports.Set, Value: "Janet"}})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	// a load outside the transaction before it commits, which could read the previous user
+	repo.GetByID(ctx, id)
+	if stats := repo.Stats(); stats.Entries != 2 {
+		t.Fatalf("expected the load cached, got %+v", stats)
+	}
+	uow.Committed()
+	if stats := repo.Stats(); stats.Entries != 0 {
+		t.Fatalf("expected the commit to drop it, got %+v", stats)
+	}
+}
@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/hinphansa/7-solutions-challenge/api/gen/user/github.com/hinphansa/7-solutions-challenge/api/gen/user"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+)
+
+// ListAttributes implements the ListAttributes RPC method
+func (s *UserServer) ListAttributes(ctx context.Context, req *user.ListAttributesRequest) (*user.ListAttributesResponse, error) {
+	schema, err := s.attributeService.ListAttributes(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to list attributes: %v", err)
+		return nil, toStatus(err, "failed to list attributes")
+	}
+
+	response := &user.ListAttributesResponse{Attributes: make([]*user.AttributeDefinition, len(schema))}
+	for i := range schema {
+		response.Attributes[i] = toProtoAttribute(&schema[i])
+	}
+	return response, nil
+}
+
+// PutAttribute implements the PutAttribute RPC method
+func (s *UserServer) PutAttribute(ctx context.Context, req *user.AttributeDefinition) (*user.AttributeDefinition, error) {
+	def := &domain.AttributeDefinition{
+		Name:        req.GetName(),
+		Type:        domain.AttributeType(req.GetType()),
+		Required:    req.GetRequired(),
+		Enum:        req.GetEnum(),
+		Pattern:     req.GetPattern(),
+		Description: req.GetDescription(),
+	}
+	if err := s.attributeService.PutAttribute(ctx, def); err != nil {
+		s.log.Errorf("Failed to put attribute: %v", err)
+		return nil, toStatus(err, "failed to put attribute")
+	}
+	return toProtoAttribute(def), nil
+}
+
+// DeleteAttribute implements the DeleteAttribute RPC method
+func (s *UserServer) DeleteAttribute(ctx context.Context, req *user.DeleteAttributeRequest) (*user.DeleteAttributeResponse, error) {
+	if err := s.attributeService.DeleteAttribute(ctx, req.GetName()); err != nil {
+		s.log.Errorf("Failed to delete attribute: %v", err)
+		return nil, toStatus(err, "failed to delete attribute")
+	}
+	return &user.DeleteAttributeResponse{Message: "Attribute deleted successfully"}, nil
+}
+
+func toProtoAttribute(def *domain.AttributeDefinition) *user.AttributeDefinition {
+	return &user.AttributeDefinition{
+		Name:        def.Name,
+		Type:        string(def.Type),
+		Required:    def.Required,
+		Enum:        def.Enum,
+		Pattern:     def.Pattern,
+		Description: def.Description,
+	}
+}
@@ -0,0 +1,30 @@
+package grpc
+
+import (
+	"errors"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus maps a service error to a gRPC status. Client errors expose the error
+// message since it explains what to fix, anything else responds with the fallback message.
+func toStatus(err error, fallback string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidArgument):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, domain.ErrPrecondition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, domain.ErrUnavailable):
+		return status.Error(codes.Unavailable, "service unavailable, retry later") // the cause stays internal
+	default:
+		return status.Error(codes.Internal, fallback)
+	}
+}
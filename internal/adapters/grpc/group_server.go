@@ -0,0 +1,233 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hinphansa/7-solutions-challenge/api/gen/user/github.com/hinphansa/7-solutions-challenge/api/gen/user"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CreateGroup implements the CreateGroup RPC method
+func (s *UserServer) CreateGroup(ctx context.Context, req *user.CreateGroupRequest) (*user.Group, error) {
+	group, err := s.groupService.CreateGroup(ctx, &domain.Group{Name: req.GetName(), Description: req.GetDescription(), Roles: req.GetRoles()})
+	if err != nil {
+		s.log.Errorf("Failed to create group: %v", err)
+		return nil, toStatus(err, "failed to create group")
+	}
+	return toProtoGroup(group), nil
+}
+
+// GetGroup implements the GetGroup RPC method
+func (s *UserServer) GetGroup(ctx context.Context, req *user.GetGroupRequest) (*user.Group, error) {
+	id, err := bson.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid group ID")
+	}
+
+	group, err := s.groupService.GetGroup(ctx, id)
+	if err != nil {
+		return nil, toStatus(err, "failed to get group")
+	}
+	return toProtoGroup(group), nil
+}
+
+// ListGroups implements the ListGroups RPC method
+func (s *UserServer) ListGroups(ctx context.Context, req *user.ListGroupsRequest) (*user.ListGroupsResponse, error) {
+	page, err := s.groupService.ListGroups(ctx, &ports.PageRequest{PageSize: int64(req.GetPageSize()), PageToken: req.GetPageToken()})
+	if err != nil {
+		return nil, toStatus(err, "failed to list groups")
+	}
+	return toProtoGroupPage(page), nil
+}
+
+// UpdateGroup implements the UpdateGroup RPC method
+func (s *UserServer) UpdateGroup(ctx context.Context, req *user.UpdateGroupRequest) (*user.Group, error) {
+	id, err := bson.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid group ID")
+	}
+	update, err := groupUpdateOf(req)
+	if err != nil {
+		return nil, toStatus(err, "invalid update_mask")
+	}
+
+	group, err := s.groupService.UpdateGroup(ctx, id, update)
+	if err != nil {
+		s.log.Errorf("Failed to update group: %v", err)
+		return nil, toStatus(err, "failed to update group")
+	}
+	return toProtoGroup(group), nil
+}
+
+// DeleteGroup implements the DeleteGroup RPC method
+func (s *UserServer) DeleteGroup(ctx context.Context, req *user.DeleteGroupRequest) (*user.GroupResponse, error) {
+	id, err := bson.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid group ID")
+	}
+
+	if err := s.groupService.DeleteGroup(ctx, id); err != nil {
+		s.log.Errorf("Failed to delete group: %v", err)
+		return nil, toStatus(err, "failed to delete group")
+	}
+	return &user.GroupResponse{Message: "Group deleted successfully"}, nil
+}
+
+// AddGroupMember implements the AddGroupMember RPC method
+func (s *UserServer) AddGroupMember(ctx context.Context, req *user.GroupMemberRequest) (*user.GroupResponse, error) {
+	groupID, memberID, err := groupMemberIDs(req)
+	if err != nil {
+		return nil, err
+	}
+	kind, err := domain.ParseMemberKind(req.GetKind())
+	if err != nil {
+		return nil, toStatus(err, "invalid member kind")
+	}
+
+	if err := s.groupService.AddMember(ctx, groupID, kind, memberID); err != nil {
+		s.log.Errorf("Failed to add group member: %v", err)
+		return nil, toStatus(err, "failed to add group member")
+	}
+	return &user.GroupResponse{Message: "Member added successfully"}, nil
+}
+
+// RemoveGroupMember implements the RemoveGroupMember RPC method
+func (s *UserServer) RemoveGroupMember(ctx context.Context, req *user.GroupMemberRequest) (*user.GroupResponse, error) {
+	groupID, memberID, err := groupMemberIDs(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.groupService.RemoveMember(ctx, groupID, memberID); err != nil {
+		s.log.Errorf("Failed to remove group member: %v", err)
+		return nil, toStatus(err, "failed to remove group member")
+	}
+	return &user.GroupResponse{Message: "Member removed successfully"}, nil
+}
+
+// ListGroupMembers implements the ListGroupMembers RPC method
+func (s *UserServer) ListGroupMembers(ctx context.Context, req *user.ListGroupMembersRequest) (*user.ListGroupMembersResponse, error) {
+	id, err := bson.ObjectIDFromHex(req.GetGroupId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid group ID")
+	}
+
+	page, err := s.groupService.ListMembers(ctx, id, &ports.PageRequest{PageSize: int64(req.GetPageSize()), PageToken: req.GetPageToken()})
+	if err != nil {
+		return nil, toStatus(err, "failed to list group members")
+	}
+
+	response := &user.ListGroupMembersResponse{
+		Members:       make([]*user.GroupMember, len(page.Members)),
+		NextPageToken: page.NextPageToken,
+	}
+	for i, member := range page.Members {
+		response.Members[i] = &user.GroupMember{
+			Kind:    string(member.Kind),
+			Id:      member.ID.Hex(),
+			AddedAt: timestamppb.New(member.AddedAt),
+		}
+	}
+	return response, nil
+}
+
+// ListUserGroups implements the ListUserGroups RPC method, for the user and admins
+func (s *UserServer) ListUserGroups(ctx context.Context, req *user.ListUserGroupsRequest) (*user.ListGroupsResponse, error) {
+	id, err := bson.ObjectIDFromHex(req.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+	if callerOf(ctx).ViewOf(id) == ports.ViewPublic {
+		return nil, status.Error(codes.PermissionDenied, "only the user and admins can list their groups")
+	}
+
+	page, err := s.groupService.ListUserGroups(ctx, id, &ports.PageRequest{PageSize: int64(req.GetPageSize()), PageToken: req.GetPageToken()})
+	if err != nil {
+		return nil, toStatus(err, "failed to list user groups")
+	}
+	return toProtoGroupPage(page), nil
+}
+
+// groupUpdateOf converts the request into a partial update, see UpdateGroupRequest
+func groupUpdateOf(req *user.UpdateGroupRequest) (*ports.GroupUpdate, error) {
+	paths := req.GetUpdateMask().GetPaths()
+	if req.GetUpdateMask() == nil {
+		if req.Name != nil {
+			paths = append(paths, "name")
+		}
+		if req.Description != nil {
+			paths = append(paths, "description")
+		}
+		if len(req.GetRoles()) > 0 {
+			paths = append(paths, "roles")
+		}
+	}
+
+	update := &ports.GroupUpdate{}
+	for _, path := range paths {
+		switch path {
+		case "name":
+			if req.Name == nil {
+				update.Name = ports.Cleared[string]()
+			} else {
+				update.Name = ports.SetTo(req.GetName())
+			}
+		case "description":
+			if req.Description == nil {
+				update.Description = ports.Cleared[string]()
+			} else {
+				update.Description = ports.SetTo(req.GetDescription())
+			}
+		case "roles":
+			if len(req.GetRoles()) == 0 {
+				update.Roles = ports.Cleared[[]string]()
+			} else {
+				update.Roles = ports.SetTo(req.GetRoles())
+			}
+		default:
+			return nil, fmt.Errorf("%w: field %q can't be updated, expected name, description or roles", domain.ErrInvalidArgument, path)
+		}
+	}
+	return update, nil
+}
+
+// groupMemberIDs parses the ids of a membership request
+func groupMemberIDs(req *user.GroupMemberRequest) (bson.ObjectID, bson.ObjectID, error) {
+	groupID, err := bson.ObjectIDFromHex(req.GetGroupId())
+	if err != nil {
+		return groupID, groupID, status.Error(codes.InvalidArgument, "invalid group ID")
+	}
+	memberID, err := bson.ObjectIDFromHex(req.GetMemberId())
+	if err != nil {
+		return groupID, memberID, status.Error(codes.InvalidArgument, "invalid member ID")
+	}
+	return groupID, memberID, nil
+}
+
+func toProtoGroupPage(page *ports.GroupPage) *user.ListGroupsResponse {
+	response := &user.ListGroupsResponse{
+		Groups:        make([]*user.Group, len(page.Groups)),
+		NextPageToken: page.NextPageToken,
+	}
+	for i := range page.Groups {
+		response.Groups[i] = toProtoGroup(&page.Groups[i])
+	}
+	return response
+}
+
+func toProtoGroup(group *domain.Group) *user.Group {
+	return &user.Group{
+		Id:          group.ID.Hex(),
+		Name:        group.Name,
+		Description: group.Description,
+		Roles:       group.Roles,
+		CreatedAt:   timestamppb.New(group.CreatedAt),
+		UpdatedAt:   timestamppb.New(group.UpdatedAt),
+	}
+}
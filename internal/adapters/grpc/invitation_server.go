@@ -0,0 +1,117 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/hinphansa/7-solutions-challenge/api/gen/user/github.com/hinphansa/7-solutions-challenge/api/gen/user"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CreateInvitation implements the CreateInvitation RPC method
+func (s *UserServer) CreateInvitation(ctx context.Context, req *user.CreateInvitationRequest) (*user.Invitation, error) {
+	groupIDs := make([]bson.ObjectID, len(req.GetGroupIds()))
+	for i, raw := range req.GetGroupIds() {
+		id, err := bson.ObjectIDFromHex(raw)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid group ID")
+		}
+		groupIDs[i] = id
+	}
+	invitedBy := callerOf(ctx).ID
+
+	invitation, err := s.invitationService.Invite(ctx, &domain.Invitation{
+		Email:     req.GetEmail(),
+		Roles:     req.GetRoles(),
+		GroupIDs:  groupIDs,
+		InvitedBy: invitedBy,
+	})
+	if err != nil {
+		s.log.Errorf("Failed to invite user: %v", err)
+		return nil, toStatus(err, "failed to invite user")
+	}
+	return toProtoInvitation(invitation), nil
+}
+
+// ListInvitations implements the ListInvitations RPC method
+func (s *UserServer) ListInvitations(ctx context.Context, req *user.ListInvitationsRequest) (*user.ListInvitationsResponse, error) {
+	page, err := s.invitationService.ListInvitations(ctx, &ports.PageRequest{PageSize: int64(req.GetPageSize()), PageToken: req.GetPageToken()})
+	if err != nil {
+		return nil, toStatus(err, "failed to list invitations")
+	}
+
+	response := &user.ListInvitationsResponse{
+		Invitations:   make([]*user.Invitation, len(page.Invitations)),
+		NextPageToken: page.NextPageToken,
+	}
+	for i := range page.Invitations {
+		response.Invitations[i] = toProtoInvitation(&page.Invitations[i])
+	}
+	return response, nil
+}
+
+// ResendInvitation implements the ResendInvitation RPC method
+func (s *UserServer) ResendInvitation(ctx context.Context, req *user.InvitationRequest) (*user.Invitation, error) {
+	id, err := bson.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid invitation ID")
+	}
+
+	invitation, err := s.invitationService.ResendInvitation(ctx, id)
+	if err != nil {
+		s.log.Errorf("Failed to resend invitation: %v", err)
+		return nil, toStatus(err, "failed to resend invitation")
+	}
+	return toProtoInvitation(invitation), nil
+}
+
+// RevokeInvitation implements the RevokeInvitation RPC method
+func (s *UserServer) RevokeInvitation(ctx context.Context, req *user.InvitationRequest) (*user.RevokeInvitationResponse, error) {
+	id, err := bson.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid invitation ID")
+	}
+
+	if err := s.invitationService.RevokeInvitation(ctx, id); err != nil {
+		s.log.Errorf("Failed to revoke invitation: %v", err)
+		return nil, toStatus(err, "failed to revoke invitation")
+	}
+	return &user.RevokeInvitationResponse{Message: "Invitation revoked successfully"}, nil
+}
+
+// AcceptInvitation implements the AcceptInvitation RPC method, authenticated by the mailed token
+func (s *UserServer) AcceptInvitation(ctx context.Context, req *user.AcceptInvitationRequest) (*user.CreateUserResponse, error) {
+	id, err := s.invitationService.AcceptInvitation(ctx, req.GetToken(), &domain.User{
+		Name:     req.GetName(),
+		Password: req.GetPassword(),
+		Username: req.GetUsername(),
+	})
+	if err != nil {
+		return nil, toStatus(err, "failed to accept invitation")
+	}
+	return &user.CreateUserResponse{Id: id.Hex()}, nil
+}
+
+func toProtoInvitation(invitation *domain.Invitation) *user.Invitation {
+	groupIDs := make([]string, len(invitation.GroupIDs))
+	for i, id := range invitation.GroupIDs {
+		groupIDs[i] = id.Hex()
+	}
+	response := &user.Invitation{
+		Id:        invitation.ID.Hex(),
+		Email:     invitation.Email,
+		Roles:     invitation.Roles,
+		GroupIds:  groupIDs,
+		ExpiresAt: timestamppb.New(invitation.ExpiresAt),
+		SentAt:    timestamppb.New(invitation.SentAt),
+		CreatedAt: timestamppb.New(invitation.CreatedAt),
+	}
+	if !invitation.InvitedBy.IsZero() {
+		response.InvitedBy = invitation.InvitedBy.Hex()
+	}
+	return response
+}
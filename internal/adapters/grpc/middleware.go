@@ -2,9 +2,14 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"strings"
 
 	"github.com/hinphansa/7-solutions-challenge/internal/adapters/auth"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -14,56 +19,152 @@ import (
 type ctxKey string
 
 const (
-	userIDKey ctxKey = "user_id"
+	userIDKey   ctxKey = "user_id"
+	rolesKey    ctxKey = "roles"
+	tokenTenant ctxKey = "token_tenant"
 )
 
-// UnaryAuthInterceptor is a gRPC middleware that handles JWT authentication
-func UnaryAuthInterceptor(jwtManager *auth.JWTMaker) grpc.UnaryServerInterceptor {
+// errMissingToken is a request without a token, public endpoints serve it anonymously
+var errMissingToken = status.Error(codes.Unauthenticated, "missing authorization token")
+
+// UnaryAuthInterceptor is a gRPC middleware that handles JWT authentication. Public endpoints
+// take an optional token, ListUsers and SearchUsers require one when requireAuthForListing.
+// Tokens of users no longer active are refused.
+func UnaryAuthInterceptor(jwtManager *auth.JWTMaker, authService ports.AuthService, requireAuthForListing bool) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
-		// Skip authentication for public endpoints
-		if isPublicEndpoint(info.FullMethod) {
+		public := isPublicEndpoint(info.FullMethod) && !(requireAuthForListing && isListingEndpoint(info.FullMethod))
+
+		ctx, err := authenticate(ctx, jwtManager, authService)
+		if errors.Is(err, errMissingToken) && public {
 			return handler(ctx, req)
 		}
-
-		// Get token from metadata
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			return nil, status.Error(codes.Unauthenticated, "missing metadata")
+		if err != nil {
+			return nil, err
 		}
 
-		values := md.Get("authorization")
-		if len(values) == 0 {
-			return nil, status.Error(codes.Unauthenticated, "missing authorization token")
+		if isAdminEndpoint(info.FullMethod) && !hasRole(ctx, domain.RoleAdmin) {
+			return nil, status.Error(codes.PermissionDenied, "admin role required")
 		}
-
-		// Extract token from "Bearer <token>"
-		authHeader := values[0]
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			return nil, status.Error(codes.Unauthenticated, "invalid authorization format")
+		if isSuperAdminEndpoint(info.FullMethod) && !hasRole(ctx, domain.RoleSuperAdmin) {
+			return nil, status.Error(codes.PermissionDenied, "superadmin role required")
 		}
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		return handler(ctx, req)
+	}
+}
 
-		// Validate the JWT
-		id, valid, err := jwtManager.Verify(tokenString)
-		if err != nil {
-			return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
-		}
-		if !valid {
-			return nil, status.Errorf(codes.Unauthenticated, "invalid token")
-		}
+// authenticate verifies the bearer token of the request and puts the caller in the context,
+// once its user is checked to still be active
+func authenticate(ctx context.Context, jwtManager *auth.JWTMaker, authService ports.AuthService) (context.Context, error) {
+	// Get token from metadata
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ctx, errMissingToken
+	}
 
-		ctx = context.WithValue(ctx, userIDKey, id)
-		return handler(ctx, req)
+	// Extract token from "Bearer <token>"
+	authHeader := values[0]
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return ctx, status.Error(codes.Unauthenticated, "invalid authorization format")
+	}
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+
+	// Validate the JWT
+	claims, err := jwtManager.Verify(tokenString)
+	if err != nil {
+		return ctx, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+	if claims.TenantID.IsZero() {
+		return ctx, status.Error(codes.Unauthenticated, "token has no organization, log in again")
+	}
+	if err := authService.CheckActive(ports.WithTenant(ctx, claims.TenantID), claims.ID); err != nil {
+		if errors.Is(err, domain.ErrForbidden) {
+			return ctx, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return ctx, toStatus(err, "failed to authenticate")
 	}
+
+	ctx = context.WithValue(ctx, userIDKey, claims.ID)
+	ctx = context.WithValue(ctx, rolesKey, claims.Roles)
+	ctx = context.WithValue(ctx, tokenTenant, claims.TenantID)
+	return ctx, nil
+}
+
+// isListingEndpoint checks if the endpoint lists users
+func isListingEndpoint(fullMethod string) bool {
+	return fullMethod == "/user.UserService/ListUsers" || fullMethod == "/user.UserService/SearchUsers"
 }
 
 // isPublicEndpoint checks if the endpoint requires authentication
 func isPublicEndpoint(fullMethod string) bool {
 	publicEndpoints := map[string]bool{
-		"/user.UserService/CreateUser":  true,
-		"/user.UserService/GetUserById": true,
-		"/user.UserService/ListUsers":   true,
-		"/user.UserService/Login":       true,
+		"/user.UserService/CreateUser":        true,
+		"/user.UserService/GetUserById":       true,
+		"/user.UserService/GetUserByUsername": true,
+		"/user.UserService/CheckUsername":     true,
+		"/user.UserService/ListUsers":         true,
+		"/user.UserService/SearchUsers":       true,
+		"/user.UserService/Login":             true,
+
+		"/user.UserService/ConfirmEmailChange": true,
+		"/user.UserService/RevertEmailChange":  true,
+		"/user.UserService/AcceptInvitation":   true,
 	}
 	return publicEndpoints[fullMethod]
 }
+
+// isAdminEndpoint checks if the endpoint requires the admin role
+func isAdminEndpoint(fullMethod string) bool {
+	adminEndpoints := map[string]bool{
+		"/user.UserService/SuspendUser":    true,
+		"/user.UserService/ReactivateUser": true,
+
+		"/user.UserService/ListPendingRegistrations": true,
+		"/user.UserService/ApproveRegistration":      true,
+		"/user.UserService/RejectRegistration":       true,
+
+		"/user.UserService/ListAttributes":  true,
+		"/user.UserService/PutAttribute":    true,
+		"/user.UserService/DeleteAttribute": true,
+
+		"/user.UserService/CreateGroup":       true,
+		"/user.UserService/GetGroup":          true,
+		"/user.UserService/ListGroups":        true,
+		"/user.UserService/UpdateGroup":       true,
+		"/user.UserService/DeleteGroup":       true,
+		"/user.UserService/AddGroupMember":    true,
+		"/user.UserService/RemoveGroupMember": true,
+		"/user.UserService/ListGroupMembers":  true,
+
+		"/user.UserService/CreateInvitation": true,
+		"/user.UserService/ListInvitations":  true,
+		"/user.UserService/ResendInvitation": true,
+		"/user.UserService/RevokeInvitation": true,
+	}
+	return adminEndpoints[fullMethod]
+}
+
+// isSuperAdminEndpoint checks if the endpoint requires the superadmin role
+func isSuperAdminEndpoint(fullMethod string) bool {
+	superAdminEndpoints := map[string]bool{
+		"/user.UserService/CreateOrganization": true,
+		"/user.UserService/GetOrganization":    true,
+		"/user.UserService/ListOrganizations":  true,
+		"/user.UserService/UpdateOrganization": true,
+		"/user.UserService/DeleteOrganization": true,
+	}
+	return superAdminEndpoints[fullMethod]
+}
+
+// callerOf returns the principal authenticated by UnaryAuthInterceptor, anonymous when there's none
+func callerOf(ctx context.Context) ports.Caller {
+	id, _ := ctx.Value(userIDKey).(bson.ObjectID)
+	roles, _ := ctx.Value(rolesKey).([]string)
+	return ports.Caller{ID: id, Roles: roles}
+}
+
+// hasRole reports whether the authenticated caller has role
+func hasRole(ctx context.Context, role string) bool {
+	roles, _ := ctx.Value(rolesKey).([]string)
+	return slices.Contains(roles, role)
+}
@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hinphansa/7-solutions-challenge/internal/adapters/auth"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/mocks"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryAuthInterceptor_InactiveUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+	authService := mocks.NewMockAuthService(ctrl)
+	jwtMaker := auth.NewJWT("secret", time.Hour)
+	interceptor := UnaryAuthInterceptor(jwtMaker, authService, false)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/user.UserService/UpdateUser"}
+	handler := func(ctx context.Context, req any) (any, error) { return nil, nil }
+	call := func(id bson.ObjectID) error {
+		t.Helper()
+		token, err := jwtMaker.Generate(id, bson.NewObjectID(), "jane@example.com", nil)
+		if err != nil {
+			t.Fatalf("generate: %v", err)
+		}
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+		_, err = interceptor(ctx, nil, info, handler)
+		return err
+	}
+
+	active, suspended := bson.NewObjectID(), bson.NewObjectID()
+	authService.EXPECT().CheckActive(gomock.Any(), active).Return(nil)
+	authService.EXPECT().CheckActive(gomock.Any(), suspended).Return(domain.ErrForbidden)
+
+	if err := call(active); err != nil {
+		t.Fatalf("expected an active user to pass, got %v", err)
+	}
+	// the token is still valid, its user was suspended since it was issued
+	if err := call(suspended); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected a suspended user to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/hinphansa/7-solutions-challenge/api/gen/user/github.com/hinphansa/7-solutions-challenge/api/gen/user"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CreateOrganization implements the CreateOrganization RPC method
+func (s *UserServer) CreateOrganization(ctx context.Context, req *user.CreateOrganizationRequest) (*user.Organization, error) {
+	org, err := s.organizationService.CreateOrganization(ctx, &domain.Organization{Slug: req.GetSlug(), Name: req.GetName()})
+	if err != nil {
+		s.log.Errorf("Failed to create organization: %v", err)
+		return nil, toStatus(err, "failed to create organization")
+	}
+	return toProtoOrganization(org), nil
+}
+
+// GetOrganization implements the GetOrganization RPC method
+func (s *UserServer) GetOrganization(ctx context.Context, req *user.GetOrganizationRequest) (*user.Organization, error) {
+	id, err := bson.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid organization ID")
+	}
+
+	org, err := s.organizationService.GetOrganization(ctx, id)
+	if err != nil {
+		s.log.Errorf("Failed to get organization: %v", err)
+		return nil, toStatus(err, "failed to get organization")
+	}
+	return toProtoOrganization(org), nil
+}
+
+// ListOrganizations implements the ListOrganizations RPC method
+func (s *UserServer) ListOrganizations(ctx context.Context, req *user.ListOrganizationsRequest) (*user.ListOrganizationsResponse, error) {
+	orgs, err := s.organizationService.ListOrganizations(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to list organizations: %v", err)
+		return nil, toStatus(err, "failed to list organizations")
+	}
+
+	response := &user.ListOrganizationsResponse{Organizations: make([]*user.Organization, len(orgs))}
+	for i := range orgs {
+		response.Organizations[i] = toProtoOrganization(&orgs[i])
+	}
+	return response, nil
+}
+
+// UpdateOrganization implements the UpdateOrganization RPC method
+func (s *UserServer) UpdateOrganization(ctx context.Context, req *user.UpdateOrganizationRequest) (*user.Organization, error) {
+	id, err := bson.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid organization ID")
+	}
+
+	org, err := s.organizationService.UpdateOrganization(ctx, id, &ports.OrganizationUpdate{Name: req.GetName(), Slug: req.GetSlug()})
+	if err != nil {
+		s.log.Errorf("Failed to update organization: %v", err)
+		return nil, toStatus(err, "failed to update organization")
+	}
+	return toProtoOrganization(org), nil
+}
+
+// DeleteOrganization implements the DeleteOrganization RPC method
+func (s *UserServer) DeleteOrganization(ctx context.Context, req *user.DeleteOrganizationRequest) (*user.DeleteOrganizationResponse, error) {
+	id, err := bson.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid organization ID")
+	}
+
+	if err := s.organizationService.DeleteOrganization(ctx, id); err != nil {
+		s.log.Errorf("Failed to delete organization: %v", err)
+		return nil, toStatus(err, "failed to delete organization")
+	}
+	return &user.DeleteOrganizationResponse{Message: "Organization deleted successfully"}, nil
+}
+
+func toProtoOrganization(org *domain.Organization) *user.Organization {
+	return &user.Organization{
+		Id:        org.ID.Hex(),
+		Slug:      org.Slug,
+		Name:      org.Name,
+		CreatedAt: timestamppb.New(org.CreatedAt),
+		UpdatedAt: timestamppb.New(org.UpdatedAt),
+	}
+}
@@ -0,0 +1,78 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/hinphansa/7-solutions-challenge/api/gen/user/github.com/hinphansa/7-solutions-challenge/api/gen/user"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// GetSettings implements the GetSettings RPC method
+func (s *UserServer) GetSettings(ctx context.Context, req *user.GetSettingsRequest) (*user.SettingsResponse, error) {
+	id, err := settingsOwner(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.settingsService.GetSettings(ctx, id)
+	if err != nil {
+		s.log.Errorf("Failed to get settings: %v", err)
+		return nil, toStatus(err, "failed to get settings")
+	}
+	return toSettingsResponse(settings)
+}
+
+// ReplaceSettings implements the ReplaceSettings RPC method
+func (s *UserServer) ReplaceSettings(ctx context.Context, req *user.UpdateSettingsRequest) (*user.SettingsResponse, error) {
+	return s.writeSettings(ctx, req, s.settingsService.ReplaceSettings)
+}
+
+// UpdateSettings implements the UpdateSettings RPC method
+func (s *UserServer) UpdateSettings(ctx context.Context, req *user.UpdateSettingsRequest) (*user.SettingsResponse, error) {
+	return s.writeSettings(ctx, req, s.settingsService.UpdateSettings)
+}
+
+func (s *UserServer) writeSettings(ctx context.Context, req *user.UpdateSettingsRequest, write func(context.Context, bson.ObjectID, map[string]any) (domain.Settings, error)) (*user.SettingsResponse, error) {
+	id, err := settingsOwner(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	// null values reset settings to their default
+	settings, err := write(ctx, id, req.GetSettings().AsMap())
+	if err != nil {
+		s.log.Errorf("Failed to update settings: %v", err)
+		return nil, toStatus(err, "failed to update settings")
+	}
+	return toSettingsResponse(settings)
+}
+
+// settingsOwner returns the user id of a settings request if it's the caller
+func settingsOwner(ctx context.Context, reqID string) (bson.ObjectID, error) {
+	userID, ok := ctx.Value(userIDKey).(bson.ObjectID)
+	if !ok {
+		return bson.ObjectID{}, status.Error(codes.Unauthenticated, "missing user ID")
+	}
+
+	id, err := bson.ObjectIDFromHex(reqID)
+	if err != nil {
+		return id, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	if userID != id {
+		return id, status.Error(codes.PermissionDenied, "only the user can access their settings")
+	}
+	return id, nil
+}
+
+func toSettingsResponse(settings domain.Settings) (*user.SettingsResponse, error) {
+	pb, err := structpb.NewStruct(settings)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to encode settings")
+	}
+	return &user.SettingsResponse{Settings: pb}, nil
+}
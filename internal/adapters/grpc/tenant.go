@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tenantMetadata names the organization of a request by its slug
+const tenantMetadata = "x-tenant"
+
+// UnaryTenantInterceptor scopes the request context to an organization, it runs after
+// UnaryAuthInterceptor. Authenticated requests are scoped to the organization of their
+// token, which must match the x-tenant metadata when given. Anonymous requests are scoped
+// to the organization of the metadata, else to defaultSlug unless empty.
+func UnaryTenantInterceptor(orgsvc ports.OrganizationService, defaultSlug string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		var requested bson.ObjectID
+		if values := md.Get(tenantMetadata); len(values) > 0 && values[0] != "" {
+			org, err := orgsvc.ResolveOrganization(ctx, values[0])
+			if err != nil {
+				return nil, toStatus(err, "failed to resolve organization")
+			}
+			requested = org.ID
+		}
+
+		if tenantID, ok := ctx.Value(tokenTenant).(bson.ObjectID); ok {
+			if !requested.IsZero() && requested != tenantID {
+				return nil, status.Error(codes.PermissionDenied, "token belongs to another organization")
+			}
+			return handler(ports.WithTenant(ctx, tenantID), req)
+		}
+
+		if requested.IsZero() && defaultSlug != "" {
+			org, err := orgsvc.ResolveOrganization(ctx, defaultSlug)
+			if err != nil {
+				return nil, toStatus(err, "failed to resolve organization")
+			}
+			requested = org.ID
+		}
+		if requested.IsZero() {
+			return nil, status.Error(codes.InvalidArgument, "organization required, name it with the "+tenantMetadata+" metadata")
+		}
+		return handler(ports.WithTenant(ctx, requested), req)
+	}
+}
@@ -2,45 +2,69 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"strings"
 
 	"github.com/hinphansa/7-solutions-challenge/api/gen/user/github.com/hinphansa/7-solutions-challenge/api/gen/user"
 	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/filterexpr"
 	"github.com/hinphansa/7-solutions-challenge/internal/ports"
 	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type UserServer struct {
 	user.UnimplementedUserServiceServer
-	log         logger.Logger
-	userService ports.UserService
-	authService ports.AuthService
+	log              logger.Logger
+	userService      ports.UserService
+	authService      ports.AuthService
+	attributeService ports.AttributeService
+	settingsService  ports.SettingsService
+
+	organizationService ports.OrganizationService
+	groupService        ports.GroupService
+	invitationService   ports.InvitationService
 }
 
-func NewUserServer(log logger.Logger, userService ports.UserService, authService ports.AuthService) *UserServer {
+func NewUserServer(log logger.Logger, userService ports.UserService, authService ports.AuthService, attributeService ports.AttributeService, settingsService ports.SettingsService, organizationService ports.OrganizationService, groupService ports.GroupService, invitationService ports.InvitationService) *UserServer {
 	return &UserServer{
-		log:         log,
-		userService: userService,
-		authService: authService,
+		log:                 log,
+		userService:         userService,
+		authService:         authService,
+		attributeService:    attributeService,
+		settingsService:     settingsService,
+		organizationService: organizationService,
+		groupService:        groupService,
+		invitationService:   invitationService,
 	}
 }
 
 // CreateUser implements the CreateUser RPC method
 func (s *UserServer) CreateUser(ctx context.Context, req *user.CreateUserRequest) (*user.CreateUserResponse, error) {
-	id, err := s.userService.Register(ctx, &domain.User{
-		Name:     req.GetName(),
-		Email:    req.GetEmail(),
-		Password: req.GetPassword(),
-	})
+	u := &domain.User{
+		Name:        req.GetName(),
+		Email:       req.GetEmail(),
+		Password:    req.GetPassword(),
+		Username:    req.GetUsername(),
+		DisplayName: req.GetDisplayName(),
+		Phone:       req.GetPhone(),
+		Locale:      req.GetLocale(),
+		TimeZone:    req.GetTimeZone(),
+		AvatarURL:   req.GetAvatarUrl(),
+		Bio:         req.GetBio(),
+		Attributes:  req.GetAttributes().AsMap(),
+	}
+	id, err := s.userService.Register(ctx, u)
 	if err != nil {
 		s.log.Errorf("Failed to create user: %v", err)
-		return nil, status.Error(codes.Internal, "failed to create user")
+		return nil, toStatus(err, "failed to create user")
 	}
 
-	return &user.CreateUserResponse{Id: id.Hex()}, nil
+	return &user.CreateUserResponse{Id: id.Hex(), Status: string(u.Status)}, nil
 }
 
 // GetUserById implements the GetUserById RPC method
@@ -50,52 +74,169 @@ func (s *UserServer) GetUserById(ctx context.Context, req *user.GetUserRequest)
 		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
 	}
 
-	u, err := s.userService.GetByID(ctx, id)
+	fields, err := ports.ParseFields(req.GetReadMask().GetPaths())
+	if err != nil {
+		return nil, toStatus(err, "invalid read_mask")
+	}
+
+	view := callerOf(ctx).ViewOf(id)
+	u, err := s.userService.GetByID(ctx, id, view.Fields(fields)...)
 	if err != nil {
 		s.log.Errorf("Failed to get user: %v", err)
 		return nil, status.Error(codes.Internal, "failed to get user")
 	}
 
-	return &user.User{
-		Id:        u.ID.Hex(),
-		Name:      u.Name,
-		Email:     u.Email,
-		CreatedAt: timestamppb.New(u.CreatedAt),
-	}, nil
+	return toProtoUserView(u, view), nil
+}
+
+// GetUserByUsername implements the GetUserByUsername RPC method, a previous username
+// within its grace period returns the user under its current username.
+func (s *UserServer) GetUserByUsername(ctx context.Context, req *user.GetUserByUsernameRequest) (*user.User, error) {
+	fields, err := ports.ParseFields(req.GetReadMask().GetPaths())
+	if err != nil {
+		return nil, toStatus(err, "invalid read_mask")
+	}
+
+	// the view depends on who the user is, so the user is loaded whole then masked
+	u, _, err := s.userService.GetByUsername(ctx, req.GetUsername())
+	if err != nil {
+		s.log.Errorf("Failed to get user by username: %v", err)
+		return nil, toStatus(err, "failed to get user")
+	}
+
+	view := callerOf(ctx).ViewOf(u.ID)
+	ports.MaskUser(u, view.Fields(fields))
+	return toProtoUserView(u, view), nil
+}
+
+// CheckUsername implements the CheckUsername RPC method
+func (s *UserServer) CheckUsername(ctx context.Context, req *user.CheckUsernameRequest) (*user.CheckUsernameResponse, error) {
+	username, err := s.userService.CheckUsername(ctx, req.GetUsername())
+	switch {
+	case err == nil:
+		return &user.CheckUsernameResponse{Username: username, Available: true}, nil
+	case errors.Is(err, domain.ErrInvalidArgument), errors.Is(err, domain.ErrConflict):
+		return &user.CheckUsernameResponse{
+			Username: strings.ToLower(strings.TrimSpace(req.GetUsername())),
+			Reason:   err.Error(),
+		}, nil
+	default:
+		s.log.Errorf("Failed to check username: %v", err)
+		return nil, toStatus(err, "failed to check username")
+	}
 }
 
 // ListUsers implements the ListUsers RPC method
 func (s *UserServer) ListUsers(ctx context.Context, req *user.ListUsersRequest) (*user.ListUsersResponse, error) {
-	users, err := s.userService.List(ctx, &ports.Pagination{
-		Limit:  int64(req.GetLimit()),
-		Offset: int64(req.GetOffset()),
+	if req.GetOffset() != 0 {
+		return nil, status.Error(codes.InvalidArgument, "offset is not supported, use page_token")
+	}
+	if req.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid limit")
+	}
+
+	sort, err := ports.ParseSort(req.GetOrderBy())
+	if err != nil {
+		return nil, toStatus(err, "invalid order_by")
+	}
+
+	expr, err := filterexpr.Parse(req.GetFilter())
+	if err != nil {
+		return nil, toStatus(err, "invalid filter")
+	}
+
+	fields, err := ports.ParseFields(req.GetReadMask().GetPaths())
+	if err != nil {
+		return nil, toStatus(err, "invalid read_mask")
+	}
+
+	view := callerOf(ctx).ViewOf(bson.ObjectID{})
+	if err := view.CheckSort(sort); err != nil {
+		return nil, toStatus(err, "invalid order_by")
+	}
+
+	filter := ports.UserFilter{
+		NamePrefix:  req.GetNamePrefix(),
+		EmailDomain: req.GetEmailDomain(),
+		Search:      req.GetSearch(),
+		Status:      req.GetStatus(),
+		Expr:        expr,
+	}
+	if req.CreatedAfter != nil {
+		t := req.GetCreatedAfter().AsTime()
+		filter.CreatedAfter = &t
+	}
+	if req.CreatedBefore != nil {
+		t := req.GetCreatedBefore().AsTime()
+		filter.CreatedBefore = &t
+	}
+	if err := view.CheckFilter(&filter); err != nil {
+		return nil, toStatus(err, "invalid filter")
+	}
+
+	page, err := s.userService.List(ctx, &ports.ListRequest{
+		Filter:       filter,
+		Sort:         sort,
+		PageSize:     int64(req.GetLimit()),
+		PageToken:    req.GetPageToken(),
+		IncludeTotal: req.GetIncludeTotal(),
+		Fields:       view.Fields(fields),
 	})
 	if err != nil {
 		s.log.Errorf("Failed to list users: %v", err)
-		return nil, status.Error(codes.Internal, "failed to list users")
+		return nil, toStatus(err, "failed to list users")
 	}
 
 	response := &user.ListUsersResponse{
-		Users: make([]*user.User, len(users)),
+		Users:         make([]*user.User, len(page.Users)),
+		NextPageToken: page.NextPageToken,
+		TotalCount:    page.TotalCount,
 	}
 
-	for i, u := range users {
-		response.Users[i] = &user.User{
-			Id:        u.ID.Hex(),
-			Name:      u.Name,
-			Email:     u.Email,
-			CreatedAt: timestamppb.New(u.CreatedAt),
-		}
+	for i := range page.Users {
+		response.Users[i] = toProtoUserView(&page.Users[i], view)
 	}
 
 	return response, nil
 }
 
+// SearchUsers implements the SearchUsers RPC method
+func (s *UserServer) SearchUsers(ctx context.Context, req *user.SearchUsersRequest) (*user.SearchUsersResponse, error) {
+	if req.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid limit")
+	}
+
+	view := callerOf(ctx).ViewOf(bson.ObjectID{})
+	results, err := s.userService.Search(ctx, &ports.SearchRequest{
+		Query:  req.GetQuery(),
+		Limit:  int64(req.GetLimit()),
+		Fields: view.SearchFields(),
+	})
+	if err != nil {
+		s.log.Errorf("Failed to search users: %v", err)
+		return nil, toStatus(err, "failed to search users")
+	}
+
+	response := &user.SearchUsersResponse{Results: make([]*user.SearchResult, len(results))}
+	for i := range results {
+		result := view.SearchResult(results[i])
+		response.Results[i] = &user.SearchResult{
+			User:       toProtoUserView(&result.User, view),
+			Score:      result.Score,
+			Highlights: result.Highlights,
+		}
+	}
+	return response, nil
+}
+
 // Login implements the Login RPC method
 func (s *UserServer) Login(ctx context.Context, req *user.LoginRequest) (*user.LoginResponse, error) {
 	token, err := s.authService.Login(ctx, req.GetEmail(), req.GetPassword())
 	if err != nil {
 		s.log.Errorf("Failed to login: %v", err)
+		if errors.Is(err, domain.ErrForbidden) || errors.Is(err, domain.ErrUnavailable) {
+			return nil, toStatus(err, "failed to login")
+		}
 		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
 	}
 
@@ -119,25 +260,30 @@ func (s *UserServer) UpdateUser(ctx context.Context, req *user.UpdateUserRequest
 		return nil, status.Error(codes.Unauthenticated, "missing user ID")
 	}
 
-	err = s.userService.Update(ctx, reqID, &domain.User{
-		Name:  req.GetName(),
-		Email: req.GetEmail(),
-	})
+	update, err := updateFromRequest(req)
+	if err != nil {
+		return nil, toStatus(err, "invalid update_mask")
+	}
+
+	err = s.userService.Update(ctx, reqID, update)
 	if err != nil {
 		s.log.Errorf("Failed to update user: %v", err)
-		return nil, status.Error(codes.Internal, "failed to update user")
+		return nil, toStatus(err, "failed to update user")
 	}
 
+	message := "user updated successfully"
+	if update.Email.Op == ports.Set {
+		message = "user updated successfully, confirm the new email to change it"
+	}
 	return &user.UpdateUserResponse{
-		Message: "user updated successfully",
+		Message: message,
 	}, nil
 }
 
 // DeleteUser implements the DeleteUser RPC method
 func (s *UserServer) DeleteUser(ctx context.Context, req *user.DeleteUserRequest) (*user.DeleteUserResponse, error) {
 	// Get user ID from JWT token metadata
-	userID, ok := ctx.Value(userIDKey).(bson.ObjectID)
-	if !ok {
+	if _, ok := ctx.Value(userIDKey).(bson.ObjectID); !ok {
 		return nil, status.Error(codes.Unauthenticated, "missing user ID")
 	}
 
@@ -146,17 +292,234 @@ func (s *UserServer) DeleteUser(ctx context.Context, req *user.DeleteUserRequest
 		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
 	}
 
-	if len(userID) == 0 || userID != reqID {
-		return nil, status.Error(codes.Unauthenticated, "missing user ID")
+	// users delete their own account, admins any account of the organization
+	if callerOf(ctx).ViewOf(reqID) == ports.ViewPublic {
+		return nil, status.Error(codes.PermissionDenied, "only the user or an admin can delete the account")
 	}
 
 	err = s.userService.Delete(ctx, reqID)
 	if err != nil {
 		s.log.Errorf("Failed to delete user: %v", err)
-		return nil, status.Error(codes.Internal, "failed to delete user")
+		return nil, toStatus(err, "failed to delete user")
 	}
 
 	return &user.DeleteUserResponse{
 		Message: "user deleted successfully",
 	}, nil
 }
+
+// RequestEmailChange implements the RequestEmailChange RPC method
+func (s *UserServer) RequestEmailChange(ctx context.Context, req *user.RequestEmailChangeRequest) (*user.EmailChangeResponse, error) {
+	userID, ok := ctx.Value(userIDKey).(bson.ObjectID)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing user ID")
+	}
+
+	reqID, err := bson.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	if userID != reqID {
+		return nil, status.Error(codes.PermissionDenied, "only the user can change their email")
+	}
+
+	if err := s.userService.RequestEmailChange(ctx, reqID, req.GetEmail()); err != nil {
+		s.log.Errorf("Failed to request email change: %v", err)
+		return nil, toStatus(err, "failed to request email change")
+	}
+	return &user.EmailChangeResponse{Message: "confirmation sent to the new email"}, nil
+}
+
+// ConfirmEmailChange implements the ConfirmEmailChange RPC method
+func (s *UserServer) ConfirmEmailChange(ctx context.Context, req *user.EmailChangeTokenRequest) (*user.EmailChangeResponse, error) {
+	if err := s.userService.ConfirmEmailChange(ctx, req.GetToken()); err != nil {
+		return nil, toStatus(err, "failed to confirm email change")
+	}
+	return &user.EmailChangeResponse{Message: "email changed successfully"}, nil
+}
+
+// RevertEmailChange implements the RevertEmailChange RPC method
+func (s *UserServer) RevertEmailChange(ctx context.Context, req *user.EmailChangeTokenRequest) (*user.EmailChangeResponse, error) {
+	if err := s.userService.RevertEmailChange(ctx, req.GetToken()); err != nil {
+		return nil, toStatus(err, "failed to revert email change")
+	}
+	return &user.EmailChangeResponse{Message: "email reverted successfully"}, nil
+}
+
+// SuspendUser implements the SuspendUser RPC method
+func (s *UserServer) SuspendUser(ctx context.Context, req *user.ChangeUserStatusRequest) (*user.ChangeUserStatusResponse, error) {
+	return s.changeStatus(ctx, req, domain.StatusSuspended, "Failed to suspend user")
+}
+
+// ReactivateUser implements the ReactivateUser RPC method
+func (s *UserServer) ReactivateUser(ctx context.Context, req *user.ChangeUserStatusRequest) (*user.ChangeUserStatusResponse, error) {
+	return s.changeStatus(ctx, req, domain.StatusActive, "Failed to reactivate user")
+}
+
+// ListPendingRegistrations implements the ListPendingRegistrations RPC method
+func (s *UserServer) ListPendingRegistrations(ctx context.Context, req *user.ListPendingRegistrationsRequest) (*user.ListUsersResponse, error) {
+	page, err := s.userService.ListPendingRegistrations(ctx, &ports.PageRequest{PageSize: int64(req.GetPageSize()), PageToken: req.GetPageToken()})
+	if err != nil {
+		return nil, toStatus(err, "failed to list pending registrations")
+	}
+
+	response := &user.ListUsersResponse{
+		Users:         make([]*user.User, len(page.Users)),
+		NextPageToken: page.NextPageToken,
+	}
+	for i := range page.Users {
+		response.Users[i] = toProtoUserView(&page.Users[i], ports.ViewAdmin)
+	}
+	return response, nil
+}
+
+// ApproveRegistration implements the ApproveRegistration RPC method
+func (s *UserServer) ApproveRegistration(ctx context.Context, req *user.RegistrationRequest) (*user.ChangeUserStatusResponse, error) {
+	id, err := bson.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	u, err := s.userService.ApproveRegistration(ctx, id)
+	if err != nil {
+		s.log.Errorf("Failed to approve registration: %v", err)
+		return nil, toStatus(err, "failed to approve registration")
+	}
+	return &user.ChangeUserStatusResponse{User: toProtoUserView(u, ports.ViewAdmin)}, nil
+}
+
+// RejectRegistration implements the RejectRegistration RPC method
+func (s *UserServer) RejectRegistration(ctx context.Context, req *user.RegistrationRequest) (*user.RejectRegistrationResponse, error) {
+	id, err := bson.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	if err := s.userService.RejectRegistration(ctx, id); err != nil {
+		s.log.Errorf("Failed to reject registration: %v", err)
+		return nil, toStatus(err, "failed to reject registration")
+	}
+	return &user.RejectRegistrationResponse{Message: "Registration rejected successfully"}, nil
+}
+
+func (s *UserServer) changeStatus(ctx context.Context, req *user.ChangeUserStatusRequest, to domain.Status, fallback string) (*user.ChangeUserStatusResponse, error) {
+	id, err := bson.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	u, err := s.userService.ChangeStatus(ctx, id, to, req.GetReason())
+	if err != nil {
+		s.log.Errorf("%s: %v", fallback, err)
+		return nil, toStatus(err, fallback)
+	}
+	return &user.ChangeUserStatusResponse{User: toProtoUserView(u, ports.ViewAdmin)}, nil
+}
+
+// updateFromRequest builds an update from the field mask, or from the fields present in
+// the request when there's no mask.
+func updateFromRequest(req *user.UpdateUserRequest) (*ports.UserUpdate, error) {
+	values := map[string]*string{
+		ports.FieldName:        req.Name,
+		ports.FieldEmail:       req.Email,
+		ports.FieldUsername:    req.Username,
+		ports.FieldDisplayName: req.DisplayName,
+		ports.FieldPhone:       req.Phone,
+		ports.FieldLocale:      req.Locale,
+		ports.FieldTimeZone:    req.TimeZone,
+		ports.FieldAvatarURL:   req.AvatarUrl,
+		ports.FieldBio:         req.Bio,
+	}
+
+	attributes := req.GetAttributes().AsMap()
+
+	paths := req.GetUpdateMask().GetPaths()
+	if req.GetUpdateMask() == nil {
+		for field, value := range values {
+			if value != nil {
+				paths = append(paths, field)
+			}
+		}
+		if len(attributes) > 0 {
+			paths = append(paths, ports.FieldAttributes)
+		}
+	} else if len(paths) == 1 && paths[0] == "*" {
+		paths = append(ports.UpdatableFields(), ports.FieldAttributes)
+	}
+
+	update := &ports.UserUpdate{}
+	for _, path := range paths {
+		var err error
+		if path == ports.FieldAttributes {
+			// every attribute of the request, there's no clearing them all at once
+			for name, value := range attributes {
+				if err := setAttribute(update, name, value); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if name, ok := ports.AttributePath(path); ok {
+			err = setAttribute(update, name, attributes[name])
+		} else if value := values[path]; value != nil {
+			err = update.SetField(path, *value)
+		} else {
+			err = update.ClearField(path) // also rejects unknown fields
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return update, nil
+}
+
+// setAttribute sets an attribute, or clears it for a null or missing value
+func setAttribute(update *ports.UserUpdate, name string, value any) error {
+	if value == nil {
+		return update.ClearAttribute(name)
+	}
+	return update.SetAttribute(name, value)
+}
+
+// toProtoUserView converts a user as seen in view, the user must have been masked to
+// view.Fields. Only admins see the status reason and roles.
+func toProtoUserView(u *domain.User, view ports.View) *user.User {
+	pb := toProtoUser(u)
+	if view == ports.ViewAdmin {
+		pb.StatusReason = u.StatusReason
+		pb.Roles = u.Roles
+	}
+	return pb
+}
+
+// toProtoUser converts a user, fields left out by a read mask are zero and stay unset.
+func toProtoUser(u *domain.User) *user.User {
+	pb := &user.User{
+		Name:        u.Name,
+		Email:       u.Email,
+		Username:    u.Username,
+		Status:      string(u.Status),
+		DisplayName: u.DisplayName,
+		Phone:       u.Phone,
+		Locale:      u.Locale,
+		TimeZone:    u.TimeZone,
+		AvatarUrl:   u.AvatarURL,
+		AvatarHash:  u.AvatarHash,
+		Bio:         u.Bio,
+	}
+	if !u.ID.IsZero() {
+		pb.Id = u.ID.Hex()
+	}
+	if !u.CreatedAt.IsZero() {
+		pb.CreatedAt = timestamppb.New(u.CreatedAt)
+	}
+	if !u.UpdatedAt.IsZero() {
+		pb.UpdatedAt = timestamppb.New(u.UpdatedAt)
+	}
+	if len(u.Attributes) > 0 {
+		// values were checked against the schema, they're strings, numbers and bools
+		pb.Attributes, _ = structpb.NewStruct(u.Attributes)
+	}
+	return pb
+}
@@ -0,0 +1,89 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hinphansa/7-solutions-challenge/api/gen/user/github.com/hinphansa/7-solutions-challenge/api/gen/user"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/mocks"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestUserServer(t *testing.T) (*UserServer, *mocks.MockUserService) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+	userService := mocks.NewMockUserService(ctrl)
+	return NewUserServer(logger.New(logrus.PanicLevel), userService, nil, nil, nil, nil, nil, nil), userService
+}
+
+// withCaller authenticates ctx like UnaryAuthInterceptor
+func withCaller(ctx context.Context, id bson.ObjectID, roles ...string) context.Context {
+	ctx = context.WithValue(ctx, userIDKey, id)
+	return context.WithValue(ctx, rolesKey, roles)
+}
+
+func TestUserServer_ListUsers_PublicFilter(t *testing.T) {
+	server, userService := newTestUserServer(t)
+
+	// filters on fields the public view hides are refused before listing
+	for name, req := range map[string]*user.ListUsersRequest{
+		"email filter":  {Filter: `email ~ "@acme.com"`},
+		"status filter": {Filter: `name ~ "jo" and status = "suspended"`},
+		"email domain":  {EmailDomain: "acme.com"},
+		"search":        {Search: "jane@"},
+		"status":        {Status: "active"},
+	} {
+		for _, ctx := range []context.Context{context.Background(), withCaller(context.Background(), bson.NewObjectID())} {
+			_, err := server.ListUsers(ctx, req)
+			if status.Code(err) != codes.PermissionDenied {
+				t.Fatalf("%s: expected permission denied, got %v", name, err)
+			}
+		}
+	}
+
+	// public fields and admins are fine
+	userService.EXPECT().List(gomock.Any(), gomock.Any()).Return(&ports.UserPage{}, nil).Times(2)
+	if _, err := server.ListUsers(context.Background(), &user.ListUsersRequest{Filter: `name ~ "jo"`}); err != nil {
+		t.Fatalf("expected a name filter to pass, got %v", err)
+	}
+	admin := withCaller(context.Background(), bson.NewObjectID(), domain.RoleAdmin)
+	if _, err := server.ListUsers(admin, &user.ListUsersRequest{Filter: `email ~ "@acme.com"`}); err != nil {
+		t.Fatalf("expected an admin email filter to pass, got %v", err)
+	}
+}
+
+func TestUserServer_SearchUsers_PublicNames(t *testing.T) {
+	server, userService := newTestUserServer(t)
+
+	userService.EXPECT().Search(gomock.Any(), &ports.SearchRequest{Query: "jane", Fields: []string{ports.FieldName}}).Return(nil, nil)
+	if _, err := server.SearchUsers(context.Background(), &user.SearchUsersRequest{Query: "jane"}); err != nil {
+		t.Fatalf("search: %v", err)
+	}
+}
+
+func TestUserServer_DeleteUser_SelfOrAdmin(t *testing.T) {
+	server, userService := newTestUserServer(t)
+	self, other := bson.NewObjectID(), bson.NewObjectID()
+
+	member := withCaller(context.Background(), self)
+	if _, err := server.DeleteUser(member, &user.DeleteUserRequest{Id: other.Hex()}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected deleting another user to be denied, got %v", err)
+	}
+
+	userService.EXPECT().Delete(gomock.Any(), self).Return(nil)
+	userService.EXPECT().Delete(gomock.Any(), other).Return(nil)
+	if _, err := server.DeleteUser(member, &user.DeleteUserRequest{Id: self.Hex()}); err != nil {
+		t.Fatalf("expected users to delete themselves, got %v", err)
+	}
+	admin := withCaller(context.Background(), bson.NewObjectID(), domain.RoleAdmin)
+	if _, err := server.DeleteUser(admin, &user.DeleteUserRequest{Id: other.Hex()}); err != nil {
+		t.Fatalf("expected admins to delete another user, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                              AttributeHandler                              */
+/* -------------------------------------------------------------------------- */
+
+type AttributeHandler struct {
+	log     logger.Logger
+	attrsvc ports.AttributeService
+}
+
+func NewAttributeHandler(log logger.Logger, attributeService ports.AttributeService) *AttributeHandler {
+	log = log.WithFields(logrus.Fields{
+		"module": "attribute-handler",
+	})
+	return &AttributeHandler{log: log, attrsvc: attributeService}
+}
+
+type PutAttributeRequest struct {
+	Type        domain.AttributeType `json:"type" validate:"required"`
+	Required    bool                 `json:"required"`
+	Enum        []string             `json:"enum"`
+	Pattern     string               `json:"pattern"`
+	Description string               `json:"description"`
+}
+
+// ListAttributes
+// @Summary List the attribute schema
+// @Description List the custom attributes users can carry, requires the admin role
+// @Tags admin
+// @Produce json
+// @Success 200 {array} domain.AttributeDefinition
+func (h *AttributeHandler) ListAttributes(c *fiber.Ctx) error {
+	schema, err := h.attrsvc.ListAttributes(c.Context())
+	if err != nil {
+		h.log.Errorf("Failed to list attributes: %v", err)
+		return errorResponse(c, err, "Failed to list attributes")
+	}
+	if schema == nil {
+		schema = domain.AttributeSchema{}
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"attributes": schema,
+	})
+}
+
+// PutAttribute by name
+// @Summary Define an attribute
+// @Description Create or replace the definition of a custom attribute, requires the admin role.
+// @Description Users already carrying the attribute aren't revalidated.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Attribute name"
+// @Param request body PutAttributeRequest true "Attribute definition"
+// @Success 200 {object} domain.AttributeDefinition
+func (h *AttributeHandler) PutAttribute(c *fiber.Ctx) error {
+	var req PutAttributeRequest
+	if err := MustValid(c, &req); err != nil {
+		return err
+	}
+
+	def := &domain.AttributeDefinition{
+		Name:        c.Params("name"),
+		Type:        req.Type,
+		Required:    req.Required,
+		Enum:        req.Enum,
+		Pattern:     req.Pattern,
+		Description: req.Description,
+	}
+	if err := h.attrsvc.PutAttribute(c.Context(), def); err != nil {
+		h.log.Errorf("Failed to put attribute: %v", err)
+		return errorResponse(c, err, "Failed to put attribute")
+	}
+	return c.Status(fiber.StatusOK).JSON(def)
+}
+
+// DeleteAttribute by name
+// @Summary Delete an attribute
+// @Description Remove a custom attribute from the schema, requires the admin role. Users keep
+// @Description their values until they clear them.
+// @Tags admin
+// @Produce json
+// @Param name path string true "Attribute name"
+func (h *AttributeHandler) DeleteAttribute(c *fiber.Ctx) error {
+	if err := h.attrsvc.DeleteAttribute(c.Context(), c.Params("name")); err != nil {
+		h.log.Errorf("Failed to delete attribute: %v", err)
+		return errorResponse(c, err, "Failed to delete attribute")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Attribute deleted successfully",
+	})
+}
@@ -1,7 +1,10 @@
 package http
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
 	"github.com/hinphansa/7-solutions-challenge/internal/ports"
 	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
 	"github.com/sirupsen/logrus"
@@ -44,8 +47,11 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 
 	token, err := h.authsvc.Login(c.Context(), req.Email, req.Password)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to login",
+		if errors.Is(err, domain.ErrForbidden) || errors.Is(err, domain.ErrUnavailable) {
+			return errorResponse(c, err, "Failed to login")
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid credentials",
 		})
 	}
 
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                AvatarHandler                               */
+/* -------------------------------------------------------------------------- */
+
+type AvatarHandler struct {
+	log       logger.Logger
+	avatarsvc ports.AvatarService
+	maxBytes  int64
+}
+
+// NewAvatarHandler serves avatars, uploads larger than maxBytes are refused before reading them
+func NewAvatarHandler(log logger.Logger, avatarService ports.AvatarService, maxBytes int64) *AvatarHandler {
+	log = log.WithFields(logrus.Fields{
+		"module": "avatar-handler",
+	})
+	return &AvatarHandler{log: log, avatarsvc: avatarService, maxBytes: maxBytes}
+}
+
+const (
+	// avatarCacheControl lets clients and proxies store avatars but revalidate them with the
+	// ETag on every use, the URL of an avatar stays the same when it's replaced
+	avatarCacheControl = "public, no-cache"
+	httpTimeFormat     = "Mon, 02 Jan 2006 15:04:05 GMT"
+)
+
+// UploadAvatar of a user
+// @Summary Upload an avatar
+// @Description Upload the avatar of the user as the "avatar" file of a multipart form. JPEG, PNG,
+// @Description GIF and WebP images are accepted, whatever their declared type, and stored as
+// @Description square thumbnails of standard sizes.
+// @Tags user
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "User ID"
+// @Param avatar formData file true "Image"
+func (h *AvatarHandler) UploadAvatar(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	if caller, ok := callerID(c); !ok || caller != id {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the user can change their avatar",
+		})
+	}
+
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Avatar must be uploaded as the avatar file of a multipart form",
+		})
+	}
+	if file.Size > h.maxBytes {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": "Avatar is larger than " + strconv.FormatInt(h.maxBytes, 10) + " bytes",
+		})
+	}
+	f, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid avatar file",
+		})
+	}
+	defer f.Close()
+
+	hash, err := h.avatarsvc.UploadAvatar(c.Context(), id, f)
+	if err != nil {
+		h.log.Errorf("Failed to upload avatar: %v", err)
+		return errorResponse(c, err, "Failed to upload avatar")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"avatar_hash": hash,
+	})
+}
+
+// GetAvatar of a user
+// @Summary Get an avatar
+// @Description Stream the avatar of the user as a square thumbnail, size is rounded up to the next
+// @Description standard size. Responses carry an ETag, If-None-Match responds 304 when unchanged.
+// @Tags user
+// @Produce image/jpeg
+// @Produce image/png
+// @Param id path string true "User ID"
+// @Param size query int false "Size in pixels"
+func (h *AvatarHandler) GetAvatar(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	size := c.QueryInt("size", 256)
+	if size < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid size",
+		})
+	}
+
+	avatar, err := h.avatarsvc.OpenAvatar(c.Context(), id, size)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) {
+			h.log.Errorf("Failed to get avatar: %v", err)
+		}
+		return errorResponse(c, err, "Failed to get avatar")
+	}
+
+	c.Set(fiber.HeaderCacheControl, avatarCacheControl)
+	c.Set(fiber.HeaderETag, avatar.ETag)
+	if !avatar.ModTime.IsZero() {
+		c.Set(fiber.HeaderLastModified, avatar.ModTime.UTC().Format(httpTimeFormat))
+	}
+	if c.Fresh() { // If-None-Match or If-Modified-Since match
+		avatar.Body.Close()
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, avatar.ContentType)
+	// fasthttp closes the body once it's sent
+	return c.Status(fiber.StatusOK).SendStream(avatar.Body, int(avatar.Size))
+}
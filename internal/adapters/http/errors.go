@@ -0,0 +1,40 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+)
+
+// errorResponse maps a service error to a status code. Client errors expose the error
+// message since it explains what to fix, anything else responds with the fallback message.
+func errorResponse(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidArgument):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, domain.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, domain.ErrConflict), errors.Is(err, domain.ErrPrecondition):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, domain.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, domain.ErrUnavailable):
+		// not the client's doing, the cause stays internal
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Service unavailable, retry later",
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fallback,
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+)
+
+// parseFieldsQuery parses the comma separated sparse fieldset of ?fields=id,name,
+// nil when the param is missing.
+func parseFieldsQuery(c *fiber.Ctx) ([]string, error) {
+	value := c.Query("fields")
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	return ports.ParseFields(strings.Split(value, ","))
+}
+
+// userPageView is a ports.UserPage with its users rendered in a view
+type userPageView struct {
+	Users         []any  `json:"users"`
+	NextPageToken string `json:"next_page_token,omitempty"`
+	TotalCount    *int64 `json:"total_count,omitempty"`
+}
+
+// sparseUser renders only the selected fields of user, or the whole user when fields is nil.
+func sparseUser(user *domain.User, fields []string) any {
+	if fields == nil {
+		return user
+	}
+	out := make(fiber.Map, len(fields))
+	for _, field := range fields {
+		switch field {
+		case ports.FieldID:
+			out[field] = user.ID
+		case ports.FieldName:
+			out[field] = user.Name
+		case ports.FieldEmail:
+			out[field] = user.Email
+		case ports.FieldUsername:
+			out[field] = user.Username
+		case ports.FieldCreatedAt:
+			out[field] = user.CreatedAt
+		case ports.FieldStatus:
+			out[field] = user.Status
+		case ports.FieldUpdatedAt:
+			out[field] = user.UpdatedAt
+		case ports.FieldDisplayName:
+			out[field] = user.DisplayName
+		case ports.FieldPhone:
+			out[field] = user.Phone
+		case ports.FieldLocale:
+			out[field] = user.Locale
+		case ports.FieldTimeZone:
+			out[field] = user.TimeZone
+		case ports.FieldAvatarURL:
+			out[field] = user.AvatarURL
+		case ports.FieldBio:
+			out[field] = user.Bio
+		case ports.FieldAvatarHash:
+			out[field] = user.AvatarHash
+		case ports.FieldAttributes:
+			out[field] = user.Attributes
+		}
+	}
+	return out
+}
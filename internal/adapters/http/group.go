@@ -0,0 +1,324 @@
+package http
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                GroupHandler                                */
+/* -------------------------------------------------------------------------- */
+
+type GroupHandler struct {
+	log      logger.Logger
+	groupsvc ports.GroupService
+}
+
+func NewGroupHandler(log logger.Logger, groupService ports.GroupService) *GroupHandler {
+	log = log.WithFields(logrus.Fields{
+		"module": "group-handler",
+	})
+	return &GroupHandler{log: log, groupsvc: groupService}
+}
+
+type CreateGroupRequest struct {
+	Name        string   `json:"name" validate:"required"`
+	Description string   `json:"description"`
+	Roles       []string `json:"roles"`
+}
+
+// UpdateGroupRequest changes the fields given, an empty description or roles clears them
+type UpdateGroupRequest struct {
+	Name        *string   `json:"name"`
+	Description *string   `json:"description"`
+	Roles       *[]string `json:"roles"`
+}
+
+type AddGroupMemberRequest struct {
+	Kind string `json:"kind" validate:"required,oneof=user group"`
+	ID   string `json:"id" validate:"required"`
+}
+
+// CreateGroup
+// @Summary Create a group
+// @Description Create a group in the organization, requires the admin role. Members get the
+// @Description roles of the group from their next login.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param request body CreateGroupRequest true "Group"
+// @Success 201 {object} domain.Group
+func (h *GroupHandler) CreateGroup(c *fiber.Ctx) error {
+	var req CreateGroupRequest
+	if err := MustValid(c, &req); err != nil {
+		return err
+	}
+
+	group, err := h.groupsvc.CreateGroup(c.Context(), &domain.Group{Name: req.Name, Description: req.Description, Roles: req.Roles})
+	if err != nil {
+		h.log.Errorf("Failed to create group: %v", err)
+		return errorResponse(c, err, "Failed to create group")
+	}
+	return c.Status(fiber.StatusCreated).JSON(group)
+}
+
+// ListGroups
+// @Summary List groups
+// @Description List the groups of the organization page by page, requires the admin role
+// @Tags groups
+// @Produce json
+// @Param limit query int false "Page size, capped by the server"
+// @Param page_token query string false "Token of the page to return"
+// @Success 200 {object} ports.GroupPage
+func (h *GroupHandler) ListGroups(c *fiber.Ctx) error {
+	req, ok, err := pageRequest(c)
+	if !ok {
+		return err
+	}
+
+	page, err := h.groupsvc.ListGroups(c.Context(), req)
+	if err != nil {
+		return errorResponse(c, err, "Failed to list groups")
+	}
+	if page.NextPageToken != "" {
+		c.Set(fiber.HeaderLink, nextPageLink(c, page.NextPageToken))
+	}
+	return c.Status(fiber.StatusOK).JSON(page)
+}
+
+// GetGroup by id
+// @Summary Get a group
+// @Description Get a group by id, requires the admin role
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID"
+// @Success 200 {object} domain.Group
+func (h *GroupHandler) GetGroup(c *fiber.Ctx) error {
+	id, ok, err := groupID(c)
+	if !ok {
+		return err
+	}
+
+	group, err := h.groupsvc.GetGroup(c.Context(), id)
+	if err != nil {
+		return errorResponse(c, err, "Failed to get group")
+	}
+	return c.Status(fiber.StatusOK).JSON(group)
+}
+
+// UpdateGroup by id
+// @Summary Update a group
+// @Description Change the fields given, an empty description or roles clears them. Requires
+// @Description the admin role, role changes apply to members from their next login.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param request body UpdateGroupRequest true "Fields to change"
+// @Success 200 {object} domain.Group
+func (h *GroupHandler) UpdateGroup(c *fiber.Ctx) error {
+	id, ok, err := groupID(c)
+	if !ok {
+		return err
+	}
+
+	var req UpdateGroupRequest
+	if err := MustValid(c, &req); err != nil {
+		return err
+	}
+	update := &ports.GroupUpdate{}
+	if req.Name != nil {
+		update.Name = ports.SetTo(*req.Name)
+	}
+	if req.Description != nil {
+		update.Description = ports.SetTo(*req.Description)
+	}
+	if req.Roles != nil {
+		update.Roles = ports.SetTo(*req.Roles)
+	}
+
+	group, err := h.groupsvc.UpdateGroup(c.Context(), id, update)
+	if err != nil {
+		h.log.Errorf("Failed to update group: %v", err)
+		return errorResponse(c, err, "Failed to update group")
+	}
+	return c.Status(fiber.StatusOK).JSON(group)
+}
+
+// DeleteGroup by id
+// @Summary Delete a group
+// @Description Delete a group and its memberships, requires the admin role
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID"
+func (h *GroupHandler) DeleteGroup(c *fiber.Ctx) error {
+	id, ok, err := groupID(c)
+	if !ok {
+		return err
+	}
+
+	if err := h.groupsvc.DeleteGroup(c.Context(), id); err != nil {
+		h.log.Errorf("Failed to delete group: %v", err)
+		return errorResponse(c, err, "Failed to delete group")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Group deleted successfully",
+	})
+}
+
+// AddMember to a group
+// @Summary Add a group member
+// @Description Add a user or a group to a group, requires the admin role. A group can't
+// @Description contain itself, directly or through nested groups.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param request body AddGroupMemberRequest true "Member"
+func (h *GroupHandler) AddMember(c *fiber.Ctx) error {
+	id, ok, err := groupID(c)
+	if !ok {
+		return err
+	}
+
+	var req AddGroupMemberRequest
+	if err := MustValid(c, &req); err != nil {
+		return err
+	}
+	memberID, err := bson.ObjectIDFromHex(req.ID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid member ID",
+		})
+	}
+
+	if err := h.groupsvc.AddMember(c.Context(), id, domain.MemberKind(req.Kind), memberID); err != nil {
+		h.log.Errorf("Failed to add group member: %v", err)
+		return errorResponse(c, err, "Failed to add group member")
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Member added successfully",
+	})
+}
+
+// RemoveMember from a group
+// @Summary Remove a group member
+// @Description Remove a direct member of a group, requires the admin role
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param memberId path string true "Member ID"
+func (h *GroupHandler) RemoveMember(c *fiber.Ctx) error {
+	id, ok, err := groupID(c)
+	if !ok {
+		return err
+	}
+	memberID, err := bson.ObjectIDFromHex(c.Params("memberId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid member ID",
+		})
+	}
+
+	if err := h.groupsvc.RemoveMember(c.Context(), id, memberID); err != nil {
+		h.log.Errorf("Failed to remove group member: %v", err)
+		return errorResponse(c, err, "Failed to remove group member")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Member removed successfully",
+	})
+}
+
+// ListMembers of a group
+// @Summary List group members
+// @Description List the direct members of a group page by page, users and groups, requires the admin role
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param limit query int false "Page size, capped by the server"
+// @Param page_token query string false "Token of the page to return"
+// @Success 200 {object} ports.GroupMemberPage
+func (h *GroupHandler) ListMembers(c *fiber.Ctx) error {
+	id, ok, err := groupID(c)
+	if !ok {
+		return err
+	}
+	req, ok, err := pageRequest(c)
+	if !ok {
+		return err
+	}
+
+	page, err := h.groupsvc.ListMembers(c.Context(), id, req)
+	if err != nil {
+		return errorResponse(c, err, "Failed to list group members")
+	}
+	if page.NextPageToken != "" {
+		c.Set(fiber.HeaderLink, nextPageLink(c, page.NextPageToken))
+	}
+	return c.Status(fiber.StatusOK).JSON(page)
+}
+
+// ListUserGroups
+// @Summary List the groups of a user
+// @Description List the groups a user belongs to, directly or through nested groups, page by
+// @Description page. Only the user and admins can list them.
+// @Tags groups
+// @Produce json
+// @Param id path string true "User ID"
+// @Param limit query int false "Page size, capped by the server"
+// @Param page_token query string false "Token of the page to return"
+// @Success 200 {object} ports.GroupPage
+func (h *GroupHandler) ListUserGroups(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	if caller(c).ViewOf(id) == ports.ViewPublic {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the user and admins can list their groups",
+		})
+	}
+	req, ok, err := pageRequest(c)
+	if !ok {
+		return err
+	}
+
+	page, err := h.groupsvc.ListUserGroups(c.Context(), id, req)
+	if err != nil {
+		return errorResponse(c, err, "Failed to list user groups")
+	}
+	if page.NextPageToken != "" {
+		c.Set(fiber.HeaderLink, nextPageLink(c, page.NextPageToken))
+	}
+	return c.Status(fiber.StatusOK).JSON(page)
+}
+
+// groupID parses the id path param, responding 400 when it's invalid
+func groupID(c *fiber.Ctx) (bson.ObjectID, bool, error) {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return id, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid group ID",
+		})
+	}
+	return id, true, nil
+}
+
+// pageRequest parses the limit and page_token query params, responding 400 when the limit is invalid
+func pageRequest(c *fiber.Ctx) (*ports.PageRequest, bool, error) {
+	limit, err := strconv.Atoi(c.Query("limit", "0"))
+	if err != nil || limit < 0 {
+		return nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid limit",
+		})
+	}
+	return &ports.PageRequest{PageSize: int64(limit), PageToken: c.Query("page_token")}, true, nil
+}
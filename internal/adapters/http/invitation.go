@@ -0,0 +1,178 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                              InvitationHandler                             */
+/* -------------------------------------------------------------------------- */
+
+type InvitationHandler struct {
+	log       logger.Logger
+	invitesvc ports.InvitationService
+}
+
+func NewInvitationHandler(log logger.Logger, invitationService ports.InvitationService) *InvitationHandler {
+	log = log.WithFields(logrus.Fields{
+		"module": "invitation-handler",
+	})
+	return &InvitationHandler{log: log, invitesvc: invitationService}
+}
+
+type InviteRequest struct {
+	Email    string   `json:"email" validate:"required,email"`
+	Roles    []string `json:"roles"`     // granted to the account, validated by the service
+	GroupIDs []string `json:"group_ids"` // groups the account joins
+}
+
+// AcceptInvitationRequest registers the invitee, the email is the invited one
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+	Name     string `json:"name" validate:"required,min=3"`
+	Username string `json:"username"` // optional unique handle, validated by the service
+}
+
+// Invite
+// @Summary Invite a user
+// @Description Mail an invitation to register to an email, requires the admin role. The
+// @Description account gets the roles and joins the groups of the invitation.
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Param request body InviteRequest true "Invitation"
+// @Success 201 {object} domain.Invitation
+func (h *InvitationHandler) Invite(c *fiber.Ctx) error {
+	var req InviteRequest
+	if err := MustValid(c, &req); err != nil {
+		return err
+	}
+	groupIDs := make([]bson.ObjectID, len(req.GroupIDs))
+	for i, raw := range req.GroupIDs {
+		id, err := bson.ObjectIDFromHex(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid group ID",
+			})
+		}
+		groupIDs[i] = id
+	}
+	invitedBy, _ := callerID(c)
+
+	invitation, err := h.invitesvc.Invite(c.Context(), &domain.Invitation{
+		Email:     req.Email,
+		Roles:     req.Roles,
+		GroupIDs:  groupIDs,
+		InvitedBy: invitedBy,
+	})
+	if err != nil {
+		h.log.Errorf("Failed to invite user: %v", err)
+		return errorResponse(c, err, "Failed to invite user")
+	}
+	return c.Status(fiber.StatusCreated).JSON(invitation)
+}
+
+// ListInvitations
+// @Summary List pending invitations
+// @Description List the invitations not accepted nor revoked yet page by page, expired ones
+// @Description included, requires the admin role
+// @Tags invitations
+// @Produce json
+// @Param limit query int false "Page size, capped by the server"
+// @Param page_token query string false "Token of the page to return"
+// @Success 200 {object} ports.InvitationPage
+func (h *InvitationHandler) ListInvitations(c *fiber.Ctx) error {
+	req, ok, err := pageRequest(c)
+	if !ok {
+		return err
+	}
+
+	page, err := h.invitesvc.ListInvitations(c.Context(), req)
+	if err != nil {
+		return errorResponse(c, err, "Failed to list invitations")
+	}
+	if page.NextPageToken != "" {
+		c.Set(fiber.HeaderLink, nextPageLink(c, page.NextPageToken))
+	}
+	return c.Status(fiber.StatusOK).JSON(page)
+}
+
+// ResendInvitation by id
+// @Summary Resend an invitation
+// @Description Mail a new link and extend the invitation, the previous link stops working.
+// @Description Requires the admin role.
+// @Tags invitations
+// @Produce json
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} domain.Invitation
+func (h *InvitationHandler) ResendInvitation(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid invitation ID",
+		})
+	}
+
+	invitation, err := h.invitesvc.ResendInvitation(c.Context(), id)
+	if err != nil {
+		h.log.Errorf("Failed to resend invitation: %v", err)
+		return errorResponse(c, err, "Failed to resend invitation")
+	}
+	return c.Status(fiber.StatusOK).JSON(invitation)
+}
+
+// RevokeInvitation by id
+// @Summary Revoke an invitation
+// @Description Delete a pending invitation so its link stops working, requires the admin role
+// @Tags invitations
+// @Produce json
+// @Param id path string true "Invitation ID"
+func (h *InvitationHandler) RevokeInvitation(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid invitation ID",
+		})
+	}
+
+	if err := h.invitesvc.RevokeInvitation(c.Context(), id); err != nil {
+		h.log.Errorf("Failed to revoke invitation: %v", err)
+		return errorResponse(c, err, "Failed to revoke invitation")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Invitation revoked successfully",
+	})
+}
+
+// AcceptInvitation
+// @Summary Accept an invitation
+// @Description Register the invited email with a password of the invitee's choosing, using
+// @Description the token mailed with the invitation
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Param request body AcceptInvitationRequest true "Invitee"
+func (h *InvitationHandler) AcceptInvitation(c *fiber.Ctx) error {
+	var req AcceptInvitationRequest
+	if err := MustValid(c, &req); err != nil {
+		return err
+	}
+
+	id, err := h.invitesvc.AcceptInvitation(c.Context(), req.Token, &domain.User{
+		Name:     req.Name,
+		Password: req.Password,
+		Username: req.Username,
+	})
+	if err != nil {
+		return errorResponse(c, err, "Failed to accept invitation")
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"id": id,
+	})
+}
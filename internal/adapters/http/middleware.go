@@ -1,24 +1,94 @@
 package http
 
 import (
+	"slices"
+
 	jwtware "github.com/gofiber/contrib/jwt"
 	"github.com/gofiber/fiber/v2"
 	fiberlogger "github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/hinphansa/7-solutions-challenge/internal/adapters/auth"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
-func AuthMiddleware(sec string) fiber.Handler {
+// AuthMiddleware authenticates requests by their bearer token and scopes them to the
+// organization of the token. Tokens of users no longer active are refused.
+func AuthMiddleware(sec string, authsvc ports.AuthService) fiber.Handler {
+	return jwtware.New(jwtware.Config{
+		SigningKey: jwtware.SigningKey{
+			Key:    []byte(sec),
+			JWTAlg: jwt.SigningMethodHS256.Name,
+		},
+		TokenLookup:    "header:Authorization",
+		AuthScheme:     "Bearer",
+		SuccessHandler: scopeToToken(authsvc),
+	})
+}
+
+// OptionalAuthMiddleware authenticates requests carrying a token like AuthMiddleware, and
+// lets anonymous requests through. An invalid token is still rejected.
+func OptionalAuthMiddleware(sec string, authsvc ports.AuthService) fiber.Handler {
 	return jwtware.New(jwtware.Config{
+		Filter: func(c *fiber.Ctx) bool {
+			return c.Get(fiber.HeaderAuthorization) == ""
+		},
 		SigningKey: jwtware.SigningKey{
 			Key:    []byte(sec),
 			JWTAlg: jwt.SigningMethodHS256.Name,
 		},
-		TokenLookup: "header:Authorization",
-		AuthScheme:  "Bearer",
+		TokenLookup:    "header:Authorization",
+		AuthScheme:     "Bearer",
+		SuccessHandler: scopeToToken(authsvc),
 	})
 }
 
+// caller returns the principal authenticated by AuthMiddleware or OptionalAuthMiddleware,
+// anonymous when there's none
+func caller(c *fiber.Ctx) ports.Caller {
+	id, ok := callerID(c)
+	if !ok {
+		return ports.Caller{}
+	}
+	token := c.Locals("user").(*jwt.Token)
+	claims, _ := token.Claims.(jwt.MapClaims)
+	return ports.Caller{ID: id, Roles: auth.RolesOf(claims)}
+}
+
+// callerID returns the id of the user authenticated by AuthMiddleware
+func callerID(c *fiber.Ctx) (bson.ObjectID, bool) {
+	token, ok := c.Locals("user").(*jwt.Token)
+	if !ok {
+		return bson.ObjectID{}, false
+	}
+	sub, err := token.Claims.GetSubject()
+	if err != nil {
+		return bson.ObjectID{}, false
+	}
+	id, err := bson.ObjectIDFromHex(sub)
+	return id, err == nil
+}
+
+// RequireRole allows requests authenticated by AuthMiddleware with a token carrying role
+func RequireRole(role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token, ok := c.Locals("user").(*jwt.Token)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Missing or malformed JWT",
+			})
+		}
+		claims, _ := token.Claims.(jwt.MapClaims)
+		if !slices.Contains(auth.RolesOf(claims), role) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": role + " role required",
+			})
+		}
+		return c.Next()
+	}
+}
+
 func RequestIdMiddleware() fiber.Handler {
 	return requestid.New()
 }
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/hinphansa/7-solutions-challenge/internal/adapters/auth"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/mocks"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestAuthMiddleware_InactiveUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+	authService := mocks.NewMockAuthService(ctrl)
+	jwtMaker := auth.NewJWT("secret", time.Hour)
+
+	app := fiber.New()
+	app.Get("/me", AuthMiddleware("secret", authService), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	request := func(id bson.ObjectID) int {
+		t.Helper()
+		token, err := jwtMaker.Generate(id, bson.NewObjectID(), "jane@example.com", nil)
+		if err != nil {
+			t.Fatalf("generate: %v", err)
+		}
+		req := httptest.NewRequest(fiber.MethodGet, "/me", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("GET /me: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	active, suspended := bson.NewObjectID(), bson.NewObjectID()
+	authService.EXPECT().CheckActive(gomock.Any(), active).Return(nil)
+	authService.EXPECT().CheckActive(gomock.Any(), suspended).Return(domain.ErrForbidden)
+
+	if code := request(active); code != fiber.StatusOK {
+		t.Fatalf("expected an active user to pass, got %d", code)
+	}
+	// the token is still valid, its user was suspended since it was issued
+	if code := request(suspended); code != fiber.StatusUnauthorized {
+		t.Fatalf("expected a suspended user to be refused, got %d", code)
+	}
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                             OrganizationHandler                            */
+/* -------------------------------------------------------------------------- */
+
+type OrganizationHandler struct {
+	log    logger.Logger
+	orgsvc ports.OrganizationService
+}
+
+func NewOrganizationHandler(log logger.Logger, organizationService ports.OrganizationService) *OrganizationHandler {
+	log = log.WithFields(logrus.Fields{
+		"module": "organization-handler",
+	})
+	return &OrganizationHandler{log: log, orgsvc: organizationService}
+}
+
+type CreateOrganizationRequest struct {
+	Slug string `json:"slug" validate:"required"`
+	Name string `json:"name" validate:"required"`
+}
+
+// UpdateOrganizationRequest changes the fields given, a new slug moves the organization
+// to another subdomain
+type UpdateOrganizationRequest struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// CreateOrganization
+// @Summary Create an organization
+// @Description Create an organization, requires the superadmin role. Its slug names it in
+// @Description subdomains and the X-Tenant header.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param request body CreateOrganizationRequest true "Organization"
+// @Success 201 {object} domain.Organization
+func (h *OrganizationHandler) CreateOrganization(c *fiber.Ctx) error {
+	var req CreateOrganizationRequest
+	if err := MustValid(c, &req); err != nil {
+		return err
+	}
+
+	org, err := h.orgsvc.CreateOrganization(c.Context(), &domain.Organization{Slug: req.Slug, Name: req.Name})
+	if err != nil {
+		h.log.Errorf("Failed to create organization: %v", err)
+		return errorResponse(c, err, "Failed to create organization")
+	}
+	return c.Status(fiber.StatusCreated).JSON(org)
+}
+
+// ListOrganizations
+// @Summary List organizations
+// @Description List every organization sorted by slug, requires the superadmin role
+// @Tags organizations
+// @Produce json
+// @Success 200 {array} domain.Organization
+func (h *OrganizationHandler) ListOrganizations(c *fiber.Ctx) error {
+	orgs, err := h.orgsvc.ListOrganizations(c.Context())
+	if err != nil {
+		h.log.Errorf("Failed to list organizations: %v", err)
+		return errorResponse(c, err, "Failed to list organizations")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"organizations": orgs,
+	})
+}
+
+// GetOrganization by id
+// @Summary Get an organization
+// @Description Get an organization by id, requires the superadmin role
+// @Tags organizations
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {object} domain.Organization
+func (h *OrganizationHandler) GetOrganization(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid organization ID",
+		})
+	}
+
+	org, err := h.orgsvc.GetOrganization(c.Context(), id)
+	if err != nil {
+		h.log.Errorf("Failed to get organization: %v", err)
+		return errorResponse(c, err, "Failed to get organization")
+	}
+	return c.Status(fiber.StatusOK).JSON(org)
+}
+
+// UpdateOrganization by id
+// @Summary Update an organization
+// @Description Rename an organization or change its slug, requires the superadmin role.
+// @Description Requests naming the previous slug stop resolving.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param request body UpdateOrganizationRequest true "Fields to change"
+// @Success 200 {object} domain.Organization
+func (h *OrganizationHandler) UpdateOrganization(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid organization ID",
+		})
+	}
+
+	var req UpdateOrganizationRequest
+	if err := MustValid(c, &req); err != nil {
+		return err
+	}
+
+	org, err := h.orgsvc.UpdateOrganization(c.Context(), id, &ports.OrganizationUpdate{Name: req.Name, Slug: req.Slug})
+	if err != nil {
+		h.log.Errorf("Failed to update organization: %v", err)
+		return errorResponse(c, err, "Failed to update organization")
+	}
+	return c.Status(fiber.StatusOK).JSON(org)
+}
+
+// DeleteOrganization by id
+// @Summary Delete an organization
+// @Description Delete an organization without users, requires the superadmin role
+// @Tags organizations
+// @Produce json
+// @Param id path string true "Organization ID"
+func (h *OrganizationHandler) DeleteOrganization(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid organization ID",
+		})
+	}
+
+	if err := h.orgsvc.DeleteOrganization(c.Context(), id); err != nil {
+		h.log.Errorf("Failed to delete organization: %v", err)
+		return errorResponse(c, err, "Failed to delete organization")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Organization deleted successfully",
+	})
+}
@@ -0,0 +1,279 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+)
+
+// Media types of PATCH bodies
+const (
+	mediaTypeMergePatch = "application/merge-patch+json" // RFC 7396
+	mediaTypeJSONPatch  = "application/json-patch+json"  // RFC 6902
+)
+
+// errPatchTestFailed is a JSON Patch "test" operation that didn't hold, the patch isn't applied
+var errPatchTestFailed = errors.New("patch test failed")
+
+func invalidPatch(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", domain.ErrInvalidArgument, fmt.Sprintf(format, args...))
+}
+
+// mergePatchUpdate converts a JSON Merge Patch into an update: a string sets the
+// field, null clears it and absent fields are kept. Attributes are merged by name the
+// same way, e.g. {"attributes": {"department": "sales", "floor": null}}.
+func mergePatchUpdate(body []byte) (*ports.UserUpdate, error) {
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		return nil, invalidPatch("merge patch must be a JSON object")
+	}
+
+	update := &ports.UserUpdate{}
+	for field, raw := range patch {
+		if field == ports.FieldAttributes {
+			if err := mergeAttributes(update, raw); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		value, err := patchValue(raw)
+		if err != nil {
+			return nil, invalidPatch("field %q %v", field, err)
+		}
+		if value == nil {
+			err = update.ClearField(field)
+		} else {
+			err = update.SetField(field, *value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return update, nil
+}
+
+// mergeAttributes merges the attributes object of a merge patch into update
+func mergeAttributes(update *ports.UserUpdate, raw json.RawMessage) error {
+	var attributes map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &attributes); err != nil || attributes == nil {
+		return invalidPatch("attributes must be an object, clear attributes one by one with null")
+	}
+	for name, raw := range attributes {
+		value, err := attributeValue(raw)
+		if err != nil {
+			return invalidPatch("attribute %q %v", name, err)
+		}
+		if value == nil {
+			err = update.ClearAttribute(name)
+		} else {
+			err = update.SetAttribute(name, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// jsonPatchUpdate applies a JSON Patch to the updatable fields and attributes of current
+// and returns the resulting changes. Operations apply in order and the patch applies as
+// a whole.
+func jsonPatchUpdate(body []byte, current *domain.User) (*ports.UserUpdate, error) {
+	var ops []patchOperation
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return nil, invalidPatch("JSON patch must be an array of operations")
+	}
+
+	// the document holds the values by field name, attributes as "attributes.<name>".
+	// Missing fields aren't in it, they can be added but not replaced.
+	before := map[string]any{}
+	for field, value := range ports.UpdatableValues(current) {
+		before[field] = value
+	}
+	for name, value := range current.Attributes {
+		before[ports.FieldAttributes+"."+name] = value
+	}
+	doc := maps.Clone(before)
+
+	for i, op := range ops {
+		path, err := patchPath(op.Path)
+		if err != nil {
+			return nil, invalidPatch("operation %d: %v", i, err)
+		}
+		patched, exists := doc[path]
+
+		switch op.Op {
+		case "add", "replace":
+			if op.Op == "replace" && !exists {
+				return nil, invalidPatch("operation %d: can't replace missing field %q", i, path)
+			}
+			value, err := patchPathValue(path, op.Value)
+			if err != nil {
+				return nil, invalidPatch("operation %d: value %v", i, err)
+			}
+			setPatched(doc, path, value)
+		case "remove":
+			if !exists {
+				return nil, invalidPatch("operation %d: can't remove missing field %q", i, path)
+			}
+			delete(doc, path)
+		case "copy", "move":
+			from, err := patchPath(op.From)
+			if err != nil {
+				return nil, invalidPatch("operation %d: from %v", i, err)
+			}
+			value, ok := doc[from]
+			if !ok {
+				return nil, invalidPatch("operation %d: can't %s missing field %q", i, op.Op, from)
+			}
+			if _, isString := value.(string); !isString && !isAttributePath(path) {
+				return nil, invalidPatch("operation %d: field %q must be a string", i, path)
+			}
+			if op.Op == "move" {
+				delete(doc, from)
+			}
+			doc[path] = value
+		case "test":
+			value, err := patchPathValue(path, op.Value)
+			if err != nil {
+				return nil, invalidPatch("operation %d: value %v", i, err)
+			}
+			if value != patched {
+				return nil, fmt.Errorf("%w: operation %d on %q", errPatchTestFailed, i, path)
+			}
+		default:
+			return nil, invalidPatch("operation %d: unknown op %q", i, op.Op)
+		}
+	}
+
+	update := &ports.UserUpdate{}
+	for path := range mergedKeys(before, doc) {
+		old, existed := before[path]
+		value, exists := doc[path]
+		if exists == existed && value == old {
+			continue
+		}
+
+		var err error
+		name, isAttribute := ports.AttributePath(path)
+		switch {
+		case isAttribute && !exists:
+			err = update.ClearAttribute(name)
+		case isAttribute:
+			err = update.SetAttribute(name, value)
+		case !exists:
+			err = update.ClearField(path)
+		default:
+			err = update.SetField(path, value.(string))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return update, nil
+}
+
+// setPatched sets a value of the patched document, nil removes it
+func setPatched(doc map[string]any, path string, value any) {
+	if value == nil {
+		delete(doc, path)
+		return
+	}
+	doc[path] = value
+}
+
+// mergedKeys returns the keys of both maps
+func mergedKeys(a, b map[string]any) map[string]struct{} {
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+	return keys
+}
+
+// patchPath resolves a JSON Pointer to an updatable field, e.g. /name, or to an
+// attribute, e.g. /attributes/department, returned as "attributes.department".
+func patchPath(pointer string) (string, error) {
+	segments := strings.Split(pointer, "/")
+	if segments[0] != "" || len(segments) < 2 || len(segments) > 3 {
+		return "", fmt.Errorf("path %q must point to a field, e.g. /name, or an attribute, e.g. /attributes/department", pointer)
+	}
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	field := unescape.Replace(segments[1])
+	if len(segments) == 3 && field == ports.FieldAttributes {
+		name := unescape.Replace(segments[2])
+		if !domain.ValidAttributeName(name) {
+			return "", fmt.Errorf("invalid attribute name %q", name)
+		}
+		return ports.FieldAttributes + "." + name, nil
+	}
+	if len(segments) == 3 || !slices.Contains(ports.UpdatableFields(), field) {
+		return "", fmt.Errorf("field %q can't be patched", strings.TrimPrefix(pointer, "/"))
+	}
+	return field, nil
+}
+
+func isAttributePath(path string) bool {
+	_, ok := ports.AttributePath(path)
+	return ok
+}
+
+// patchPathValue decodes the value of an operation on path, nil for a JSON null
+func patchPathValue(path string, raw json.RawMessage) (any, error) {
+	if isAttributePath(path) {
+		return attributeValue(raw)
+	}
+	value, err := patchValue(raw)
+	if value == nil || err != nil {
+		return nil, err
+	}
+	return *value, nil
+}
+
+// patchValue decodes a string value, nil for a JSON null
+func patchValue(raw json.RawMessage) (*string, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("is missing")
+	}
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil, nil
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, errors.New("must be a string or null")
+	}
+	return &value, nil
+}
+
+// attributeValue decodes an attribute value: a string, number, bool or null
+func attributeValue(raw json.RawMessage) (any, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("is missing")
+	}
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, errors.New("must be a string, number, bool or null")
+	}
+	switch value.(type) {
+	case nil, string, float64, bool:
+		return value, nil
+	}
+	return nil, errors.New("must be a string, number, bool or null")
+}
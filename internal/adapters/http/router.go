@@ -3,10 +3,16 @@ package http
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/hinphansa/7-solutions-challenge/config"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
 )
 
-func SetupRoutes(app *fiber.App, cfg *config.Config, userHandler *UserHandler, authHandler *AuthHandler) {
-	authMiddleware := AuthMiddleware(cfg.JWT.Secret)
+func SetupRoutes(app *fiber.App, cfg *config.Config, userHandler *UserHandler, authHandler *AuthHandler, attributeHandler *AttributeHandler, settingsHandler *SettingsHandler, avatarHandler *AvatarHandler, organizationHandler *OrganizationHandler, groupHandler *GroupHandler, invitationHandler *InvitationHandler) {
+	authMiddleware := AuthMiddleware(cfg.JWT.Secret, authHandler.authsvc)
+	// listings show anonymous callers the public view of users, and more to authenticated ones
+	listingMiddleware := OptionalAuthMiddleware(cfg.JWT.Secret, authHandler.authsvc)
+	if cfg.Users.RequireAuthForListing {
+		listingMiddleware = authMiddleware
+	}
 
 	// Setup routes
 	api := app.Group("/api")
@@ -18,15 +24,66 @@ func SetupRoutes(app *fiber.App, cfg *config.Config, userHandler *UserHandler, a
 				// Public users endpoints
 				users := v1.Group("/users")
 				users.Post("/", userHandler.Register)
-				users.Get("/", userHandler.ListUsers)
+				users.Get("/", listingMiddleware, userHandler.ListUsers)
+				users.Get("/search", listingMiddleware, userHandler.SearchUsers)
+				users.Get("/username-availability", userHandler.CheckUsername)
+				users.Post("/email-change/confirm", userHandler.ConfirmEmailChange) // authenticated by the token
+				users.Post("/email-change/revert", userHandler.RevertEmailChange)
+				users.Get("/:id/avatar", avatarHandler.GetAvatar) // public, images are embedded without tokens
 
 				// Protected users endpoints
 				authUsers := users.Group("/").Use(authMiddleware)
+				authUsers.Get("/by-username/:username", userHandler.GetUserByUsername)
 				authUsers.Get("/:id", userHandler.GetUser)
 				authUsers.Put("/:id", userHandler.UpdateUser)
+				authUsers.Patch("/:id", userHandler.PatchUser)
 				authUsers.Delete("/:id", userHandler.DeleteUser)
+				authUsers.Post("/:id/email-change", userHandler.RequestEmailChange)
+				authUsers.Get("/:id/settings", settingsHandler.GetSettings)
+				authUsers.Put("/:id/settings", settingsHandler.PutSettings)
+				authUsers.Patch("/:id/settings", settingsHandler.PatchSettings)
+				authUsers.Put("/:id/avatar", avatarHandler.UploadAvatar)
+				authUsers.Get("/:id/groups", groupHandler.ListUserGroups)
+			}
+
+			//// admin endpoints
+			admin := v1.Group("/admin", authMiddleware, RequireRole(domain.RoleAdmin))
+			{
+				admin.Post("/users/:id/suspend", userHandler.SuspendUser)
+				admin.Post("/users/:id/reactivate", userHandler.ReactivateUser)
+				admin.Get("/registrations", userHandler.ListPendingRegistrations)
+				admin.Post("/registrations/:id/approve", userHandler.ApproveRegistration)
+				admin.Post("/registrations/:id/reject", userHandler.RejectRegistration)
+				admin.Get("/attributes", attributeHandler.ListAttributes)
+				admin.Put("/attributes/:name", attributeHandler.PutAttribute)
+				admin.Delete("/attributes/:name", attributeHandler.DeleteAttribute)
+				admin.Post("/groups", groupHandler.CreateGroup)
+				admin.Get("/groups", groupHandler.ListGroups)
+				admin.Get("/groups/:id", groupHandler.GetGroup)
+				admin.Patch("/groups/:id", groupHandler.UpdateGroup)
+				admin.Delete("/groups/:id", groupHandler.DeleteGroup)
+				admin.Get("/groups/:id/members", groupHandler.ListMembers)
+				admin.Post("/groups/:id/members", groupHandler.AddMember)
+				admin.Delete("/groups/:id/members/:memberId", groupHandler.RemoveMember)
+				admin.Post("/invitations", invitationHandler.Invite)
+				admin.Get("/invitations", invitationHandler.ListInvitations)
+				admin.Post("/invitations/:id/resend", invitationHandler.ResendInvitation)
+				admin.Delete("/invitations/:id", invitationHandler.RevokeInvitation)
 			}
 
+			//// organization endpoints
+			orgs := v1.Group("/organizations", authMiddleware, RequireRole(domain.RoleSuperAdmin))
+			{
+				orgs.Post("/", organizationHandler.CreateOrganization)
+				orgs.Get("/", organizationHandler.ListOrganizations)
+				orgs.Get("/:id", organizationHandler.GetOrganization)
+				orgs.Patch("/:id", organizationHandler.UpdateOrganization)
+				orgs.Delete("/:id", organizationHandler.DeleteOrganization)
+			}
+
+			//// invitation endpoints, authenticated by the mailed token
+			v1.Post("/invitations/accept", invitationHandler.AcceptInvitation)
+
 			//// auth endpoints
 			auth := v1.Group("/auth")
 			{
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                               SettingsHandler                              */
+/* -------------------------------------------------------------------------- */
+
+type SettingsHandler struct {
+	log    logger.Logger
+	setsvc ports.SettingsService
+}
+
+func NewSettingsHandler(log logger.Logger, settingsService ports.SettingsService) *SettingsHandler {
+	log = log.WithFields(logrus.Fields{
+		"module": "settings-handler",
+	})
+	return &SettingsHandler{log: log, setsvc: settingsService}
+}
+
+// GetSettings of a user
+// @Summary Get user settings
+// @Description Get every setting of the user, with the default of those they didn't change.
+// @Description Only the user can read their settings.
+// @Tags settings
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} domain.Settings
+func (h *SettingsHandler) GetSettings(c *fiber.Ctx) error {
+	id, ok, err := h.settingsOwner(c)
+	if !ok {
+		return err
+	}
+
+	settings, err := h.setsvc.GetSettings(c.Context(), id)
+	if err != nil {
+		h.log.Errorf("Failed to get settings: %v", err)
+		return errorResponse(c, err, "Failed to get settings")
+	}
+	return c.Status(fiber.StatusOK).JSON(settings)
+}
+
+// PutSettings of a user
+// @Summary Replace user settings
+// @Description Set every setting of the user, settings left out go back to their default
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body domain.Settings true "Settings"
+// @Success 200 {object} domain.Settings
+func (h *SettingsHandler) PutSettings(c *fiber.Ctx) error {
+	return h.writeSettings(c, h.setsvc.ReplaceSettings)
+}
+
+// PatchSettings of a user
+// @Summary Update user settings
+// @Description Change some settings of the user as a JSON Merge Patch, null resets a setting to its default
+// @Tags settings
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body domain.Settings true "Settings to change"
+// @Success 200 {object} domain.Settings
+func (h *SettingsHandler) PatchSettings(c *fiber.Ctx) error {
+	return h.writeSettings(c, h.setsvc.UpdateSettings)
+}
+
+func (h *SettingsHandler) writeSettings(c *fiber.Ctx, write func(context.Context, bson.ObjectID, map[string]any) (domain.Settings, error)) error {
+	id, ok, err := h.settingsOwner(c)
+	if !ok {
+		return err
+	}
+
+	var settings map[string]any
+	if err := json.Unmarshal(c.Body(), &settings); err != nil || settings == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Settings must be a JSON object",
+		})
+	}
+
+	resolved, err := write(c.Context(), id, settings)
+	if err != nil {
+		h.log.Errorf("Failed to update settings: %v", err)
+		return errorResponse(c, err, "Failed to update settings")
+	}
+	return c.Status(fiber.StatusOK).JSON(resolved)
+}
+
+// settingsOwner returns the user id of the path if it's the caller, otherwise it responds
+// with the error and returns false.
+func (h *SettingsHandler) settingsOwner(c *fiber.Ctx) (bson.ObjectID, bool, error) {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return id, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	if caller, ok := callerID(c); !ok || caller != id {
+		return id, false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the user can access their settings",
+		})
+	}
+	return id, true, nil
+}
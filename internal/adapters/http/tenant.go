@@ -0,0 +1,109 @@
+package http
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hinphansa/7-solutions-challenge/internal/adapters/auth"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// TenantHeader names the organization of a request by its slug
+const TenantHeader = "X-Tenant"
+
+// requestedTenantKey holds the organization a request named by header or subdomain,
+// a token of another organization is rejected
+const requestedTenantKey = "requested_tenant"
+
+// TenantMiddleware scopes the request context to an organization, named by the X-Tenant
+// header, else by the subdomain of baseDomain, else defaultSlug. Authenticated requests
+// are scoped to the organization of their token instead, see scopeToToken, so naming one
+// is optional for them. Empty baseDomain or defaultSlug disable the subdomain or default.
+func TenantMiddleware(orgsvc ports.OrganizationService, baseDomain, defaultSlug string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		slug, requested := c.Get(TenantHeader), true
+		if slug == "" {
+			slug = subdomain(c.Hostname(), baseDomain)
+		}
+		if slug == "" {
+			slug, requested = defaultSlug, false
+		}
+		if slug == "" && c.Get(fiber.HeaderAuthorization) != "" {
+			return c.Next() // the token names the organization
+		}
+		if slug == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "organization required, name it with the " + TenantHeader + " header",
+			})
+		}
+
+		org, err := orgsvc.ResolveOrganization(c.Context(), slug)
+		if err != nil {
+			return errorResponse(c, err, "Failed to resolve organization")
+		}
+		c.Locals(ports.TenantKey{}, org.ID)
+		if requested {
+			c.Locals(requestedTenantKey, org.ID)
+		}
+		return c.Next()
+	}
+}
+
+// scopeToToken scopes a request authenticated by AuthMiddleware or OptionalAuthMiddleware
+// to the organization of its token, and refuses the token once its user is no longer
+// active, e.g. suspended since they logged in
+func scopeToToken(authsvc ports.AuthService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Locals("user").(*jwt.Token)
+		claims, _ := token.Claims.(jwt.MapClaims)
+		tenantID := auth.TenantOf(claims)
+		if tenantID.IsZero() {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Token has no organization, log in again",
+			})
+		}
+		if requested, ok := c.Locals(requestedTenantKey).(bson.ObjectID); ok && requested != tenantID {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Token belongs to another organization",
+			})
+		}
+		c.Locals(ports.TenantKey{}, tenantID)
+
+		id, ok := callerID(c)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid or expired JWT",
+			})
+		}
+		if err := authsvc.CheckActive(ports.WithTenant(c.Context(), tenantID), id); err != nil {
+			if errors.Is(err, domain.ErrForbidden) {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return errorResponse(c, err, "Failed to authenticate")
+		}
+		return c.Next()
+	}
+}
+
+// subdomain returns the single label host has in front of baseDomain, e.g. "acme" for
+// acme.users.example.com under users.example.com, empty otherwise
+func subdomain(host, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	label, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(baseDomain))
+	if !ok || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
@@ -1,10 +1,18 @@
 package http
 
 import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/filterexpr"
 	"github.com/hinphansa/7-solutions-challenge/internal/ports"
 	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
 	"github.com/sirupsen/logrus"
@@ -31,11 +39,24 @@ type RegisterRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8"`
 	Name     string `json:"name" validate:"required,min=3"`
+	Username string `json:"username"` // optional unique handle, validated by the service
+
+	// optional profile, validated by the service
+	DisplayName string `json:"display_name"`
+	Phone       string `json:"phone"`     // E.164, e.g. +14155552671
+	Locale      string `json:"locale"`    // BCP 47, e.g. en-US
+	TimeZone    string `json:"time_zone"` // IANA, e.g. Asia/Bangkok
+	AvatarURL   string `json:"avatar_url"`
+	Bio         string `json:"bio"`
+
+	// custom attributes, validated against the attribute schema
+	Attributes map[string]any `json:"attributes"`
 }
 
 // Register
 // @Summary Register a new user
-// @Description Register a new user
+// @Description Register a new user, depending on the registration policy only invitees or
+// @Description some email domains can, and the account may wait for an admin's approval
 // @Tags user
 // @Accept json
 // @Produce json
@@ -46,30 +67,41 @@ func (h *UserHandler) Register(c *fiber.Ctx) error {
 		return err
 	}
 
-	id, err := h.usersvc.Register(c.Context(), &domain.User{
-		Email:    req.Email,
-		Password: req.Password,
-		Name:     req.Name,
-	})
+	user := &domain.User{
+		Email:       req.Email,
+		Password:    req.Password,
+		Name:        req.Name,
+		Username:    req.Username,
+		DisplayName: req.DisplayName,
+		Phone:       req.Phone,
+		Locale:      req.Locale,
+		TimeZone:    req.TimeZone,
+		AvatarURL:   req.AvatarURL,
+		Bio:         req.Bio,
+		Attributes:  req.Attributes,
+	}
+	id, err := h.usersvc.Register(c.Context(), user)
 
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to register user",
-		})
+		return errorResponse(c, err, "Failed to register user")
 	}
 
+	// pending_approval when an admin has to approve the account before it can log in
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"id": id,
+		"id":     id,
+		"status": user.Status,
 	})
 }
 
 // GetUser by id
 // @Summary Get user by id
-// @Description Get user by id
+// @Description Get user by id, users see every field of themselves, admins also see the status
+// @Description reason and roles, anyone else only sees the public profile
 // @Tags user
 // @Accept json
 // @Produce json
 // @Param id path string true "User ID"
+// @Param fields query string false "Comma separated fields to return, e.g. id,name"
 // @Success 200 {object} domain.User
 func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 	id := c.Params("id")
@@ -86,24 +118,104 @@ func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 		})
 	}
 
-	user, err := h.usersvc.GetByID(c.Context(), bsonId)
+	fields, err := parseFieldsQuery(c)
+	if err != nil {
+		return errorResponse(c, err, "Invalid fields")
+	}
+
+	view := caller(c).ViewOf(bsonId)
+	user, err := h.usersvc.GetByID(c.Context(), bsonId, view.Fields(fields)...)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get user",
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(user)
+	return c.Status(fiber.StatusOK).JSON(viewUser(user, view, fields))
+}
+
+// GetUserByUsername
+// @Summary Get user by username
+// @Description Get user by username, case-insensitively. A previous username still within its
+// @Description grace period redirects to the current one with 307.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param username path string true "Username"
+// @Param fields query string false "Comma separated fields to return, e.g. id,name"
+// @Success 200 {object} domain.User
+func (h *UserHandler) GetUserByUsername(c *fiber.Ctx) error {
+	fields, err := parseFieldsQuery(c)
+	if err != nil {
+		return errorResponse(c, err, "Invalid fields")
+	}
+
+	// the view depends on who the user is, so the user is loaded whole then masked
+	username := c.Params("username")
+	user, current, err := h.usersvc.GetByUsername(c.Context(), username)
+	if err != nil {
+		return errorResponse(c, err, "Failed to get user")
+	}
+	if current != "" && !strings.EqualFold(current, strings.TrimSpace(username)) {
+		// temporary, the previous username can be claimed by someone else once it expires.
+		// A user who dropped their username is served under the previous one.
+		return c.Redirect(usernameLink(c, current), fiber.StatusTemporaryRedirect)
+	}
+
+	view := caller(c).ViewOf(user.ID)
+	ports.MaskUser(user, view.Fields(fields))
+	return c.Status(fiber.StatusOK).JSON(viewUser(user, view, fields))
+}
+
+type UsernameAvailability struct {
+	Username  string `json:"username"` // canonical form, as it would be stored
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"` // why it can't be claimed
+}
+
+// CheckUsername
+// @Summary Check a username is available
+// @Description Check a username is valid, not reserved and not taken, including by a previous
+// @Description username still redirecting to its user
+// @Tags user
+// @Produce json
+// @Param username query string true "Username"
+// @Success 200 {object} UsernameAvailability
+func (h *UserHandler) CheckUsername(c *fiber.Ctx) error {
+	username := c.Query("username")
+	canonical, err := h.usersvc.CheckUsername(c.Context(), username)
+	switch {
+	case err == nil:
+		return c.Status(fiber.StatusOK).JSON(UsernameAvailability{Username: canonical, Available: true})
+	case errors.Is(err, domain.ErrInvalidArgument), errors.Is(err, domain.ErrConflict):
+		return c.Status(fiber.StatusOK).JSON(UsernameAvailability{
+			Username: strings.ToLower(strings.TrimSpace(username)),
+			Reason:   err.Error(),
+		})
+	default:
+		return errorResponse(c, err, "Failed to check username")
+	}
 }
 
 type UpdateUserRequest struct {
 	Email string `json:"email" validate:"omitempty,email"`
 	Name  string `json:"name" validate:"omitempty,min=3"`
+
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Phone       string `json:"phone"`
+	Locale      string `json:"locale"`
+	TimeZone    string `json:"time_zone"`
+	AvatarURL   string `json:"avatar_url"`
+	Bio         string `json:"bio"`
+
+	// Attributes are set by name, null clears one, attributes left out are kept
+	Attributes map[string]any `json:"attributes"`
 }
 
 // UpdateUser by id
 // @Summary Update user by id
-// @Description Update user's email and name by id
+// @Description Update user's email, name and profile by id, empty fields are left as they are
 // @Tags user
 // @Accept json
 // @Produce json
@@ -128,29 +240,356 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 			"error": "Invalid user ID",
 		})
 	}
+	// an email set here starts an email change, like RequestEmailChange
+	if caller, ok := callerID(c); !ok || caller != bsonId {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the user can update their account",
+		})
+	}
 
 	if err := MustValid(c, &req); err != nil {
 		return err
 	}
 
-	if err := h.usersvc.Update(c.Context(), bsonId, &domain.User{
-		Email: req.Email,
-		Name:  req.Name,
-	}); err != nil {
+	// empty fields are left as they are, PATCH can clear fields
+	update := &ports.UserUpdate{}
+	for field, value := range map[string]string{
+		ports.FieldEmail:       req.Email,
+		ports.FieldName:        req.Name,
+		ports.FieldUsername:    req.Username,
+		ports.FieldDisplayName: req.DisplayName,
+		ports.FieldPhone:       req.Phone,
+		ports.FieldLocale:      req.Locale,
+		ports.FieldTimeZone:    req.TimeZone,
+		ports.FieldAvatarURL:   req.AvatarURL,
+		ports.FieldBio:         req.Bio,
+	} {
+		if value != "" {
+			_ = update.SetField(field, value) // every field is updatable
+		}
+	}
+	for name, value := range req.Attributes {
+		if value == nil {
+			err = update.ClearAttribute(name)
+		} else {
+			err = update.SetAttribute(name, value)
+		}
+		if err != nil {
+			return errorResponse(c, err, "Failed to update user")
+		}
+	}
+
+	if err := h.usersvc.Update(c.Context(), bsonId, update); err != nil {
 		h.log.Errorf("Failed to update user: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to update user",
+		return errorResponse(c, err, "Failed to update user")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": updatedMessage(update),
+	})
+}
+
+// PatchUser by id
+// @Summary Patch user by id
+// @Description Partially update a user with a JSON Merge Patch (RFC 7396), where null clears a field,
+// @Description or a JSON Patch (RFC 6902). A failed JSON Patch test operation responds 409.
+// @Tags user
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param id path string true "User ID"
+func (h *UserHandler) PatchUser(c *fiber.Ctx) error {
+	bsonId, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	// an email set here starts an email change, like RequestEmailChange
+	if caller, ok := callerID(c); !ok || caller != bsonId {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the user can update their account",
+		})
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	var update *ports.UserUpdate
+	switch mediaType {
+	case mediaTypeMergePatch:
+		update, err = mergePatchUpdate(c.Body())
+	case mediaTypeJSONPatch:
+		var current *domain.User
+		current, err = h.usersvc.GetByID(c.Context(), bsonId)
+		if err != nil {
+			return errorResponse(c, err, "Failed to get user")
+		}
+		update, err = jsonPatchUpdate(c.Body(), current)
+	default:
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": fmt.Sprintf("Content-Type must be %s or %s", mediaTypeMergePatch, mediaTypeJSONPatch),
+		})
+	}
+	if errors.Is(err, errPatchTestFailed) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
+	if err != nil {
+		return errorResponse(c, err, "Invalid patch")
+	}
+
+	if !update.Empty() {
+		if err := h.usersvc.Update(c.Context(), bsonId, update); err != nil {
+			h.log.Errorf("Failed to patch user: %v", err)
+			return errorResponse(c, err, "Failed to update user")
+		}
+	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "User updated successfully",
+		"message": updatedMessage(update),
+	})
+}
+
+// updatedMessage tells the client a new email still needs confirmation
+func updatedMessage(update *ports.UserUpdate) string {
+	if update.Email.Op == ports.Set {
+		return "User updated successfully, confirm the new email to change it"
+	}
+	return "User updated successfully"
+}
+
+type EmailChangeRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// RequestEmailChange
+// @Summary Request an email change
+// @Description Send a confirmation token to the new email and a revert link to the current one.
+// @Description The email only changes once the token is confirmed.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body EmailChangeRequest true "New email"
+func (h *UserHandler) RequestEmailChange(c *fiber.Ctx) error {
+	bsonId, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	if caller, ok := callerID(c); !ok || caller != bsonId {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the user can change their email",
+		})
+	}
+
+	var req EmailChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := h.usersvc.RequestEmailChange(c.Context(), bsonId, req.Email); err != nil {
+		h.log.Errorf("Failed to request email change: %v", err)
+		return errorResponse(c, err, "Failed to request email change")
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Confirmation sent to the new email",
 	})
 }
 
+type EmailTokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ConfirmEmailChange
+// @Summary Confirm an email change
+// @Description Swap in the new email with the token sent to it
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body EmailTokenRequest true "Confirmation token"
+func (h *UserHandler) ConfirmEmailChange(c *fiber.Ctx) error {
+	var req EmailTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := h.usersvc.ConfirmEmailChange(c.Context(), req.Token); err != nil {
+		return errorResponse(c, err, "Failed to confirm email change")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Email changed successfully",
+	})
+}
+
+// RevertEmailChange
+// @Summary Revert an email change
+// @Description Restore the previous email with the token sent to it, and cancel any pending change
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body EmailTokenRequest true "Revert token"
+func (h *UserHandler) RevertEmailChange(c *fiber.Ctx) error {
+	var req EmailTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := h.usersvc.RevertEmailChange(c.Context(), req.Token); err != nil {
+		return errorResponse(c, err, "Failed to revert email change")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Email reverted successfully",
+	})
+}
+
+type ChangeStatusRequest struct {
+	Reason string `json:"reason"`
+}
+
+// SuspendUser
+// @Summary Suspend a user
+// @Description Suspend a user so they can't log in, requires the admin role
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body ChangeStatusRequest true "Why the user is suspended"
+// @Success 200 {object} domain.User
+func (h *UserHandler) SuspendUser(c *fiber.Ctx) error {
+	return h.changeStatus(c, domain.StatusSuspended, "Failed to suspend user")
+}
+
+// ReactivateUser
+// @Summary Reactivate a user
+// @Description Make a suspended, locked or deactivated user active again, requires the admin role
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body ChangeStatusRequest false "Why the user is reactivated"
+// @Success 200 {object} domain.User
+func (h *UserHandler) ReactivateUser(c *fiber.Ctx) error {
+	return h.changeStatus(c, domain.StatusActive, "Failed to reactivate user")
+}
+
+// ListPendingRegistrations
+// @Summary List registrations waiting for approval
+// @Description List the accounts waiting for an admin to approve them page by page, oldest
+// @Description first, requires the admin role
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Page size, capped by the server"
+// @Param page_token query string false "Token of the page to return"
+// @Success 200 {object} userPageView
+func (h *UserHandler) ListPendingRegistrations(c *fiber.Ctx) error {
+	req, ok, err := pageRequest(c)
+	if !ok {
+		return err
+	}
+
+	page, err := h.usersvc.ListPendingRegistrations(c.Context(), req)
+	if err != nil {
+		return errorResponse(c, err, "Failed to list pending registrations")
+	}
+	if page.NextPageToken != "" {
+		c.Set(fiber.HeaderLink, nextPageLink(c, page.NextPageToken))
+	}
+	response := userPageView{
+		Users:         make([]any, len(page.Users)),
+		NextPageToken: page.NextPageToken,
+	}
+	for i := range page.Users {
+		response.Users[i] = viewUser(&page.Users[i], ports.ViewAdmin, nil)
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// ApproveRegistration
+// @Summary Approve a registration
+// @Description Activate an account waiting for approval so it can log in, requires the admin role
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} domain.User
+func (h *UserHandler) ApproveRegistration(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	user, err := h.usersvc.ApproveRegistration(c.Context(), id)
+	if err != nil {
+		h.log.Errorf("Failed to approve registration: %v", err)
+		return errorResponse(c, err, "Failed to approve registration")
+	}
+	return c.Status(fiber.StatusOK).JSON(viewUser(user, ports.ViewAdmin, nil))
+}
+
+// RejectRegistration
+// @Summary Reject a registration
+// @Description Delete an account waiting for approval, its email can register again. Requires
+// @Description the admin role.
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+func (h *UserHandler) RejectRegistration(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	if err := h.usersvc.RejectRegistration(c.Context(), id); err != nil {
+		h.log.Errorf("Failed to reject registration: %v", err)
+		return errorResponse(c, err, "Failed to reject registration")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Registration rejected successfully",
+	})
+}
+
+func (h *UserHandler) changeStatus(c *fiber.Ctx, to domain.Status, fallback string) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req ChangeStatusRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	user, err := h.usersvc.ChangeStatus(c.Context(), id, to, req.Reason)
+	if err != nil {
+		h.log.Errorf("%s: %v", fallback, err)
+		return errorResponse(c, err, fallback)
+	}
+	return c.Status(fiber.StatusOK).JSON(viewUser(user, ports.ViewAdmin, nil))
+}
+
 // DeleteUser by id
 // @Summary Delete user by id
-// @Description Delete user by id
+// @Description Delete user by id, the user themself or an admin
 // @Tags user
 // @Accept json
 // @Produce json
@@ -171,59 +610,193 @@ func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.usersvc.Delete(c.Context(), bsonId); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to delete user",
+	// users delete their own account, admins any account of the organization
+	if caller(c).ViewOf(bsonId) == ports.ViewPublic {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the user or an admin can delete the account",
 		})
 	}
 
+	if err := h.usersvc.Delete(c.Context(), bsonId); err != nil {
+		h.log.Errorf("Failed to delete user: %v", err)
+		return errorResponse(c, err, "Failed to delete user")
+	}
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "User deleted successfully",
 	})
 }
 
-// ListAllUsers
-// @Summary List all users
-// @Description List all users
+// ListUsers
+// @Summary List users
+// @Description List users matching the filters page by page, oldest first unless sorted otherwise.
+// @Description Follow next_page_token (or the Link header) to get the next page. Users are in
+// @Description their public profile view unless the caller is an admin, who alone can filter
+// @Description by email_domain, q, status or on other fields than id, name and the timestamps.
 // @Tags user
 // @Accept json
 // @Produce json
-// @Success 200 {object} domain.User
+// @Param limit query int false "Page size, capped by the server"
+// @Param page_token query string false "Token of the page to return"
+// @Param include_total query bool false "Include the total number of matching users"
+// @Param name_prefix query string false "Case-insensitive name prefix"
+// @Param email_domain query string false "Email domain, e.g. example.com"
+// @Param created_after query string false "RFC 3339 time or YYYY-MM-DD, inclusive"
+// @Param created_before query string false "RFC 3339 time or YYYY-MM-DD, exclusive"
+// @Param q query string false "Case-insensitive prefix of the name or the email"
+// @Param status query string false "Account status, e.g. suspended"
+// @Param sort query string false "Comma separated name, email, created_at, prefixed with - for descending"
+// @Param filter query string false "Filter expression, e.g. email ~ \"@acme.com\" and created_at > 2025-01-01"
+// @Param fields query string false "Comma separated fields to return, e.g. id,name"
+// @Success 200 {object} ports.UserPage
 func (h *UserHandler) ListUsers(c *fiber.Ctx) error {
-	offset, err := strconv.Atoi(c.Query("offset", "0"))
-	if err != nil {
+	if c.Query("offset") != "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid offset",
+			"error": "offset is not supported, use page_token",
 		})
 	}
 	limit, err := strconv.Atoi(c.Query("limit", "0"))
-	if err != nil {
+	if err != nil || limit < 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid limit",
 		})
 	}
 
-	var users []domain.User
-	if offset == 0 && limit == 0 {
-		users, err = h.usersvc.GetAll(c.Context())
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to list users",
-			})
-		}
-	} else {
-		users, err = h.usersvc.List(c.Context(), &ports.Pagination{
-			Offset: int64(offset),
-			Limit:  int64(limit),
+	createdAfter, err := parseTimeQuery(c, "created_after")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid created_after",
 		})
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to list users",
-			})
-		}
+	}
+	createdBefore, err := parseTimeQuery(c, "created_before")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid created_before",
+		})
+	}
+	sort, err := ports.ParseSort(c.Query("sort"))
+	if err != nil {
+		return errorResponse(c, err, "Invalid sort")
+	}
+	expr, err := filterexpr.Parse(c.Query("filter"))
+	if err != nil {
+		return errorResponse(c, err, "Invalid filter")
+	}
+	fields, err := parseFieldsQuery(c)
+	if err != nil {
+		return errorResponse(c, err, "Invalid fields")
+	}
+	filter := ports.UserFilter{
+		NamePrefix:    c.Query("name_prefix"),
+		EmailDomain:   c.Query("email_domain"),
+		CreatedAfter:  createdAfter,
+		CreatedBefore: createdBefore,
+		Search:        c.Query("q"),
+		Status:        c.Query("status"),
+		Expr:          expr,
+	}
+	view := caller(c).ViewOf(bson.ObjectID{})
+	if err := view.CheckSort(sort); err != nil {
+		return errorResponse(c, err, "Invalid sort")
+	}
+	if err := view.CheckFilter(&filter); err != nil {
+		return errorResponse(c, err, "Invalid filter")
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"users": users,
+	page, err := h.usersvc.List(c.Context(), &ports.ListRequest{
+		Filter:       filter,
+		Sort:         sort,
+		PageSize:     int64(limit),
+		PageToken:    c.Query("page_token"),
+		IncludeTotal: c.QueryBool("include_total"),
+		Fields:       view.Fields(fields),
+	})
+	if err != nil {
+		return errorResponse(c, err, "Failed to list users")
+	}
+
+	if page.NextPageToken != "" {
+		c.Set(fiber.HeaderLink, nextPageLink(c, page.NextPageToken))
+	}
+	if page.TotalCount != nil {
+		c.Set("X-Total-Count", strconv.FormatInt(*page.TotalCount, 10))
+	}
+	response := userPageView{
+		Users:         make([]any, len(page.Users)),
+		NextPageToken: page.NextPageToken,
+		TotalCount:    page.TotalCount,
+	}
+	for i := range page.Users {
+		response.Users[i] = viewUser(&page.Users[i], view, fields)
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+type SearchUsersResponse struct {
+	Results []searchResultView `json:"results"`
+}
+
+// SearchUsers
+// @Summary Search users
+// @Description Typo-tolerant search on names and emails, best matches first. Users are in
+// @Description their public profile view, matched on their names only, unless the caller is an admin.
+// @Tags user
+// @Produce json
+// @Param q query string true "Search query"
+// @Param limit query int false "Number of results, capped by the server"
+// @Success 200 {object} SearchUsersResponse
+func (h *UserHandler) SearchUsers(c *fiber.Ctx) error {
+	limit, err := strconv.Atoi(c.Query("limit", "0"))
+	if err != nil || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid limit",
+		})
+	}
+
+	view := caller(c).ViewOf(bson.ObjectID{})
+	results, err := h.usersvc.Search(c.Context(), &ports.SearchRequest{
+		Query:  c.Query("q"),
+		Limit:  int64(limit),
+		Fields: view.SearchFields(),
 	})
+	if err != nil {
+		return errorResponse(c, err, "Failed to search users")
+	}
+
+	response := SearchUsersResponse{Results: make([]searchResultView, len(results))}
+	for i := range results {
+		response.Results[i] = viewSearchResult(&results[i], view)
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// parseTimeQuery parses an optional RFC 3339 time or YYYY-MM-DD date query param
+func parseTimeQuery(c *fiber.Ctx, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid time %q", value)
+}
+
+// usernameLink points the current request to another username, keeping the query params
+func usernameLink(c *fiber.Ctx, username string) string {
+	link := c.BaseURL() + path.Join(path.Dir(c.Path()), url.PathEscape(username))
+	if query := c.Request().URI().QueryString(); len(query) > 0 {
+		link += "?" + string(query)
+	}
+	return link
+}
+
+// nextPageLink builds an RFC 8288 Link header pointing to the next page,
+// keeping every other query param of the current request.
+func nextPageLink(c *fiber.Ctx, token string) string {
+	query, _ := url.ParseQuery(string(c.Request().URI().QueryString()))
+	query.Set("page_token", token)
+	return fmt.Sprintf(`<%s%s?%s>; rel="next"`, c.BaseURL(), c.Path(), query.Encode())
 }
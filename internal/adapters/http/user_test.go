@@ -0,0 +1,118 @@
+package http
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/mocks"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func newTestUserHandler(t *testing.T) (*UserHandler, *mocks.MockUserService) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+	userService := mocks.NewMockUserService(ctrl)
+	return NewUserHandler(logger.New(logrus.PanicLevel), userService), userService
+}
+
+// withCaller authenticates requests like AuthMiddleware, as the user with id and roles
+func withCaller(id bson.ObjectID, roles ...string) fiber.Handler {
+	rol := make([]any, len(roles))
+	for i, role := range roles {
+		rol[i] = role
+	}
+	return func(c *fiber.Ctx) error {
+		c.Locals("user", &jwt.Token{Claims: jwt.MapClaims{"sub": id.Hex(), "rol": rol}})
+		return c.Next()
+	}
+}
+
+// get returns the status of a GET of target on app
+func get(t *testing.T, app *fiber.App, target string) int {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, target, nil))
+	if err != nil {
+		t.Fatalf("GET %s: %v", target, err)
+	}
+	return resp.StatusCode
+}
+
+func TestUserHandler_ListUsers_PublicFilter(t *testing.T) {
+	h, userService := newTestUserHandler(t)
+	app := fiber.New()
+	app.Get("/users", h.ListUsers)
+	app.Get("/member/users", withCaller(bson.NewObjectID()), h.ListUsers)
+	app.Get("/admin/users", withCaller(bson.NewObjectID(), domain.RoleAdmin), h.ListUsers)
+
+	// filters on fields the public view hides are refused before listing
+	for _, query := range []string{
+		"filter=" + url.QueryEscape(`email ~ "@acme.com"`),
+		"filter=" + url.QueryEscape(`name ~ "jo" or attributes.vip`),
+		"email_domain=acme.com",
+		"q=jane",
+		"status=active",
+	} {
+		for _, path := range []string{"/users", "/member/users"} {
+			if code := get(t, app, path+"?"+query); code != fiber.StatusForbidden {
+				t.Fatalf("GET %s?%s: expected 403, got %d", path, query, code)
+			}
+		}
+	}
+
+	// public fields and admins are fine
+	userService.EXPECT().List(gomock.Any(), gomock.Any()).Return(&ports.UserPage{}, nil).Times(2)
+	if code := get(t, app, "/users?filter="+url.QueryEscape(`name ~ "jo"`)); code != fiber.StatusOK {
+		t.Fatalf("expected a name filter to pass, got %d", code)
+	}
+	if code := get(t, app, "/admin/users?filter="+url.QueryEscape(`email ~ "@acme.com"`)); code != fiber.StatusOK {
+		t.Fatalf("expected an admin email filter to pass, got %d", code)
+	}
+}
+
+func TestUserHandler_SearchUsers_PublicNames(t *testing.T) {
+	h, userService := newTestUserHandler(t)
+	app := fiber.New()
+	app.Get("/users/search", h.SearchUsers)
+
+	userService.EXPECT().Search(gomock.Any(), &ports.SearchRequest{Query: "jane", Fields: []string{ports.FieldName}}).Return(nil, nil)
+	if code := get(t, app, "/users/search?q=jane"); code != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+}
+
+func TestUserHandler_DeleteUser_SelfOrAdmin(t *testing.T) {
+	h, userService := newTestUserHandler(t)
+	self, other := bson.NewObjectID(), bson.NewObjectID()
+	app := fiber.New()
+	app.Delete("/users/:id", withCaller(self), h.DeleteUser)
+	app.Delete("/admin/users/:id", withCaller(bson.NewObjectID(), domain.RoleAdmin), h.DeleteUser)
+	del := func(target string) int {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodDelete, target, nil))
+		if err != nil {
+			t.Fatalf("DELETE %s: %v", target, err)
+		}
+		return resp.StatusCode
+	}
+
+	if code := del("/users/" + other.Hex()); code != fiber.StatusForbidden {
+		t.Fatalf("expected deleting another user to be forbidden, got %d", code)
+	}
+
+	userService.EXPECT().Delete(gomock.Any(), self).Return(nil)
+	userService.EXPECT().Delete(gomock.Any(), other).Return(nil)
+	if code := del("/users/" + self.Hex()); code != fiber.StatusOK {
+		t.Fatalf("expected users to delete themselves, got %d", code)
+	}
+	if code := del("/admin/users/" + other.Hex()); code != fiber.StatusOK {
+		t.Fatalf("expected admins to delete another user, got %d", code)
+	}
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// publicUser is the public view of a user, see ports.PublicFields
+type publicUser struct {
+	ID          bson.ObjectID `json:"id"`
+	Name        string        `json:"name"`
+	Username    string        `json:"username,omitempty"`
+	DisplayName string        `json:"display_name,omitempty"`
+	AvatarURL   string        `json:"avatar_url,omitempty"`
+	AvatarHash  string        `json:"avatar_hash,omitempty"`
+	Bio         string        `json:"bio,omitempty"`
+}
+
+// adminUser is the admin view of a user, with the fields kept from users themselves
+type adminUser struct {
+	*domain.User
+	StatusReason string   `json:"status_reason,omitempty"`
+	Roles        []string `json:"roles,omitempty"`
+}
+
+// viewUser renders user as seen in view, only the sparse fieldset when fields isn't nil.
+// The user must have been loaded with view.Fields(fields).
+func viewUser(user *domain.User, view ports.View, fields []string) any {
+	if fields != nil {
+		return sparseUser(user, view.Fields(fields))
+	}
+	switch view {
+	case ports.ViewAdmin:
+		return adminUser{User: user, StatusReason: user.StatusReason, Roles: user.Roles}
+	case ports.ViewSelf:
+		return user
+	default:
+		return publicUser{
+			ID:          user.ID,
+			Name:        user.Name,
+			Username:    user.Username,
+			DisplayName: user.DisplayName,
+			AvatarURL:   user.AvatarURL,
+			AvatarHash:  user.AvatarHash,
+			Bio:         user.Bio,
+		}
+	}
+}
+
+// searchResultView is a ports.SearchResult rendered in a view
+type searchResultView struct {
+	User       any               `json:"user"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// viewSearchResult renders a search result as seen in view
+func viewSearchResult(result *ports.SearchResult, view ports.View) searchResultView {
+	seen := view.SearchResult(*result)
+	return searchResultView{User: viewUser(&seen.User, view, nil), Score: seen.Score, Highlights: seen.Highlights}
+}
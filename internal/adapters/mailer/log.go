@@ -0,0 +1,29 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// compile time check to ensure logMailer implements ports.Mailer
+var _ ports.Mailer = (*logMailer)(nil)
+
+// logMailer writes emails to the log instead of sending them, for development.
+type logMailer struct {
+	log logger.Logger
+}
+
+func NewLogMailer(log logger.Logger) *logMailer {
+	return &logMailer{log: log.WithFields(logrus.Fields{"module": "mailer"})}
+}
+
+func (m *logMailer) Send(ctx context.Context, msg *ports.Message) error {
+	m.log.WithFields(logrus.Fields{
+		"to":      msg.To,
+		"subject": msg.Subject,
+	}).Info(msg.Body)
+	return nil
+}
@@ -0,0 +1,17 @@
+// Package mailer implements ports.Mailer.
+package mailer
+
+import (
+	"github.com/hinphansa/7-solutions-challenge/config"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/pkg/logger"
+)
+
+// New returns the mailer selected by mailer.driver in the config
+func New(cfg *config.Config, log logger.Logger) ports.Mailer {
+	if cfg.Mailer.Driver == "smtp" {
+		smtp := cfg.Mailer.SMTP
+		return NewSMTPMailer(smtp.Host, smtp.Port, smtp.Username, smtp.Password, cfg.Mailer.From)
+	}
+	return NewLogMailer(log)
+}
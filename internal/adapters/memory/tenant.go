@@ -0,0 +1,34 @@
+// Package memory implements repositories in process memory, for tests and single-process
+// demos. Stored values are copied in and out, so callers can't alter them behind the lock.
+package memory
+
+import (
+	"context"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// tenantScope is the organization a context is scoped to, or every organization
+type tenantScope struct {
+	id  bson.ObjectID
+	all bool
+}
+
+// scopeOf returns the scope of ctx, ports.ErrNoTenant when ctx names no organization and
+// isn't ports.AllTenants, like the Mongo repositories.
+func scopeOf(ctx context.Context) (tenantScope, error) {
+	if ports.IsAllTenants(ctx) {
+		return tenantScope{all: true}, nil
+	}
+	id, ok := ports.TenantOf(ctx)
+	if !ok {
+		return tenantScope{}, ports.ErrNoTenant
+	}
+	return tenantScope{id: id}, nil
+}
+
+// contains reports whether a document of the organization tenantID is in scope
+func (s tenantScope) contains(tenantID bson.ObjectID) bool {
+	return s.all || s.id == tenantID
+}
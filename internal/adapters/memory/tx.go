@@ -0,0 +1,31 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+)
+
+// compile time check to ensure txManager implements ports.TxManager
+var _ ports.TxManager = (*txManager)(nil)
+
+// txManager runs units of work without isolation or rollback, the memory repositories
+// apply each write on its own. It keeps the AfterCommit contract, for tests and storage
+// without transactions.
+type txManager struct{}
+
+func NewTxManager() *txManager {
+	return &txManager{}
+}
+
+func (m *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ports.InTx(ctx) {
+		return fn(ctx)
+	}
+	txCtx, uow := ports.NewUnitOfWork(ctx)
+	if err := fn(txCtx); err != nil {
+		return err
+	}
+	uow.Committed()
+	return nil
+}
@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+)
+
+func TestTxManager(t *testing.T) {
+	tx := NewTxManager()
+	ctx := context.Background()
+
+	var committed []string
+	err := tx.WithinTx(ctx, func(ctx context.Context) error {
+		if !ports.InTx(ctx) {
+			t.Fatalf("expected a transaction")
+		}
+		ports.AfterCommit(ctx, func() { committed = append(committed, "outer") })
+		// a nested call joins, its callbacks wait for the outer one
+		return tx.WithinTx(ctx, func(ctx context.Context) error {
+			ports.AfterCommit(ctx, func() { committed = append(committed, "inner") })
+			if len(committed) != 0 {
+				t.Fatalf("expected nothing run before the commit, got %v", committed)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("within tx: %v", err)
+	}
+	if len(committed) != 2 || committed[0] != "outer" || committed[1] != "inner" {
+		t.Fatalf("expected the callbacks in order, got %v", committed)
+	}
+
+	failed := errors.New("failed")
+	err = tx.WithinTx(ctx, func(ctx context.Context) error {
+		ports.AfterCommit(ctx, func() { t.Fatalf("expected no callback after a failure") })
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+)
+
+// matchesFilter reports whether user matches filter, nil matches every user. Strings compare
+// case-insensitively, like the collation of the Mongo listing queries.
+func matchesFilter(user *domain.User, filter *ports.UserFilter) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.NamePrefix != "" && !hasPrefixFold(user.Name, filter.NamePrefix) {
+		return false
+	}
+	if filter.EmailDomain != "" && !strings.EqualFold(emailDomain(user.Email), filter.EmailDomain) {
+		return false
+	}
+	if filter.CreatedAfter != nil && user.CreatedAt.Before(*filter.CreatedAfter) {
+		return false
+	}
+	if filter.CreatedBefore != nil && !user.CreatedAt.Before(*filter.CreatedBefore) {
+		return false
+	}
+	if filter.Status != "" && !strings.EqualFold(string(user.Status), filter.Status) {
+		return false
+	}
+	if filter.Search != "" && !hasPrefixFold(user.Name, filter.Search) && !hasPrefixFold(user.Email, filter.Search) {
+		return false
+	}
+	return filter.Expr == nil || filter.Expr.Match(user)
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+func emailDomain(email string) string {
+	_, host, _ := strings.Cut(email, "@")
+	return host
+}
+
+// compareUsers orders two users by the sort keys then by id, so the order is total
+func compareUsers(a, b *domain.User, sort []ports.SortField) int {
+	for _, field := range sort {
+		c := compareField(field.Field, a, b)
+		if field.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return slices.Compare(a.ID[:], b.ID[:])
+}
+
+func compareField(field string, a, b *domain.User) int {
+	switch field {
+	case ports.SortByName:
+		return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	case ports.SortByEmail:
+		return cmp.Compare(strings.ToLower(a.Email), strings.ToLower(b.Email))
+	case ports.SortByCreatedAt:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	}
+	return 0
+}
+
+// cursorUser is the position of a cursor as a user, to compare users with it
+func cursorUser(cursor *ports.Cursor) *domain.User {
+	return &domain.User{ID: cursor.ID, Name: cursor.Name, Email: cursor.Email, CreatedAt: cursor.CreatedAt}
+}
+
+// cloneUser copies user deeply enough that no stored value is shared with the caller
+func cloneUser(user *domain.User) *domain.User {
+	clone := *user
+	clone.Attributes = maps.Clone(user.Attributes)
+	clone.Roles = slices.Clone(user.Roles)
+	clone.PreviousUsernames = slices.Clone(user.PreviousUsernames)
+	if user.PendingEmail != nil {
+		pending := *user.PendingEmail
+		clone.PendingEmail = &pending
+	}
+	if user.EmailRevert != nil {
+		revert := *user.EmailRevert
+		clone.EmailRevert = &revert
+	}
+	return &clone
+}
+
+// project returns a copy of user with only the fields selected, and its id like Mongo
+func project(user *domain.User, fields []string) *domain.User {
+	clone := cloneUser(user)
+	if len(fields) > 0 {
+		ports.MaskUser(clone, fields)
+		clone.ID = user.ID
+	}
+	return clone
+}
@@ -0,0 +1,445 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/search"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// compile time check to ensure userRepository implements ports.UserRepository
+var _ ports.UserRepository = (*userRepository)(nil)
+
+// userRepository keeps users in a map guarded by a single lock. Every method holds the lock
+// for its whole read or write, so checks like email uniqueness are atomic with the write,
+// the way unique indexes make them in Mongo. Lists scan every user, which is fine at the
+// sizes it's meant for.
+type userRepository struct {
+	mu    sync.RWMutex
+	users map[bson.ObjectID]*domain.User
+}
+
+func NewUserRepository() *userRepository {
+	return &userRepository{users: map[bson.ObjectID]*domain.User{}}
+}
+
+// Create stores the user in the organization of ctx, whatever its TenantID. The id is
+// generated unless the user has one.
+func (r *userRepository) Create(ctx context.Context, user *domain.User) (*bson.ObjectID, error) {
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return nil, ports.ErrNoTenant
+	}
+	stored := cloneUser(user)
+	stored.TenantID = tenantID
+	if stored.ID.IsZero() {
+		stored.ID = bson.NewObjectID()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[stored.ID]; ok {
+		return nil, domain.ErrConflict
+	}
+	if err := r.checkUnique(stored); err != nil {
+		return nil, err
+	}
+	r.users[stored.ID] = stored
+	id := stored.ID
+	return &id, nil
+}
+
+// checkUnique fails when another user of the organization of user has its email or
+// username, the caller holds the lock.
+func (r *userRepository) checkUnique(user *domain.User) error {
+	for _, other := range r.users {
+		if other.ID == user.ID || other.TenantID != user.TenantID {
+			continue
+		}
+		if other.Email == user.Email {
+			return domain.ErrEmailTaken
+		}
+		if user.Username != "" && other.Username == user.Username {
+			return domain.ErrUsernameTaken
+		}
+	}
+	return nil
+}
+
+// get returns the stored user with id if it's in the scope of ctx, the caller holds the lock
+func (r *userRepository) get(ctx context.Context, id bson.ObjectID) (*domain.User, error) {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, ok := r.users[id]
+	if !ok || !scope.contains(user.TenantID) {
+		return nil, domain.ErrNotFound
+	}
+	return user, nil
+}
+
+// find returns the first stored user in the scope of ctx matching match, in id order so
+// the result doesn't depend on map iteration. The caller holds the lock.
+func (r *userRepository) find(ctx context.Context, match func(*domain.User) bool) (*domain.User, error) {
+	users, err := r.scan(ctx, match)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return users[0], nil
+}
+
+// scan returns the stored users in the scope of ctx matching match, sorted by id. The
+// caller holds the lock and copies the users it returns.
+func (r *userRepository) scan(ctx context.Context, match func(*domain.User) bool) ([]*domain.User, error) {
+	scope, err := scopeOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var users []*domain.User
+	for _, user := range r.users {
+		if scope.contains(user.TenantID) && match(user) {
+			users = append(users, user)
+		}
+	}
+	slices.SortFunc(users, func(a, b *domain.User) int {
+		return compareUsers(a, b, nil)
+	})
+	return users, nil
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id bson.ObjectID, fields ...string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, err := r.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return project(user, fields), nil
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, err := r.find(ctx, func(u *domain.User) bool { return u.Email == email })
+	if err != nil {
+		return nil, err
+	}
+	return cloneUser(user), nil
+}
+
+// GetByUsername looks up the current usernames first, a previous username only matches
+// while its redirect hasn't expired.
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, err := r.find(ctx, func(u *domain.User) bool { return u.Username == username })
+	if errors.Is(err, domain.ErrNotFound) {
+		now := time.Now()
+		user, err = r.find(ctx, func(u *domain.User) bool {
+			return slices.ContainsFunc(u.PreviousUsernames, func(redirect domain.UsernameRedirect) bool {
+				return redirect.Username == username && redirect.ExpiresAt.After(now)
+			})
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cloneUser(user), nil
+}
+
+func (r *userRepository) GetAll(ctx context.Context) ([]domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	users, err := r.scan(ctx, func(*domain.User) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	results := make([]domain.User, len(users))
+	for i, user := range users {
+		results[i] = *cloneUser(user)
+	}
+	return results, nil
+}
+
+// List sorts the matching users and returns the window after the cursor, the same keyset
+// pagination as Mongo without the index.
+func (r *userRepository) List(ctx context.Context, filter *ports.UserFilter, pagination *ports.Pagination) ([]domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	users, err := r.scan(ctx, func(u *domain.User) bool { return matchesFilter(u, filter) })
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(users, func(a, b *domain.User) int {
+		return compareUsers(a, b, pagination.Sort)
+	})
+	if pagination.After != nil {
+		after := cursorUser(pagination.After)
+		start, _ := slices.BinarySearchFunc(users, after, func(u, after *domain.User) int {
+			if compareUsers(u, after, pagination.Sort) <= 0 {
+				return -1
+			}
+			return 1
+		})
+		users = users[start:]
+	}
+	if pagination.Limit > 0 && int64(len(users)) > pagination.Limit {
+		users = users[:pagination.Limit]
+	}
+
+	results := make([]domain.User, len(users))
+	for i, user := range users {
+		results[i] = *project(user, pagination.Fields)
+	}
+	return results, nil
+}
+
+// Search returns the users sharing the most trigrams with the query, best first, like the
+// Mongo aggregation.
+func (r *userRepository) Search(ctx context.Context, query string, limit int64) ([]domain.User, error) {
+	grams := search.Trigrams(query)
+	if len(grams) == 0 {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	overlap := map[bson.ObjectID]int{}
+	users, err := r.scan(ctx, func(u *domain.User) bool {
+		own := append(search.Trigrams(u.Name), search.Trigrams(u.Email)...)
+		for _, gram := range grams {
+			if slices.Contains(own, gram) {
+				overlap[u.ID]++
+			}
+		}
+		return overlap[u.ID] > 0
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(users, func(a, b *domain.User) int {
+		return overlap[b.ID] - overlap[a.ID]
+	})
+	if int64(len(users)) > limit {
+		users = users[:limit]
+	}
+
+	results := make([]domain.User, len(users))
+	for i, user := range users {
+		results[i] = *cloneUser(user)
+	}
+	return results, nil
+}
+
+// Update applies a partial update, domain.ErrNotFound if there's no such user
+func (r *userRepository) Update(ctx context.Context, id bson.ObjectID, update *ports.UserUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, err := r.get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	user := cloneUser(stored)
+	changed := false
+	for _, field := range []struct {
+		update ports.FieldUpdate[string]
+		value  *string
+	}{
+		{update.Name, &user.Name},
+		{update.Email, &user.Email},
+		{update.DisplayName, &user.DisplayName},
+		{update.Phone, &user.Phone},
+		{update.Locale, &user.Locale},
+		{update.TimeZone, &user.TimeZone},
+		{update.AvatarURL, &user.AvatarURL},
+		{update.Bio, &user.Bio},
+	} {
+		switch field.update.Op {
+		case ports.Set:
+			*field.value, changed = field.update.Value, true
+		case ports.Clear:
+			*field.value, changed = "", true
+		}
+	}
+	for name, field := range update.Attributes {
+		switch field.Op {
+		case ports.Set:
+			if user.Attributes == nil {
+				user.Attributes = map[string]any{}
+			}
+			user.Attributes[name], changed = field.Value, true
+		case ports.Clear:
+			delete(user.Attributes, name)
+			changed = true
+		}
+	}
+	if !changed {
+		return errors.New("no fields to update")
+	}
+
+	if err := r.checkUnique(user); err != nil {
+		return err
+	}
+	user.UpdatedAt = time.Now()
+	r.users[id] = user
+	return nil
+}
+
+func (r *userRepository) SetEmailChange(ctx context.Context, id bson.ObjectID, change *domain.EmailChange, revert *domain.EmailRevert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, err := r.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	user := cloneUser(stored)
+	user.PendingEmail, user.EmailRevert = nil, nil
+	if change != nil {
+		pending := *change
+		user.PendingEmail = &pending
+	}
+	if revert != nil {
+		undo := *revert
+		user.EmailRevert = &undo
+	}
+	r.users[id] = user
+	return nil
+}
+
+// ConfirmEmailChange checks the token hash under the same lock as the swap, so a token can
+// only be used once and a newer request invalidates it.
+func (r *userRepository) ConfirmEmailChange(ctx context.Context, id bson.ObjectID, tokenHash string, email string) error {
+	return r.swapEmail(ctx, id, email, func(u *domain.User) bool {
+		return u.PendingEmail != nil && u.PendingEmail.TokenHash == tokenHash && u.PendingEmail.Email == email
+	}, func(u *domain.User) {
+		u.PendingEmail = nil
+	})
+}
+
+func (r *userRepository) RevertEmailChange(ctx context.Context, id bson.ObjectID, tokenHash string, email string) error {
+	return r.swapEmail(ctx, id, email, func(u *domain.User) bool {
+		return u.EmailRevert != nil && u.EmailRevert.TokenHash == tokenHash && u.EmailRevert.Email == email
+	}, func(u *domain.User) {
+		u.PendingEmail, u.EmailRevert = nil, nil
+	})
+}
+
+func (r *userRepository) swapEmail(ctx context.Context, id bson.ObjectID, email string, match func(*domain.User) bool, clear func(*domain.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, err := r.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !match(stored) {
+		return domain.ErrNotFound
+	}
+
+	user := cloneUser(stored)
+	user.Email = email
+	clear(user)
+	if err := r.checkUnique(user); err != nil {
+		return err
+	}
+	user.UpdatedAt = time.Now()
+	r.users[id] = user
+	return nil
+}
+
+// SetStatus checks the current status under the same lock as the change, so concurrent
+// transitions can't both apply.
+func (r *userRepository) SetStatus(ctx context.Context, id bson.ObjectID, change *ports.StatusChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, err := r.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if stored.Status != change.From {
+		return domain.ErrNotFound
+	}
+
+	user := cloneUser(stored)
+	user.Status, user.StatusReason, user.StatusChangedAt = change.To, change.Reason, change.At
+	r.users[id] = user
+	return nil
+}
+
+// SetUsername moves the replaced username to the redirects, dropping expired ones and the
+// redirect of a reclaimed username, see the Mongo repository.
+func (r *userRepository) SetUsername(ctx context.Context, id bson.ObjectID, username string, redirectUntil time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, err := r.get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user := cloneUser(stored)
+	user.PreviousUsernames = slices.DeleteFunc(user.PreviousUsernames, func(redirect domain.UsernameRedirect) bool {
+		return !redirect.ExpiresAt.After(now) || redirect.Username == username
+	})
+	if stored.Username != "" && stored.Username != username {
+		user.PreviousUsernames = append(user.PreviousUsernames, domain.UsernameRedirect{
+			Username:  stored.Username,
+			RenamedAt: now,
+			ExpiresAt: redirectUntil,
+		})
+	}
+	user.Username = username
+	if err := r.checkUnique(user); err != nil {
+		return err
+	}
+	user.UpdatedAt = now
+	r.users[id] = user
+	return nil
+}
+
+func (r *userRepository) SetAvatar(ctx context.Context, id bson.ObjectID, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, err := r.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	user := cloneUser(stored)
+	user.AvatarHash, user.UpdatedAt = hash, time.Now()
+	r.users[id] = user
+	return nil
+}
+
+// Delete removes a user, deleting a missing user isn't an error
+func (r *userRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.get(ctx, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	delete(r.users, id)
+	return nil
+}
+
+func (r *userRepository) Count(ctx context.Context, filter *ports.UserFilter) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	users, err := r.scan(ctx, func(u *domain.User) bool { return matchesFilter(u, filter) })
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(users)), nil
+}
@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports/porttest"
+)
+
+func TestUserRepository(t *testing.T) {
+	porttest.TestUserRepository(t, func(t *testing.T) ports.UserRepository {
+		return NewUserRepository()
+	})
+}
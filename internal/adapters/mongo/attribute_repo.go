@@ -0,0 +1,57 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// compile time check to ensure attributeRepository implements ports.AttributeSchemaRepository
+var _ ports.AttributeSchemaRepository = (*attributeRepository)(nil)
+
+const (
+	attributeCollectionName = "attribute_schema"
+)
+
+// attributeRepository stores one document per attribute definition, keyed by name
+type attributeRepository struct {
+	coll *mongo.Collection
+}
+
+func NewAttributeRepository(db *mongo.Database) *attributeRepository {
+	return &attributeRepository{coll: db.Collection(attributeCollectionName)}
+}
+
+func (r *attributeRepository) List(ctx context.Context) (domain.AttributeSchema, error) {
+	cursor, err := r.coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	schema := domain.AttributeSchema{}
+	if err := cursor.All(ctx, &schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+func (r *attributeRepository) Put(ctx context.Context, def *domain.AttributeDefinition) error {
+	_, err := r.coll.ReplaceOne(ctx, bson.M{"_id": def.Name}, def, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *attributeRepository) Delete(ctx context.Context, name string) error {
+	res, err := r.coll.DeleteOne(ctx, bson.M{"_id": name})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
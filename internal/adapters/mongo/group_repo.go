@@ -0,0 +1,266 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// compile time check to ensure groupRepository implements ports.GroupRepository
+var _ ports.GroupRepository = (*groupRepository)(nil)
+
+const (
+	groupCollectionName       = "groups"
+	groupMemberCollectionName = "group_members"
+	groupLockCollectionName   = "group_locks"
+)
+
+// groupRepository stores groups and their direct memberships in separate collections, so
+// groups of any size and "groups of a member" lookups stay indexed. Names and memberships
+// are unique through indexes created by cmd/migrate.
+type groupRepository struct {
+	groups  *mongo.Collection
+	members *mongo.Collection
+	locks   *mongo.Collection
+}
+
+// groupMemberDocument is the stored form of a membership
+type groupMemberDocument struct {
+	TenantID bson.ObjectID     `bson:"tenant_id"`
+	GroupID  bson.ObjectID     `bson:"group_id"`
+	Kind     domain.MemberKind `bson:"kind"`
+	MemberID bson.ObjectID     `bson:"member_id"`
+	AddedAt  time.Time         `bson:"added_at"`
+}
+
+func NewGroupRepository(db *mongo.Database) *groupRepository {
+	return &groupRepository{
+		groups:  db.Collection(groupCollectionName),
+		members: db.Collection(groupMemberCollectionName),
+		locks:   db.Collection(groupLockCollectionName),
+	}
+}
+
+// Create stores the group in the organization of ctx, whatever its TenantID
+func (r *groupRepository) Create(ctx context.Context, group *domain.Group) (*bson.ObjectID, error) {
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return nil, ports.ErrNoTenant
+	}
+	doc := *group
+	doc.TenantID = tenantID
+	res, err := r.groups.InsertOne(ctx, &doc)
+	if err != nil {
+		return nil, mapGroupWriteError(err)
+	}
+	id := res.InsertedID.(bson.ObjectID)
+	return &id, nil
+}
+
+func (r *groupRepository) GetByID(ctx context.Context, id bson.ObjectID) (*domain.Group, error) {
+	filter, err := byID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var result *domain.Group
+	if err := r.groups.FindOne(ctx, filter).Decode(&result); err != nil {
+		return nil, mapReadError(err)
+	}
+	return result, nil
+}
+
+func (r *groupRepository) List(ctx context.Context, ids []bson.ObjectID, after *bson.ObjectID, limit int64) ([]domain.Group, error) {
+	filter, err := scoped(ctx, keyset("_id", ids, after))
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := r.groups.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []domain.Group{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *groupRepository) Update(ctx context.Context, id bson.ObjectID, update *ports.GroupUpdate) error {
+	set, unset := bson.M{"updated_at": time.Now()}, bson.M{}
+	if update.Name.Op == ports.Set {
+		set["name"] = update.Name.Value
+	}
+	switch update.Description.Op {
+	case ports.Set:
+		set["description"] = update.Description.Value
+	case ports.Clear:
+		unset["description"] = ""
+	}
+	switch update.Roles.Op {
+	case ports.Set:
+		set["roles"] = update.Roles.Value
+	case ports.Clear:
+		unset["roles"] = ""
+	}
+
+	changes := bson.M{"$set": set}
+	if len(unset) > 0 {
+		changes["$unset"] = unset
+	}
+	filter, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	res, err := r.groups.UpdateOne(ctx, filter, changes)
+	if err != nil {
+		return mapGroupWriteError(err)
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes the group first, memberships left behind by a failure point to a group
+// that no longer resolves and are removed by the next delete of the group id.
+func (r *groupRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	filter, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	res, err := r.groups.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	memberships, err := scoped(ctx, bson.M{"$or": bson.A{bson.M{"group_id": id}, bson.M{"member_id": id}}})
+	if err != nil {
+		return err
+	}
+	_, err = r.members.DeleteMany(ctx, memberships)
+	return err
+}
+
+func (r *groupRepository) AddMember(ctx context.Context, groupID bson.ObjectID, member *domain.GroupMember) error {
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return ports.ErrNoTenant
+	}
+	if member.Kind == domain.MemberGroup && ports.InTx(ctx) {
+		if err := r.lockNesting(ctx, tenantID); err != nil {
+			return err
+		}
+	}
+	_, err := r.members.InsertOne(ctx, &groupMemberDocument{
+		TenantID: tenantID,
+		GroupID:  groupID,
+		Kind:     member.Kind,
+		MemberID: member.ID,
+		AddedAt:  member.AddedAt,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrConflict
+	}
+	return err
+}
+
+// lockNesting writes the nesting lock of the organization. Transactions nesting groups
+// concurrently write the same document and conflict, the one retried then checks for
+// cycles with the group the other added.
+func (r *groupRepository) lockNesting(ctx context.Context, tenantID bson.ObjectID) error {
+	_, err := r.locks.UpdateOne(ctx,
+		bson.M{"_id": tenantID},
+		bson.M{"$inc": bson.M{"version": 1}},
+		options.UpdateOne().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *groupRepository) RemoveMember(ctx context.Context, groupID, memberID bson.ObjectID) error {
+	filter, err := scoped(ctx, bson.M{"group_id": groupID, "member_id": memberID})
+	if err != nil {
+		return err
+	}
+	res, err := r.members.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *groupRepository) RemoveFromAll(ctx context.Context, memberID bson.ObjectID) error {
+	filter, err := scoped(ctx, bson.M{"member_id": memberID})
+	if err != nil {
+		return err
+	}
+	_, err = r.members.DeleteMany(ctx, filter)
+	return err
+}
+
+func (r *groupRepository) ListMembers(ctx context.Context, groupID bson.ObjectID, after *bson.ObjectID, limit int64) ([]domain.GroupMember, error) {
+	query := keyset("member_id", nil, after)
+	query["group_id"] = groupID
+	filter, err := scoped(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := r.members.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "member_id", Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []domain.GroupMember{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *groupRepository) ParentsOf(ctx context.Context, memberIDs []bson.ObjectID) ([]bson.ObjectID, error) {
+	filter, err := scoped(ctx, bson.M{"member_id": bson.M{"$in": memberIDs}})
+	if err != nil {
+		return nil, err
+	}
+	var parents []bson.ObjectID
+	if err := r.members.Distinct(ctx, "group_id", filter).Decode(&parents); err != nil {
+		return nil, err
+	}
+	return parents, nil
+}
+
+// keyset filters field to ids, nil for any, and to values greater than after, nil for any
+func keyset(field string, ids []bson.ObjectID, after *bson.ObjectID) bson.M {
+	cond := bson.M{}
+	if ids != nil {
+		cond["$in"] = ids
+	}
+	if after != nil {
+		cond["$gt"] = *after
+	}
+	if len(cond) == 0 {
+		return bson.M{}
+	}
+	return bson.M{field: cond}
+}
+
+// mapGroupWriteError maps a violation of the unique name index to domain.ErrGroupTaken
+func mapGroupWriteError(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrGroupTaken
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// compile time check to ensure invitationRepository implements ports.InvitationRepository
+var _ ports.InvitationRepository = (*invitationRepository)(nil)
+
+const (
+	invitationCollectionName = "invitations"
+)
+
+// invitationRepository stores pending invitations, an email has at most one per
+// organization through an index created by cmd/migrate
+type invitationRepository struct {
+	coll *mongo.Collection
+}
+
+func NewInvitationRepository(db *mongo.Database) *invitationRepository {
+	return &invitationRepository{coll: db.Collection(invitationCollectionName)}
+}
+
+// Create stores the invitation in the organization of ctx, whatever its TenantID
+func (r *invitationRepository) Create(ctx context.Context, invitation *domain.Invitation) error {
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return ports.ErrNoTenant
+	}
+	doc := *invitation
+	doc.TenantID = tenantID
+	_, err := r.coll.InsertOne(ctx, &doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrInvited
+	}
+	return err
+}
+
+func (r *invitationRepository) GetByID(ctx context.Context, id bson.ObjectID) (*domain.Invitation, error) {
+	filter, err := byID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var result *domain.Invitation
+	if err := r.coll.FindOne(ctx, filter).Decode(&result); err != nil {
+		return nil, mapReadError(err)
+	}
+	return result, nil
+}
+
+func (r *invitationRepository) List(ctx context.Context, after *bson.ObjectID, limit int64) ([]domain.Invitation, error) {
+	filter, err := scoped(ctx, keyset("_id", nil, after))
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := r.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []domain.Invitation{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *invitationRepository) Renew(ctx context.Context, id bson.ObjectID, tokenHash string, expiresAt, sentAt time.Time) error {
+	filter, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	res, err := r.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"token_hash": tokenHash,
+		"expires_at": expiresAt,
+		"sent_at":    sentAt,
+	}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *invitationRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	filter, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	res, err := r.coll.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
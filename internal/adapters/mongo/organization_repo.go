@@ -0,0 +1,105 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// compile time check to ensure organizationRepository implements ports.OrganizationRepository
+var _ ports.OrganizationRepository = (*organizationRepository)(nil)
+
+const (
+	organizationCollectionName = "organizations"
+)
+
+// organizationRepository stores the organizations, slugs are unique through an index
+// created by cmd/migrate
+type organizationRepository struct {
+	coll *mongo.Collection
+}
+
+func NewOrganizationRepository(db *mongo.Database) *organizationRepository {
+	return &organizationRepository{coll: db.Collection(organizationCollectionName)}
+}
+
+func (r *organizationRepository) Create(ctx context.Context, org *domain.Organization) (*bson.ObjectID, error) {
+	res, err := r.coll.InsertOne(ctx, org)
+	if err != nil {
+		return nil, mapOrganizationWriteError(err)
+	}
+	id := res.InsertedID.(bson.ObjectID)
+	return &id, nil
+}
+
+func (r *organizationRepository) GetByID(ctx context.Context, id bson.ObjectID) (*domain.Organization, error) {
+	var result *domain.Organization
+	if err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&result); err != nil {
+		return nil, mapReadError(err)
+	}
+	return result, nil
+}
+
+func (r *organizationRepository) GetBySlug(ctx context.Context, slug string) (*domain.Organization, error) {
+	var result *domain.Organization
+	if err := r.coll.FindOne(ctx, bson.M{"slug": slug}).Decode(&result); err != nil {
+		return nil, mapReadError(err)
+	}
+	return result, nil
+}
+
+func (r *organizationRepository) List(ctx context.Context) ([]domain.Organization, error) {
+	cursor, err := r.coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "slug", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []domain.Organization{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *organizationRepository) Update(ctx context.Context, id bson.ObjectID, update *ports.OrganizationUpdate) error {
+	set := bson.M{"updated_at": time.Now()}
+	if update.Name != "" {
+		set["name"] = update.Name
+	}
+	if update.Slug != "" {
+		set["slug"] = update.Slug
+	}
+	res, err := r.coll.UpdateByID(ctx, id, bson.M{"$set": set})
+	if err != nil {
+		return mapOrganizationWriteError(err)
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *organizationRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	res, err := r.coll.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// mapOrganizationWriteError maps a violation of the unique slug index to domain.ErrSlugTaken
+func mapOrganizationWriteError(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrSlugTaken
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// compile time check to ensure settingsRepository implements ports.SettingsRepository
+var _ ports.SettingsRepository = (*settingsRepository)(nil)
+
+const (
+	settingsCollectionName = "user_settings"
+)
+
+// settingsRepository stores one document per user, keyed by the user id and scoped to
+// the organization of the user like the users themselves
+type settingsRepository struct {
+	coll *mongo.Collection
+}
+
+type settingsDocument struct {
+	UserID    bson.ObjectID  `bson:"_id"`
+	TenantID  bson.ObjectID  `bson:"tenant_id"`
+	Settings  map[string]any `bson:"settings"`
+	UpdatedAt time.Time      `bson:"updated_at"`
+}
+
+func NewSettingsRepository(db *mongo.Database) *settingsRepository {
+	return &settingsRepository{coll: db.Collection(settingsCollectionName)}
+}
+
+func (r *settingsRepository) Get(ctx context.Context, userID bson.ObjectID) (map[string]any, error) {
+	filter, err := byID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var doc settingsDocument
+	err = r.coll.FindOne(ctx, filter).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc.Settings, nil
+}
+
+func (r *settingsRepository) Replace(ctx context.Context, userID bson.ObjectID, settings map[string]any) error {
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return ports.ErrNoTenant
+	}
+	doc := settingsDocument{UserID: userID, TenantID: tenantID, Settings: settings, UpdatedAt: time.Now()}
+	// settings of a user of another organization match no document, the upsert then
+	// collides on _id rather than taking them over
+	_, err := r.coll.ReplaceOne(ctx, bson.M{"_id": userID, "tenant_id": tenantID}, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *settingsRepository) Update(ctx context.Context, userID bson.ObjectID, set map[string]any, unset []string) error {
+	setDoc := bson.M{"updated_at": time.Now()}
+	for name, value := range set {
+		setDoc["settings."+name] = value
+	}
+	update := bson.M{"$set": setDoc}
+	if len(unset) > 0 {
+		unsetDoc := bson.M{}
+		for _, name := range unset {
+			unsetDoc["settings."+name] = ""
+		}
+		update["$unset"] = unsetDoc
+	}
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return ports.ErrNoTenant
+	}
+	_, err := r.coll.UpdateOne(ctx, bson.M{"_id": userID, "tenant_id": tenantID}, update, options.UpdateOne().SetUpsert(true))
+	return err
+}
+
+func (r *settingsRepository) Delete(ctx context.Context, userID bson.ObjectID) error {
+	filter, err := byID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	_, err = r.coll.DeleteOne(ctx, filter)
+	return err
+}
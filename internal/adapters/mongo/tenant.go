@@ -0,0 +1,33 @@
+package mongo
+
+import (
+	"context"
+	"maps"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// scoped restricts filter to the organization ctx is scoped to, every query on a
+// tenant-scoped collection goes through it. It fails with ports.ErrNoTenant rather than
+// reading across organizations, unless ctx is ports.AllTenants.
+func scoped(ctx context.Context, filter bson.M) (bson.M, error) {
+	if ports.IsAllTenants(ctx) {
+		return filter, nil
+	}
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return nil, ports.ErrNoTenant
+	}
+	query := maps.Clone(filter)
+	if query == nil {
+		query = bson.M{}
+	}
+	query["tenant_id"] = tenantID
+	return query, nil
+}
+
+// byID is the scoped filter of the document with id
+func byID(ctx context.Context, id bson.ObjectID) (bson.M, error) {
+	return scoped(ctx, bson.M{"_id": id})
+}
@@ -0,0 +1,114 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// compile time check to ensure txManager implements ports.TxManager
+var _ ports.TxManager = (*txManager)(nil)
+
+// error labels of the transaction errors worth retrying, see
+// https://www.mongodb.com/docs/manual/core/transactions-in-applications/
+const (
+	transientTransactionError      = "TransientTransactionError"      // retry the whole transaction
+	unknownTransactionCommitResult = "UnknownTransactionCommitResult" // retry the commit
+)
+
+const (
+	defaultTxAttempts = 5
+	txRetryBackoff    = 10 * time.Millisecond // doubled on every retry, with jitter
+)
+
+// txManager runs units of work in multi-document transactions, which need a replica set
+// or a sharded cluster. The repositories take part through the session in the context.
+type txManager struct {
+	client   *mongo.Client
+	attempts int
+}
+
+type TxOption func(*txManager)
+
+// WithTxAttempts sets how many times a transaction is tried, and its commit, when they
+// fail with a transient error
+func WithTxAttempts(attempts int) TxOption {
+	return func(m *txManager) {
+		m.attempts = max(attempts, 1)
+	}
+}
+
+func NewTxManager(client *mongo.Client, opts ...TxOption) *txManager {
+	m := &txManager{client: client, attempts: defaultTxAttempts}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// WithinTx retries the whole transaction while it fails with a transient error, like a
+// write conflict with another transaction or a primary election, and the commit while
+// its result is unknown
+func (m *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ports.InTx(ctx) && mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	session, err := m.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.WithoutCancel(ctx))
+
+	backoff := txRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := m.run(ctx, session, fn)
+		if err == nil || attempt == m.attempts || !hasErrorLabel(err, transientTransactionError) {
+			return err
+		}
+		select {
+		case <-time.After(backoff/2 + rand.N(backoff)):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// run makes one attempt of a transaction
+func (m *txManager) run(ctx context.Context, session *mongo.Session, fn func(ctx context.Context) error) error {
+	if err := session.StartTransaction(); err != nil {
+		return err
+	}
+	txCtx, uow := ports.NewUnitOfWork(mongo.NewSessionContext(ctx, session))
+	if err := fn(txCtx); err != nil {
+		// aborting cleans up the server side even when ctx is done
+		session.AbortTransaction(context.WithoutCancel(ctx))
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := session.CommitTransaction(context.WithoutCancel(ctx))
+		if err == nil {
+			uow.Committed()
+			return nil
+		}
+		if attempt == m.attempts || !hasErrorLabel(err, unknownTransactionCommitResult) {
+			return err
+		}
+	}
+}
+
+// IsTransient reports whether err is a network error, a timeout or an error of an attempt
+// of a transaction, which may go away when the operation is tried again
+func IsTransient(err error) bool {
+	return mongo.IsNetworkError(err) || mongo.IsTimeout(err) || hasErrorLabel(err, transientTransactionError)
+}
+
+func hasErrorLabel(err error, label string) bool {
+	var labeled mongo.LabeledError
+	return errors.As(err, &labeled) && labeled.HasErrorLabel(label)
+}
@@ -0,0 +1,123 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// TestTxManager runs transactions against the Mongo at MONGO_TEST_URI, in a database of
+// its own dropped afterwards. It's skipped without one, or when it isn't a replica set.
+func TestTxManager(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI not set")
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	var hello bson.M
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		t.Fatalf("hello: %v", err)
+	}
+	if _, ok := hello["setName"]; !ok {
+		client.Disconnect(ctx)
+		t.Skip("transactions need a replica set")
+	}
+	db := client.Database("tx_test_" + bson.NewObjectID().Hex())
+	t.Cleanup(func() {
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	// collections can't be created in a transaction on older servers
+	if err := db.CreateCollection(ctx, collectionName); err != nil {
+		t.Fatalf("create collection: %v", err)
+	}
+
+	tx := NewTxManager(client)
+	repo := NewUserRepository(db)
+	ctx = ports.WithTenant(ctx, bson.NewObjectID())
+	newUser := func(email string) *domain.User {
+		return &domain.User{Name: "Jane", Email: email, Password: "hash", Status: domain.StatusActive}
+	}
+
+	t.Run("Commit", func(t *testing.T) {
+		var id *bson.ObjectID
+		committed := false
+		err := tx.WithinTx(ctx, func(txCtx context.Context) error {
+			var err error
+			if id, err = repo.Create(txCtx, newUser("commit@example.com")); err != nil {
+				return err
+			}
+			ports.AfterCommit(txCtx, func() { committed = true })
+			// not visible outside the transaction until it commits
+			if _, err := repo.GetByID(ctx, *id); !errors.Is(err, domain.ErrNotFound) {
+				t.Errorf("expected the user hidden before the commit, got %v", err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("within tx: %v", err)
+		}
+		if !committed {
+			t.Fatalf("expected the commit callback run")
+		}
+		if _, err := repo.GetByID(ctx, *id); err != nil {
+			t.Fatalf("expected the user committed, got %v", err)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		failed := errors.New("failed")
+		var id *bson.ObjectID
+		err := tx.WithinTx(ctx, func(ctx context.Context) error {
+			var err error
+			if id, err = repo.Create(ctx, newUser("rollback@example.com")); err != nil {
+				return err
+			}
+			ports.AfterCommit(ctx, func() { t.Errorf("expected no commit callback") })
+			return failed
+		})
+		if !errors.Is(err, failed) {
+			t.Fatalf("expected the error of fn, got %v", err)
+		}
+		if _, err := repo.GetByID(ctx, *id); !errors.Is(err, domain.ErrNotFound) {
+			t.Fatalf("expected the user rolled back, got %v", err)
+		}
+	})
+
+	// concurrent transactions writing the same user conflict, the losers are retried
+	t.Run("Conflict", func(t *testing.T) {
+		id, err := repo.Create(ctx, newUser("conflict@example.com"))
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		var wg sync.WaitGroup
+		errs := make([]error, 4)
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = tx.WithinTx(ctx, func(ctx context.Context) error {
+					return repo.Update(ctx, *id, &ports.UserUpdate{Name: ports.FieldUpdate[string]{Op: ports.Set, Value: "Janet"}})
+				})
+			}()
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				t.Fatalf("expected the conflicts retried, got %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package mongo
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/filterexpr"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/search"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// caseInsensitive is the collation of list queries, string comparisons ignore case.
+// The listing indexes created by cmd/migrate use the same collation, otherwise
+// Mongo can't use them for string comparisons.
+var caseInsensitive = &options.Collation{Locale: "en", Strength: 2}
+
+// userDocument is the stored form of a user, with fields derived for indexed queries.
+type userDocument struct {
+	domain.User `bson:",inline"`
+	EmailDomain string       `bson:"email_domain"`
+	Search      searchFields `bson:"search"`
+}
+
+// searchFields holds the trigrams of the searchable fields, indexed to find fuzzy search candidates
+type searchFields struct {
+	Name  []string `bson:"name"`
+	Email []string `bson:"email"`
+}
+
+func newUserDocument(user *domain.User) userDocument {
+	return userDocument{
+		User:        *user,
+		EmailDomain: EmailDomain(user.Email),
+		Search:      searchFields{Name: search.Trigrams(user.Name), Email: search.Trigrams(user.Email)},
+	}
+}
+
+// EmailDomain returns the part of an email after the "@".
+func EmailDomain(email string) string {
+	_, host, _ := strings.Cut(email, "@")
+	return host
+}
+
+// prefixRange matches strings starting with prefix. Under an ICU collation U+FFFF sorts
+// after every character, so the range can be served by an index unlike a regex.
+func prefixRange(prefix string) bson.M {
+	return bson.M{"$gte": prefix, "$lt": prefix + "\uffff"}
+}
+
+// userFilter translates a filter into a Mongo query, nil filter matches every user.
+func userFilter(filter *ports.UserFilter) bson.M {
+	query := bson.M{}
+	if filter == nil {
+		return query
+	}
+
+	if filter.NamePrefix != "" {
+		query["name"] = prefixRange(filter.NamePrefix)
+	}
+	if filter.EmailDomain != "" {
+		query["email_domain"] = filter.EmailDomain
+	}
+	if filter.CreatedAfter != nil || filter.CreatedBefore != nil {
+		createdAt := bson.M{}
+		if filter.CreatedAfter != nil {
+			createdAt["$gte"] = *filter.CreatedAfter
+		}
+		if filter.CreatedBefore != nil {
+			createdAt["$lt"] = *filter.CreatedBefore
+		}
+		query["created_at"] = createdAt
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.Search != "" {
+		query["$or"] = bson.A{
+			bson.M{"name": prefixRange(filter.Search)},
+			bson.M{"email": prefixRange(filter.Search)},
+		}
+	}
+	if filter.Expr != nil {
+		query["$and"] = bson.A{compileExpr(filter.Expr)}
+	}
+	return query
+}
+
+// projection selects the document keys of the fields, nil selects the whole document.
+// _id is always returned by Mongo.
+func projection(fields []string) bson.M {
+	if len(fields) == 0 {
+		return nil
+	}
+	proj := bson.M{}
+	for _, field := range fields {
+		proj[exprKey(field)] = 1
+	}
+	return proj
+}
+
+// exprFields maps filter expression and selectable fields to document keys
+var exprFields = map[string]string{
+	"id": "_id",
+}
+
+// compileExpr translates a type-checked filter expression into a Mongo query. Values are
+// typed Go values from the parser, and strings only reach $regex quoted, so an expression
+// can't inject operators. Equality and ordering rely on the case-insensitive collation.
+func compileExpr(expr filterexpr.Expr) bson.M {
+	switch e := expr.(type) {
+	case *filterexpr.And:
+		return bson.M{"$and": bson.A{compileExpr(e.Left), compileExpr(e.Right)}}
+	case *filterexpr.Or:
+		return bson.M{"$or": bson.A{compileExpr(e.Left), compileExpr(e.Right)}}
+	case *filterexpr.Not:
+		return bson.M{"$nor": bson.A{compileExpr(e.Expr)}}
+	case *filterexpr.Field:
+		return bson.M{exprKey(e.Name): true}
+	case *filterexpr.Compare:
+		key := exprKey(e.Field)
+		switch e.Op {
+		case filterexpr.Eq:
+			return bson.M{key: e.Value}
+		case filterexpr.Ne:
+			return bson.M{key: bson.M{"$ne": e.Value}}
+		case filterexpr.Lt:
+			return bson.M{key: bson.M{"$lt": e.Value}}
+		case filterexpr.Le:
+			return bson.M{key: bson.M{"$lte": e.Value}}
+		case filterexpr.Gt:
+			return bson.M{key: bson.M{"$gt": e.Value}}
+		case filterexpr.Ge:
+			return bson.M{key: bson.M{"$gte": e.Value}}
+		case filterexpr.Contains:
+			return bson.M{key: bson.M{"$regex": regexp.QuoteMeta(e.Value.(string)), "$options": "i"}}
+		}
+	}
+	// unreachable for parsed expressions, match nothing rather than everything
+	return bson.M{"_id": bson.M{"$exists": false}}
+}
+
+func exprKey(field string) string {
+	if key, ok := exprFields[field]; ok {
+		return key
+	}
+	return field
+}
+
+// sortKeys returns the Mongo sort for a page, with _id as the tiebreak so the order is total.
+func sortKeys(sort []ports.SortField) bson.D {
+	keys := make(bson.D, 0, len(sort)+1)
+	for _, field := range sort {
+		keys = append(keys, bson.E{Key: field.Field, Value: direction(field.Desc)})
+	}
+	return append(keys, bson.E{Key: "_id", Value: 1})
+}
+
+func direction(desc bool) int {
+	if desc {
+		return -1
+	}
+	return 1
+}
+
+// keysetFilter matches users after the cursor in the page order. For sort keys k1..kn
+// it builds (k1 > v1) or (k1 = v1 and k2 > v2) or ..., with < for descending keys.
+func keysetFilter(sort []ports.SortField, after *ports.Cursor) bson.M {
+	keys := append(append([]ports.SortField{}, sort...), ports.SortField{Field: "_id"})
+
+	or := make(bson.A, 0, len(keys))
+	for i, key := range keys {
+		cond := bson.M{}
+		for _, prev := range keys[:i] {
+			cond[prev.Field] = cursorValue(prev.Field, after)
+		}
+		op := "$gt"
+		if key.Desc {
+			op = "$lt"
+		}
+		cond[key.Field] = bson.M{op: cursorValue(key.Field, after)}
+		or = append(or, cond)
+	}
+	return bson.M{"$or": or}
+}
+
+func cursorValue(field string, cursor *ports.Cursor) any {
+	switch field {
+	case ports.SortByName:
+		return cursor.Name
+	case ports.SortByEmail:
+		return cursor.Email
+	case ports.SortByCreatedAt:
+		return cursor.CreatedAt
+	default:
+		return cursor.ID
+	}
+}
@@ -3,9 +3,12 @@ package mongo
 import (
 	"context"
 	"errors"
+	"regexp"
+	"time"
 
 	"github.com/hinphansa/7-solutions-challenge/internal/domain"
 	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/search"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
@@ -16,8 +19,14 @@ var _ ports.UserRepository = (*userRepository)(nil)
 
 const (
 	collectionName = "users"
+
+	// unique indexes created by cmd/migrate, duplicates are told apart by index name.
+	// Emails and usernames are unique per organization.
+	emailIndex    = "uniq_tenant_email"
+	usernameIndex = "uniq_tenant_username"
 )
 
+// userRepository scopes every query to the organization of its context, see scoped
 type userRepository struct {
 	coll *mongo.Collection
 }
@@ -26,33 +35,115 @@ func NewUserRepository(db *mongo.Database) *userRepository {
 	return &userRepository{coll: db.Collection(collectionName)}
 }
 
+// Create stores the user in the organization of ctx, whatever its TenantID
 func (r *userRepository) Create(ctx context.Context, user *domain.User) (*bson.ObjectID, error) {
-	res, err := r.coll.InsertOne(ctx, user)
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return nil, ports.ErrNoTenant
+	}
+	doc := newUserDocument(user)
+	doc.TenantID = tenantID
+	res, err := r.coll.InsertOne(ctx, doc)
 	if err != nil {
-		return nil, err
+		return nil, mapWriteError(err)
 	}
 	id := res.InsertedID.(bson.ObjectID)
 	return &id, nil
 }
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
-	var result *domain.User
-	if err := r.coll.FindOne(ctx, bson.M{"email": email}).Decode(&result); err != nil {
+	filter, err := scoped(ctx, bson.M{"email": email})
+	if err != nil {
 		return nil, err
 	}
+	var result *domain.User
+	if err := r.coll.FindOne(ctx, filter).Decode(&result); err != nil {
+		return nil, mapReadError(err)
+	}
 	return result, nil
 }
 
-func (r *userRepository) GetByID(ctx context.Context, id bson.ObjectID) (*domain.User, error) {
+// GetByUsername looks up the current usernames first, a previous username only matches
+// while its redirect hasn't expired.
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	current, err := scoped(ctx, bson.M{"username": username})
+	if err != nil {
+		return nil, err
+	}
+	previous, err := scoped(ctx, bson.M{"previous_usernames": bson.M{"$elemMatch": bson.M{
+		"username":   username,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}}})
+	if err != nil {
+		return nil, err
+	}
+
 	var result *domain.User
-	if err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&result); err != nil {
+	err = r.coll.FindOne(ctx, current).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		err = r.coll.FindOne(ctx, previous).Decode(&result)
+	}
+	if err != nil {
+		return nil, mapReadError(err)
+	}
+	return result, nil
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id bson.ObjectID, fields ...string) (*domain.User, error) {
+	filter, err := byID(ctx, id)
+	if err != nil {
 		return nil, err
 	}
+	opts := options.FindOne()
+	if proj := projection(fields); proj != nil {
+		opts.SetProjection(proj)
+	}
+
+	var result *domain.User
+	if err := r.coll.FindOne(ctx, filter, opts).Decode(&result); err != nil {
+		return nil, mapReadError(err)
+	}
 	return result, nil
 }
 
 func (r *userRepository) GetAll(ctx context.Context) ([]domain.User, error) {
-	cursor, err := r.coll.Find(ctx, bson.D{{}})
+	filter, err := scoped(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := r.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []domain.User
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// List uses keyset pagination on the sort fields then _id, so a page costs the same regardless
+// of its position and concurrent inserts don't shift pages. Queries run case-insensitively,
+// matching the collation of the listing indexes.
+func (r *userRepository) List(ctx context.Context, filter *ports.UserFilter, pagination *ports.Pagination) ([]domain.User, error) {
+	query, err := scoped(ctx, userFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+	if pagination.After != nil {
+		query = bson.M{"$and": bson.A{query, keysetFilter(pagination.Sort, pagination.After)}}
+	}
+
+	opts := options.Find().
+		SetCollation(caseInsensitive).
+		SetSort(sortKeys(pagination.Sort)).
+		SetLimit(pagination.Limit)
+	if proj := projection(pagination.Fields); proj != nil {
+		opts.SetProjection(proj)
+	}
+	cursor, err := r.coll.Find(ctx, query, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -65,10 +156,44 @@ func (r *userRepository) GetAll(ctx context.Context) ([]domain.User, error) {
 	return results, nil
 }
 
-func (r *userRepository) List(ctx context.Context, pagination *ports.Pagination) ([]domain.User, error) {
-	limit := pagination.Limit
-	skip := pagination.Offset * pagination.Limit
-	cursor, err := r.coll.Find(ctx, bson.D{{}}, options.Find().SetLimit(limit).SetSkip(skip))
+// Search returns candidate users for query, best first. Whole words are looked up in the
+// text index and ranked by their text score, the remaining slots go to the users sharing
+// the most trigrams with the query, so typos and partial words still find candidates. The
+// service ranks them precisely.
+func (r *userRepository) Search(ctx context.Context, query string, limit int64) ([]domain.User, error) {
+	results, err := r.textSearch(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(results)) >= limit {
+		return results, nil
+	}
+	seen := make(bson.A, len(results))
+	for i, u := range results {
+		seen[i] = u.ID
+	}
+	fuzzy, err := r.trigramSearch(ctx, query, limit-int64(len(results)), seen)
+	if err != nil {
+		return nil, err
+	}
+	return append(results, fuzzy...), nil
+}
+
+// textSearch returns the users matching words of query in the text index, by text score.
+// The index starts with tenant_id, so it can't serve queries across organizations, these
+// are left to the trigrams.
+func (r *userRepository) textSearch(ctx context.Context, query string, limit int64) ([]domain.User, error) {
+	if ports.IsAllTenants(ctx) {
+		return nil, nil
+	}
+	filter, err := scoped(ctx, bson.M{"$text": bson.M{"$search": query}})
+	if err != nil {
+		return nil, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: 1}}).
+		SetLimit(limit)
+	cursor, err := r.coll.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -81,28 +206,318 @@ func (r *userRepository) List(ctx context.Context, pagination *ports.Pagination)
 	return results, nil
 }
 
-func (r *userRepository) Update(ctx context.Context, id bson.ObjectID, user *domain.User) error {
-	updateFields := bson.M{}
-	if user.Email != "" {
-		updateFields["email"] = user.Email
+// trigramSearch returns the users sharing the most trigrams with query, best first,
+// leaving out the users in exclude. The trigram arrays are indexed.
+func (r *userRepository) trigramSearch(ctx context.Context, query string, limit int64, exclude bson.A) ([]domain.User, error) {
+	grams := search.Trigrams(query)
+	if len(grams) == 0 {
+		return nil, nil
+	}
+
+	match, err := scoped(ctx, bson.M{
+		"$or": bson.A{
+			bson.M{"search.name": bson.M{"$in": grams}},
+			bson.M{"search.email": bson.M{"$in": grams}},
+		},
+		"_id": bson.M{"$nin": exclude},
+	})
+	if err != nil {
+		return nil, err
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$addFields", Value: bson.M{"_overlap": bson.M{"$size": bson.M{"$setIntersection": bson.A{
+			bson.M{"$concatArrays": bson.A{
+				bson.M{"$ifNull": bson.A{"$search.name", bson.A{}}},
+				bson.M{"$ifNull": bson.A{"$search.email", bson.A{}}},
+			}},
+			grams,
+		}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_overlap", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
 	}
-	if user.Name != "" {
-		updateFields["name"] = user.Name
+	cursor, err := r.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	if len(updateFields) == 0 {
+	var results []domain.User
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Update applies a partial update, keeping derived fields in sync with the fields they're
+// derived from. domain.ErrNotFound if there's no such user.
+func (r *userRepository) Update(ctx context.Context, id bson.ObjectID, update *ports.UserUpdate) error {
+	set, unset := bson.M{}, bson.M{}
+	switch update.Email.Op {
+	case ports.Set:
+		set["email"] = update.Email.Value
+		set["email_domain"] = EmailDomain(update.Email.Value)
+		set["search.email"] = search.Trigrams(update.Email.Value)
+	case ports.Clear:
+		unset["email"] = ""
+		unset["email_domain"] = ""
+		unset["search.email"] = ""
+	}
+	switch update.Name.Op {
+	case ports.Set:
+		set["name"] = update.Name.Value
+		set["search.name"] = search.Trigrams(update.Name.Value)
+	case ports.Clear:
+		unset["name"] = ""
+		unset["search.name"] = ""
+	}
+	// profile fields are stored under their names
+	for key, field := range map[string]ports.FieldUpdate[string]{
+		ports.FieldDisplayName: update.DisplayName,
+		ports.FieldPhone:       update.Phone,
+		ports.FieldLocale:      update.Locale,
+		ports.FieldTimeZone:    update.TimeZone,
+		ports.FieldAvatarURL:   update.AvatarURL,
+		ports.FieldBio:         update.Bio,
+	} {
+		switch field.Op {
+		case ports.Set:
+			set[key] = field.Value
+		case ports.Clear:
+			unset[key] = ""
+		}
+	}
+
+	// attribute names are restricted to [a-z0-9_], so they're safe in a path
+	for name, field := range update.Attributes {
+		switch field.Op {
+		case ports.Set:
+			set["attributes."+name] = field.Value
+		case ports.Clear:
+			unset["attributes."+name] = ""
+		}
+	}
+
+	if len(set) == 0 && len(unset) == 0 {
 		return errors.New("no fields to update")
 	}
+	set["updated_at"] = time.Now()
 
-	_, err := r.coll.UpdateByID(ctx, id, bson.M{"$set": updateFields})
+	changes := bson.M{}
+	if len(set) > 0 {
+		changes["$set"] = set
+	}
+	if len(unset) > 0 {
+		changes["$unset"] = unset
+	}
+	filter, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	res, err := r.coll.UpdateOne(ctx, filter, changes)
+	if err != nil {
+		return mapWriteError(err)
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) SetEmailChange(ctx context.Context, id bson.ObjectID, change *domain.EmailChange, revert *domain.EmailRevert) error {
+	filter, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	res, err := r.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"pending_email": change, "email_revert": revert}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// ConfirmEmailChange matches on the token hash in the same update as the swap, so a token
+// can only be used once and a newer request invalidates it.
+func (r *userRepository) ConfirmEmailChange(ctx context.Context, id bson.ObjectID, tokenHash string, email string) error {
+	filter := bson.M{"_id": id, "pending_email.token_hash": tokenHash, "pending_email.email": email}
+	return r.swapEmail(ctx, filter, email, bson.M{"pending_email": ""})
+}
+
+func (r *userRepository) RevertEmailChange(ctx context.Context, id bson.ObjectID, tokenHash string, email string) error {
+	filter := bson.M{"_id": id, "email_revert.token_hash": tokenHash, "email_revert.email": email}
+	return r.swapEmail(ctx, filter, email, bson.M{"pending_email": "", "email_revert": ""})
+}
+
+func (r *userRepository) swapEmail(ctx context.Context, filter bson.M, email string, unset bson.M) error {
+	filter, err := scoped(ctx, filter)
+	if err != nil {
+		return err
+	}
+	res, err := r.coll.UpdateOne(ctx, filter, bson.M{
+		"$set": bson.M{
+			"email":        email,
+			"email_domain": EmailDomain(email),
+			"search.email": search.Trigrams(email),
+			"updated_at":   time.Now(),
+		},
+		"$unset": unset,
+	})
+	if err != nil {
+		return mapWriteError(err)
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// SetStatus matches on the current status in the same update, so concurrent transitions
+// can't both apply.
+func (r *userRepository) SetStatus(ctx context.Context, id bson.ObjectID, change *ports.StatusChange) error {
+	set := bson.M{"status": change.To, "status_changed_at": change.At}
+	update := bson.M{"$set": set}
+	if change.Reason != "" {
+		set["status_reason"] = change.Reason
+	} else {
+		update["$unset"] = bson.M{"status_reason": ""}
+	}
+
+	filter, err := scoped(ctx, bson.M{"_id": id, "status": change.From})
+	if err != nil {
+		return err
+	}
+	res, err := r.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// SetUsername runs as a pipeline update, so the replaced username is read and moved to the
+// redirects in the same atomic write. Reclaiming a previous username drops its redirect.
+func (r *userRepository) SetUsername(ctx context.Context, id bson.ObjectID, username string, redirectUntil time.Time) error {
+	now := time.Now()
+	current := bson.M{"$ifNull": bson.A{"$username", ""}}
+	redirects := bson.M{"$concatArrays": bson.A{
+		bson.M{"$filter": bson.M{
+			"input": bson.M{"$ifNull": bson.A{"$previous_usernames", bson.A{}}},
+			"cond": bson.M{"$and": bson.A{
+				bson.M{"$gt": bson.A{"$$this.expires_at", now}},
+				bson.M{"$ne": bson.A{"$$this.username", username}},
+			}},
+		}},
+		bson.M{"$cond": bson.A{
+			bson.M{"$and": bson.A{bson.M{"$ne": bson.A{current, ""}}, bson.M{"$ne": bson.A{current, username}}}},
+			bson.A{bson.M{"username": "$username", "renamed_at": now, "expires_at": redirectUntil}},
+			bson.A{},
+		}},
+	}}
+
+	rename := bson.D{{Key: "$set", Value: bson.M{"username": username}}}
+	if username == "" {
+		rename = bson.D{{Key: "$unset", Value: "username"}}
+	}
+	filter, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	res, err := r.coll.UpdateOne(ctx, filter, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"previous_usernames": redirects, "updated_at": now}}},
+		rename,
+	})
+	if err != nil {
+		return mapWriteError(err)
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) SetAvatar(ctx context.Context, id bson.ObjectID, hash string) error {
+	filter, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	res, err := r.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"avatar_hash": hash, "updated_at": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// mapReadError maps a missing document to domain.ErrNotFound
+func mapReadError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return domain.ErrNotFound
+	}
 	return err
 }
 
+// mapWriteError maps unique index violations to domain errors. Emails and usernames are
+// stored canonical, so their unique indexes are the duplicate checks.
+func mapWriteError(err error) error {
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	switch duplicateIndex(err) {
+	case emailIndex:
+		return domain.ErrEmailTaken
+	case usernameIndex:
+		return domain.ErrUsernameTaken
+	default:
+		return domain.ErrConflict
+	}
+}
+
+// duplicateKeyIndex matches the index named in a duplicate key error message, e.g.
+// "E11000 duplicate key error collection: db.users index: uniq_email dup key: { ... }"
+var duplicateKeyIndex = regexp.MustCompile(`index: (\S+) dup key`)
+
+// duplicateIndex returns the name of the unique index a duplicate key error violated
+func duplicateIndex(err error) string {
+	var messages []string
+	var writeErr mongo.WriteException
+	var cmdErr mongo.CommandError
+	switch {
+	case errors.As(err, &writeErr):
+		for _, e := range writeErr.WriteErrors {
+			messages = append(messages, e.Message)
+		}
+	case errors.As(err, &cmdErr):
+		messages = append(messages, cmdErr.Message)
+	}
+	for _, message := range messages {
+		if m := duplicateKeyIndex.FindStringSubmatch(message); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// Delete removes a user, deleting a missing user isn't an error
 func (r *userRepository) Delete(ctx context.Context, id bson.ObjectID) error {
-	_, err := r.coll.DeleteOne(ctx, bson.M{"_id": id})
+	filter, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, err = r.coll.DeleteOne(ctx, filter)
 	return err
 }
 
-func (r *userRepository) Count(ctx context.Context) (int64, error) {
-	return r.coll.CountDocuments(ctx, nil)
+func (r *userRepository) Count(ctx context.Context, filter *ports.UserFilter) (int64, error) {
+	query, err := scoped(ctx, userFilter(filter))
+	if err != nil {
+		return 0, err
+	}
+	return r.coll.CountDocuments(ctx, query, options.Count().SetCollation(caseInsensitive))
 }
@@ -0,0 +1,52 @@
+package mongo
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports/porttest"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// TestUserRepository runs the repository contract against the Mongo at MONGO_TEST_URI, in
+// a database of its own dropped afterwards. It's skipped without one.
+func TestUserRepository(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI not set")
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	db := client.Database("user_repo_test_" + bson.NewObjectID().Hex())
+	t.Cleanup(func() {
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+
+	// the unique indexes cmd/migrate creates, duplicates are told apart by their names
+	_, err = db.Collection(collectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "email", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName(emailIndex),
+		},
+		{
+			Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "username", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName(usernameIndex).
+				SetPartialFilterExpression(bson.M{"username": bson.M{"$type": "string"}}),
+		},
+	})
+	if err != nil {
+		t.Fatalf("create indexes: %v", err)
+	}
+
+	porttest.TestUserRepository(t, func(t *testing.T) ports.UserRepository {
+		return NewUserRepository(db)
+	})
+}
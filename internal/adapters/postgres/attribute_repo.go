@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// compile time check to ensure attributeRepository implements ports.AttributeSchemaRepository
+var _ ports.AttributeSchemaRepository = (*attributeRepository)(nil)
+
+// attributeRepository stores one row per attribute definition, keyed by name
+type attributeRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewAttributeRepository returns a repository on a database opened and migrated by Open
+func NewAttributeRepository(db *pgxpool.Pool) *attributeRepository {
+	return &attributeRepository{db: db}
+}
+
+func (r *attributeRepository) List(ctx context.Context) (domain.AttributeSchema, error) {
+	rows, err := r.db.Query(ctx, "SELECT name, type, required, enum, pattern, description FROM attribute_schema ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schema := domain.AttributeSchema{}
+	for rows.Next() {
+		var (
+			def  domain.AttributeDefinition
+			kind string
+		)
+		if err := rows.Scan(&def.Name, &kind, &def.Required, &def.Enum, &def.Pattern, &def.Description); err != nil {
+			return nil, err
+		}
+		def.Type = domain.AttributeType(kind)
+		schema = append(schema, def)
+	}
+	return schema, rows.Err()
+}
+
+func (r *attributeRepository) Put(ctx context.Context, def *domain.AttributeDefinition) error {
+	var enum []string
+	if len(def.Enum) > 0 {
+		enum = def.Enum
+	}
+	_, err := r.db.Exec(ctx, bind(`INSERT INTO attribute_schema (name, type, required, enum, pattern, description)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET type = excluded.type, required = excluded.required, enum = excluded.enum,
+			pattern = excluded.pattern, description = excluded.description`),
+		def.Name, string(def.Type), def.Required, enum, def.Pattern, def.Description)
+	return err
+}
+
+func (r *attributeRepository) Delete(ctx context.Context, name string) error {
+	return affected(r.db.Exec(ctx, "DELETE FROM attribute_schema WHERE name = $1", name))
+}
+
+// affected maps the result of a write of one row to domain.ErrNotFound when it matched none
+func affected(tag pgconn.CommandTag, err error) error {
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports/porttest"
+)
+
+func TestAttributeRepository(t *testing.T) {
+	db := openTest(t)
+	porttest.TestAttributeSchemaRepository(t, func(t *testing.T) ports.AttributeSchemaRepository {
+		return NewAttributeRepository(db)
+	})
+}
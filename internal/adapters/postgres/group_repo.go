@@ -0,0 +1,323 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// compile time check to ensure groupRepository implements ports.GroupRepository
+var _ ports.GroupRepository = (*groupRepository)(nil)
+
+const groupColumns = "id, tenant_id, name, description, roles, created_at, updated_at"
+
+// groupNestingLock is the class of the transaction advisory locks serializing the nested
+// groups added to an organization, keyed by the hash of the organization id
+const groupNestingLock = 7_104_048
+
+// groupRepository stores groups and their direct memberships in separate tables, so groups
+// of any size and "groups of a member" lookups stay indexed. Names and memberships are
+// unique through indexes.
+type groupRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewGroupRepository returns a repository on a database opened and migrated by Open
+func NewGroupRepository(db *pgxpool.Pool) *groupRepository {
+	return &groupRepository{db: db}
+}
+
+// Create stores the group in the organization of ctx, whatever its TenantID. The id is
+// generated unless the group has one.
+func (r *groupRepository) Create(ctx context.Context, group *domain.Group) (*bson.ObjectID, error) {
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return nil, ports.ErrNoTenant
+	}
+	id := group.ID
+	if id.IsZero() {
+		id = bson.NewObjectID()
+	}
+	var roles []string
+	if len(group.Roles) > 0 {
+		roles = group.Roles
+	}
+	_, err := r.db.Exec(ctx, "INSERT INTO groups ("+groupColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		id.Hex(), tenantID.Hex(), group.Name, group.Description, roles, group.CreatedAt, group.UpdatedAt)
+	if err != nil {
+		return nil, mapWriteError(err)
+	}
+	return &id, nil
+}
+
+func (r *groupRepository) GetByID(ctx context.Context, id bson.ObjectID) (*domain.Group, error) {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	group, err := scanGroup(r.db.QueryRow(ctx, bind("SELECT "+groupColumns+" FROM groups WHERE "+w.String()), w.args...))
+	if err != nil {
+		return nil, mapReadError(err)
+	}
+	return group, nil
+}
+
+func (r *groupRepository) List(ctx context.Context, ids []bson.ObjectID, after *bson.ObjectID, limit int64) ([]domain.Group, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keyset(w, "id", ids, after)
+	query, args := limited("SELECT "+groupColumns+" FROM groups WHERE "+w.String()+" ORDER BY id", w.args, limit)
+	rows, err := r.db.Query(ctx, bind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []domain.Group{}
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *group)
+	}
+	return results, rows.Err()
+}
+
+func (r *groupRepository) Update(ctx context.Context, id bson.ObjectID, update *ports.GroupUpdate) error {
+	set, args := []string{"updated_at = ?"}, []any{time.Now()}
+	if update.Name.Op == ports.Set {
+		set, args = append(set, "name = ?"), append(args, update.Name.Value)
+	}
+	switch update.Description.Op {
+	case ports.Set:
+		set, args = append(set, "description = ?"), append(args, update.Description.Value)
+	case ports.Clear:
+		set = append(set, "description = ''")
+	}
+	switch update.Roles.Op {
+	case ports.Set:
+		var roles []string
+		if len(update.Roles.Value) > 0 {
+			roles = update.Roles.Value
+		}
+		set, args = append(set, "roles = ?"), append(args, roles)
+	case ports.Clear:
+		set = append(set, "roles = NULL")
+	}
+
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	tag, err := r.db.Exec(ctx, bind("UPDATE groups SET "+strings.Join(set, ", ")+" WHERE "+w.String()), append(args, w.args...)...)
+	if err != nil {
+		return mapWriteError(err)
+	}
+	return affected(tag, nil)
+}
+
+// Delete removes the group along with its memberships in one transaction
+func (r *groupRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	memberships, _ := scoped(ctx)
+	memberships.and("group_id = ? OR member_id = ?", id.Hex(), id.Hex())
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if err := affected(tx.Exec(ctx, bind("DELETE FROM groups WHERE "+w.String()), w.args...)); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, bind("DELETE FROM group_members WHERE "+memberships.String()), memberships.args...)
+		return err
+	})
+}
+
+// AddMember checks a group member doesn't contain the group, directly or nested, in the
+// transaction adding it. The transaction holds the nesting lock of the organization, so
+// concurrent additions can't close a cycle between them: domain.ErrGroupCycle.
+func (r *groupRepository) AddMember(ctx context.Context, groupID bson.ObjectID, member *domain.GroupMember) error {
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return ports.ErrNoTenant
+	}
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if member.Kind == domain.MemberGroup {
+			// the check reads after the lock is taken, so it sees the additions committed before
+			if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2))`, groupNestingLock, tenantID.Hex()); err != nil {
+				return err
+			}
+			// the group and the groups it's nested in, a member among them would contain itself
+			var cycle bool
+			err := tx.QueryRow(ctx, `WITH RECURSIVE ancestors (id) AS (
+					SELECT $1::text COLLATE "C"
+					UNION
+					SELECT m.group_id FROM group_members AS m JOIN ancestors AS a ON m.member_id = a.id
+					WHERE m.tenant_id = $2
+				)
+				SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $3)`,
+				groupID.Hex(), tenantID.Hex(), member.ID.Hex()).Scan(&cycle)
+			if err != nil {
+				return err
+			}
+			if cycle {
+				return domain.ErrGroupCycle
+			}
+		}
+		_, err := tx.Exec(ctx, "INSERT INTO group_members (tenant_id, group_id, kind, member_id, added_at) VALUES ($1, $2, $3, $4, $5)",
+			tenantID.Hex(), groupID.Hex(), string(member.Kind), member.ID.Hex(), member.AddedAt)
+		return mapWriteError(err)
+	})
+}
+
+func (r *groupRepository) RemoveMember(ctx context.Context, groupID, memberID bson.ObjectID) error {
+	w, err := scoped(ctx)
+	if err != nil {
+		return err
+	}
+	w.and("group_id = ?", groupID.Hex()).and("member_id = ?", memberID.Hex())
+	return affected(r.db.Exec(ctx, bind("DELETE FROM group_members WHERE "+w.String()), w.args...))
+}
+
+func (r *groupRepository) RemoveFromAll(ctx context.Context, memberID bson.ObjectID) error {
+	w, err := scoped(ctx)
+	if err != nil {
+		return err
+	}
+	w.and("member_id = ?", memberID.Hex())
+	_, err = r.db.Exec(ctx, bind("DELETE FROM group_members WHERE "+w.String()), w.args...)
+	return err
+}
+
+func (r *groupRepository) ListMembers(ctx context.Context, groupID bson.ObjectID, after *bson.ObjectID, limit int64) ([]domain.GroupMember, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keyset(w.and("group_id = ?", groupID.Hex()), "member_id", nil, after)
+	query, args := limited("SELECT kind, member_id, added_at FROM group_members WHERE "+w.String()+" ORDER BY member_id", w.args, limit)
+	rows, err := r.db.Query(ctx, bind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []domain.GroupMember{}
+	for rows.Next() {
+		var (
+			member   domain.GroupMember
+			kind, id string
+			addedAt  *time.Time
+		)
+		if err := rows.Scan(&kind, &id, &addedAt); err != nil {
+			return nil, err
+		}
+		if member.ID, err = bson.ObjectIDFromHex(id); err != nil {
+			return nil, err
+		}
+		member.Kind, member.AddedAt = domain.MemberKind(kind), fromTimestamp(addedAt)
+		results = append(results, member)
+	}
+	return results, rows.Err()
+}
+
+func (r *groupRepository) ParentsOf(ctx context.Context, memberIDs []bson.ObjectID) ([]bson.ObjectID, error) {
+	if len(memberIDs) == 0 {
+		return nil, nil
+	}
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keyset(w, "member_id", memberIDs, nil)
+	rows, err := r.db.Query(ctx, bind("SELECT DISTINCT group_id FROM group_members WHERE "+w.String()), w.args...)
+	if err != nil {
+		return nil, err
+	}
+	parents, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+	return fromHexes(parents)
+}
+
+// keyset restricts column to ids, nil for any, and to ids greater than after, nil for any.
+// Ids are stored as hex strings compared bytewise, so they sort like the ids.
+func keyset(w *where, column string, ids []bson.ObjectID, after *bson.ObjectID) *where {
+	if ids != nil {
+		if len(ids) == 0 {
+			return w.and("false")
+		}
+		w.and(column+" = ANY (?::text[])", hexes(ids))
+	}
+	if after != nil {
+		w.and(column+" > ?", after.Hex())
+	}
+	return w
+}
+
+// limited appends the LIMIT of a query returning up to limit rows, none for a zero limit
+func limited(query string, args []any, limit int64) (string, []any) {
+	if limit <= 0 {
+		return query, args
+	}
+	return query + " LIMIT ?", append(args, limit)
+}
+
+// hexes returns the hex strings of ids, nil for none
+func hexes(ids []bson.ObjectID) []string {
+	if len(ids) == 0 {
+		return nil
+	}
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id.Hex()
+	}
+	return out
+}
+
+// fromHexes parses the hex strings of ids, nil for none
+func fromHexes(hexes []string) ([]bson.ObjectID, error) {
+	if len(hexes) == 0 {
+		return nil, nil
+	}
+	ids := make([]bson.ObjectID, len(hexes))
+	for i, hex := range hexes {
+		id, err := bson.ObjectIDFromHex(hex)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// scanGroup reads a group selected by groupColumns
+func scanGroup(row pgx.Row) (*domain.Group, error) {
+	var (
+		group                domain.Group
+		id, tenantID         string
+		createdAt, updatedAt *time.Time
+	)
+	err := row.Scan(&id, &tenantID, &group.Name, &group.Description, &group.Roles, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if group.ID, err = bson.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if group.TenantID, err = bson.ObjectIDFromHex(tenantID); err != nil {
+		return nil, err
+	}
+	group.CreatedAt, group.UpdatedAt = fromTimestamp(createdAt), fromTimestamp(updatedAt)
+	return &group, nil
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports/porttest"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestGroupRepository(t *testing.T) {
+	db := openTest(t)
+	porttest.TestGroupRepository(t, func(t *testing.T) ports.GroupRepository {
+		return NewGroupRepository(db)
+	})
+}
+
+func TestGroupRepository_Cycle(t *testing.T) {
+	repo := NewGroupRepository(openTest(t))
+	ctx := ports.WithTenant(context.Background(), bson.NewObjectID())
+	newGroup := func(name string) bson.ObjectID {
+		id, err := repo.Create(ctx, &domain.Group{Name: name, CreatedAt: time.Now(), UpdatedAt: time.Now()})
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		return *id
+	}
+	nest := func(group, member bson.ObjectID) error {
+		return repo.AddMember(ctx, group, &domain.GroupMember{Kind: domain.MemberGroup, ID: member, AddedAt: time.Now()})
+	}
+
+	// engineering contains backend, which contains oncall
+	engineering, backend, oncall := newGroup("Engineering"), newGroup("Backend"), newGroup("On call")
+	if err := nest(engineering, backend); err != nil {
+		t.Fatalf("nest backend: %v", err)
+	}
+	if err := nest(backend, oncall); err != nil {
+		t.Fatalf("nest oncall: %v", err)
+	}
+	for _, member := range []bson.ObjectID{engineering, backend, oncall} {
+		if err := nest(oncall, member); !errors.Is(err, domain.ErrGroupCycle) {
+			t.Fatalf("expected cycle error, got %v", err)
+		}
+	}
+
+	// groups joining each other concurrently, one of them closes the cycle and is refused
+	for range 20 {
+		a, b := newGroup(bson.NewObjectID().Hex()), newGroup(bson.NewObjectID().Hex())
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for i, pair := range [][2]bson.ObjectID{{a, b}, {b, a}} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = nest(pair[0], pair[1])
+			}()
+		}
+		wg.Wait()
+		if (errs[0] == nil) == (errs[1] == nil) || !errors.Is(errors.Join(errs...), domain.ErrGroupCycle) {
+			t.Fatalf("expected exactly one cycle error, got %v", errs)
+		}
+	}
+}
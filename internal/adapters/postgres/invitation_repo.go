@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// compile time check to ensure invitationRepository implements ports.InvitationRepository
+var _ ports.InvitationRepository = (*invitationRepository)(nil)
+
+const invitationColumns = "id, tenant_id, email, roles, group_ids, invited_by, token_hash, expires_at, sent_at, created_at"
+
+// invitationRepository stores pending invitations, an email has at most one per
+// organization through an index
+type invitationRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewInvitationRepository returns a repository on a database opened and migrated by Open
+func NewInvitationRepository(db *pgxpool.Pool) *invitationRepository {
+	return &invitationRepository{db: db}
+}
+
+// Create stores the invitation in the organization of ctx, whatever its TenantID
+func (r *invitationRepository) Create(ctx context.Context, invitation *domain.Invitation) error {
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return ports.ErrNoTenant
+	}
+	var roles []string
+	if len(invitation.Roles) > 0 {
+		roles = invitation.Roles
+	}
+	_, err := r.db.Exec(ctx, "INSERT INTO invitations ("+invitationColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		invitation.ID.Hex(), tenantID.Hex(), invitation.Email, roles, hexes(invitation.GroupIDs), invitation.InvitedBy.Hex(),
+		invitation.TokenHash, invitation.ExpiresAt, invitation.SentAt, invitation.CreatedAt)
+	return mapWriteError(err)
+}
+
+func (r *invitationRepository) GetByID(ctx context.Context, id bson.ObjectID) (*domain.Invitation, error) {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	invitation, err := scanInvitation(r.db.QueryRow(ctx, bind("SELECT "+invitationColumns+" FROM invitations WHERE "+w.String()), w.args...))
+	if err != nil {
+		return nil, mapReadError(err)
+	}
+	return invitation, nil
+}
+
+func (r *invitationRepository) List(ctx context.Context, after *bson.ObjectID, limit int64) ([]domain.Invitation, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keyset(w, "id", nil, after)
+	query, args := limited("SELECT "+invitationColumns+" FROM invitations WHERE "+w.String()+" ORDER BY id", w.args, limit)
+	rows, err := r.db.Query(ctx, bind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []domain.Invitation{}
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *invitation)
+	}
+	return results, rows.Err()
+}
+
+func (r *invitationRepository) Renew(ctx context.Context, id bson.ObjectID, tokenHash string, expiresAt, sentAt time.Time) error {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return affected(r.db.Exec(ctx, bind("UPDATE invitations SET token_hash = ?, expires_at = ?, sent_at = ? WHERE "+w.String()),
+		append([]any{tokenHash, expiresAt, sentAt}, w.args...)...))
+}
+
+func (r *invitationRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return affected(r.db.Exec(ctx, bind("DELETE FROM invitations WHERE "+w.String()), w.args...))
+}
+
+// scanInvitation reads an invitation selected by invitationColumns
+func scanInvitation(row pgx.Row) (*domain.Invitation, error) {
+	var (
+		invitation                   domain.Invitation
+		id, tenantID, invitedBy      string
+		groupIDs                     []string
+		expiresAt, sentAt, createdAt *time.Time
+	)
+	err := row.Scan(&id, &tenantID, &invitation.Email, &invitation.Roles, &groupIDs, &invitedBy, &invitation.TokenHash,
+		&expiresAt, &sentAt, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.ID, err = bson.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if invitation.TenantID, err = bson.ObjectIDFromHex(tenantID); err != nil {
+		return nil, err
+	}
+	if invitation.InvitedBy, err = bson.ObjectIDFromHex(invitedBy); err != nil {
+		return nil, err
+	}
+	if invitation.GroupIDs, err = fromHexes(groupIDs); err != nil {
+		return nil, err
+	}
+	invitation.ExpiresAt, invitation.SentAt, invitation.CreatedAt = fromTimestamp(expiresAt), fromTimestamp(sentAt), fromTimestamp(createdAt)
+	return &invitation, nil
+}
@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports/porttest"
+)
+
+func TestInvitationRepository(t *testing.T) {
+	db := openTest(t)
+	porttest.TestInvitationRepository(t, func(t *testing.T) ports.InvitationRepository {
+		return NewInvitationRepository(db)
+	})
+}
@@ -0,0 +1,45 @@
+-- Users, one row per user. Ids are ObjectID hex strings, compared bytewise so they sort
+-- like ObjectIDs, and the nested values are JSON.
+CREATE TABLE users (
+    id                 text COLLATE "C" PRIMARY KEY,
+    tenant_id          text COLLATE "C" NOT NULL,
+    name               text NOT NULL,
+    email              text NOT NULL,
+    email_domain       text NOT NULL GENERATED ALWAYS AS (split_part(email, '@', 2)) STORED,
+    username           text,          -- NULL when the user has none
+    password           text NOT NULL,
+    display_name       text NOT NULL DEFAULT '',
+    phone              text NOT NULL DEFAULT '',
+    locale             text NOT NULL DEFAULT '',
+    time_zone          text NOT NULL DEFAULT '',
+    avatar_url         text NOT NULL DEFAULT '',
+    bio                text NOT NULL DEFAULT '',
+    avatar_hash        text NOT NULL DEFAULT '',
+    attributes         jsonb,
+    status             text NOT NULL,
+    status_reason      text NOT NULL DEFAULT '',
+    status_changed_at  timestamptz,
+    roles              text[],
+    pending_email      jsonb,
+    email_revert       jsonb,
+    previous_usernames jsonb,
+    name_grams         text[] NOT NULL DEFAULT '{}', -- search trigrams of the name
+    email_grams        text[] NOT NULL DEFAULT '{}', -- and of the email
+    created_at         timestamptz NOT NULL,
+    updated_at         timestamptz NOT NULL
+);
+
+-- duplicates are told apart by the name of the violated constraint, see mapWriteError
+CREATE UNIQUE INDEX uniq_tenant_email ON users (tenant_id, email);
+CREATE UNIQUE INDEX uniq_tenant_username ON users (tenant_id, username) WHERE username IS NOT NULL;
+
+-- listing indexes, strings sort case-insensitively like the queries
+CREATE INDEX idx_tenant_created_at ON users (tenant_id, created_at, id);
+CREATE INDEX idx_tenant_name ON users (tenant_id, (lower(name) COLLATE "C"), id);
+CREATE INDEX idx_tenant_email_lower ON users (tenant_id, (lower(email) COLLATE "C"), id);
+CREATE INDEX idx_tenant_email_domain ON users (tenant_id, lower(email_domain));
+CREATE INDEX idx_tenant_status ON users (tenant_id, lower(status));
+
+-- fuzzy search candidates share trigrams with the query
+CREATE INDEX idx_name_grams ON users USING gin (name_grams);
+CREATE INDEX idx_email_grams ON users USING gin (email_grams);
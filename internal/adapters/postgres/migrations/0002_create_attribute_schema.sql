@@ -0,0 +1,9 @@
+-- Definitions of custom user attributes, shared by every organization like in Mongo
+CREATE TABLE attribute_schema (
+    name        text COLLATE "C" PRIMARY KEY,
+    type        text NOT NULL,
+    required    boolean NOT NULL DEFAULT false,
+    enum        text[], -- NULL when any value goes
+    pattern     text NOT NULL DEFAULT '',
+    description text NOT NULL DEFAULT ''
+);
@@ -0,0 +1,8 @@
+-- Settings users changed from their defaults, one row per user in the organization of the
+-- user. Settings are a JSON object by name.
+CREATE TABLE user_settings (
+    user_id    text COLLATE "C" PRIMARY KEY,
+    tenant_id  text COLLATE "C" NOT NULL,
+    settings   jsonb NOT NULL,
+    updated_at timestamptz NOT NULL
+);
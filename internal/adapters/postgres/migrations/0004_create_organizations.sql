@@ -0,0 +1,11 @@
+-- Organizations, the tenants of the other tables. Slugs are stored lowercase.
+CREATE TABLE organizations (
+    id         text COLLATE "C" PRIMARY KEY,
+    slug       text COLLATE "C" NOT NULL,
+    name       text NOT NULL,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL
+);
+
+-- duplicates are told apart by the name of the violated constraint, see mapWriteError
+CREATE UNIQUE INDEX uniq_organization_slug ON organizations (slug);
@@ -0,0 +1,26 @@
+-- Groups of an organization and their direct members, users or groups. Nesting is walked
+-- up from members to the groups they belong to.
+CREATE TABLE groups (
+    id          text COLLATE "C" PRIMARY KEY,
+    tenant_id   text COLLATE "C" NOT NULL,
+    name        text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    roles       text[],
+    created_at  timestamptz NOT NULL,
+    updated_at  timestamptz NOT NULL
+);
+
+-- names are unique case-insensitively
+CREATE UNIQUE INDEX uniq_tenant_group_name ON groups (tenant_id, (lower(name) COLLATE "C"));
+
+CREATE TABLE group_members (
+    tenant_id text COLLATE "C" NOT NULL,
+    group_id  text COLLATE "C" NOT NULL,
+    kind      text NOT NULL,
+    member_id text COLLATE "C" NOT NULL,
+    added_at  timestamptz NOT NULL,
+    PRIMARY KEY (tenant_id, group_id, member_id)
+);
+
+-- groups of a member, walked up for nested groups
+CREATE INDEX idx_group_members_member ON group_members (tenant_id, member_id);
@@ -0,0 +1,15 @@
+-- Pending invitations, an email has at most one per organization
+CREATE TABLE invitations (
+    id         text COLLATE "C" PRIMARY KEY,
+    tenant_id  text COLLATE "C" NOT NULL,
+    email      text NOT NULL,
+    roles      text[],
+    group_ids  text[], -- ObjectID hex strings
+    invited_by text COLLATE "C" NOT NULL,
+    token_hash text NOT NULL,
+    expires_at timestamptz NOT NULL,
+    sent_at    timestamptz NOT NULL,
+    created_at timestamptz NOT NULL
+);
+
+CREATE UNIQUE INDEX uniq_tenant_invitation_email ON invitations (tenant_id, email);
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// compile time check to ensure settingsRepository implements ports.SettingsRepository
+var _ ports.SettingsRepository = (*settingsRepository)(nil)
+
+// settingsRepository stores one row per user, keyed by the user id and scoped to the
+// organization of the user like the users themselves
+type settingsRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewSettingsRepository returns a repository on a database opened and migrated by Open
+func NewSettingsRepository(db *pgxpool.Pool) *settingsRepository {
+	return &settingsRepository{db: db}
+}
+
+// Get returns the stored settings of a user, nil if none are stored
+func (r *settingsRepository) Get(ctx context.Context, userID bson.ObjectID) (map[string]any, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w.and("user_id = ?", userID.Hex())
+	var stored []byte
+	err = r.db.QueryRow(ctx, bind("SELECT settings FROM user_settings WHERE "+w.String()), w.args...).Scan(&stored)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var settings map[string]any
+	if err := json.Unmarshal(stored, &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// Replace stores exactly settings for the user
+func (r *settingsRepository) Replace(ctx context.Context, userID bson.ObjectID, settings map[string]any) error {
+	return r.put(ctx, userID, settings, "excluded.settings", nil)
+}
+
+// Update merges the settings in the upsert itself, so concurrent updates of a user don't
+// lose each other's settings
+func (r *settingsRepository) Update(ctx context.Context, userID bson.ObjectID, set map[string]any, unset []string) error {
+	if unset == nil {
+		unset = []string{}
+	}
+	return r.put(ctx, userID, set, "(user_settings.settings || excluded.settings) - ?::text[]", []any{unset})
+}
+
+// put inserts settings for the user, or replaces the stored ones with merged. Settings of
+// a user of another organization aren't taken over, the write fails with domain.ErrConflict.
+func (r *settingsRepository) put(ctx context.Context, userID bson.ObjectID, settings map[string]any, merged string, mergeArgs []any) error {
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return ports.ErrNoTenant
+	}
+	if settings == nil {
+		settings = map[string]any{}
+	}
+	value, err := jsonValue(settings, false)
+	if err != nil {
+		return err
+	}
+	args := append([]any{userID.Hex(), tenantID.Hex(), value, time.Now()}, mergeArgs...)
+	tag, err := r.db.Exec(ctx, bind(`INSERT INTO user_settings (user_id, tenant_id, settings, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET settings = `+merged+`, updated_at = excluded.updated_at
+		WHERE user_settings.tenant_id = excluded.tenant_id`), args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrConflict
+	}
+	return nil
+}
+
+func (r *settingsRepository) Delete(ctx context.Context, userID bson.ObjectID) error {
+	w, err := scoped(ctx)
+	if err != nil {
+		return err
+	}
+	w.and("user_id = ?", userID.Hex())
+	_, err = r.db.Exec(ctx, bind("DELETE FROM user_settings WHERE "+w.String()), w.args...)
+	return err
+}
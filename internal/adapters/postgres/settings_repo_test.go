@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports/porttest"
+)
+
+func TestSettingsRepository(t *testing.T) {
+	db := openTest(t)
+	porttest.TestSettingsRepository(t, func(t *testing.T) ports.SettingsRepository {
+		return NewSettingsRepository(db)
+	})
+}
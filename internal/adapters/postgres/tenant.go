@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// where is a conjunction of SQL conditions and the arguments of their placeholders.
+// Conditions use ? placeholders so they compose in any order, bind numbers them.
+type where struct {
+	conds []string
+	args  []any
+}
+
+// and adds a condition, its placeholders bound to args in order
+func (w *where) and(cond string, args ...any) *where {
+	w.conds = append(w.conds, cond)
+	w.args = append(w.args, args...)
+	return w
+}
+
+// String is the SQL of the conjunction, true when it has no condition
+func (w *where) String() string {
+	if len(w.conds) == 0 {
+		return "true"
+	}
+	return "(" + strings.Join(w.conds, ") AND (") + ")"
+}
+
+// bind numbers the ? placeholders of a query $1, $2... in order. Queries don't use the
+// jsonb operators spelled with a question mark, so every one is a placeholder.
+func bind(query string) string {
+	var b strings.Builder
+	n := 0
+	for {
+		i := strings.IndexByte(query, '?')
+		if i < 0 {
+			b.WriteString(query)
+			return b.String()
+		}
+		n++
+		b.WriteString(query[:i])
+		b.WriteString("$" + strconv.Itoa(n))
+		query = query[i+1:]
+	}
+}
+
+// scoped restricts a query to the organization ctx is scoped to, every query on a
+// tenant-scoped table starts from it. It fails with ports.ErrNoTenant rather than reading
+// across organizations, unless ctx is ports.AllTenants, like the Mongo repositories.
+func scoped(ctx context.Context) (*where, error) {
+	if ports.IsAllTenants(ctx) {
+		return &where{}, nil
+	}
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return nil, ports.ErrNoTenant
+	}
+	return (&where{}).and("tenant_id = ?", tenantID.Hex()), nil
+}
+
+// byID is the scoped condition of the row with id
+func byID(ctx context.Context, id bson.ObjectID) (*where, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return w.and("id = ?", id.Hex()), nil
+}
@@ -0,0 +1,458 @@
+package postgres
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/filterexpr"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/search"
+	"github.com/jackc/pgx/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// userColumns are the columns a user is read from, in the order of scanUser
+const userColumns = `id, tenant_id, name, email, username, password, display_name, phone, locale,
+	time_zone, avatar_url, bio, avatar_hash, attributes, status, status_reason, status_changed_at,
+	roles, pending_email, email_revert, previous_usernames, created_at, updated_at`
+
+// emailToken is the stored form of domain.EmailChange and domain.EmailRevert, queried by
+// its JSON keys
+type emailToken struct {
+	Email     string `json:"email"`
+	TokenHash string `json:"token_hash"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// usernameRedirect is the stored form of domain.UsernameRedirect, expires_at is compared
+// in SQL so times are Unix milliseconds
+type usernameRedirect struct {
+	Username  string `json:"username"`
+	RenamedAt int64  `json:"renamed_at"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// timestamp is the stored form of a time, NULL for the zero time
+func timestamp(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// fromTimestamp returns a time read from the database, which pgx returns in the local zone
+func fromTimestamp(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+// nullString is NULL for the empty string, for the columns whose uniqueness ignores unset values
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// jsonValue is the JSON of v, NULL when v is nil or empty. pgx sends strings to jsonb
+// parameters as they are.
+func jsonValue(v any, empty bool) (any, error) {
+	if empty {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func emailTokenValue(email, tokenHash string, expiresAt time.Time) (any, error) {
+	return jsonValue(emailToken{Email: email, TokenHash: tokenHash, ExpiresAt: expiresAt.UnixMilli()}, false)
+}
+
+func redirectsValue(redirects []domain.UsernameRedirect) (any, error) {
+	stored := make([]usernameRedirect, len(redirects))
+	for i, r := range redirects {
+		stored[i] = usernameRedirect{Username: r.Username, RenamedAt: r.RenamedAt.UnixMilli(), ExpiresAt: r.ExpiresAt.UnixMilli()}
+	}
+	return jsonValue(stored, len(stored) == 0)
+}
+
+func decodeRedirects(b []byte) ([]domain.UsernameRedirect, error) {
+	if b == nil {
+		return nil, nil
+	}
+	var stored []usernameRedirect
+	if err := json.Unmarshal(b, &stored); err != nil {
+		return nil, err
+	}
+	redirects := make([]domain.UsernameRedirect, len(stored))
+	for i, r := range stored {
+		redirects[i] = domain.UsernameRedirect{
+			Username:  r.Username,
+			RenamedAt: time.UnixMilli(r.RenamedAt).UTC(),
+			ExpiresAt: time.UnixMilli(r.ExpiresAt).UTC(),
+		}
+	}
+	return redirects, nil
+}
+
+func decodeEmailToken(b []byte) (*emailToken, error) {
+	if b == nil {
+		return nil, nil
+	}
+	var t emailToken
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// trigrams are the search trigrams of a name or email, never NULL
+func trigrams(s string) []string {
+	grams := search.Trigrams(s)
+	if grams == nil {
+		return []string{}
+	}
+	return grams
+}
+
+// userInsert returns the insert columns and values of a user
+func userInsert(user *domain.User) (string, []any, error) {
+	var pending, revert any
+	var err error
+	if p := user.PendingEmail; p != nil {
+		if pending, err = emailTokenValue(p.Email, p.TokenHash, p.ExpiresAt); err != nil {
+			return "", nil, err
+		}
+	}
+	if r := user.EmailRevert; r != nil {
+		if revert, err = emailTokenValue(r.Email, r.TokenHash, r.ExpiresAt); err != nil {
+			return "", nil, err
+		}
+	}
+	attributes, err := jsonValue(user.Attributes, user.Attributes == nil)
+	if err != nil {
+		return "", nil, err
+	}
+	redirects, err := redirectsValue(user.PreviousUsernames)
+	if err != nil {
+		return "", nil, err
+	}
+	var roles []string
+	if len(user.Roles) > 0 {
+		roles = user.Roles
+	}
+
+	columns := userColumns + ", name_grams, email_grams"
+	return columns, []any{
+		user.ID.Hex(), user.TenantID.Hex(), user.Name, user.Email, nullString(user.Username), user.Password,
+		user.DisplayName, user.Phone, user.Locale, user.TimeZone, user.AvatarURL, user.Bio, user.AvatarHash,
+		attributes, string(user.Status), user.StatusReason, timestamp(user.StatusChangedAt),
+		roles, pending, revert, redirects, user.CreatedAt, user.UpdatedAt,
+		trigrams(user.Name), trigrams(user.Email),
+	}, nil
+}
+
+// scanUser reads a user selected by userColumns
+func scanUser(row pgx.Row) (*domain.User, error) {
+	var (
+		user                                   domain.User
+		id, tenantID                           string
+		username                               *string
+		attributes, pending, revert, redirects []byte
+		statusChangedAt, createdAt, updatedAt  *time.Time
+	)
+	err := row.Scan(&id, &tenantID, &user.Name, &user.Email, &username, &user.Password,
+		&user.DisplayName, &user.Phone, &user.Locale, &user.TimeZone, &user.AvatarURL, &user.Bio, &user.AvatarHash,
+		&attributes, &user.Status, &user.StatusReason, &statusChangedAt,
+		&user.Roles, &pending, &revert, &redirects, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.ID, err = bson.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if user.TenantID, err = bson.ObjectIDFromHex(tenantID); err != nil {
+		return nil, err
+	}
+	if username != nil {
+		user.Username = *username
+	}
+	user.StatusChangedAt = fromTimestamp(statusChangedAt)
+	user.CreatedAt = fromTimestamp(createdAt)
+	user.UpdatedAt = fromTimestamp(updatedAt)
+	if attributes != nil {
+		if err := json.Unmarshal(attributes, &user.Attributes); err != nil {
+			return nil, err
+		}
+	}
+	t, err := decodeEmailToken(pending)
+	if err != nil {
+		return nil, err
+	}
+	if t != nil {
+		user.PendingEmail = &domain.EmailChange{Email: t.Email, TokenHash: t.TokenHash, ExpiresAt: time.UnixMilli(t.ExpiresAt).UTC()}
+	}
+	if t, err = decodeEmailToken(revert); err != nil {
+		return nil, err
+	}
+	if t != nil {
+		user.EmailRevert = &domain.EmailRevert{Email: t.Email, TokenHash: t.TokenHash, ExpiresAt: time.UnixMilli(t.ExpiresAt).UTC()}
+	}
+	if user.PreviousUsernames, err = decodeRedirects(redirects); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// scanUsers reads every row of a query selecting userColumns
+func scanUsers(rows pgx.Rows) ([]domain.User, error) {
+	defer rows.Close()
+	var users []domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+// folded is a string expression compared case-insensitively. Lowered strings compare
+// bytewise rather than by the database locale, so they sort like the other repositories.
+func folded(expr string) string {
+	return "lower(" + expr + ") COLLATE \"C\""
+}
+
+// userFilter adds the conditions of a filter to w, nil filter matches every user
+func userFilter(w *where, filter *ports.UserFilter) *where {
+	if filter == nil {
+		return w
+	}
+
+	if filter.NamePrefix != "" {
+		w.and("starts_with(lower(name), lower(?::text))", filter.NamePrefix)
+	}
+	if filter.EmailDomain != "" {
+		w.and("lower(email_domain) = lower(?::text)", filter.EmailDomain)
+	}
+	if filter.CreatedAfter != nil {
+		w.and("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		w.and("created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.Status != "" {
+		w.and("lower(status) = lower(?::text)", filter.Status)
+	}
+	if filter.Search != "" {
+		w.and("starts_with(lower(name), lower(?::text)) OR starts_with(lower(email), lower(?::text))", filter.Search, filter.Search)
+	}
+	if filter.Expr != nil {
+		cond, args := compileExpr(filter.Expr)
+		w.and(cond, args...)
+	}
+	return w
+}
+
+// exprColumns are the columns of the filter expression fields, custom attributes are in
+// the attributes JSON
+var exprColumns = map[string]string{
+	ports.FieldID:        "id",
+	ports.FieldName:      "name",
+	ports.FieldEmail:     "email",
+	ports.FieldCreatedAt: "created_at",
+	ports.FieldUpdatedAt: "updated_at",
+	ports.FieldStatus:    "status",
+}
+
+var sqlOps = map[filterexpr.Op]string{
+	filterexpr.Eq: "=",
+	filterexpr.Ne: "<>",
+	filterexpr.Lt: "<",
+	filterexpr.Le: "<=",
+	filterexpr.Gt: ">",
+	filterexpr.Ge: ">=",
+}
+
+// compileExpr translates a type-checked filter expression into a SQL condition. Values
+// are bound as arguments and columns come from exprColumns, so an expression can't inject
+// SQL. Every condition is true or false, never NULL, so NOT negates it like Match does.
+func compileExpr(expr filterexpr.Expr) (string, []any) {
+	switch e := expr.(type) {
+	case *filterexpr.And:
+		left, leftArgs := compileExpr(e.Left)
+		right, rightArgs := compileExpr(e.Right)
+		return "(" + left + " AND " + right + ")", append(leftArgs, rightArgs...)
+	case *filterexpr.Or:
+		left, leftArgs := compileExpr(e.Left)
+		right, rightArgs := compileExpr(e.Right)
+		return "(" + left + " OR " + right + ")", append(leftArgs, rightArgs...)
+	case *filterexpr.Not:
+		inner, args := compileExpr(e.Expr)
+		return "NOT " + inner, args
+	case *filterexpr.Field:
+		// only custom attributes are boolean
+		if name, ok := attributeName(e.Name); ok {
+			return "coalesce(attributes -> ?::text = 'true'::jsonb, false)", []any{name}
+		}
+	case *filterexpr.Compare:
+		if column, ok := exprColumns[e.Field]; ok {
+			return compareColumn(column, e.Op, e.Value)
+		}
+		if name, ok := attributeName(e.Field); ok {
+			return compareAttribute(name, e.Op, e.Value)
+		}
+	}
+	// unreachable for parsed expressions, match nothing rather than everything
+	return "false", nil
+}
+
+// compareColumn compares a column to a literal of the type of the field
+func compareColumn(column string, op filterexpr.Op, value any) (string, []any) {
+	switch v := value.(type) {
+	case string:
+		if op == filterexpr.Contains {
+			return "strpos(lower(" + column + "), lower(?::text)) > 0", []any{v}
+		}
+		return folded(column) + " " + sqlOps[op] + " " + folded("?::text"), []any{v}
+	case time.Time:
+		return column + " " + sqlOps[op] + " ?::timestamptz", []any{v}
+	case bson.ObjectID:
+		return column + " " + sqlOps[op] + " ?::text", []any{v.Hex()}
+	}
+	return "false", nil
+}
+
+// compareAttribute compares a custom attribute to a literal. Values of another JSON type,
+// and missing attributes, are only unequal, like in Match. The CASE guards the casts, the
+// value is only converted once its type is known.
+func compareAttribute(name string, op filterexpr.Op, value any) (string, []any) {
+	var jsonType, extracted, param string
+	switch value.(type) {
+	case string:
+		jsonType, extracted, param = "string", folded("attributes ->> ?::text"), folded("?::text")
+	case float64:
+		jsonType, extracted, param = "number", "(attributes ->> ?::text)::numeric", "?::numeric"
+	case bool:
+		jsonType, extracted, param = "boolean", "(attributes ->> ?::text)::boolean", "?::boolean"
+	default:
+		// JSON has no times or ids
+		if op == filterexpr.Ne {
+			return "true", nil
+		}
+		return "false", nil
+	}
+
+	var cond string
+	switch op {
+	case filterexpr.Contains:
+		cond = "strpos(lower(attributes ->> ?::text), lower(?::text)) > 0"
+	case filterexpr.Ne:
+		cond = extracted + " = " + param
+	default:
+		cond = extracted + " " + sqlOps[op] + " " + param
+	}
+	typed := "CASE WHEN jsonb_typeof(attributes -> ?::text) = '" + jsonType + "' THEN " + cond + " ELSE false END"
+	if op == filterexpr.Ne {
+		typed = "NOT " + typed
+	}
+	return typed, []any{name, name, value}
+}
+
+// attributeName returns the name of a custom attribute field, e.g. team for
+// attributes.team. Names are bound as arguments.
+func attributeName(field string) (string, bool) {
+	name, ok := strings.CutPrefix(field, ports.FieldAttributes+".")
+	if !ok || !domain.ValidAttributeName(name) {
+		return "", false
+	}
+	return name, true
+}
+
+// sortColumns are the columns of the sortable fields, strings sort case-insensitively
+// like the listing indexes
+var sortColumns = map[string]string{
+	ports.SortByName:      folded("name"),
+	ports.SortByEmail:     folded("email"),
+	ports.SortByCreatedAt: "created_at",
+}
+
+// orderBy returns the ORDER BY of a page, with id as the tiebreak so the order is total
+func orderBy(sort []ports.SortField) string {
+	keys := make([]string, 0, len(sort)+1)
+	for _, field := range sort {
+		key := sortColumns[field.Field]
+		if field.Desc {
+			key += " DESC"
+		}
+		keys = append(keys, key)
+	}
+	return strings.Join(append(keys, "id"), ", ")
+}
+
+// keysetFilter matches users after the cursor in the page order. For sort keys k1..kn it
+// builds (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ..., with < for descending keys.
+func keysetFilter(sort []ports.SortField, after *ports.Cursor) (string, []any) {
+	keys := append(append([]ports.SortField{}, sort...), ports.SortField{Field: ports.FieldID})
+
+	var (
+		or   []string
+		args []any
+	)
+	for i, key := range keys {
+		var and []string
+		for _, prev := range keys[:i] {
+			and = append(and, keyColumn(prev.Field)+" = "+keyParam(prev.Field))
+			args = append(args, cursorValue(prev.Field, after))
+		}
+		op := " > "
+		if key.Desc {
+			op = " < "
+		}
+		and = append(and, keyColumn(key.Field)+op+keyParam(key.Field))
+		args = append(args, cursorValue(key.Field, after))
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+	}
+	return strings.Join(or, " OR "), args
+}
+
+func keyColumn(field string) string {
+	if column, ok := sortColumns[field]; ok {
+		return column
+	}
+	return "id"
+}
+
+// keyParam is the placeholder of a cursor value, folded like its column
+func keyParam(field string) string {
+	switch field {
+	case ports.SortByName, ports.SortByEmail:
+		return folded("?::text")
+	case ports.SortByCreatedAt:
+		return "?::timestamptz"
+	default:
+		return "?::text"
+	}
+}
+
+func cursorValue(field string, cursor *ports.Cursor) any {
+	switch field {
+	case ports.SortByName:
+		return cursor.Name
+	case ports.SortByEmail:
+		return cursor.Email
+	case ports.SortByCreatedAt:
+		return cursor.CreatedAt
+	default:
+		return cursor.ID.Hex()
+	}
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/filterexpr"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// TestCompileExpr checks the SQL of filter expressions selects the users Match does
+func TestCompileExpr(t *testing.T) {
+	ctx := ports.WithTenant(context.Background(), bson.NewObjectID())
+	repo := NewUserRepository(openTest(t))
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var users []*domain.User
+	for i, user := range []*domain.User{
+		{Name: "Jane Doe", Email: "jane@acme.com", Status: domain.StatusActive, Attributes: map[string]any{"department": "Sales", "level": 3, "vip": true}},
+		{Name: "John Roe", Email: "john@corp.example", Status: domain.StatusSuspended, Attributes: map[string]any{"department": "support", "level": "3"}},
+		{Name: "jim", Email: "jim@acme.com", Status: domain.StatusActive},
+	} {
+		user.Password = "hash"
+		user.CreatedAt = start.Add(time.Duration(i) * 24 * time.Hour)
+		user.UpdatedAt = user.CreatedAt
+		id, err := repo.Create(ctx, user)
+		if err != nil {
+			t.Fatalf("create %s: %v", user.Email, err)
+		}
+		user.ID = *id
+		users = append(users, user)
+	}
+
+	for _, src := range []string{
+		`email ~ "@ACME.com" and created_at > 2025-01-01`,
+		`name = "jane doe" or name = "jim"`,
+		`name < "jo" and not status = "active"`,
+		`status != "ACTIVE"`,
+		`id = "` + users[1].ID.Hex() + `"`,
+		`id > "` + users[0].ID.Hex() + `"`,
+		`updated_at <= 2025-01-02`,
+		`attributes.department = "sales"`,
+		`attributes.department ~ "UP"`,
+		`attributes.level >= 3`,
+		`attributes.level = "3"`,
+		`attributes.level != 3`,
+		`attributes.vip`,
+		`not attributes.vip`,
+		`attributes.vip = false`,
+		`attributes.vip != true`,
+		`attributes.missing = "x"`,
+		`not attributes.missing = "x"`,
+		`attributes.cohort != "beta"`,
+	} {
+		expr, err := filterexpr.Parse(src)
+		if err != nil {
+			t.Fatalf("parse %q: %v", src, err)
+		}
+		var want []bson.ObjectID
+		for _, user := range users {
+			if expr.Match(user) {
+				want = append(want, user.ID)
+			}
+		}
+		listed, err := repo.List(ctx, &ports.UserFilter{Expr: expr}, &ports.Pagination{Limit: 10})
+		if err != nil {
+			t.Fatalf("list %q: %v", src, err)
+		}
+		var got []bson.ObjectID
+		for _, user := range listed {
+			got = append(got, user.ID)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("%q: expected %v, got %v", src, want, got)
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/search"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// compile time check to ensure userRepository implements ports.UserRepository
+var _ ports.UserRepository = (*userRepository)(nil)
+
+// userRepository scopes every query to the organization of its context, see scoped. Each
+// write is a single statement, except SetUsername which locks the row it reads.
+type userRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewUserRepository returns a repository on a database opened and migrated by Open
+func NewUserRepository(db *pgxpool.Pool) *userRepository {
+	return &userRepository{db: db}
+}
+
+// Create stores the user in the organization of ctx, whatever its TenantID. The id is
+// generated unless the user has one.
+func (r *userRepository) Create(ctx context.Context, user *domain.User) (*bson.ObjectID, error) {
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return nil, ports.ErrNoTenant
+	}
+	stored := *user
+	stored.TenantID = tenantID
+	if stored.ID.IsZero() {
+		stored.ID = bson.NewObjectID()
+	}
+	columns, values, err := userInsert(&stored)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "INSERT INTO users (" + columns + ") VALUES (?" + strings.Repeat(", ?", len(values)-1) + ")"
+	if _, err := r.db.Exec(ctx, bind(query), values...); err != nil {
+		return nil, mapWriteError(err)
+	}
+	return &stored.ID, nil
+}
+
+// findOne loads the first user matching w in id order, domain.ErrNotFound if there's none
+func (r *userRepository) findOne(ctx context.Context, w *where) (*domain.User, error) {
+	row := r.db.QueryRow(ctx, bind("SELECT "+userColumns+" FROM users WHERE "+w.String()+" ORDER BY id LIMIT 1"), w.args...)
+	user, err := scanUser(row)
+	if err != nil {
+		return nil, mapReadError(err)
+	}
+	return user, nil
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.findOne(ctx, w.and("email = ?", email))
+}
+
+// GetByUsername looks up the current usernames first, a previous username only matches
+// while its redirect hasn't expired.
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	current, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := r.findOne(ctx, current.and("username = ?", username))
+	if !errors.Is(err, domain.ErrNotFound) {
+		return user, err
+	}
+
+	previous, _ := scoped(ctx)
+	previous.and(`EXISTS (SELECT 1 FROM jsonb_array_elements(users.previous_usernames) AS redirect
+		WHERE redirect ->> 'username' = ?::text AND (redirect ->> 'expires_at')::bigint > ?::bigint)`,
+		username, time.Now().UnixMilli())
+	return r.findOne(ctx, previous)
+}
+
+// GetByID loads the selected fields of a user, the row is read whole then masked
+func (r *userRepository) GetByID(ctx context.Context, id bson.ObjectID, fields ...string) (*domain.User, error) {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := r.findOne(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+	return project(user, fields), nil
+}
+
+// project masks the fields of user that aren't selected, keeping its id like Mongo
+func project(user *domain.User, fields []string) *domain.User {
+	if len(fields) > 0 {
+		id := user.ID
+		ports.MaskUser(user, fields)
+		user.ID = id
+	}
+	return user
+}
+
+func (r *userRepository) GetAll(ctx context.Context) ([]domain.User, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx, bind("SELECT "+userColumns+" FROM users WHERE "+w.String()+" ORDER BY id"), w.args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanUsers(rows)
+}
+
+// List uses keyset pagination on the sort fields then id, so a page costs the same regardless
+// of its position and concurrent inserts don't shift pages. Strings compare lowered, like
+// the listing indexes.
+func (r *userRepository) List(ctx context.Context, filter *ports.UserFilter, pagination *ports.Pagination) ([]domain.User, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userFilter(w, filter)
+	if pagination.After != nil {
+		cond, args := keysetFilter(pagination.Sort, pagination.After)
+		w.and(cond, args...)
+	}
+
+	query := "SELECT " + userColumns + " FROM users WHERE " + w.String() + " ORDER BY " + orderBy(pagination.Sort)
+	args := w.args
+	if pagination.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, pagination.Limit)
+	}
+	rows, err := r.db.Query(ctx, bind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		project(&users[i], pagination.Fields)
+	}
+	return users, nil
+}
+
+// Search returns the users sharing the most trigrams with the query, best first. The
+// trigram arrays are indexed, so typos and partial words still find candidates, the
+// service ranks them precisely.
+func (r *userRepository) Search(ctx context.Context, query string, limit int64) ([]domain.User, error) {
+	grams := search.Trigrams(query)
+	if len(grams) == 0 {
+		return nil, nil
+	}
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w.and("name_grams && ?::text[] OR email_grams && ?::text[]", grams, grams)
+
+	args := append(w.args, grams, limit)
+	rows, err := r.db.Query(ctx, bind(`SELECT `+userColumns+` FROM users WHERE `+w.String()+`
+		ORDER BY (SELECT count(DISTINCT gram) FROM unnest(name_grams || email_grams) AS gram
+			WHERE gram = ANY (?::text[])) DESC, id
+		LIMIT ?`), args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanUsers(rows)
+}
+
+// execer is a pool or a transaction
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// update runs an UPDATE of the users row matching w, domain.ErrNotFound if there's none
+func update(ctx context.Context, exec execer, set string, setArgs []any, w *where) error {
+	tag, err := exec.Exec(ctx, bind("UPDATE users SET "+set+" WHERE "+w.String()), append(setArgs, w.args...)...)
+	if err != nil {
+		return mapWriteError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// Update applies a partial update, keeping the search trigrams in sync with the name and
+// email. domain.ErrNotFound if there's no such user.
+func (r *userRepository) Update(ctx context.Context, id bson.ObjectID, upd *ports.UserUpdate) error {
+	var (
+		set  []string
+		args []any
+	)
+	switch upd.Email.Op {
+	case ports.Set:
+		set = append(set, "email = ?", "email_grams = ?")
+		args = append(args, upd.Email.Value, trigrams(upd.Email.Value))
+	case ports.Clear:
+		set = append(set, "email = ''", "email_grams = '{}'")
+	}
+	switch upd.Name.Op {
+	case ports.Set:
+		set = append(set, "name_grams = ?")
+		args = append(args, trigrams(upd.Name.Value))
+	case ports.Clear:
+		set = append(set, "name_grams = '{}'")
+	}
+	// profile fields are stored in columns of their names
+	for _, field := range []struct {
+		column string
+		update ports.FieldUpdate[string]
+	}{
+		{ports.FieldName, upd.Name},
+		{ports.FieldDisplayName, upd.DisplayName},
+		{ports.FieldPhone, upd.Phone},
+		{ports.FieldLocale, upd.Locale},
+		{ports.FieldTimeZone, upd.TimeZone},
+		{ports.FieldAvatarURL, upd.AvatarURL},
+		{ports.FieldBio, upd.Bio},
+	} {
+		switch field.update.Op {
+		case ports.Set:
+			set = append(set, field.column+" = ?")
+			args = append(args, field.update.Value)
+		case ports.Clear:
+			set = append(set, field.column+" = ''")
+		}
+	}
+
+	// attributes are edited in place, each edit wrapping the previous ones
+	attributes, changed := "coalesce(attributes, '{}')", false
+	names := make([]string, 0, len(upd.Attributes))
+	for name := range upd.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field := upd.Attributes[name]
+		switch field.Op {
+		case ports.Set:
+			value, err := jsonValue(field.Value, false)
+			if err != nil {
+				return err
+			}
+			attributes, changed = "jsonb_set("+attributes+", ARRAY[?::text], ?::jsonb)", true
+			args = append(args, name, value)
+		case ports.Clear:
+			attributes, changed = "("+attributes+" - ?::text)", true
+			args = append(args, name)
+		}
+	}
+	if changed {
+		set = append(set, "attributes = "+attributes)
+	}
+
+	if len(set) == 0 {
+		return errors.New("no fields to update")
+	}
+	set = append(set, "updated_at = ?")
+	args = append(args, time.Now())
+
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return update(ctx, r.db, strings.Join(set, ", "), args, w)
+}
+
+func (r *userRepository) SetEmailChange(ctx context.Context, id bson.ObjectID, change *domain.EmailChange, revert *domain.EmailRevert) error {
+	var pending, undo any
+	var err error
+	if change != nil {
+		if pending, err = emailTokenValue(change.Email, change.TokenHash, change.ExpiresAt); err != nil {
+			return err
+		}
+	}
+	if revert != nil {
+		if undo, err = emailTokenValue(revert.Email, revert.TokenHash, revert.ExpiresAt); err != nil {
+			return err
+		}
+	}
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return update(ctx, r.db, "pending_email = ?, email_revert = ?", []any{pending, undo}, w)
+}
+
+// ConfirmEmailChange matches on the token hash in the same update as the swap, so a token
+// can only be used once and a newer request invalidates it.
+func (r *userRepository) ConfirmEmailChange(ctx context.Context, id bson.ObjectID, tokenHash string, email string) error {
+	return r.swapEmail(ctx, id, "pending_email", tokenHash, email, "pending_email = NULL")
+}
+
+func (r *userRepository) RevertEmailChange(ctx context.Context, id bson.ObjectID, tokenHash string, email string) error {
+	return r.swapEmail(ctx, id, "email_revert", tokenHash, email, "pending_email = NULL, email_revert = NULL")
+}
+
+// swapEmail sets the email of a user whose token column still holds the token hash and the
+// email, and clears the token columns
+func (r *userRepository) swapEmail(ctx context.Context, id bson.ObjectID, column, tokenHash, email, clear string) error {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	w.and(column+" ->> 'token_hash' = ?::text AND "+column+" ->> 'email' = ?::text", tokenHash, email)
+	return update(ctx, r.db, "email = ?, email_grams = ?, updated_at = ?, "+clear,
+		[]any{email, trigrams(email), time.Now()}, w)
+}
+
+// SetStatus matches on the current status in the same update, so concurrent transitions
+// can't both apply.
+func (r *userRepository) SetStatus(ctx context.Context, id bson.ObjectID, change *ports.StatusChange) error {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	w.and("status = ?", string(change.From))
+	return update(ctx, r.db, "status = ?, status_reason = ?, status_changed_at = ?",
+		[]any{string(change.To), change.Reason, timestamp(change.At)}, w)
+}
+
+// SetUsername locks the row while it reads the replaced username and writes the redirects,
+// so concurrent renames can't lose a redirect. Reclaiming a previous username drops its redirect.
+func (r *userRepository) SetUsername(ctx context.Context, id bson.ObjectID, username string, redirectUntil time.Time) error {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var (
+			current *string
+			stored  []byte
+		)
+		err := tx.QueryRow(ctx, bind("SELECT username, previous_usernames FROM users WHERE "+w.String()+" FOR UPDATE"), w.args...).
+			Scan(&current, &stored)
+		if err != nil {
+			return mapReadError(err)
+		}
+		redirects, err := decodeRedirects(stored)
+		if err != nil {
+			return err
+		}
+		redirects = slices.DeleteFunc(redirects, func(redirect domain.UsernameRedirect) bool {
+			return !redirect.ExpiresAt.After(now) || redirect.Username == username
+		})
+		if current != nil && *current != username {
+			redirects = append(redirects, domain.UsernameRedirect{
+				Username:  *current,
+				RenamedAt: now,
+				ExpiresAt: redirectUntil,
+			})
+		}
+		value, err := redirectsValue(redirects)
+		if err != nil {
+			return err
+		}
+		return update(ctx, tx, "username = ?, previous_usernames = ?, updated_at = ?",
+			[]any{nullString(username), value, now}, w)
+	})
+}
+
+func (r *userRepository) SetAvatar(ctx context.Context, id bson.ObjectID, hash string) error {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return update(ctx, r.db, "avatar_hash = ?, updated_at = ?", []any{hash, time.Now()}, w)
+}
+
+// Delete removes a user, deleting a missing user isn't an error
+func (r *userRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(ctx, bind("DELETE FROM users WHERE "+w.String()), w.args...)
+	return err
+}
+
+func (r *userRepository) Count(ctx context.Context, filter *ports.UserFilter) (int64, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return 0, err
+	}
+	userFilter(w, filter)
+	var count int64
+	err = r.db.QueryRow(ctx, bind("SELECT count(*) FROM users WHERE "+w.String()), w.args...).Scan(&count)
+	return count, err
+}
+
+// mapReadError maps a missing row to domain.ErrNotFound
+func mapReadError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrNotFound
+	}
+	return err
+}
+
+// uniqueViolation is the SQLSTATE of unique constraint errors
+const uniqueViolation = "23505"
+
+// uniqueIndexes are the unique indexes of the tables by name. Emails, usernames and slugs
+// are stored canonical, so the indexes are the duplicate checks.
+var uniqueIndexes = map[string]error{
+	"uniq_tenant_email":            domain.ErrEmailTaken,
+	"uniq_tenant_username":         domain.ErrUsernameTaken,
+	"uniq_organization_slug":       domain.ErrSlugTaken,
+	"uniq_tenant_group_name":       domain.ErrGroupTaken,
+	"uniq_tenant_invitation_email": domain.ErrInvited,
+}
+
+// mapWriteError maps unique constraint violations to domain errors, told apart by the
+// violated index
+func mapWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolation {
+		return err
+	}
+	if mapped, ok := uniqueIndexes[pgErr.ConstraintName]; ok {
+		return mapped
+	}
+	return domain.ErrConflict
+}
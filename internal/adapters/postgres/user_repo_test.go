@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports/porttest"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// openTest opens the database at the POSTGRES_TEST_URI URL, in a schema of its own
+// dropped afterwards. The test is skipped without one.
+func openTest(t *testing.T) *pgxpool.Pool {
+	uri := os.Getenv("POSTGRES_TEST_URI")
+	if uri == "" {
+		t.Skip("POSTGRES_TEST_URI not set")
+	}
+	ctx := context.Background()
+	admin, err := pgx.Connect(ctx, uri)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	schema := "user_repo_test_" + bson.NewObjectID().Hex()
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE")
+		admin.Close(ctx)
+	})
+
+	sep := "?"
+	if strings.Contains(uri, "?") {
+		sep = "&"
+	}
+	db, err := Open(ctx, uri+sep+"search_path="+schema, PoolConfig{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+func TestUserRepository(t *testing.T) {
+	db := openTest(t)
+	porttest.TestUserRepository(t, func(t *testing.T) ports.UserRepository {
+		return NewUserRepository(db)
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	db := openTest(t)
+	ctx := context.Background()
+
+	all, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	var version int
+	if err := db.QueryRow(ctx, `SELECT max(version) FROM schema_migrations`).Scan(&version); err != nil {
+		t.Fatalf("read version: %v", err)
+	}
+	if latest := all[len(all)-1].version; version != latest {
+		t.Fatalf("expected version %d, got %d", latest, version)
+	}
+
+	// migrating again is a no-op
+	if err := Migrate(ctx, db); err != nil {
+		t.Fatalf("migrate again: %v", err)
+	}
+
+	// a schema from a newer build is refused
+	if _, err := db.Exec(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, 'future', now())`, version+1); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := Migrate(ctx, db); err == nil {
+		t.Fatal("expected a newer schema to be refused")
+	}
+}
+
+func TestBind(t *testing.T) {
+	got := bind("SELECT a FROM t WHERE (b = ?) AND (c IN (?, ?)) LIMIT ?")
+	want := "SELECT a FROM t WHERE (b = $1) AND (c IN ($2, $3)) LIMIT $4"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,110 @@
+package resilience
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the state of a circuit breaker
+type State int
+
+const (
+	Closed   State = iota // calls go through
+	Open                  // calls fail fast until the cooldown ends
+	HalfOpen              // one call probes whether the storage is back
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// breaker opens after threshold failures in a row, then lets one probe through once the
+// cooldown is over. A successful probe closes it, a failed one opens it again.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	state    State
+	failures int       // in a row, while closed
+	openedAt time.Time // while open
+	probing  bool      // a probe is in flight, while half-open
+
+	onChange func(from, to State) // called without the lock held
+}
+
+// allow reports whether a call may go through, the caller reports its outcome with done
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	from := b.state
+	switch b.state {
+	case Open:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			b.mu.Unlock()
+			return false
+		}
+		b.state, b.probing = HalfOpen, true
+	case HalfOpen:
+		if b.probing {
+			b.mu.Unlock()
+			return false
+		}
+		b.probing = true
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.changed(from, to)
+	return true
+}
+
+// done records the outcome of a call allow let through
+func (b *breaker) done(failed bool) {
+	b.mu.Lock()
+	from := b.state
+	switch {
+	case !failed:
+		b.state, b.failures, b.probing = Closed, 0, false
+	case b.state == HalfOpen:
+		b.state, b.openedAt, b.probing = Open, b.now(), false
+	case b.state == Closed:
+		b.failures++
+		if b.failures >= b.threshold {
+			b.state, b.openedAt, b.failures = Open, b.now(), 0
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.changed(from, to)
+}
+
+// release ends a call allow let through without an outcome, as its caller gave up before
+// the storage answered. The state stays as it is, a probe frees its slot for the next call.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == HalfOpen {
+		b.probing = false
+	}
+}
+
+func (b *breaker) current() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *breaker) changed(from, to State) {
+	if from != to && b.onChange != nil {
+		b.onChange(from, to)
+	}
+}
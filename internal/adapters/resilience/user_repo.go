@@ -0,0 +1,363 @@
+// Package resilience decorates repositories so a failing storage degrades into quick,
+// retryable errors: calls time out, idempotent reads are retried on transient errors and
+// a circuit breaker fails calls fast with domain.ErrUnavailable while the storage is down.
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// compile time check to ensure userRepository implements ports.UserRepository
+var _ ports.UserRepository = (*userRepository)(nil)
+
+var errCircuitOpen = fmt.Errorf("%w: user storage is failing, calls are suspended", domain.ErrUnavailable)
+
+// Stats are the state of the circuit breaker and the counters of a repository since it
+// was created
+type Stats struct {
+	State    string `json:"state"`
+	Calls    uint64 `json:"calls"`    // calls let through to the wrapped repository, retries included
+	Failures uint64 `json:"failures"` // calls failing for the storage, timeouts included
+	Timeouts uint64 `json:"timeouts"`
+	Retries  uint64 `json:"retries"`
+	Rejected uint64 `json:"rejected"` // calls failed fast by the open circuit
+	Opens    uint64 `json:"opens"`    // times the circuit opened
+}
+
+type Option func(*userRepository)
+
+// WithTimeouts bounds each call to the storage, reads and writes apart, 0 doesn't bound them
+func WithTimeouts(read, write time.Duration) Option {
+	return func(r *userRepository) {
+		r.readTimeout, r.writeTimeout = read, write
+	}
+}
+
+// WithRetries sets how many times a read is tried, waiting backoff before the first retry
+// and twice as long before every next one, with jitter
+func WithRetries(attempts int, backoff time.Duration) Option {
+	return func(r *userRepository) {
+		r.attempts, r.backoff = max(attempts, 1), backoff
+	}
+}
+
+// WithRetryable sets which errors of the storage are transient, worth retrying a read
+// for. Timeouts of the repository always are.
+func WithRetryable(retryable func(err error) bool) Option {
+	return func(r *userRepository) {
+		r.retryable = retryable
+	}
+}
+
+// WithBreaker opens the circuit after threshold failures in a row, for cooldown before a
+// call probes the storage again
+func WithBreaker(threshold int, cooldown time.Duration) Option {
+	return func(r *userRepository) {
+		r.breaker.threshold, r.breaker.cooldown = max(threshold, 1), cooldown
+	}
+}
+
+// WithStateChange calls fn whenever the circuit changes state, e.g. to report readiness
+func WithStateChange(fn func(from, to State)) Option {
+	return func(r *userRepository) {
+		r.breaker.onChange = fn
+	}
+}
+
+// userRepository guards the calls to the wrapped repository. Only reads are retried, a
+// write that timed out may still have been applied. Errors the storage answers with,
+// like domain.ErrNotFound, and callers giving up aren't failures.
+type userRepository struct {
+	next ports.UserRepository
+
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	attempts     int
+	backoff      time.Duration
+	retryable    func(err error) bool
+	breaker      *breaker
+
+	calls, failures, timeouts, retries, rejected, opens atomic.Uint64
+}
+
+// NewUserRepository returns next guarded, by default with 2 second reads and 5 second
+// writes, 3 attempts of a read from 50ms apart, and a circuit opening for 10 seconds
+// after 5 failures in a row
+func NewUserRepository(next ports.UserRepository, opts ...Option) *userRepository {
+	r := &userRepository{
+		next:         next,
+		readTimeout:  2 * time.Second,
+		writeTimeout: 5 * time.Second,
+		attempts:     3,
+		backoff:      50 * time.Millisecond,
+		retryable:    IsTransient,
+		breaker:      &breaker{threshold: 5, cooldown: 10 * time.Second, now: time.Now},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	onChange := r.breaker.onChange
+	r.breaker.onChange = func(from, to State) {
+		if to == Open {
+			r.opens.Add(1)
+		}
+		if onChange != nil {
+			onChange(from, to)
+		}
+	}
+	return r
+}
+
+// IsTransient is the default of WithRetryable, network errors
+func IsTransient(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// Stats returns the state and counters of the repository, for monitoring
+func (r *userRepository) Stats() Stats {
+	return Stats{
+		State:    r.breaker.current().String(),
+		Calls:    r.calls.Load(),
+		Failures: r.failures.Load(),
+		Timeouts: r.timeouts.Load(),
+		Retries:  r.retries.Load(),
+		Rejected: r.rejected.Load(),
+		Opens:    r.opens.Load(),
+	}
+}
+
+// Ready reports whether calls go through to the storage, false while the circuit is open
+func (r *userRepository) Ready() bool {
+	return r.breaker.current() != Open
+}
+
+// read runs an idempotent call, retried on transient errors outside transactions, which
+// their manager retries as a whole
+func (r *userRepository) read(ctx context.Context, fn func(ctx context.Context) error) error {
+	attempts := r.attempts
+	if ports.InTx(ctx) {
+		attempts = 1
+	}
+	backoff := r.backoff
+	for attempt := 1; ; attempt++ {
+		err := r.call(ctx, r.readTimeout, fn)
+		if !r.failed(ctx, err) || attempt >= attempts || !r.transient(err) || errors.Is(err, errCircuitOpen) {
+			return r.unavailable(ctx, err)
+		}
+		r.retries.Add(1)
+		select {
+		case <-time.After(backoff/2 + rand.N(backoff+1)):
+		case <-ctx.Done():
+			return r.unavailable(ctx, err)
+		}
+		backoff *= 2
+	}
+}
+
+// write runs a call applying changes, tried once
+func (r *userRepository) write(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.unavailable(ctx, r.call(ctx, r.writeTimeout, fn))
+}
+
+// call makes one call through the circuit breaker, within timeout
+func (r *userRepository) call(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if !r.breaker.allow() {
+		r.rejected.Add(1)
+		return errCircuitOpen
+	}
+	r.calls.Add(1)
+	callCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err := fn(callCtx)
+	if err != nil && ctx.Err() == nil && callCtx.Err() != nil {
+		r.timeouts.Add(1)
+		err = fmt.Errorf("user storage timed out after %s: %w", timeout, context.DeadlineExceeded)
+	}
+	if ctx.Err() != nil {
+		r.breaker.release() // not an answer of the storage
+		return err
+	}
+	failed := r.failed(ctx, err)
+	if failed {
+		r.failures.Add(1)
+	}
+	r.breaker.done(failed)
+	return err
+}
+
+// failed reports whether err is a failure of the storage, rather than an answer of it or
+// the caller giving up. In a transaction, errors labeled for the transaction manager, like
+// a write conflict with another transaction, and calls canceled when the transaction is
+// aborted aren't either, the manager retries the transaction as a whole.
+func (r *userRepository) failed(ctx context.Context, err error) bool {
+	switch {
+	case err == nil, ctx.Err() != nil:
+		return false
+	case errors.Is(err, domain.ErrNotFound), errors.Is(err, domain.ErrConflict),
+		errors.Is(err, domain.ErrInvalidArgument), errors.Is(err, domain.ErrPrecondition),
+		errors.Is(err, domain.ErrForbidden):
+		return false
+	case ports.InTx(ctx) && (errors.Is(err, context.Canceled) || hasTxLabel(err)):
+		return false
+	}
+	return true
+}
+
+// txErrorLabels are the labels of transaction errors the transaction manager retries
+var txErrorLabels = []string{"TransientTransactionError", "UnknownTransactionCommitResult"}
+
+// hasTxLabel reports whether err carries a label of txErrorLabels, as Mongo errors do
+func hasTxLabel(err error) bool {
+	var labeled interface{ HasErrorLabel(label string) bool }
+	if !errors.As(err, &labeled) {
+		return false
+	}
+	return slices.ContainsFunc(txErrorLabels, labeled.HasErrorLabel)
+}
+
+// transient reports whether err may go away when tried again, timeouts of the repository
+// always may
+func (r *userRepository) transient(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || r.retryable(err)
+}
+
+// unavailable marks the transient failures as domain.ErrUnavailable, the operation may
+// succeed when tried later
+func (r *userRepository) unavailable(ctx context.Context, err error) error {
+	if errors.Is(err, domain.ErrUnavailable) || !r.failed(ctx, err) || !r.transient(err) {
+		return err
+	}
+	return fmt.Errorf("%w: user storage: %w", domain.ErrUnavailable, err)
+}
+
+/* --------------------------------- Reads --------------------------------- */
+
+func (r *userRepository) GetByID(ctx context.Context, id bson.ObjectID, fields ...string) (user *domain.User, err error) {
+	err = r.read(ctx, func(ctx context.Context) error {
+		user, err = r.next.GetByID(ctx, id, fields...)
+		return err
+	})
+	return user, err
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (user *domain.User, err error) {
+	err = r.read(ctx, func(ctx context.Context) error {
+		user, err = r.next.GetByEmail(ctx, email)
+		return err
+	})
+	return user, err
+}
+
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (user *domain.User, err error) {
+	err = r.read(ctx, func(ctx context.Context) error {
+		user, err = r.next.GetByUsername(ctx, username)
+		return err
+	})
+	return user, err
+}
+
+func (r *userRepository) GetAll(ctx context.Context) (users []domain.User, err error) {
+	err = r.read(ctx, func(ctx context.Context) error {
+		users, err = r.next.GetAll(ctx)
+		return err
+	})
+	return users, err
+}
+
+func (r *userRepository) List(ctx context.Context, filter *ports.UserFilter, pagination *ports.Pagination) (users []domain.User, err error) {
+	err = r.read(ctx, func(ctx context.Context) error {
+		users, err = r.next.List(ctx, filter, pagination)
+		return err
+	})
+	return users, err
+}
+
+func (r *userRepository) Count(ctx context.Context, filter *ports.UserFilter) (count int64, err error) {
+	err = r.read(ctx, func(ctx context.Context) error {
+		count, err = r.next.Count(ctx, filter)
+		return err
+	})
+	return count, err
+}
+
+func (r *userRepository) Search(ctx context.Context, query string, limit int64) (users []domain.User, err error) {
+	err = r.read(ctx, func(ctx context.Context) error {
+		users, err = r.next.Search(ctx, query, limit)
+		return err
+	})
+	return users, err
+}
+
+/* --------------------------------- Writes -------------------------------- */
+
+func (r *userRepository) Create(ctx context.Context, user *domain.User) (id *bson.ObjectID, err error) {
+	err = r.write(ctx, func(ctx context.Context) error {
+		id, err = r.next.Create(ctx, user)
+		return err
+	})
+	return id, err
+}
+
+func (r *userRepository) Update(ctx context.Context, id bson.ObjectID, update *ports.UserUpdate) error {
+	return r.write(ctx, func(ctx context.Context) error {
+		return r.next.Update(ctx, id, update)
+	})
+}
+
+func (r *userRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	return r.write(ctx, func(ctx context.Context) error {
+		return r.next.Delete(ctx, id)
+	})
+}
+
+func (r *userRepository) SetEmailChange(ctx context.Context, id bson.ObjectID, change *domain.EmailChange, revert *domain.EmailRevert) error {
+	return r.write(ctx, func(ctx context.Context) error {
+		return r.next.SetEmailChange(ctx, id, change, revert)
+	})
+}
+
+func (r *userRepository) ConfirmEmailChange(ctx context.Context, id bson.ObjectID, tokenHash string, email string) error {
+	return r.write(ctx, func(ctx context.Context) error {
+		return r.next.ConfirmEmailChange(ctx, id, tokenHash, email)
+	})
+}
+
+func (r *userRepository) RevertEmailChange(ctx context.Context, id bson.ObjectID, tokenHash string, email string) error {
+	return r.write(ctx, func(ctx context.Context) error {
+		return r.next.RevertEmailChange(ctx, id, tokenHash, email)
+	})
+}
+
+func (r *userRepository) SetStatus(ctx context.Context, id bson.ObjectID, change *ports.StatusChange) error {
+	return r.write(ctx, func(ctx context.Context) error {
+		return r.next.SetStatus(ctx, id, change)
+	})
+}
+
+func (r *userRepository) SetUsername(ctx context.Context, id bson.ObjectID, username string, redirectUntil time.Time) error {
+	return r.write(ctx, func(ctx context.Context) error {
+		return r.next.SetUsername(ctx, id, username, redirectUntil)
+	})
+}
+
+func (r *userRepository) SetAvatar(ctx context.Context, id bson.ObjectID, hash string) error {
+	return r.write(ctx, func(ctx context.Context) error {
+		return r.next.SetAvatar(ctx, id, hash)
+	})
+}
@@ -0,0 +1,256 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/adapters/memory"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports/porttest"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestUserRepository(t *testing.T) {
+	porttest.TestUserRepository(t, func(t *testing.T) ports.UserRepository {
+		return NewUserRepository(memory.NewUserRepository())
+	})
+}
+
+var errFlaky = errors.New("flaky storage")
+
+// flakyRepository fails the next calls with errFlaky while failing is positive, and
+// blocks them until their context is done while slow is set. They fail with err instead
+// while it's set.
+type flakyRepository struct {
+	ports.UserRepository
+	failing atomic.Int64
+	slow    atomic.Bool
+	calls   atomic.Int64
+	err     atomic.Pointer[error]
+}
+
+func (r *flakyRepository) fail(ctx context.Context) error {
+	r.calls.Add(1)
+	if r.slow.Load() {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if err := r.err.Load(); err != nil {
+		return *err
+	}
+	if r.failing.Add(-1) >= 0 {
+		return errFlaky
+	}
+	return nil
+}
+
+func (r *flakyRepository) GetByID(ctx context.Context, id bson.ObjectID, fields ...string) (*domain.User, error) {
+	if err := r.fail(ctx); err != nil {
+		return nil, err
+	}
+	return r.UserRepository.GetByID(ctx, id, fields...)
+}
+
+func (r *flakyRepository) SetAvatar(ctx context.Context, id bson.ObjectID, hash string) error {
+	if err := r.fail(ctx); err != nil {
+		return err
+	}
+	return r.UserRepository.SetAvatar(ctx, id, hash)
+}
+
+func newTestRepo(t *testing.T, opts ...Option) (*userRepository, *flakyRepository, context.Context, bson.ObjectID) {
+	t.Helper()
+	next := &flakyRepository{UserRepository: memory.NewUserRepository()}
+	ctx := ports.WithTenant(context.Background(), bson.NewObjectID())
+	id, err := next.Create(ctx, &domain.User{Name: "Jane", Email: "jane@example.com", Password: "hash", Status: domain.StatusActive})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	opts = append([]Option{
+		WithRetries(3, time.Millisecond),
+		WithRetryable(func(err error) bool { return errors.Is(err, errFlaky) }),
+	}, opts...)
+	return NewUserRepository(next, opts...), next, ctx, *id
+}
+
+func TestResilienceRetries(t *testing.T) {
+	repo, next, ctx, id := newTestRepo(t)
+
+	// reads are retried
+	next.failing.Store(2)
+	if _, err := repo.GetByID(ctx, id); err != nil {
+		t.Fatalf("expected the third attempt to succeed, got %v", err)
+	}
+	if stats := repo.Stats(); stats.Retries != 2 || stats.Failures != 2 || stats.Calls != 3 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	// up to the attempts, then they're unavailable
+	next.failing.Store(3)
+	if _, err := repo.GetByID(ctx, id); !errors.Is(err, domain.ErrUnavailable) || !errors.Is(err, errFlaky) {
+		t.Fatalf("expected unavailable, got %v", err)
+	}
+
+	// writes aren't
+	next.calls.Store(0)
+	next.failing.Store(1)
+	if err := repo.SetAvatar(ctx, id, "hash"); !errors.Is(err, domain.ErrUnavailable) {
+		t.Fatalf("expected unavailable, got %v", err)
+	}
+	if n := next.calls.Load(); n != 1 {
+		t.Fatalf("expected 1 call, got %d", n)
+	}
+
+	// nor answers of the storage, which aren't failures either
+	failures := repo.Stats().Failures
+	if _, err := repo.GetByID(ctx, bson.NewObjectID()); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if stats := repo.Stats(); stats.Failures != failures {
+		t.Fatalf("expected not found not to be a failure, got %+v", stats)
+	}
+
+	// nor reads in a transaction, retried with it
+	next.calls.Store(0)
+	next.failing.Store(1)
+	txCtx, _ := ports.NewUnitOfWork(ctx)
+	if _, err := repo.GetByID(txCtx, id); !errors.Is(err, errFlaky) {
+		t.Fatalf("expected the error, got %v", err)
+	}
+	if n := next.calls.Load(); n != 1 {
+		t.Fatalf("expected 1 call in a transaction, got %d", n)
+	}
+}
+
+func TestResilienceTimeout(t *testing.T) {
+	repo, next, ctx, id := newTestRepo(t, WithTimeouts(5*time.Millisecond, 5*time.Millisecond), WithRetries(1, 0))
+	next.slow.Store(true)
+
+	_, err := repo.GetByID(ctx, id)
+	if !errors.Is(err, domain.ErrUnavailable) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected unavailable after a timeout, got %v", err)
+	}
+	if stats := repo.Stats(); stats.Timeouts != 1 || stats.Failures != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	// a caller giving up isn't a failure of the storage
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := repo.GetByID(canceled, id); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	if stats := repo.Stats(); stats.Timeouts != 1 || stats.Failures != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+// labeledError is an error of a transaction, labeled like Mongo errors
+type labeledError struct{ label string }
+
+func (e labeledError) Error() string                   { return "write conflict" }
+func (e labeledError) HasErrorLabel(label string) bool { return label == e.label }
+
+func TestResilienceTransactionErrors(t *testing.T) {
+	repo, next, ctx, id := newTestRepo(t, WithBreaker(1, time.Minute))
+	txCtx, _ := ports.NewUnitOfWork(ctx)
+
+	// errors the transaction manager retries the transaction for don't open the circuit
+	for _, want := range []error{
+		labeledError{label: "TransientTransactionError"},
+		fmt.Errorf("transaction aborted: %w", context.Canceled),
+	} {
+		next.err.Store(&want)
+		_, err := repo.GetByID(txCtx, id)
+		if !errors.Is(err, want) || errors.Is(err, domain.ErrUnavailable) {
+			t.Fatalf("expected %v as is, got %v", want, err)
+		}
+	}
+	if stats := repo.Stats(); stats.Failures != 0 || !repo.Ready() {
+		t.Fatalf("expected no failure, got %+v", stats)
+	}
+
+	// outside a transaction they're failures
+	repo.GetByID(ctx, id)
+	if repo.Ready() {
+		t.Fatalf("expected the circuit open")
+	}
+}
+
+func TestResilienceBreaker(t *testing.T) {
+	var changes []string
+	repo, next, ctx, id := newTestRepo(t,
+		WithRetries(1, 0),
+		WithBreaker(2, time.Minute),
+		WithStateChange(func(from, to State) { changes = append(changes, from.String()+">"+to.String()) }),
+	)
+	now := time.Now()
+	repo.breaker.now = func() time.Time { return now }
+
+	// opens after 2 failures in a row, then fails fast
+	next.failing.Store(2)
+	for range 2 {
+		repo.GetByID(ctx, id)
+	}
+	if repo.Ready() {
+		t.Fatalf("expected the circuit open")
+	}
+	next.calls.Store(0)
+	if _, err := repo.GetByID(ctx, id); !errors.Is(err, domain.ErrUnavailable) {
+		t.Fatalf("expected unavailable, got %v", err)
+	}
+	if err := repo.SetAvatar(ctx, id, "hash"); !errors.Is(err, domain.ErrUnavailable) {
+		t.Fatalf("expected unavailable, got %v", err)
+	}
+	if n := next.calls.Load(); n != 0 {
+		t.Fatalf("expected no call while open, got %d", n)
+	}
+
+	// after the cooldown, a probe its caller gives up on leaves it half-open for the next one
+	now = now.Add(time.Minute)
+	next.slow.Store(true)
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := repo.GetByID(canceled, id); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	next.slow.Store(false)
+	if state := repo.Stats().State; state != "half-open" {
+		t.Fatalf("expected the circuit half-open, got %s", state)
+	}
+
+	// a failed probe opens it again
+	next.failing.Store(1)
+	repo.GetByID(ctx, id)
+	if repo.Ready() {
+		t.Fatalf("expected the circuit open after a failed probe")
+	}
+
+	// and a successful one closes it
+	now = now.Add(time.Minute)
+	if _, err := repo.GetByID(ctx, id); err != nil {
+		t.Fatalf("expected the probe to succeed, got %v", err)
+	}
+	if !repo.Ready() {
+		t.Fatalf("expected the circuit closed")
+	}
+
+	stats := repo.Stats()
+	if stats.State != "closed" || stats.Opens != 2 || stats.Rejected != 2 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	want := []string{"closed>open", "open>half-open", "half-open>open", "open>half-open", "half-open>closed"}
+	if len(changes) != len(want) {
+		t.Fatalf("expected changes %v, got %v", want, changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("expected changes %v, got %v", want, changes)
+		}
+	}
+}
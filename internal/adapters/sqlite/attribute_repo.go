@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+)
+
+// compile time check to ensure attributeRepository implements ports.AttributeSchemaRepository
+var _ ports.AttributeSchemaRepository = (*attributeRepository)(nil)
+
+// attributeRepository stores one row per attribute definition, keyed by name
+type attributeRepository struct {
+	db *sql.DB
+}
+
+// NewAttributeRepository returns a repository on a database opened and migrated by Open
+func NewAttributeRepository(db *sql.DB) *attributeRepository {
+	return &attributeRepository{db: db}
+}
+
+func (r *attributeRepository) List(ctx context.Context) (domain.AttributeSchema, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT name, type, required, enum, pattern, description FROM attribute_schema ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schema := domain.AttributeSchema{}
+	for rows.Next() {
+		var (
+			def  domain.AttributeDefinition
+			enum sql.NullString
+		)
+		if err := rows.Scan(&def.Name, &def.Type, &def.Required, &enum, &def.Pattern, &def.Description); err != nil {
+			return nil, err
+		}
+		if err := decodeJSON(enum, &def.Enum); err != nil {
+			return nil, err
+		}
+		schema = append(schema, def)
+	}
+	return schema, rows.Err()
+}
+
+func (r *attributeRepository) Put(ctx context.Context, def *domain.AttributeDefinition) error {
+	enum, err := jsonValue(def.Enum, len(def.Enum) == 0)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `INSERT INTO attribute_schema (name, type, required, enum, pattern, description)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET type = excluded.type, required = excluded.required, enum = excluded.enum,
+			pattern = excluded.pattern, description = excluded.description`,
+		def.Name, string(def.Type), def.Required, enum, def.Pattern, def.Description)
+	return err
+}
+
+func (r *attributeRepository) Delete(ctx context.Context, name string) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM attribute_schema WHERE name = ?", name)
+	return affected(res, err)
+}
+
+// affected maps the result of a write of one row to domain.ErrNotFound when it matched none
+func affected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports/porttest"
+)
+
+func TestAttributeRepository(t *testing.T) {
+	porttest.TestAttributeSchemaRepository(t, func(t *testing.T) ports.AttributeSchemaRepository {
+		return NewAttributeRepository(openTestDB(t))
+	})
+}
@@ -0,0 +1,149 @@
+// Package sqlite implements repositories on an embedded SQLite database, for local
+// development and small deployments that don't run MongoDB. It uses a pure-Go driver, so
+// binaries still build without cgo.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// pragmas of every connection: foreign keys cascade deletes to the trigrams, WAL lets
+// readers run alongside the writer, and writes wait for the lock rather than failing.
+// Transactions take the write lock when they begin, so a read-then-write transaction
+// can't deadlock with another one.
+const pragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+
+// Open opens the database file at path, creating it and its directory if needed, and
+// migrates its schema
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?"+pragmas)
+	if err != nil {
+		return nil, err
+	}
+	if err := Migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migration is a schema change, numbered by the prefix of its file name, e.g. 0001_create_users.sql
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// loadMigrations returns the embedded migrations in version order
+func loadMigrations() ([]migration, error) {
+	files, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	var out []migration
+	for _, file := range files { // Glob sorts, and versions are zero-padded
+		name := strings.TrimSuffix(path.Base(file), ".sql")
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: no version prefix", file)
+		}
+		if len(out) > 0 && out[len(out)-1].version >= version {
+			return nil, fmt.Errorf("migration %s: version %d isn't after the previous one", file, version)
+		}
+		content, err := migrations.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, migration{version: version, name: name, sql: string(content)})
+	}
+	return out, nil
+}
+
+// Migrate applies the migrations the database hasn't seen yet, in version order. Each one
+// runs in a transaction with the record of its version, so a failed migration leaves no
+// trace and runs again next time. A database migrated by a newer build is refused rather
+// than used with a schema this build doesn't know.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	all, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	var current int
+	if err := db.QueryRowContext(ctx, `SELECT coalesce(max(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if latest := all[len(all)-1].version; current > latest {
+		return fmt.Errorf("database schema version %d is newer than this build, which knows up to %d", current, latest)
+	}
+
+	for _, m := range all {
+		if m.version <= current {
+			continue
+		}
+		err := inTx(ctx, db, func(tx *sql.Tx) error {
+			// another process may have applied it since, transactions hold the write lock
+			var applied bool
+			err := tx.QueryRowContext(ctx, `SELECT count(*) > 0 FROM schema_migrations WHERE version = ?`, m.version).Scan(&applied)
+			if err != nil || applied {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+				m.version, m.name, time.Now().UnixMilli())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", m.name, err)
+		}
+	}
+	return nil
+}
+
+// execer is a database or a transaction
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// inTx runs fn in a transaction, committed when fn succeeds and rolled back otherwise
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
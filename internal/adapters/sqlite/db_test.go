@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+// openTestDB opens a migrated database in a temporary directory, closed after the test
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := Open(context.Background(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "migrate.db")
+	db, err := Open(ctx, path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	all, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	version := func() int {
+		var v, n int
+		if err := db.QueryRowContext(ctx, `SELECT max(version), count(*) FROM schema_migrations`).Scan(&v, &n); err != nil {
+			t.Fatalf("read schema version: %v", err)
+		}
+		if n != len(all) {
+			t.Fatalf("expected %d applied migrations, got %d", len(all), n)
+		}
+		return v
+	}
+	if got, want := version(), all[len(all)-1].version; got != want {
+		t.Fatalf("expected schema version %d, got %d", want, got)
+	}
+
+	// migrating again applies nothing
+	if err := Migrate(ctx, db); err != nil {
+		t.Fatalf("migrate again: %v", err)
+	}
+	version()
+
+	// a database from a newer build is refused
+	if _, err := db.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (9999, 'future', 0)`); err != nil {
+		t.Fatalf("record future migration: %v", err)
+	}
+	if err := Migrate(ctx, db); err == nil {
+		t.Fatal("expected a newer schema to be refused")
+	}
+}
@@ -0,0 +1,310 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// compile time check to ensure groupRepository implements ports.GroupRepository
+var _ ports.GroupRepository = (*groupRepository)(nil)
+
+const groupColumns = "id, tenant_id, name, description, roles, created_at, updated_at"
+
+// groupRepository stores groups and their direct memberships in separate tables, so groups
+// of any size and "groups of a member" lookups stay indexed. Names and memberships are
+// unique through indexes.
+type groupRepository struct {
+	db *sql.DB
+}
+
+// NewGroupRepository returns a repository on a database opened and migrated by Open
+func NewGroupRepository(db *sql.DB) *groupRepository {
+	return &groupRepository{db: db}
+}
+
+// Create stores the group in the organization of ctx, whatever its TenantID. The id is
+// generated unless the group has one.
+func (r *groupRepository) Create(ctx context.Context, group *domain.Group) (*bson.ObjectID, error) {
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return nil, ports.ErrNoTenant
+	}
+	id := group.ID
+	if id.IsZero() {
+		id = bson.NewObjectID()
+	}
+	roles, err := jsonValue(group.Roles, len(group.Roles) == 0)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.db.ExecContext(ctx, "INSERT INTO groups ("+groupColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+		id.Hex(), tenantID.Hex(), group.Name, group.Description, roles, group.CreatedAt.UnixMilli(), group.UpdatedAt.UnixMilli())
+	if err != nil {
+		return nil, mapWriteError(err)
+	}
+	return &id, nil
+}
+
+func (r *groupRepository) GetByID(ctx context.Context, id bson.ObjectID) (*domain.Group, error) {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	group, err := scanGroup(r.db.QueryRowContext(ctx, "SELECT "+groupColumns+" FROM groups WHERE "+w.String(), w.args...))
+	if err != nil {
+		return nil, mapReadError(err)
+	}
+	return group, nil
+}
+
+func (r *groupRepository) List(ctx context.Context, ids []bson.ObjectID, after *bson.ObjectID, limit int64) ([]domain.Group, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keyset(w, "id", ids, after)
+	rows, err := r.db.QueryContext(ctx, "SELECT "+groupColumns+" FROM groups WHERE "+w.String()+" ORDER BY id"+limitClause(limit), w.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []domain.Group{}
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *group)
+	}
+	return results, rows.Err()
+}
+
+func (r *groupRepository) Update(ctx context.Context, id bson.ObjectID, update *ports.GroupUpdate) error {
+	set, args := []string{"updated_at = ?"}, []any{time.Now().UnixMilli()}
+	if update.Name.Op == ports.Set {
+		set, args = append(set, "name = ?"), append(args, update.Name.Value)
+	}
+	switch update.Description.Op {
+	case ports.Set:
+		set, args = append(set, "description = ?"), append(args, update.Description.Value)
+	case ports.Clear:
+		set = append(set, "description = ''")
+	}
+	switch update.Roles.Op {
+	case ports.Set:
+		roles, err := jsonValue(update.Roles.Value, len(update.Roles.Value) == 0)
+		if err != nil {
+			return err
+		}
+		set, args = append(set, "roles = ?"), append(args, roles)
+	case ports.Clear:
+		set = append(set, "roles = NULL")
+	}
+
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	res, err := r.db.ExecContext(ctx, "UPDATE groups SET "+strings.Join(set, ", ")+" WHERE "+w.String(), append(args, w.args...)...)
+	if err != nil {
+		return mapWriteError(err)
+	}
+	return affected(res, nil)
+}
+
+// Delete removes the group along with its memberships in one transaction
+func (r *groupRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	memberships, _ := scoped(ctx)
+	memberships.and("group_id = ? OR member_id = ?", id.Hex(), id.Hex())
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM groups WHERE "+w.String(), w.args...)
+		if err := affected(res, err); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM group_members WHERE "+memberships.String(), memberships.args...)
+		return err
+	})
+}
+
+// AddMember checks a group member doesn't contain the group, directly or nested, in the
+// transaction adding it. Transactions take the write lock when they begin, so concurrent
+// additions can't close a cycle between them: domain.ErrGroupCycle.
+func (r *groupRepository) AddMember(ctx context.Context, groupID bson.ObjectID, member *domain.GroupMember) error {
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return ports.ErrNoTenant
+	}
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if member.Kind == domain.MemberGroup {
+			// the group and the groups it's nested in, a member among them would contain itself
+			var cycle bool
+			err := tx.QueryRowContext(ctx, `WITH RECURSIVE ancestors (id) AS (
+					SELECT ?
+					UNION
+					SELECT m.group_id FROM group_members AS m JOIN ancestors AS a ON m.member_id = a.id
+					WHERE m.tenant_id = ?
+				)
+				SELECT count(*) > 0 FROM ancestors WHERE id = ?`,
+				groupID.Hex(), tenantID.Hex(), member.ID.Hex()).Scan(&cycle)
+			if err != nil {
+				return err
+			}
+			if cycle {
+				return domain.ErrGroupCycle
+			}
+		}
+		_, err := tx.ExecContext(ctx, "INSERT INTO group_members (tenant_id, group_id, kind, member_id, added_at) VALUES (?, ?, ?, ?, ?)",
+			tenantID.Hex(), groupID.Hex(), string(member.Kind), member.ID.Hex(), member.AddedAt.UnixMilli())
+		return mapWriteError(err)
+	})
+}
+
+func (r *groupRepository) RemoveMember(ctx context.Context, groupID, memberID bson.ObjectID) error {
+	w, err := scoped(ctx)
+	if err != nil {
+		return err
+	}
+	w.and("group_id = ?", groupID.Hex()).and("member_id = ?", memberID.Hex())
+	res, err := r.db.ExecContext(ctx, "DELETE FROM group_members WHERE "+w.String(), w.args...)
+	return affected(res, err)
+}
+
+func (r *groupRepository) RemoveFromAll(ctx context.Context, memberID bson.ObjectID) error {
+	w, err := scoped(ctx)
+	if err != nil {
+		return err
+	}
+	w.and("member_id = ?", memberID.Hex())
+	_, err = r.db.ExecContext(ctx, "DELETE FROM group_members WHERE "+w.String(), w.args...)
+	return err
+}
+
+func (r *groupRepository) ListMembers(ctx context.Context, groupID bson.ObjectID, after *bson.ObjectID, limit int64) ([]domain.GroupMember, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keyset(w.and("group_id = ?", groupID.Hex()), "member_id", nil, after)
+	rows, err := r.db.QueryContext(ctx, "SELECT kind, member_id, added_at FROM group_members WHERE "+w.String()+
+		" ORDER BY member_id"+limitClause(limit), w.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []domain.GroupMember{}
+	for rows.Next() {
+		var (
+			member  domain.GroupMember
+			id      string
+			addedAt sql.NullInt64
+		)
+		if err := rows.Scan(&member.Kind, &id, &addedAt); err != nil {
+			return nil, err
+		}
+		if member.ID, err = bson.ObjectIDFromHex(id); err != nil {
+			return nil, err
+		}
+		member.AddedAt = fromMillis(addedAt)
+		results = append(results, member)
+	}
+	return results, rows.Err()
+}
+
+func (r *groupRepository) ParentsOf(ctx context.Context, memberIDs []bson.ObjectID) ([]bson.ObjectID, error) {
+	if len(memberIDs) == 0 {
+		return nil, nil
+	}
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keyset(w, "member_id", memberIDs, nil)
+	rows, err := r.db.QueryContext(ctx, "SELECT DISTINCT group_id FROM group_members WHERE "+w.String(), w.args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanIDs(rows)
+}
+
+// keyset restricts column to ids, nil for any, and to ids greater than after, nil for any.
+// Ids are stored as hex strings of the same length, so they sort like the ids.
+func keyset(w *where, column string, ids []bson.ObjectID, after *bson.ObjectID) *where {
+	if ids != nil {
+		if len(ids) == 0 {
+			return w.and("0")
+		}
+		args := make([]any, len(ids))
+		for i, id := range ids {
+			args[i] = id.Hex()
+		}
+		w.and(column+" IN (?"+strings.Repeat(", ?", len(ids)-1)+")", args...)
+	}
+	if after != nil {
+		w.and(column+" > ?", after.Hex())
+	}
+	return w
+}
+
+// limitClause is the LIMIT of a query returning up to limit rows, none for a zero limit
+func limitClause(limit int64) string {
+	if limit <= 0 {
+		return ""
+	}
+	return " LIMIT " + strconv.FormatInt(limit, 10)
+}
+
+// scanGroup reads a group selected by groupColumns
+func scanGroup(row scanner) (*domain.Group, error) {
+	var (
+		group                domain.Group
+		id, tenantID         string
+		roles                sql.NullString
+		createdAt, updatedAt sql.NullInt64
+	)
+	err := row.Scan(&id, &tenantID, &group.Name, &group.Description, &roles, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if group.ID, err = bson.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if group.TenantID, err = bson.ObjectIDFromHex(tenantID); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON(roles, &group.Roles); err != nil {
+		return nil, err
+	}
+	group.CreatedAt, group.UpdatedAt = fromMillis(createdAt), fromMillis(updatedAt)
+	return &group, nil
+}
+
+// scanIDs reads the ids of a query selecting one id column
+func scanIDs(rows *sql.Rows) ([]bson.ObjectID, error) {
+	defer rows.Close()
+	var ids []bson.ObjectID
+	for rows.Next() {
+		var hex string
+		if err := rows.Scan(&hex); err != nil {
+			return nil, err
+		}
+		id, err := bson.ObjectIDFromHex(hex)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
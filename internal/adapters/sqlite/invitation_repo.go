@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// compile time check to ensure invitationRepository implements ports.InvitationRepository
+var _ ports.InvitationRepository = (*invitationRepository)(nil)
+
+const invitationColumns = "id, tenant_id, email, roles, group_ids, invited_by, token_hash, expires_at, sent_at, created_at"
+
+// invitationRepository stores pending invitations, an email has at most one per
+// organization through an index
+type invitationRepository struct {
+	db *sql.DB
+}
+
+// NewInvitationRepository returns a repository on a database opened and migrated by Open
+func NewInvitationRepository(db *sql.DB) *invitationRepository {
+	return &invitationRepository{db: db}
+}
+
+// Create stores the invitation in the organization of ctx, whatever its TenantID
+func (r *invitationRepository) Create(ctx context.Context, invitation *domain.Invitation) error {
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return ports.ErrNoTenant
+	}
+	roles, err := jsonValue(invitation.Roles, len(invitation.Roles) == 0)
+	if err != nil {
+		return err
+	}
+	groupIDs, err := jsonValue(invitation.GroupIDs, len(invitation.GroupIDs) == 0)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, "INSERT INTO invitations ("+invitationColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		invitation.ID.Hex(), tenantID.Hex(), invitation.Email, roles, groupIDs, invitation.InvitedBy.Hex(), invitation.TokenHash,
+		invitation.ExpiresAt.UnixMilli(), invitation.SentAt.UnixMilli(), invitation.CreatedAt.UnixMilli())
+	return mapWriteError(err)
+}
+
+func (r *invitationRepository) GetByID(ctx context.Context, id bson.ObjectID) (*domain.Invitation, error) {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	invitation, err := scanInvitation(r.db.QueryRowContext(ctx, "SELECT "+invitationColumns+" FROM invitations WHERE "+w.String(), w.args...))
+	if err != nil {
+		return nil, mapReadError(err)
+	}
+	return invitation, nil
+}
+
+func (r *invitationRepository) List(ctx context.Context, after *bson.ObjectID, limit int64) ([]domain.Invitation, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keyset(w, "id", nil, after)
+	rows, err := r.db.QueryContext(ctx, "SELECT "+invitationColumns+" FROM invitations WHERE "+w.String()+" ORDER BY id"+limitClause(limit), w.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []domain.Invitation{}
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *invitation)
+	}
+	return results, rows.Err()
+}
+
+func (r *invitationRepository) Renew(ctx context.Context, id bson.ObjectID, tokenHash string, expiresAt, sentAt time.Time) error {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	res, err := r.db.ExecContext(ctx, "UPDATE invitations SET token_hash = ?, expires_at = ?, sent_at = ? WHERE "+w.String(),
+		append([]any{tokenHash, expiresAt.UnixMilli(), sentAt.UnixMilli()}, w.args...)...)
+	return affected(res, err)
+}
+
+func (r *invitationRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	res, err := r.db.ExecContext(ctx, "DELETE FROM invitations WHERE "+w.String(), w.args...)
+	return affected(res, err)
+}
+
+// scanInvitation reads an invitation selected by invitationColumns
+func scanInvitation(row scanner) (*domain.Invitation, error) {
+	var (
+		invitation                   domain.Invitation
+		id, tenantID, invitedBy      string
+		roles, groupIDs              sql.NullString
+		expiresAt, sentAt, createdAt sql.NullInt64
+	)
+	err := row.Scan(&id, &tenantID, &invitation.Email, &roles, &groupIDs, &invitedBy, &invitation.TokenHash,
+		&expiresAt, &sentAt, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.ID, err = bson.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if invitation.TenantID, err = bson.ObjectIDFromHex(tenantID); err != nil {
+		return nil, err
+	}
+	if invitation.InvitedBy, err = bson.ObjectIDFromHex(invitedBy); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON(roles, &invitation.Roles); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON(groupIDs, &invitation.GroupIDs); err != nil {
+		return nil, err
+	}
+	invitation.ExpiresAt, invitation.SentAt, invitation.CreatedAt = fromMillis(expiresAt), fromMillis(sentAt), fromMillis(createdAt)
+	return &invitation, nil
+}
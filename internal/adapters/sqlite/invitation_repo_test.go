@@ -0,0 +1,14 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports/porttest"
+)
+
+func TestInvitationRepository(t *testing.T) {
+	porttest.TestInvitationRepository(t, func(t *testing.T) ports.InvitationRepository {
+		return NewInvitationRepository(openTestDB(t))
+	})
+}
@@ -0,0 +1,48 @@
+-- Users, one row per user. Ids are ObjectID hex strings, times are Unix milliseconds
+-- and the nested values are JSON.
+CREATE TABLE users (
+    id                 TEXT PRIMARY KEY,
+    tenant_id          TEXT NOT NULL,
+    name               TEXT NOT NULL,
+    email              TEXT NOT NULL,
+    email_domain       TEXT NOT NULL, -- derived from email, for the domain filter
+    username           TEXT,          -- NULL when the user has none
+    password           TEXT NOT NULL,
+    display_name       TEXT NOT NULL DEFAULT '',
+    phone              TEXT NOT NULL DEFAULT '',
+    locale             TEXT NOT NULL DEFAULT '',
+    time_zone          TEXT NOT NULL DEFAULT '',
+    avatar_url         TEXT NOT NULL DEFAULT '',
+    bio                TEXT NOT NULL DEFAULT '',
+    avatar_hash        TEXT NOT NULL DEFAULT '',
+    attributes         TEXT,
+    status             TEXT NOT NULL,
+    status_reason      TEXT NOT NULL DEFAULT '',
+    status_changed_at  INTEGER,
+    roles              TEXT,
+    pending_email      TEXT,
+    email_revert       TEXT,
+    previous_usernames TEXT,
+    created_at         INTEGER NOT NULL,
+    updated_at         INTEGER NOT NULL
+);
+
+-- duplicates are told apart by the columns of the violated index, see mapWriteError
+CREATE UNIQUE INDEX uniq_tenant_email ON users (tenant_id, email);
+CREATE UNIQUE INDEX uniq_tenant_username ON users (tenant_id, username) WHERE username IS NOT NULL;
+
+-- listing indexes, strings sort case-insensitively like the queries
+CREATE INDEX idx_tenant_created_at ON users (tenant_id, created_at, id);
+CREATE INDEX idx_tenant_name ON users (tenant_id, name COLLATE NOCASE, id);
+CREATE INDEX idx_tenant_email_nocase ON users (tenant_id, email COLLATE NOCASE, id);
+CREATE INDEX idx_tenant_email_domain ON users (tenant_id, email_domain COLLATE NOCASE);
+CREATE INDEX idx_tenant_status ON users (tenant_id, status);
+
+-- trigrams of the name and email of each user, to find fuzzy search candidates
+CREATE TABLE user_trigrams (
+    gram    TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY (gram, user_id)
+) WITHOUT ROWID;
+
+CREATE INDEX idx_user_trigrams_user ON user_trigrams (user_id);
@@ -0,0 +1,9 @@
+-- Definitions of custom user attributes, shared by every organization like in Mongo
+CREATE TABLE attribute_schema (
+    name        TEXT PRIMARY KEY,
+    type        TEXT NOT NULL,
+    required    INTEGER NOT NULL DEFAULT 0,
+    enum        TEXT, -- JSON array, NULL when any value goes
+    pattern     TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT ''
+);
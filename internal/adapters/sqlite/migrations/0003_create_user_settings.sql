@@ -0,0 +1,8 @@
+-- Settings users changed from their defaults, one row per user in the organization of the
+-- user. Settings are a JSON object by name.
+CREATE TABLE user_settings (
+    user_id    TEXT PRIMARY KEY,
+    tenant_id  TEXT NOT NULL,
+    settings   TEXT NOT NULL,
+    updated_at INTEGER NOT NULL
+);
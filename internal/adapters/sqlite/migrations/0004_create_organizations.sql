@@ -0,0 +1,11 @@
+-- Organizations, the tenants of the other tables. Slugs are stored lowercase.
+CREATE TABLE organizations (
+    id         TEXT PRIMARY KEY,
+    slug       TEXT NOT NULL,
+    name       TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+-- duplicates are told apart by the columns of the violated index, see mapWriteError
+CREATE UNIQUE INDEX uniq_organization_slug ON organizations (slug);
@@ -0,0 +1,26 @@
+-- Groups of an organization and their direct members, users or groups. Nesting is walked
+-- up from members to the groups they belong to.
+CREATE TABLE groups (
+    id          TEXT PRIMARY KEY,
+    tenant_id   TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    roles       TEXT, -- JSON array
+    created_at  INTEGER NOT NULL,
+    updated_at  INTEGER NOT NULL
+);
+
+-- names are unique case-insensitively, ASCII letters only like the other NOCASE columns
+CREATE UNIQUE INDEX uniq_tenant_group_name ON groups (tenant_id, name COLLATE NOCASE);
+
+CREATE TABLE group_members (
+    tenant_id TEXT NOT NULL,
+    group_id  TEXT NOT NULL,
+    kind      TEXT NOT NULL,
+    member_id TEXT NOT NULL,
+    added_at  INTEGER NOT NULL,
+    PRIMARY KEY (tenant_id, group_id, member_id)
+) WITHOUT ROWID;
+
+-- groups of a member, walked up for nested groups
+CREATE INDEX idx_group_members_member ON group_members (tenant_id, member_id);
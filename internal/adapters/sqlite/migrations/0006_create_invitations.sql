@@ -0,0 +1,15 @@
+-- Pending invitations, an email has at most one per organization
+CREATE TABLE invitations (
+    id         TEXT PRIMARY KEY,
+    tenant_id  TEXT NOT NULL,
+    email      TEXT NOT NULL,
+    roles      TEXT, -- JSON array
+    group_ids  TEXT, -- JSON array of group ids
+    invited_by TEXT NOT NULL,
+    token_hash TEXT NOT NULL,
+    expires_at INTEGER NOT NULL,
+    sent_at    INTEGER NOT NULL,
+    created_at INTEGER NOT NULL
+);
+
+CREATE UNIQUE INDEX uniq_tenant_invitation_email ON invitations (tenant_id, email);
@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// compile time check to ensure organizationRepository implements ports.OrganizationRepository
+var _ ports.OrganizationRepository = (*organizationRepository)(nil)
+
+const organizationColumns = "id, slug, name, created_at, updated_at"
+
+// organizationRepository stores the organizations, slugs are unique through an index
+type organizationRepository struct {
+	db *sql.DB
+}
+
+// NewOrganizationRepository returns a repository on a database opened and migrated by Open
+func NewOrganizationRepository(db *sql.DB) *organizationRepository {
+	return &organizationRepository{db: db}
+}
+
+// Create stores the organization, the id is generated unless it has one
+func (r *organizationRepository) Create(ctx context.Context, org *domain.Organization) (*bson.ObjectID, error) {
+	id := org.ID
+	if id.IsZero() {
+		id = bson.NewObjectID()
+	}
+	_, err := r.db.ExecContext(ctx, "INSERT INTO organizations ("+organizationColumns+") VALUES (?, ?, ?, ?, ?)",
+		id.Hex(), org.Slug, org.Name, org.CreatedAt.UnixMilli(), org.UpdatedAt.UnixMilli())
+	if err != nil {
+		return nil, mapWriteError(err)
+	}
+	return &id, nil
+}
+
+func (r *organizationRepository) GetByID(ctx context.Context, id bson.ObjectID) (*domain.Organization, error) {
+	return scanOrganization(r.db.QueryRowContext(ctx, "SELECT "+organizationColumns+" FROM organizations WHERE id = ?", id.Hex()))
+}
+
+func (r *organizationRepository) GetBySlug(ctx context.Context, slug string) (*domain.Organization, error) {
+	return scanOrganization(r.db.QueryRowContext(ctx, "SELECT "+organizationColumns+" FROM organizations WHERE slug = ?", slug))
+}
+
+func (r *organizationRepository) List(ctx context.Context) ([]domain.Organization, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+organizationColumns+" FROM organizations ORDER BY slug")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []domain.Organization{}
+	for rows.Next() {
+		org, err := scanOrganization(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *org)
+	}
+	return results, rows.Err()
+}
+
+func (r *organizationRepository) Update(ctx context.Context, id bson.ObjectID, update *ports.OrganizationUpdate) error {
+	set, args := []string{"updated_at = ?"}, []any{time.Now().UnixMilli()}
+	if update.Name != "" {
+		set, args = append(set, "name = ?"), append(args, update.Name)
+	}
+	if update.Slug != "" {
+		set, args = append(set, "slug = ?"), append(args, update.Slug)
+	}
+	res, err := r.db.ExecContext(ctx, "UPDATE organizations SET "+strings.Join(set, ", ")+" WHERE id = ?", append(args, id.Hex())...)
+	if err != nil {
+		return mapWriteError(err)
+	}
+	return affected(res, nil)
+}
+
+func (r *organizationRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM organizations WHERE id = ?", id.Hex())
+	return affected(res, err)
+}
+
+// scanOrganization reads an organization selected by organizationColumns
+func scanOrganization(row scanner) (*domain.Organization, error) {
+	var (
+		org                  domain.Organization
+		id                   string
+		createdAt, updatedAt sql.NullInt64
+	)
+	if err := row.Scan(&id, &org.Slug, &org.Name, &createdAt, &updatedAt); err != nil {
+		return nil, mapReadError(err)
+	}
+	var err error
+	if org.ID, err = bson.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	org.CreatedAt, org.UpdatedAt = fromMillis(createdAt), fromMillis(updatedAt)
+	return &org, nil
+}
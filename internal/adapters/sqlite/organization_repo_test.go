@@ -0,0 +1,14 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports/porttest"
+)
+
+func TestOrganizationRepository(t *testing.T) {
+	porttest.TestOrganizationRepository(t, func(t *testing.T) ports.OrganizationRepository {
+		return NewOrganizationRepository(openTestDB(t))
+	})
+}
@@ -0,0 +1,117 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// compile time check to ensure settingsRepository implements ports.SettingsRepository
+var _ ports.SettingsRepository = (*settingsRepository)(nil)
+
+// settingsRepository stores one row per user, keyed by the user id and scoped to the
+// organization of the user like the users themselves
+type settingsRepository struct {
+	db *sql.DB
+}
+
+// NewSettingsRepository returns a repository on a database opened and migrated by Open
+func NewSettingsRepository(db *sql.DB) *settingsRepository {
+	return &settingsRepository{db: db}
+}
+
+func (r *settingsRepository) Get(ctx context.Context, userID bson.ObjectID) (map[string]any, error) {
+	return getSettings(ctx, r.db, userID)
+}
+
+// getSettings reads the stored settings of a user, nil if none are stored
+func getSettings(ctx context.Context, q execer, userID bson.ObjectID) (map[string]any, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w.and("user_id = ?", userID.Hex())
+	var stored string
+	err = q.QueryRowContext(ctx, "SELECT settings FROM user_settings WHERE "+w.String(), w.args...).Scan(&stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var settings map[string]any
+	if err := json.Unmarshal([]byte(stored), &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (r *settingsRepository) Replace(ctx context.Context, userID bson.ObjectID, settings map[string]any) error {
+	return putSettings(ctx, r.db, userID, settings)
+}
+
+// putSettings stores exactly settings for the user. Settings of a user of another
+// organization aren't taken over, the write fails with domain.ErrConflict.
+func putSettings(ctx context.Context, exec execer, userID bson.ObjectID, settings map[string]any) error {
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return ports.ErrNoTenant
+	}
+	if settings == nil {
+		settings = map[string]any{}
+	}
+	value, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	res, err := exec.ExecContext(ctx, `INSERT INTO user_settings (user_id, tenant_id, settings, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET settings = excluded.settings, updated_at = excluded.updated_at
+		WHERE user_settings.tenant_id = excluded.tenant_id`,
+		userID.Hex(), tenantID.Hex(), string(value), time.Now().UnixMilli())
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return domain.ErrConflict
+	}
+	return nil
+}
+
+// Update reads and writes the settings in one transaction, which holds the write lock, so
+// concurrent updates of a user don't lose each other's settings
+func (r *settingsRepository) Update(ctx context.Context, userID bson.ObjectID, set map[string]any, unset []string) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		settings, err := getSettings(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if settings == nil {
+			settings = map[string]any{}
+		}
+		for name, value := range set {
+			settings[name] = value
+		}
+		for _, name := range unset {
+			delete(settings, name)
+		}
+		return putSettings(ctx, tx, userID, settings)
+	})
+}
+
+func (r *settingsRepository) Delete(ctx context.Context, userID bson.ObjectID) error {
+	w, err := scoped(ctx)
+	if err != nil {
+		return err
+	}
+	w.and("user_id = ?", userID.Hex())
+	_, err = r.db.ExecContext(ctx, "DELETE FROM user_settings WHERE "+w.String(), w.args...)
+	return err
+}
@@ -0,0 +1,14 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports/porttest"
+)
+
+func TestSettingsRepository(t *testing.T) {
+	porttest.TestSettingsRepository(t, func(t *testing.T) ports.SettingsRepository {
+		return NewSettingsRepository(openTestDB(t))
+	})
+}
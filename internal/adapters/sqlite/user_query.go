@@ -0,0 +1,445 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/filterexpr"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// userColumns are the columns a user is read from, in the order of scanUser
+const userColumns = `id, tenant_id, name, email, username, password, display_name, phone, locale,
+	time_zone, avatar_url, bio, avatar_hash, attributes, status, status_reason, status_changed_at,
+	roles, pending_email, email_revert, previous_usernames, created_at, updated_at`
+
+// emailToken is the stored form of domain.EmailChange and domain.EmailRevert, queried by
+// its JSON keys
+type emailToken struct {
+	Email     string `json:"email"`
+	TokenHash string `json:"token_hash"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// usernameRedirect is the stored form of domain.UsernameRedirect, expires_at is compared
+// in SQL so times are milliseconds like the columns
+type usernameRedirect struct {
+	Username  string `json:"username"`
+	RenamedAt int64  `json:"renamed_at"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// millis is the stored form of a time, NULL for the zero time
+func millis(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UnixMilli()
+}
+
+func fromMillis(ms sql.NullInt64) time.Time {
+	if !ms.Valid {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms.Int64).UTC()
+}
+
+// nullString is NULL for the empty string, for the columns whose uniqueness ignores unset values
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// jsonValue is the JSON of v, NULL when v is nil or empty
+func jsonValue(v any, empty bool) (any, error) {
+	if empty {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func emailTokenValue(email, tokenHash string, expiresAt time.Time) (any, error) {
+	return jsonValue(emailToken{Email: email, TokenHash: tokenHash, ExpiresAt: expiresAt.UnixMilli()}, false)
+}
+
+func redirectsValue(redirects []domain.UsernameRedirect) (any, error) {
+	stored := make([]usernameRedirect, len(redirects))
+	for i, r := range redirects {
+		stored[i] = usernameRedirect{Username: r.Username, RenamedAt: r.RenamedAt.UnixMilli(), ExpiresAt: r.ExpiresAt.UnixMilli()}
+	}
+	return jsonValue(stored, len(stored) == 0)
+}
+
+func decodeRedirects(s sql.NullString) ([]domain.UsernameRedirect, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	var stored []usernameRedirect
+	if err := json.Unmarshal([]byte(s.String), &stored); err != nil {
+		return nil, err
+	}
+	redirects := make([]domain.UsernameRedirect, len(stored))
+	for i, r := range stored {
+		redirects[i] = domain.UsernameRedirect{
+			Username:  r.Username,
+			RenamedAt: time.UnixMilli(r.RenamedAt).UTC(),
+			ExpiresAt: time.UnixMilli(r.ExpiresAt).UTC(),
+		}
+	}
+	return redirects, nil
+}
+
+func decodeJSON(s sql.NullString, v any) error {
+	if !s.Valid {
+		return nil
+	}
+	return json.Unmarshal([]byte(s.String), v)
+}
+
+// userInsert returns the insert columns and values of a user
+func userInsert(user *domain.User) (string, []any, error) {
+	var pending, revert any
+	var err error
+	if p := user.PendingEmail; p != nil {
+		if pending, err = emailTokenValue(p.Email, p.TokenHash, p.ExpiresAt); err != nil {
+			return "", nil, err
+		}
+	}
+	if r := user.EmailRevert; r != nil {
+		if revert, err = emailTokenValue(r.Email, r.TokenHash, r.ExpiresAt); err != nil {
+			return "", nil, err
+		}
+	}
+	attributes, err := jsonValue(user.Attributes, user.Attributes == nil)
+	if err != nil {
+		return "", nil, err
+	}
+	roles, err := jsonValue(user.Roles, len(user.Roles) == 0)
+	if err != nil {
+		return "", nil, err
+	}
+	redirects, err := redirectsValue(user.PreviousUsernames)
+	if err != nil {
+		return "", nil, err
+	}
+
+	columns := userColumns + ", email_domain"
+	return columns, []any{
+		user.ID.Hex(), user.TenantID.Hex(), user.Name, user.Email, nullString(user.Username), user.Password,
+		user.DisplayName, user.Phone, user.Locale, user.TimeZone, user.AvatarURL, user.Bio, user.AvatarHash,
+		attributes, string(user.Status), user.StatusReason, millis(user.StatusChangedAt),
+		roles, pending, revert, redirects, user.CreatedAt.UnixMilli(), user.UpdatedAt.UnixMilli(),
+		emailDomain(user.Email),
+	}, nil
+}
+
+// scanner is a *sql.Row or *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanUser reads a user selected by userColumns
+func scanUser(row scanner) (*domain.User, error) {
+	var (
+		user                                  domain.User
+		id, tenantID                          string
+		username                              sql.NullString
+		attributes, roles, pending, revert    sql.NullString
+		redirects                             sql.NullString
+		statusChangedAt, createdAt, updatedAt sql.NullInt64
+	)
+	err := row.Scan(&id, &tenantID, &user.Name, &user.Email, &username, &user.Password,
+		&user.DisplayName, &user.Phone, &user.Locale, &user.TimeZone, &user.AvatarURL, &user.Bio, &user.AvatarHash,
+		&attributes, &user.Status, &user.StatusReason, &statusChangedAt,
+		&roles, &pending, &revert, &redirects, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.ID, err = bson.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if user.TenantID, err = bson.ObjectIDFromHex(tenantID); err != nil {
+		return nil, err
+	}
+	user.Username = username.String
+	user.StatusChangedAt = fromMillis(statusChangedAt)
+	user.CreatedAt = fromMillis(createdAt)
+	user.UpdatedAt = fromMillis(updatedAt)
+	if err := decodeJSON(attributes, &user.Attributes); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON(roles, &user.Roles); err != nil {
+		return nil, err
+	}
+	if pending.Valid {
+		var t emailToken
+		if err := decodeJSON(pending, &t); err != nil {
+			return nil, err
+		}
+		user.PendingEmail = &domain.EmailChange{Email: t.Email, TokenHash: t.TokenHash, ExpiresAt: time.UnixMilli(t.ExpiresAt).UTC()}
+	}
+	if revert.Valid {
+		var t emailToken
+		if err := decodeJSON(revert, &t); err != nil {
+			return nil, err
+		}
+		user.EmailRevert = &domain.EmailRevert{Email: t.Email, TokenHash: t.TokenHash, ExpiresAt: time.UnixMilli(t.ExpiresAt).UTC()}
+	}
+	if user.PreviousUsernames, err = decodeRedirects(redirects); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// scanUsers reads every row of a query selecting userColumns
+func scanUsers(rows *sql.Rows) ([]domain.User, error) {
+	defer rows.Close()
+	var users []domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+// emailDomain returns the part of an email after the "@"
+func emailDomain(email string) string {
+	_, host, _ := strings.Cut(email, "@")
+	return host
+}
+
+// likePrefix is a LIKE pattern matching strings starting with prefix, escaped with '\'.
+// LIKE ignores ASCII case, like the NOCASE collation of the other string comparisons.
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
+
+// userFilter adds the conditions of a filter to w, nil filter matches every user.
+// String comparisons use the NOCASE collation, SQLite folds ASCII letters only.
+func userFilter(w *where, filter *ports.UserFilter) *where {
+	if filter == nil {
+		return w
+	}
+
+	if filter.NamePrefix != "" {
+		w.and(`name LIKE ? ESCAPE '\'`, likePrefix(filter.NamePrefix))
+	}
+	if filter.EmailDomain != "" {
+		w.and("email_domain = ? COLLATE NOCASE", filter.EmailDomain)
+	}
+	if filter.CreatedAfter != nil {
+		w.and("created_at >= ?", filter.CreatedAfter.UnixMilli())
+	}
+	if filter.CreatedBefore != nil {
+		w.and("created_at < ?", filter.CreatedBefore.UnixMilli())
+	}
+	if filter.Status != "" {
+		w.and("status = ? COLLATE NOCASE", filter.Status)
+	}
+	if filter.Search != "" {
+		pattern := likePrefix(filter.Search)
+		w.and(`name LIKE ? ESCAPE '\' OR email LIKE ? ESCAPE '\'`, pattern, pattern)
+	}
+	if filter.Expr != nil {
+		cond, args := compileExpr(filter.Expr)
+		w.and(cond, args...)
+	}
+	return w
+}
+
+// exprColumns are the columns of the filter expression fields, custom attributes are in
+// the attributes JSON
+var exprColumns = map[string]string{
+	ports.FieldID:        "id",
+	ports.FieldName:      "name",
+	ports.FieldEmail:     "email",
+	ports.FieldCreatedAt: "created_at",
+	ports.FieldUpdatedAt: "updated_at",
+	ports.FieldStatus:    "status",
+}
+
+var sqlOps = map[filterexpr.Op]string{
+	filterexpr.Eq: "=",
+	filterexpr.Ne: "<>",
+	filterexpr.Lt: "<",
+	filterexpr.Le: "<=",
+	filterexpr.Gt: ">",
+	filterexpr.Ge: ">=",
+}
+
+// compileExpr translates a type-checked filter expression into a SQL condition. Values
+// are bound as arguments and columns come from exprColumns, so an expression can't inject
+// SQL. Every condition is true or false, never NULL, so NOT negates it like Match does.
+func compileExpr(expr filterexpr.Expr) (string, []any) {
+	switch e := expr.(type) {
+	case *filterexpr.And:
+		left, leftArgs := compileExpr(e.Left)
+		right, rightArgs := compileExpr(e.Right)
+		return "(" + left + " AND " + right + ")", append(leftArgs, rightArgs...)
+	case *filterexpr.Or:
+		left, leftArgs := compileExpr(e.Left)
+		right, rightArgs := compileExpr(e.Right)
+		return "(" + left + " OR " + right + ")", append(leftArgs, rightArgs...)
+	case *filterexpr.Not:
+		inner, args := compileExpr(e.Expr)
+		return "NOT " + inner, args
+	case *filterexpr.Field:
+		// only custom attributes are boolean
+		if path, ok := attributePath(e.Name); ok {
+			return "coalesce(json_type(attributes, ?) = 'true', 0)", []any{path}
+		}
+	case *filterexpr.Compare:
+		if column, ok := exprColumns[e.Field]; ok {
+			return compareColumn(column, e.Op, e.Value)
+		}
+		if path, ok := attributePath(e.Field); ok {
+			return compareAttribute(path, e.Op, e.Value)
+		}
+	}
+	// unreachable for parsed expressions, match nothing rather than everything
+	return "0", nil
+}
+
+// compareColumn compares a column to a literal of the type of the field
+func compareColumn(column string, op filterexpr.Op, value any) (string, []any) {
+	switch v := value.(type) {
+	case string:
+		if op == filterexpr.Contains {
+			return "instr(lower(" + column + "), lower(?)) > 0", []any{v}
+		}
+		return column + " " + sqlOps[op] + " ? COLLATE NOCASE", []any{v}
+	case time.Time:
+		return column + " " + sqlOps[op] + " ?", []any{v.UnixMilli()}
+	case bson.ObjectID:
+		return column + " " + sqlOps[op] + " ?", []any{v.Hex()}
+	}
+	return "0", nil
+}
+
+// compareAttribute compares a custom attribute to a literal. Values of another JSON type,
+// and missing attributes, are only unequal, like in Match.
+func compareAttribute(path string, op filterexpr.Op, value any) (string, []any) {
+	var types string
+	switch v := value.(type) {
+	case string:
+		types = "'text'"
+	case float64:
+		types = "'integer', 'real'"
+	case bool:
+		types = "'true', 'false'"
+		value = 0
+		if v {
+			value = 1 // json_extract returns booleans as integers
+		}
+	default:
+		// JSON has no times or ids
+		if op == filterexpr.Ne {
+			return "1", nil
+		}
+		return "0", nil
+	}
+
+	typed := "json_type(attributes, ?) IN (" + types + ")"
+	collate := ""
+	if _, ok := value.(string); ok {
+		collate = " COLLATE NOCASE"
+	}
+	switch op {
+	case filterexpr.Contains:
+		return "coalesce(" + typed + " AND instr(lower(json_extract(attributes, ?)), lower(?)) > 0, 0)", []any{path, path, value}
+	case filterexpr.Ne:
+		return "NOT coalesce(" + typed + " AND json_extract(attributes, ?) = ?" + collate + ", 0)", []any{path, path, value}
+	}
+	return "coalesce(" + typed + " AND json_extract(attributes, ?) " + sqlOps[op] + " ?" + collate + ", 0)", []any{path, path, value}
+}
+
+// attributePath returns the JSON path of a custom attribute field, e.g. attributes.team.
+// Attribute names are restricted to [a-z0-9_], and paths are bound as arguments anyway.
+func attributePath(field string) (string, bool) {
+	name, ok := strings.CutPrefix(field, ports.FieldAttributes+".")
+	if !ok || !domain.ValidAttributeName(name) {
+		return "", false
+	}
+	return `$."` + name + `"`, true
+}
+
+// sortColumns are the columns of the sortable fields, strings sort case-insensitively
+// like the listing indexes
+var sortColumns = map[string]string{
+	ports.SortByName:      "name COLLATE NOCASE",
+	ports.SortByEmail:     "email COLLATE NOCASE",
+	ports.SortByCreatedAt: "created_at",
+}
+
+// orderBy returns the ORDER BY of a page, with id as the tiebreak so the order is total
+func orderBy(sort []ports.SortField) string {
+	keys := make([]string, 0, len(sort)+1)
+	for _, field := range sort {
+		key := sortColumns[field.Field]
+		if field.Desc {
+			key += " DESC"
+		}
+		keys = append(keys, key)
+	}
+	return strings.Join(append(keys, "id"), ", ")
+}
+
+// keysetFilter matches users after the cursor in the page order. For sort keys k1..kn it
+// builds (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ..., with < for descending keys.
+func keysetFilter(sort []ports.SortField, after *ports.Cursor) (string, []any) {
+	keys := append(append([]ports.SortField{}, sort...), ports.SortField{Field: ports.FieldID})
+
+	var (
+		or   []string
+		args []any
+	)
+	for i, key := range keys {
+		var and []string
+		for _, prev := range keys[:i] {
+			and = append(and, keyColumn(prev.Field)+" = ?")
+			args = append(args, cursorValue(prev.Field, after))
+		}
+		op := " > ?"
+		if key.Desc {
+			op = " < ?"
+		}
+		and = append(and, keyColumn(key.Field)+op)
+		args = append(args, cursorValue(key.Field, after))
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+	}
+	return strings.Join(or, " OR "), args
+}
+
+func keyColumn(field string) string {
+	if column, ok := sortColumns[field]; ok {
+		return column
+	}
+	return "id"
+}
+
+func cursorValue(field string, cursor *ports.Cursor) any {
+	switch field {
+	case ports.SortByName:
+		return cursor.Name
+	case ports.SortByEmail:
+		return cursor.Email
+	case ports.SortByCreatedAt:
+		return cursor.CreatedAt.UnixMilli()
+	default:
+		return cursor.ID.Hex()
+	}
+}
@@ -0,0 +1,493 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/search"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	sqlitedriver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// compile time check to ensure userRepository implements ports.UserRepository
+var _ ports.UserRepository = (*userRepository)(nil)
+
+// userRepository scopes every query to the organization of its context, see scoped. Writes
+// touching more than the users row run in a transaction, which takes the write lock when
+// it begins, so a read-then-write can't interleave with another write.
+type userRepository struct {
+	db *sql.DB
+}
+
+// NewUserRepository returns a repository on a database opened and migrated by Open
+func NewUserRepository(db *sql.DB) *userRepository {
+	return &userRepository{db: db}
+}
+
+// Create stores the user in the organization of ctx, whatever its TenantID. The id is
+// generated unless the user has one.
+func (r *userRepository) Create(ctx context.Context, user *domain.User) (*bson.ObjectID, error) {
+	tenantID, ok := ports.TenantOf(ctx)
+	if !ok {
+		return nil, ports.ErrNoTenant
+	}
+	stored := *user
+	stored.TenantID = tenantID
+	if stored.ID.IsZero() {
+		stored.ID = bson.NewObjectID()
+	}
+	columns, values, err := userInsert(&stored)
+	if err != nil {
+		return nil, err
+	}
+
+	err = inTx(ctx, r.db, func(tx *sql.Tx) error {
+		query := "INSERT INTO users (" + columns + ") VALUES (?" + strings.Repeat(", ?", len(values)-1) + ")"
+		if _, err := tx.ExecContext(ctx, query, values...); err != nil {
+			return err
+		}
+		return indexTrigrams(ctx, tx, stored.ID, stored.Name, stored.Email)
+	})
+	if err != nil {
+		return nil, mapWriteError(err)
+	}
+	return &stored.ID, nil
+}
+
+// findOne loads the first user matching w in id order, domain.ErrNotFound if there's none
+func (r *userRepository) findOne(ctx context.Context, w *where) (*domain.User, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE "+w.String()+" ORDER BY id LIMIT 1", w.args...)
+	user, err := scanUser(row)
+	if err != nil {
+		return nil, mapReadError(err)
+	}
+	return user, nil
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.findOne(ctx, w.and("email = ?", email))
+}
+
+// GetByUsername looks up the current usernames first, a previous username only matches
+// while its redirect hasn't expired.
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	current, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := r.findOne(ctx, current.and("username = ?", username))
+	if !errors.Is(err, domain.ErrNotFound) {
+		return user, err
+	}
+
+	previous, _ := scoped(ctx)
+	previous.and(`EXISTS (SELECT 1 FROM json_each(users.previous_usernames) AS redirect
+		WHERE json_extract(redirect.value, '$.username') = ? AND json_extract(redirect.value, '$.expires_at') > ?)`,
+		username, time.Now().UnixMilli())
+	return r.findOne(ctx, previous)
+}
+
+// GetByID loads the selected fields of a user, the row is read whole then masked
+func (r *userRepository) GetByID(ctx context.Context, id bson.ObjectID, fields ...string) (*domain.User, error) {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := r.findOne(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+	return project(user, fields), nil
+}
+
+// project masks the fields of user that aren't selected, keeping its id like Mongo
+func project(user *domain.User, fields []string) *domain.User {
+	if len(fields) > 0 {
+		id := user.ID
+		ports.MaskUser(user, fields)
+		user.ID = id
+	}
+	return user
+}
+
+func (r *userRepository) GetAll(ctx context.Context) ([]domain.User, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users WHERE "+w.String()+" ORDER BY id", w.args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanUsers(rows)
+}
+
+// List uses keyset pagination on the sort fields then id, so a page costs the same regardless
+// of its position and concurrent inserts don't shift pages. Strings compare with the NOCASE
+// collation of the listing indexes.
+func (r *userRepository) List(ctx context.Context, filter *ports.UserFilter, pagination *ports.Pagination) ([]domain.User, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userFilter(w, filter)
+	if pagination.After != nil {
+		cond, args := keysetFilter(pagination.Sort, pagination.After)
+		w.and(cond, args...)
+	}
+
+	query := "SELECT " + userColumns + " FROM users WHERE " + w.String() + " ORDER BY " + orderBy(pagination.Sort)
+	args := w.args
+	if pagination.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, pagination.Limit)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		project(&users[i], pagination.Fields)
+	}
+	return users, nil
+}
+
+// Search returns the users sharing the most trigrams with the query, best first. The
+// trigram table is keyed by gram, so typos and partial words still find candidates, the
+// service ranks them precisely.
+func (r *userRepository) Search(ctx context.Context, query string, limit int64) ([]domain.User, error) {
+	grams := search.Trigrams(query)
+	if len(grams) == 0 {
+		return nil, nil
+	}
+	w, err := scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]any, 0, len(grams)+len(w.args)+1)
+	for _, gram := range grams {
+		args = append(args, gram)
+	}
+	args = append(append(args, w.args...), limit)
+	rows, err := r.db.QueryContext(ctx, `SELECT `+userColumns+` FROM users
+		JOIN (SELECT user_id, count(*) AS shared FROM user_trigrams
+			WHERE gram IN (?`+strings.Repeat(", ?", len(grams)-1)+`) GROUP BY user_id) AS overlap
+		ON overlap.user_id = users.id
+		WHERE `+w.String()+`
+		ORDER BY overlap.shared DESC, id LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanUsers(rows)
+}
+
+// indexTrigrams replaces the search trigrams of a user with those of its name and email
+func indexTrigrams(ctx context.Context, tx *sql.Tx, id bson.ObjectID, name, email string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_trigrams WHERE user_id = ?", id.Hex()); err != nil {
+		return err
+	}
+	grams := append(search.Trigrams(name), search.Trigrams(email)...)
+	sort.Strings(grams)
+	grams = slices.Compact(grams)
+	if len(grams) == 0 {
+		return nil
+	}
+
+	args := make([]any, 0, 2*len(grams))
+	for _, gram := range grams {
+		args = append(args, gram, id.Hex())
+	}
+	_, err := tx.ExecContext(ctx, "INSERT INTO user_trigrams (gram, user_id) VALUES (?, ?)"+strings.Repeat(", (?, ?)", len(grams)-1), args...)
+	return err
+}
+
+// reindexTrigrams indexes the trigrams of the stored name and email of a user, after a
+// write changed one of them
+func reindexTrigrams(ctx context.Context, tx *sql.Tx, id bson.ObjectID) error {
+	var name, email string
+	if err := tx.QueryRowContext(ctx, "SELECT name, email FROM users WHERE id = ?", id.Hex()).Scan(&name, &email); err != nil {
+		return err
+	}
+	return indexTrigrams(ctx, tx, id, name, email)
+}
+
+// update runs an UPDATE of the users row matching w, domain.ErrNotFound if there's none
+func update(ctx context.Context, exec execer, set string, setArgs []any, w *where) error {
+	res, err := exec.ExecContext(ctx, "UPDATE users SET "+set+" WHERE "+w.String(), append(setArgs, w.args...)...)
+	if err != nil {
+		return mapWriteError(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// Update applies a partial update, keeping derived columns in sync with the fields they're
+// derived from. domain.ErrNotFound if there's no such user.
+func (r *userRepository) Update(ctx context.Context, id bson.ObjectID, upd *ports.UserUpdate) error {
+	var (
+		set  []string
+		args []any
+	)
+	switch upd.Email.Op {
+	case ports.Set:
+		set = append(set, "email = ?", "email_domain = ?")
+		args = append(args, upd.Email.Value, emailDomain(upd.Email.Value))
+	case ports.Clear:
+		set = append(set, "email = ''", "email_domain = ''")
+	}
+	// profile fields are stored in columns of their names
+	for _, field := range []struct {
+		column string
+		update ports.FieldUpdate[string]
+	}{
+		{ports.FieldName, upd.Name},
+		{ports.FieldDisplayName, upd.DisplayName},
+		{ports.FieldPhone, upd.Phone},
+		{ports.FieldLocale, upd.Locale},
+		{ports.FieldTimeZone, upd.TimeZone},
+		{ports.FieldAvatarURL, upd.AvatarURL},
+		{ports.FieldBio, upd.Bio},
+	} {
+		switch field.update.Op {
+		case ports.Set:
+			set = append(set, field.column+" = ?")
+			args = append(args, field.update.Value)
+		case ports.Clear:
+			set = append(set, field.column+" = ''")
+		}
+	}
+
+	// attributes are edited in place, each edit wrapping the previous ones
+	attributes, changed := "coalesce(attributes, '{}')", false
+	names := make([]string, 0, len(upd.Attributes))
+	for name := range upd.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field := upd.Attributes[name]
+		switch field.Op {
+		case ports.Set:
+			value, err := jsonValue(field.Value, false)
+			if err != nil {
+				return err
+			}
+			attributes, changed = "json_set("+attributes+", ?, json(?))", true
+			args = append(args, `$."`+name+`"`, value)
+		case ports.Clear:
+			attributes, changed = "json_remove("+attributes+", ?)", true
+			args = append(args, `$."`+name+`"`)
+		}
+	}
+	if changed {
+		set = append(set, "attributes = "+attributes)
+	}
+
+	if len(set) == 0 {
+		return errors.New("no fields to update")
+	}
+	set = append(set, "updated_at = ?")
+	args = append(args, time.Now().UnixMilli())
+
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := update(ctx, tx, strings.Join(set, ", "), args, w); err != nil {
+			return err
+		}
+		if upd.Name.Op == ports.Keep && upd.Email.Op == ports.Keep {
+			return nil
+		}
+		return reindexTrigrams(ctx, tx, id)
+	})
+}
+
+func (r *userRepository) SetEmailChange(ctx context.Context, id bson.ObjectID, change *domain.EmailChange, revert *domain.EmailRevert) error {
+	var pending, undo any
+	var err error
+	if change != nil {
+		if pending, err = emailTokenValue(change.Email, change.TokenHash, change.ExpiresAt); err != nil {
+			return err
+		}
+	}
+	if revert != nil {
+		if undo, err = emailTokenValue(revert.Email, revert.TokenHash, revert.ExpiresAt); err != nil {
+			return err
+		}
+	}
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return update(ctx, r.db, "pending_email = ?, email_revert = ?", []any{pending, undo}, w)
+}
+
+// ConfirmEmailChange matches on the token hash in the same update as the swap, so a token
+// can only be used once and a newer request invalidates it.
+func (r *userRepository) ConfirmEmailChange(ctx context.Context, id bson.ObjectID, tokenHash string, email string) error {
+	return r.swapEmail(ctx, id, "pending_email", tokenHash, email, "pending_email = NULL")
+}
+
+func (r *userRepository) RevertEmailChange(ctx context.Context, id bson.ObjectID, tokenHash string, email string) error {
+	return r.swapEmail(ctx, id, "email_revert", tokenHash, email, "pending_email = NULL, email_revert = NULL")
+}
+
+// swapEmail sets the email of a user whose token column still holds the token hash and the
+// email, and clears the token columns
+func (r *userRepository) swapEmail(ctx context.Context, id bson.ObjectID, column, tokenHash, email, clear string) error {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	w.and("json_extract("+column+", '$.token_hash') = ? AND json_extract("+column+", '$.email') = ?", tokenHash, email)
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		err := update(ctx, tx, "email = ?, email_domain = ?, updated_at = ?, "+clear,
+			[]any{email, emailDomain(email), time.Now().UnixMilli()}, w)
+		if err != nil {
+			return err
+		}
+		return reindexTrigrams(ctx, tx, id)
+	})
+}
+
+// SetStatus matches on the current status in the same update, so concurrent transitions
+// can't both apply.
+func (r *userRepository) SetStatus(ctx context.Context, id bson.ObjectID, change *ports.StatusChange) error {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	w.and("status = ?", string(change.From))
+	return update(ctx, r.db, "status = ?, status_reason = ?, status_changed_at = ?",
+		[]any{string(change.To), change.Reason, millis(change.At)}, w)
+}
+
+// SetUsername reads the replaced username and writes the redirects in one transaction, so
+// concurrent renames can't lose a redirect. Reclaiming a previous username drops its redirect.
+func (r *userRepository) SetUsername(ctx context.Context, id bson.ObjectID, username string, redirectUntil time.Time) error {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		var current, stored sql.NullString
+		err := tx.QueryRowContext(ctx, "SELECT username, previous_usernames FROM users WHERE "+w.String(), w.args...).Scan(&current, &stored)
+		if err != nil {
+			return mapReadError(err)
+		}
+		redirects, err := decodeRedirects(stored)
+		if err != nil {
+			return err
+		}
+		redirects = slices.DeleteFunc(redirects, func(redirect domain.UsernameRedirect) bool {
+			return !redirect.ExpiresAt.After(now) || redirect.Username == username
+		})
+		if current.String != "" && current.String != username {
+			redirects = append(redirects, domain.UsernameRedirect{
+				Username:  current.String,
+				RenamedAt: now,
+				ExpiresAt: redirectUntil,
+			})
+		}
+		value, err := redirectsValue(redirects)
+		if err != nil {
+			return err
+		}
+		return update(ctx, tx, "username = ?, previous_usernames = ?, updated_at = ?",
+			[]any{nullString(username), value, now.UnixMilli()}, w)
+	})
+}
+
+func (r *userRepository) SetAvatar(ctx context.Context, id bson.ObjectID, hash string) error {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return update(ctx, r.db, "avatar_hash = ?, updated_at = ?", []any{hash, time.Now().UnixMilli()}, w)
+}
+
+// Delete removes a user, deleting a missing user isn't an error. Its trigrams go with it.
+func (r *userRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	w, err := byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, "DELETE FROM users WHERE "+w.String(), w.args...)
+	return err
+}
+
+func (r *userRepository) Count(ctx context.Context, filter *ports.UserFilter) (int64, error) {
+	w, err := scoped(ctx)
+	if err != nil {
+		return 0, err
+	}
+	userFilter(w, filter)
+	var count int64
+	err = r.db.QueryRowContext(ctx, "SELECT count(*) FROM users WHERE "+w.String(), w.args...).Scan(&count)
+	return count, err
+}
+
+// mapReadError maps a missing row to domain.ErrNotFound
+func mapReadError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrNotFound
+	}
+	return err
+}
+
+// uniqueColumns are the columns of the unique indexes, as named by unique constraint
+// errors. Emails, usernames and slugs are stored canonical, so the indexes are the
+// duplicate checks.
+var uniqueColumns = map[string]error{
+	"users.tenant_id, users.email":             domain.ErrEmailTaken,
+	"users.tenant_id, users.username":          domain.ErrUsernameTaken,
+	"organizations.slug":                       domain.ErrSlugTaken,
+	"groups.tenant_id, groups.name":            domain.ErrGroupTaken,
+	"invitations.tenant_id, invitations.email": domain.ErrInvited,
+}
+
+// uniqueViolation matches the columns named in a unique constraint error, e.g.
+// "UNIQUE constraint failed: users.tenant_id, users.email (2067)"
+var uniqueViolation = regexp.MustCompile(`UNIQUE constraint failed: ([^()]+?) \(`)
+
+// mapWriteError maps unique constraint violations to domain errors
+func mapWriteError(err error) error {
+	var sqliteErr *sqlitedriver.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+	if code := sqliteErr.Code(); code != sqlite3.SQLITE_CONSTRAINT_UNIQUE && code != sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY {
+		return err
+	}
+	if m := uniqueViolation.FindStringSubmatch(sqliteErr.Error()); m != nil {
+		if mapped, ok := uniqueColumns[m[1]]; ok {
+			return mapped
+		}
+	}
+	return domain.ErrConflict
+}
@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// AttributeType is the type of the values of a custom attribute
+type AttributeType string
+
+const (
+	AttributeString AttributeType = "string"
+	AttributeNumber AttributeType = "number"
+	AttributeBool   AttributeType = "bool"
+)
+
+// Attribute limits, attributes are small labels rather than documents
+const (
+	MaxAttributes           = 50
+	MaxAttributeValueLength = 256
+)
+
+var attributeName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// ValidAttributeName reports whether name can name an attribute: lowercase letters, digits
+// and underscores, starting with a letter. Names are used in query paths, so nothing else
+// is allowed.
+func ValidAttributeName(name string) bool {
+	return attributeName.MatchString(name)
+}
+
+// AttributeDefinition is the schema of a custom attribute, managed by admins.
+type AttributeDefinition struct {
+	Name        string        `json:"name" bson:"_id"`
+	Type        AttributeType `json:"type" bson:"type"`
+	Required    bool          `json:"required" bson:"required"`
+	Enum        []string      `json:"enum,omitempty" bson:"enum,omitempty"`       // allowed values of a string attribute
+	Pattern     string        `json:"pattern,omitempty" bson:"pattern,omitempty"` // regexp string values must match in full
+	Description string        `json:"description,omitempty" bson:"description,omitempty"`
+}
+
+// Validate checks the definition itself
+func (d *AttributeDefinition) Validate() error {
+	if !ValidAttributeName(d.Name) {
+		return fmt.Errorf("%w: attribute name %q must be lowercase letters, digits and underscores, starting with a letter", ErrInvalidArgument, d.Name)
+	}
+	switch d.Type {
+	case AttributeString:
+	case AttributeNumber, AttributeBool:
+		if len(d.Enum) > 0 || d.Pattern != "" {
+			return fmt.Errorf("%w: enum and pattern only apply to string attributes", ErrInvalidArgument)
+		}
+	default:
+		return fmt.Errorf("%w: attribute type %q must be one of string, number, bool", ErrInvalidArgument, d.Type)
+	}
+	if d.Pattern != "" {
+		if _, err := regexp.Compile(d.Pattern); err != nil {
+			return fmt.Errorf("%w: attribute pattern: %v", ErrInvalidArgument, err)
+		}
+	}
+	return nil
+}
+
+// check validates a value of the attribute and returns it with numbers as float64
+func (d *AttributeDefinition) check(value any) (any, error) {
+	switch d.Type {
+	case AttributeString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, d.typeError(value)
+		}
+		if len(s) > MaxAttributeValueLength {
+			return nil, fmt.Errorf("%w: attribute %q is longer than %d characters", ErrInvalidArgument, d.Name, MaxAttributeValueLength)
+		}
+		if len(d.Enum) > 0 && !slices.Contains(d.Enum, s) {
+			return nil, fmt.Errorf("%w: attribute %q must be one of %s", ErrInvalidArgument, d.Name, strings.Join(d.Enum, ", "))
+		}
+		if d.Pattern != "" {
+			re, err := regexp.Compile(`^(?:` + d.Pattern + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("attribute %q has an invalid pattern: %w", d.Name, err)
+			}
+			if !re.MatchString(s) {
+				return nil, fmt.Errorf("%w: attribute %q must match %s", ErrInvalidArgument, d.Name, d.Pattern)
+			}
+		}
+		return s, nil
+	case AttributeNumber:
+		n, ok := AttributeNumberValue(value)
+		if !ok {
+			return nil, d.typeError(value)
+		}
+		return n, nil
+	case AttributeBool:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, d.typeError(value)
+		}
+		return b, nil
+	}
+	return nil, d.typeError(value)
+}
+
+func (d *AttributeDefinition) typeError(value any) error {
+	return fmt.Errorf("%w: attribute %q must be a %s, got %T", ErrInvalidArgument, d.Name, d.Type, value)
+}
+
+// AttributeNumberValue converts the numeric types decoders produce to float64
+func AttributeNumberValue(value any) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// AttributeSchema is the set of attributes users can carry
+type AttributeSchema []AttributeDefinition
+
+func (s AttributeSchema) lookup(name string) (*AttributeDefinition, bool) {
+	for i := range s {
+		if s[i].Name == name {
+			return &s[i], true
+		}
+	}
+	return nil, false
+}
+
+func (s AttributeSchema) names() string {
+	names := make([]string, len(s))
+	for i, d := range s {
+		names[i] = d.Name
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// CheckValue validates a value of the named attribute, see ValidateAttributes
+func (s AttributeSchema) CheckValue(name string, value any) (any, error) {
+	def, ok := s.lookup(name)
+	if !ok {
+		if len(s) == 0 {
+			return nil, fmt.Errorf("%w: unknown attribute %q, no attributes are defined", ErrInvalidArgument, name)
+		}
+		return nil, fmt.Errorf("%w: unknown attribute %q, expected one of %s", ErrInvalidArgument, name, s.names())
+	}
+	return def.check(value)
+}
+
+// CheckClear returns an error if the named attribute is required
+func (s AttributeSchema) CheckClear(name string) error {
+	def, ok := s.lookup(name)
+	if !ok {
+		return nil // clearing an attribute removed from the schema cleans up
+	}
+	if def.Required {
+		return fmt.Errorf("%w: attribute %q is required and can't be cleared", ErrInvalidArgument, name)
+	}
+	return nil
+}
+
+// ValidateAttributes checks every attribute is defined with a value of the right type and
+// that required attributes are present. Values are normalized, numbers become float64.
+func (s AttributeSchema) ValidateAttributes(attributes map[string]any) error {
+	if len(attributes) > MaxAttributes {
+		return fmt.Errorf("%w: more than %d attributes", ErrInvalidArgument, MaxAttributes)
+	}
+	for name, value := range attributes {
+		normalized, err := s.CheckValue(name, value)
+		if err != nil {
+			return err
+		}
+		attributes[name] = normalized
+	}
+	for _, def := range s {
+		if _, ok := attributes[def.Name]; def.Required && !ok {
+			return fmt.Errorf("%w: attribute %q is required", ErrInvalidArgument, def.Name)
+		}
+	}
+	return nil
+}
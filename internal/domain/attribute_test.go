@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+var testSchema = AttributeSchema{
+	{Name: "department", Type: AttributeString, Required: true, Enum: []string{"sales", "support"}},
+	{Name: "employee_id", Type: AttributeString, Pattern: `E[0-9]{4}`},
+	{Name: "floor", Type: AttributeNumber},
+	{Name: "remote", Type: AttributeBool},
+}
+
+func TestAttributeDefinition_Validate(t *testing.T) {
+	for _, def := range testSchema {
+		if err := def.Validate(); err != nil {
+			t.Fatalf("%s: unexpected error %v", def.Name, err)
+		}
+	}
+	invalid := map[string]AttributeDefinition{
+		"name":         {Name: "Department", Type: AttributeString},
+		"path name":    {Name: "a.b", Type: AttributeString},
+		"type":         {Name: "department", Type: "date"},
+		"number enum":  {Name: "floor", Type: AttributeNumber, Enum: []string{"1"}},
+		"pattern":      {Name: "employee_id", Type: AttributeString, Pattern: "E[0-9"},
+		"bool pattern": {Name: "remote", Type: AttributeBool, Pattern: "true"},
+	}
+	for name, def := range invalid {
+		if err := def.Validate(); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("%s: expected invalid argument, got %v", name, err)
+		}
+	}
+}
+
+func TestAttributeSchema_ValidateAttributes(t *testing.T) {
+	attributes := map[string]any{"department": "sales", "employee_id": "E0042", "floor": int32(3), "remote": true}
+	if err := testSchema.ValidateAttributes(attributes); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if attributes["floor"] != float64(3) {
+		t.Fatalf("expected numbers normalized to float64, got %T", attributes["floor"])
+	}
+
+	invalid := map[string]map[string]any{
+		"unknown":          {"department": "sales", "team": "a"},
+		"missing required": {"floor": 3.0},
+		"enum":             {"department": "marketing"},
+		"partial pattern":  {"department": "sales", "employee_id": "xE0042"},
+		"type":             {"department": "sales", "remote": "yes"},
+	}
+	for name, attributes := range invalid {
+		if err := testSchema.ValidateAttributes(attributes); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("%s: expected invalid argument, got %v", name, err)
+		}
+	}
+}
+
+func TestAttributeSchema_CheckClear(t *testing.T) {
+	if err := testSchema.CheckClear("department"); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument clearing a required attribute, got %v", err)
+	}
+	for _, name := range []string{"floor", "removed"} {
+		if err := testSchema.CheckClear(name); err != nil {
+			t.Fatalf("%s: unexpected error %v", name, err)
+		}
+	}
+}
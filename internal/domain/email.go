@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// Limits of RFC 5321
+const (
+	maxEmailLength      = 254
+	maxEmailLocalLength = 64
+)
+
+// EmailPolicy configures how emails are canonicalized beyond case and IDNA.
+type EmailPolicy struct {
+	// FoldPlusAddressing drops the "+tag" of the local part, so a+news@x.com and a@x.com are the same account
+	FoldPlusAddressing bool
+	// PlusAddressingDomains restricts folding to these domains, empty folds every domain
+	PlusAddressingDomains []string
+}
+
+// Email is a canonical email address: trimmed, lowercase, with an ASCII (punycode) domain,
+// and plus-addressing folded when the policy asks for it. Two addresses reaching the same
+// mailbox have the same canonical form, so it's safe for lookups and uniqueness.
+type Email string
+
+// ParseEmail validates and canonicalizes an email address.
+func ParseEmail(raw string, policy EmailPolicy) (Email, error) {
+	raw = strings.TrimSpace(raw)
+	at := strings.LastIndexByte(raw, '@')
+	if at <= 0 || at == len(raw)-1 {
+		return "", fmt.Errorf("%w: invalid email %q", ErrInvalidArgument, raw)
+	}
+
+	local := strings.ToLower(raw[:at])
+	if !isDotAtom(local) || len(local) > maxEmailLocalLength {
+		return "", fmt.Errorf("%w: invalid email %q", ErrInvalidArgument, raw)
+	}
+	host, err := CanonicalDomain(raw[at+1:])
+	if err != nil || !strings.Contains(host, ".") {
+		return "", fmt.Errorf("%w: invalid email domain %q", ErrInvalidArgument, raw[at+1:])
+	}
+
+	if policy.FoldPlusAddressing && (len(policy.PlusAddressingDomains) == 0 || slices.Contains(policy.PlusAddressingDomains, host)) {
+		if tag := strings.IndexByte(local, '+'); tag > 0 {
+			local = local[:tag]
+		}
+	}
+
+	email := local + "@" + host
+	if len(email) > maxEmailLength {
+		return "", fmt.Errorf("%w: email is longer than %d characters", ErrInvalidArgument, maxEmailLength)
+	}
+	return Email(email), nil
+}
+
+// CanonicalDomain lowercases a domain and converts internationalized labels to punycode,
+// e.g. "BÜCHER.example" becomes "xn--bcher-kva.example".
+func CanonicalDomain(domain string) (string, error) {
+	ascii, err := idna.Lookup.ToASCII(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(ascii), nil
+}
+
+func (e Email) String() string {
+	return string(e)
+}
+
+// Domain returns the part after the "@"
+func (e Email) Domain() string {
+	return string(e[strings.LastIndexByte(string(e), '@')+1:])
+}
+
+// isDotAtom reports whether s is an unquoted local part: atoms of allowed characters separated by single dots
+func isDotAtom(s string) bool {
+	if s == "" || s[0] == '.' || s[len(s)-1] == '.' || strings.Contains(s, "..") {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case strings.ContainsRune(".!#$%&'*+/=?^_`{|}~-", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// EmailChange is a change of email waiting for the new address to confirm it.
+// Only the hash of the confirmation token is stored.
+type EmailChange struct {
+	Email     string    `bson:"email"` // the new address
+	TokenHash string    `bson:"token_hash"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// EmailRevert lets the previous address undo an email change, before or after it's confirmed.
+type EmailRevert struct {
+	Email     string    `bson:"email"` // the address to restore
+	TokenHash string    `bson:"token_hash"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseEmail(t *testing.T) {
+	tests := []struct {
+		raw    string
+		policy EmailPolicy
+		want   Email
+	}{
+		{raw: " Foo@X.com ", want: "foo@x.com"},
+		{raw: "foo@EXAMPLE.com.", want: "foo@example.com"},
+		{raw: "user@Bücher.example", want: "user@xn--bcher-kva.example"},
+		{raw: "a+news@gmail.com", want: "a+news@gmail.com"},
+		{raw: "A+News@gmail.com", policy: EmailPolicy{FoldPlusAddressing: true}, want: "a@gmail.com"},
+		{raw: "a+news@gmail.com", policy: EmailPolicy{FoldPlusAddressing: true, PlusAddressingDomains: []string{"gmail.com"}}, want: "a@gmail.com"},
+		{raw: "a+news@x.com", policy: EmailPolicy{FoldPlusAddressing: true, PlusAddressingDomains: []string{"gmail.com"}}, want: "a+news@x.com"},
+		{raw: "+news@x.com", policy: EmailPolicy{FoldPlusAddressing: true}, want: "+news@x.com"},
+	}
+	for _, tt := range tests {
+		got, err := ParseEmail(tt.raw, tt.policy)
+		if err != nil {
+			t.Fatalf("ParseEmail(%q): unexpected error %v", tt.raw, err)
+		}
+		if got != tt.want {
+			t.Fatalf("ParseEmail(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseEmail_Invalid(t *testing.T) {
+	for _, raw := range []string{"", "foo", "@x.com", "foo@", "foo@localhost", "a..b@x.com", ".a@x.com", "a b@x.com", `"a"@x.com`, "a@-x-.com"} {
+		if _, err := ParseEmail(raw, EmailPolicy{}); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("ParseEmail(%q): expected invalid argument, got %v", raw, err)
+		}
+	}
+}
+
+func TestEmail_Domain(t *testing.T) {
+	if got := Email("foo@example.com").Domain(); got != "example.com" {
+		t.Fatalf("expected example.com, got %q", got)
+	}
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors shared across layers, transports map them to status codes.
+// Wrap them with fmt.Errorf("%w: ...") to add context.
+var (
+	ErrInvalidArgument = errors.New("invalid argument")
+	ErrConflict        = errors.New("conflict") // the change clashes with existing data, e.g. a taken email
+	ErrNotFound        = errors.New("not found")
+	ErrPrecondition    = errors.New("failed precondition") // the resource isn't in a state allowing the operation
+	ErrForbidden       = errors.New("forbidden")
+	ErrUnavailable     = errors.New("unavailable") // a dependency is failing, the operation may succeed later
+
+	ErrEmailTaken    = fmt.Errorf("%w: email is already registered", ErrConflict)
+	ErrUsernameTaken = fmt.Errorf("%w: username is taken", ErrConflict)
+	ErrSlugTaken     = fmt.Errorf("%w: organization slug is taken", ErrConflict)
+	ErrGroupTaken    = fmt.Errorf("%w: group name is taken", ErrConflict)
+	ErrInvited       = fmt.Errorf("%w: email already has a pending invitation", ErrConflict)
+
+	ErrRegistrationClosed    = fmt.Errorf("%w: registration is closed", ErrForbidden)
+	ErrInviteOnly            = fmt.Errorf("%w: registration is by invitation only", ErrForbidden)
+	ErrEmailDomainNotAllowed = fmt.Errorf("%w: email domain is not allowed to register", ErrForbidden)
+
+	// ErrGroupCycle is a membership making a group a member of itself, directly or through nested groups
+	ErrGroupCycle = fmt.Errorf("%w: membership would make the group a member of itself", ErrPrecondition)
+)
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Group limits
+const (
+	MaxGroupNameLength        = 100
+	MaxGroupDescriptionLength = 500
+	MaxRoleLength             = 32
+)
+
+// Group gathers users and other groups of an organization, members get the roles of the
+// groups they belong to, directly or through nested groups.
+type Group struct {
+	ID          bson.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID    bson.ObjectID `json:"-" bson:"tenant_id,omitempty"` // set by the repository from the context
+	Name        string        `json:"name" bson:"name"`             // unique in the organization, case-insensitively
+	Description string        `json:"description,omitempty" bson:"description,omitempty"`
+	Roles       []string      `json:"roles,omitempty" bson:"roles,omitempty"` // granted to every member, see ParseRole
+	CreatedAt   time.Time     `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at" bson:"updated_at"`
+}
+
+// MemberKind is what a group member is
+type MemberKind string
+
+const (
+	MemberUser  MemberKind = "user"
+	MemberGroup MemberKind = "group"
+)
+
+// GroupMember is a direct member of a group
+type GroupMember struct {
+	Kind    MemberKind    `json:"kind" bson:"kind"`
+	ID      bson.ObjectID `json:"id" bson:"member_id"`
+	AddedAt time.Time     `json:"added_at" bson:"added_at"`
+}
+
+// ParseMemberKind returns the member kind named s
+func ParseMemberKind(s string) (MemberKind, error) {
+	switch kind := MemberKind(s); kind {
+	case MemberUser, MemberGroup:
+		return kind, nil
+	}
+	return "", fmt.Errorf("%w: unknown member kind %q, expected user or group", ErrInvalidArgument, s)
+}
+
+// Canonicalize trims the name and description of a group and checks them and its roles
+func (g *Group) Canonicalize() error {
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Name == "" || len(g.Name) > MaxGroupNameLength {
+		return fmt.Errorf("%w: group name must be 1 to %d characters", ErrInvalidArgument, MaxGroupNameLength)
+	}
+	g.Description = strings.TrimSpace(g.Description)
+	if len(g.Description) > MaxGroupDescriptionLength {
+		return fmt.Errorf("%w: group description must be at most %d characters", ErrInvalidArgument, MaxGroupDescriptionLength)
+	}
+	roles, err := ParseRoles(g.Roles)
+	if err != nil {
+		return err
+	}
+	g.Roles = roles
+	return nil
+}
+
+// ParseRoles parses roles with ParseRole and drops duplicates, nil stays nil
+func ParseRoles(raw []string) ([]string, error) {
+	var roles []string
+	seen := map[string]bool{}
+	for _, r := range raw {
+		role, err := ParseRole(r)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[role] {
+			seen[role] = true
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+// ParseRole validates a role granted through a group and returns its lowercase form. Roles
+// are letters, digits, "_" and "-", so applications can define their own beside RoleAdmin.
+// RoleSuperAdmin spans organizations and is never granted through a group.
+func ParseRole(raw string) (string, error) {
+	role := strings.ToLower(strings.TrimSpace(raw))
+	if role == "" || len(role) > MaxRoleLength {
+		return "", fmt.Errorf("%w: role must be 1 to %d characters", ErrInvalidArgument, MaxRoleLength)
+	}
+	for _, c := range role {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '_', c == '-':
+		default:
+			return "", fmt.Errorf("%w: role %q must be letters, digits, _ and -", ErrInvalidArgument, raw)
+		}
+	}
+	if role == RoleSuperAdmin {
+		return "", fmt.Errorf("%w: %s can't be granted through a group", ErrForbidden, RoleSuperAdmin)
+	}
+	return role, nil
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGroup_Canonicalize(t *testing.T) {
+	group := &Group{Name: " Support ", Description: " Tier 1 ", Roles: []string{"Admin", "support-agent", "admin"}}
+	if err := group.Canonicalize(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if group.Name != "Support" || group.Description != "Tier 1" || !slices.Equal(group.Roles, []string{"admin", "support-agent"}) {
+		t.Fatalf("unexpected group %+v", group)
+	}
+
+	for _, invalid := range []*Group{
+		{Name: " "},
+		{Name: strings.Repeat("a", MaxGroupNameLength+1)},
+		{Name: "Support", Roles: []string{"on call"}},
+		{Name: "Support", Roles: []string{""}},
+	} {
+		if err := invalid.Canonicalize(); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("%+v: expected invalid argument, got %v", invalid, err)
+		}
+	}
+}
+
+func TestParseRole_SuperAdmin(t *testing.T) {
+	if _, err := ParseRole(" SuperAdmin "); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected superadmin to be forbidden, got %v", err)
+	}
+}
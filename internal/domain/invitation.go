@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Invitation is a pending invitation for an email to join an organization. The invitee
+// picks their own password when accepting, the account then gets the roles and groups of
+// the invitation. Accepted and revoked invitations are deleted.
+type Invitation struct {
+	ID        bson.ObjectID   `json:"id" bson:"_id"`
+	TenantID  bson.ObjectID   `json:"-" bson:"tenant_id,omitempty"` // set by the repository from the context
+	Email     string          `json:"email" bson:"email"`           // canonical, one pending invitation per email
+	Roles     []string        `json:"roles,omitempty" bson:"roles,omitempty"`
+	GroupIDs  []bson.ObjectID `json:"group_ids,omitempty" bson:"group_ids,omitempty"`
+	InvitedBy bson.ObjectID   `json:"invited_by" bson:"invited_by"`
+	TokenHash string          `json:"-" bson:"token_hash"` // sha256 of the mailed token, replaced on resend
+	ExpiresAt time.Time       `json:"expires_at" bson:"expires_at"`
+	SentAt    time.Time       `json:"sent_at" bson:"sent_at"`
+	CreatedAt time.Time       `json:"created_at" bson:"created_at"`
+}
+
+// Expired reports whether the invitation can no longer be accepted at now, it can still be resent
+func (i *Invitation) Expired(now time.Time) bool {
+	return !now.Before(i.ExpiresAt)
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Slug limits, a slug is a DNS label so it can name a subdomain
+const (
+	MinSlugLength = 2
+	MaxSlugLength = 63
+)
+
+// ReservedSlugs can't name an organization, they're subdomains of the service itself
+var ReservedSlugs = []string{"admin", "api", "app", "auth", "mail", "static", "www"}
+
+// Organization is a tenant, every user belongs to exactly one and emails and usernames
+// are unique within it.
+type Organization struct {
+	ID        bson.ObjectID `json:"id" bson:"_id,omitempty"`
+	Slug      string        `json:"slug" bson:"slug"` // names the organization in subdomains and the X-Tenant header
+	Name      string        `json:"name" bson:"name"`
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at" bson:"updated_at"`
+}
+
+// ParseSlug validates an organization slug and returns its canonical, lowercase form.
+// Slugs are ASCII letters, digits and "-", and don't start or end with "-".
+func ParseSlug(raw string) (string, error) {
+	slug := strings.ToLower(strings.TrimSpace(raw))
+	if len(slug) < MinSlugLength || len(slug) > MaxSlugLength {
+		return "", fmt.Errorf("%w: slug must be %d to %d characters", ErrInvalidArgument, MinSlugLength, MaxSlugLength)
+	}
+	if !isSlug(slug) {
+		return "", fmt.Errorf("%w: slug %q must be letters, digits and -, not starting or ending with -", ErrInvalidArgument, raw)
+	}
+	if slices.Contains(ReservedSlugs, slug) {
+		return "", fmt.Errorf("%w: slug %q is reserved", ErrInvalidArgument, slug)
+	}
+	return slug, nil
+}
+
+// isSlug reports whether s is a lowercase slug of the allowed charset
+func isSlug(s string) bool {
+	if s[0] == '-' || s[len(s)-1] == '-' {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseSlug(t *testing.T) {
+	tests := map[string]string{
+		"acme":        "acme",
+		" Acme-Corp ": "acme-corp",
+		"42":          "42",
+	}
+	for raw, want := range tests {
+		got, err := ParseSlug(raw)
+		if err != nil || got != want {
+			t.Fatalf("ParseSlug(%q) = %q, %v, want %q", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{
+		"", "a", strings.Repeat("a", 64), // length
+		"-acme", "acme-", "acme_corp", "acme.corp", "acme corp", "acmé", // charset
+		"www", "API", // reserved
+	} {
+		if _, err := ParseSlug(raw); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("ParseSlug(%q): expected invalid argument, got %v", raw, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	_ "time/tzdata" // time zones are validated against the IANA database, hosts may not ship it
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+)
+
+// Profile limits
+const (
+	MaxDisplayNameLength = 64  // runes
+	MaxBioLength         = 500 // runes
+	MaxAvatarURLLength   = 2048
+)
+
+var (
+	e164 = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+	// phoneSeparators are the characters people write in phone numbers, they carry no digits
+	phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+)
+
+// ParseDisplayName trims a display name and checks its length
+func ParseDisplayName(raw string) (string, error) {
+	name := strings.TrimSpace(raw)
+	if utf8.RuneCountInString(name) > MaxDisplayNameLength {
+		return "", fmt.Errorf("%w: display name is longer than %d characters", ErrInvalidArgument, MaxDisplayNameLength)
+	}
+	return name, nil
+}
+
+// ParsePhone returns a phone number in E.164 form, e.g. "+1 (415) 555-2671" gives
+// "+14155552671". Numbers must carry their country code.
+func ParsePhone(raw string) (string, error) {
+	phone := phoneSeparators.Replace(strings.TrimSpace(raw))
+	if !e164.MatchString(phone) {
+		return "", fmt.Errorf("%w: phone %q must be in E.164 format, e.g. +14155552671", ErrInvalidArgument, raw)
+	}
+	return phone, nil
+}
+
+// ParseLocale returns the canonical BCP 47 tag of a locale, e.g. "en_us" gives "en-US"
+func ParseLocale(raw string) (string, error) {
+	tag, err := language.Parse(strings.ReplaceAll(strings.TrimSpace(raw), "_", "-"))
+	if err != nil || tag == language.Und {
+		return "", fmt.Errorf("%w: locale %q must be a BCP 47 tag, e.g. en-US", ErrInvalidArgument, raw)
+	}
+	return tag.String(), nil
+}
+
+// ParseTimeZone checks an IANA time zone name, e.g. "Asia/Bangkok"
+func ParseTimeZone(raw string) (string, error) {
+	tz := strings.TrimSpace(raw)
+	// LoadLocation maps "" and "UTC" to UTC and "Local" to the host zone, only the last isn't a name
+	if _, err := time.LoadLocation(tz); err != nil || tz == "" || tz == "Local" {
+		return "", fmt.Errorf("%w: time zone %q must be an IANA name, e.g. Asia/Bangkok", ErrInvalidArgument, raw)
+	}
+	return tz, nil
+}
+
+// ParseAvatarURL checks an avatar is an absolute http(s) URL
+func ParseAvatarURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", fmt.Errorf("%w: avatar URL must be an absolute http(s) URL", ErrInvalidArgument)
+	}
+	if len(raw) > MaxAvatarURLLength {
+		return "", fmt.Errorf("%w: avatar URL is longer than %d characters", ErrInvalidArgument, MaxAvatarURLLength)
+	}
+	return u.String(), nil
+}
+
+// ParseBio trims a bio and checks its length
+func ParseBio(raw string) (string, error) {
+	bio := strings.TrimSpace(raw)
+	if utf8.RuneCountInString(bio) > MaxBioLength {
+		return "", fmt.Errorf("%w: bio is longer than %d characters", ErrInvalidArgument, MaxBioLength)
+	}
+	return bio, nil
+}
+
+// CanonicalizeProfile validates the profile fields of a user and puts them in canonical
+// form, empty fields are left empty.
+func (u *User) CanonicalizeProfile() error {
+	for _, field := range []struct {
+		value *string
+		parse func(string) (string, error)
+	}{
+		{&u.DisplayName, ParseDisplayName},
+		{&u.Phone, ParsePhone},
+		{&u.Locale, ParseLocale},
+		{&u.TimeZone, ParseTimeZone},
+		{&u.AvatarURL, ParseAvatarURL},
+		{&u.Bio, ParseBio},
+	} {
+		if strings.TrimSpace(*field.value) == "" {
+			*field.value = ""
+			continue
+		}
+		value, err := field.parse(*field.value)
+		if err != nil {
+			return err
+		}
+		*field.value = value
+	}
+	return nil
+}
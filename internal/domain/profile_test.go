@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParsePhone(t *testing.T) {
+	tests := map[string]string{
+		"+14155552671":      "+14155552671",
+		"+1 (415) 555-2671": "+14155552671",
+		" +66 81.234.5678 ": "+66812345678",
+	}
+	for raw, want := range tests {
+		got, err := ParsePhone(raw)
+		if err != nil || got != want {
+			t.Fatalf("ParsePhone(%q) = %q, %v, want %q", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"", "4155552671", "+04155552671", "+1415555267112345", "+1-415-CALL-NOW"} {
+		if _, err := ParsePhone(raw); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("ParsePhone(%q): expected invalid argument, got %v", raw, err)
+		}
+	}
+}
+
+func TestParseLocale(t *testing.T) {
+	tests := map[string]string{"en_us": "en-US", "th-TH": "th-TH", "zh-hant-tw": "zh-Hant-TW"}
+	for raw, want := range tests {
+		got, err := ParseLocale(raw)
+		if err != nil || got != want {
+			t.Fatalf("ParseLocale(%q) = %q, %v, want %q", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"", "und", "english", "en--US"} {
+		if _, err := ParseLocale(raw); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("ParseLocale(%q): expected invalid argument, got %v", raw, err)
+		}
+	}
+}
+
+func TestParseTimeZone(t *testing.T) {
+	for _, tz := range []string{"Asia/Bangkok", "UTC", "America/New_York"} {
+		if _, err := ParseTimeZone(tz); err != nil {
+			t.Fatalf("ParseTimeZone(%q): unexpected error %v", tz, err)
+		}
+	}
+	for _, tz := range []string{"", "Local", "Mars/Olympus", "+07:00"} {
+		if _, err := ParseTimeZone(tz); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("ParseTimeZone(%q): expected invalid argument, got %v", tz, err)
+		}
+	}
+}
+
+func TestParseAvatarURL(t *testing.T) {
+	if _, err := ParseAvatarURL("https://cdn.example.com/a.png"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for _, raw := range []string{"cdn.example.com/a.png", "javascript:alert(1)", "ftp://x.com/a.png", "https://x.com/" + strings.Repeat("a", MaxAvatarURLLength)} {
+		if _, err := ParseAvatarURL(raw); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("ParseAvatarURL(%q): expected invalid argument, got %v", raw, err)
+		}
+	}
+}
+
+func TestUser_CanonicalizeProfile(t *testing.T) {
+	user := &User{Phone: "+1 415 555 2671", Locale: "en_gb", Bio: "  hi  ", DisplayName: "   "}
+	if err := user.CanonicalizeProfile(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if user.Phone != "+14155552671" || user.Locale != "en-GB" || user.Bio != "hi" || user.DisplayName != "" {
+		t.Fatalf("unexpected profile %+v", user)
+	}
+
+	user = &User{Bio: strings.Repeat("é", MaxBioLength+1)}
+	if err := user.CanonicalizeProfile(); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+// RegistrationMode is who can create an account by registering
+type RegistrationMode string
+
+const (
+	RegistrationOpen       RegistrationMode = "open"        // anyone
+	RegistrationInviteOnly RegistrationMode = "invite_only" // only invitees, through the invitation link
+	RegistrationClosed     RegistrationMode = "closed"      // nobody, invitations included
+)
+
+// RegistrationModes lists every registration mode
+var RegistrationModes = []RegistrationMode{RegistrationOpen, RegistrationInviteOnly, RegistrationClosed}
+
+// ParseRegistrationMode returns the registration mode named s
+func ParseRegistrationMode(s string) (RegistrationMode, error) {
+	for _, mode := range RegistrationModes {
+		if string(mode) == s {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown registration mode %q, expected one of %v", ErrInvalidArgument, s, RegistrationModes)
+}
+
+//go:embed disposable_domains.txt
+var disposableDomainList string
+
+// DisposableDomains are the bundled domains of throwaway mailbox providers
+var DisposableDomains = parseDomainList(disposableDomainList)
+
+// RegistrationPolicy configures who can register. Domains match their subdomains too, so
+// "example.com" covers "eng.example.com". The zero value lets anyone register.
+type RegistrationPolicy struct {
+	Mode RegistrationMode // empty is RegistrationOpen
+	// AllowedDomains are the only email domains that can register, empty allows any domain
+	AllowedDomains []string
+	// DeniedDomains can't register, even when allowed
+	DeniedDomains []string
+	// BlockDisposable denies DisposableDomains on top of DeniedDomains
+	BlockDisposable bool
+	// RequireApproval registers accounts as StatusPendingApproval until an admin approves them
+	RequireApproval bool
+}
+
+// CheckMode returns an error unless people can register by themselves, or through an
+// invitation when invited is true.
+func (p RegistrationPolicy) CheckMode(invited bool) error {
+	switch p.Mode {
+	case RegistrationClosed:
+		return ErrRegistrationClosed
+	case RegistrationInviteOnly:
+		if !invited {
+			return ErrInviteOnly
+		}
+	}
+	return nil
+}
+
+// CheckEmail returns ErrEmailDomainNotAllowed when the domain of email can't register
+func (p RegistrationPolicy) CheckEmail(email Email) error {
+	host := email.Domain()
+	if len(p.AllowedDomains) > 0 && !matchesDomain(host, p.AllowedDomains) ||
+		matchesDomain(host, p.DeniedDomains) ||
+		p.BlockDisposable && matchesDomain(host, DisposableDomains) {
+		return fmt.Errorf("%w: %s", ErrEmailDomainNotAllowed, host)
+	}
+	return nil
+}
+
+// matchesDomain reports whether host is one of domains or a subdomain of one
+func matchesDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		domain, err := CanonicalDomain(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+		if err != nil || domain == "" {
+			continue
+		}
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDomainList returns the domains of a list with one domain per line and # comments
+func parseDomainList(list string) []string {
+	var domains []string
+	for _, line := range strings.Split(list, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			domains = append(domains, strings.ToLower(line))
+		}
+	}
+	return domains
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegistrationPolicy_CheckMode(t *testing.T) {
+	tests := []struct {
+		mode    RegistrationMode
+		invited bool
+		want    error
+	}{
+		{"", false, nil},
+		{RegistrationOpen, false, nil},
+		{RegistrationInviteOnly, false, ErrInviteOnly},
+		{RegistrationInviteOnly, true, nil},
+		{RegistrationClosed, false, ErrRegistrationClosed},
+		{RegistrationClosed, true, ErrRegistrationClosed},
+	}
+	for _, tt := range tests {
+		err := RegistrationPolicy{Mode: tt.mode}.CheckMode(tt.invited)
+		if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%q, invited %v: expected %v, got %v", tt.mode, tt.invited, tt.want, err)
+		}
+	}
+}
+
+func TestRegistrationPolicy_CheckEmail(t *testing.T) {
+	policy := RegistrationPolicy{
+		AllowedDomains:  []string{"example.com", "@Example.org"},
+		DeniedDomains:   []string{"contractors.example.com"},
+		BlockDisposable: true,
+	}
+	for email, allowed := range map[Email]bool{
+		"jane@example.com":                 true,
+		"jane@eng.example.com":             true,
+		"jane@example.org":                 true,
+		"jane@example.net":                 false,
+		"jane@notexample.com":              false,
+		"jane@contractors.example.com":     false,
+		"jane@foo.contractors.example.com": false,
+	} {
+		if err := policy.CheckEmail(email); allowed != (err == nil) || err != nil && !errors.Is(err, ErrEmailDomainNotAllowed) {
+			t.Errorf("%s: expected allowed %v, got %v", email, allowed, err)
+		}
+	}
+
+	// disposable domains are denied whatever the allowlist
+	policy = RegistrationPolicy{BlockDisposable: true}
+	if err := policy.CheckEmail("jane@mailinator.com"); !errors.Is(err, ErrEmailDomainNotAllowed) {
+		t.Fatalf("expected disposable domain to be denied, got %v", err)
+	}
+	if err := (RegistrationPolicy{}).CheckEmail("jane@mailinator.com"); err != nil {
+		t.Fatalf("expected disposable domain to be allowed without BlockDisposable, got %v", err)
+	}
+}
+
+func TestDisposableDomains(t *testing.T) {
+	if len(DisposableDomains) == 0 {
+		t.Fatal("expected the bundled disposable domains to load")
+	}
+	for _, domain := range DisposableDomains {
+		if canonical, err := CanonicalDomain(domain); err != nil || canonical != domain {
+			t.Errorf("bundled domain %q isn't canonical", domain)
+		}
+	}
+}
+
+func TestParseRegistrationMode(t *testing.T) {
+	if mode, err := ParseRegistrationMode("invite_only"); err != nil || mode != RegistrationInviteOnly {
+		t.Fatalf("expected invite_only, got %q, %v", mode, err)
+	}
+	if _, err := ParseRegistrationMode("invite"); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument, got %v", err)
+	}
+}
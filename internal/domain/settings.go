@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Settings are the preferences of a user by name, e.g. notification opt-ins and UI theme.
+// Values are strings, float64 numbers and bools.
+type Settings map[string]any
+
+// SettingDefinition describes a setting and its default, settings are defined in config.
+// Settings take the same value types as custom attributes.
+type SettingDefinition struct {
+	Name        string
+	Type        AttributeType
+	Default     any
+	Enum        []string // allowed values of a string setting
+	Description string
+}
+
+// Validate checks the definition and that its default is a valid value, normalizing it
+func (d *SettingDefinition) Validate() error {
+	if !ValidAttributeName(d.Name) {
+		return fmt.Errorf("%w: setting name %q must be lowercase letters, digits and underscores, starting with a letter", ErrInvalidArgument, d.Name)
+	}
+	if d.Type != AttributeString && len(d.Enum) > 0 {
+		return fmt.Errorf("%w: setting %q: enum only applies to string settings", ErrInvalidArgument, d.Name)
+	}
+	value, err := d.check(d.Default)
+	if err != nil {
+		return fmt.Errorf("invalid default: %w", err)
+	}
+	d.Default = value
+	return nil
+}
+
+// check validates a value of the setting and returns it with numbers as float64
+func (d *SettingDefinition) check(value any) (any, error) {
+	switch d.Type {
+	case AttributeString:
+		s, ok := value.(string)
+		if !ok {
+			break
+		}
+		if len(s) > MaxAttributeValueLength {
+			return nil, fmt.Errorf("%w: setting %q is longer than %d characters", ErrInvalidArgument, d.Name, MaxAttributeValueLength)
+		}
+		if len(d.Enum) > 0 && !slices.Contains(d.Enum, s) {
+			return nil, fmt.Errorf("%w: setting %q must be one of %s", ErrInvalidArgument, d.Name, strings.Join(d.Enum, ", "))
+		}
+		return s, nil
+	case AttributeNumber:
+		if n, ok := AttributeNumberValue(value); ok {
+			return n, nil
+		}
+	case AttributeBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	default:
+		return nil, fmt.Errorf("%w: setting %q type %q must be one of string, number, bool", ErrInvalidArgument, d.Name, d.Type)
+	}
+	return nil, fmt.Errorf("%w: setting %q must be a %s, got %T", ErrInvalidArgument, d.Name, d.Type, value)
+}
+
+// SettingsSchema is the set of settings users have
+type SettingsSchema []SettingDefinition
+
+func (s SettingsSchema) lookup(name string) (*SettingDefinition, bool) {
+	for i := range s {
+		if s[i].Name == name {
+			return &s[i], true
+		}
+	}
+	return nil, false
+}
+
+// Defaults returns the settings of a user who changed none
+func (s SettingsSchema) Defaults() Settings {
+	settings := make(Settings, len(s))
+	for _, def := range s {
+		settings[def.Name] = def.Default
+	}
+	return settings
+}
+
+// Resolve returns the defaults overridden by the stored settings of a user. Stored values
+// of settings no longer defined, or no longer valid, are ignored.
+func (s SettingsSchema) Resolve(stored map[string]any) Settings {
+	settings := s.Defaults()
+	for name, value := range stored {
+		def, ok := s.lookup(name)
+		if !ok {
+			continue
+		}
+		if value, err := def.check(value); err == nil {
+			settings[name] = value
+		}
+	}
+	return settings
+}
+
+// CheckName returns an error if no setting is named name
+func (s SettingsSchema) CheckName(name string) error {
+	if _, ok := s.lookup(name); !ok {
+		names := slices.Sorted(maps.Keys(s.Defaults()))
+		return fmt.Errorf("%w: unknown setting %q, expected one of %s", ErrInvalidArgument, name, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// CheckValue validates a value of the named setting and returns it normalized
+func (s SettingsSchema) CheckValue(name string, value any) (any, error) {
+	if err := s.CheckName(name); err != nil {
+		return nil, err
+	}
+	def, _ := s.lookup(name)
+	return def.check(value)
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSettingDefinition_Validate(t *testing.T) {
+	def := SettingDefinition{Name: "page_size", Type: AttributeNumber, Default: 20}
+	if err := def.Validate(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if def.Default != float64(20) {
+		t.Fatalf("expected the default normalized to float64, got %T", def.Default)
+	}
+
+	invalid := map[string]SettingDefinition{
+		"name":         {Name: "Theme", Type: AttributeString, Default: "dark"},
+		"type":         {Name: "theme", Type: "color", Default: "dark"},
+		"no default":   {Name: "theme", Type: AttributeString},
+		"default type": {Name: "notify_email", Type: AttributeBool, Default: "yes"},
+		"default enum": {Name: "theme", Type: AttributeString, Default: "blue", Enum: []string{"light", "dark"}},
+		"bool enum":    {Name: "notify_email", Type: AttributeBool, Default: true, Enum: []string{"true"}},
+	}
+	for name, def := range invalid {
+		if err := def.Validate(); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("%s: expected invalid argument, got %v", name, err)
+		}
+	}
+}
+
+func TestSettingsSchema_Resolve(t *testing.T) {
+	schema := SettingsSchema{
+		{Name: "notify_email", Type: AttributeBool, Default: true},
+		{Name: "theme", Type: AttributeString, Default: "system", Enum: []string{"light", "dark", "system"}},
+	}
+	// values no longer valid or defined fall back to the defaults
+	settings := schema.Resolve(map[string]any{"notify_email": false, "theme": "blue", "font": "serif"})
+	if len(settings) != 2 || settings["notify_email"] != false || settings["theme"] != "system" {
+		t.Fatalf("unexpected settings %v", settings)
+	}
+}
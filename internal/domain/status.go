@@ -0,0 +1,43 @@
+package domain
+
+import "fmt"
+
+// Status is the lifecycle state of an account, only active accounts can log in
+type Status string
+
+const (
+	StatusPendingVerification Status = "pending_verification"
+	StatusPendingApproval     Status = "pending_approval" // registered, until an admin approves it
+	StatusActive              Status = "active"
+	StatusSuspended           Status = "suspended"   // by an admin, e.g. for abuse
+	StatusLocked              Status = "locked"      // by the system, e.g. after failed logins
+	StatusDeactivated         Status = "deactivated" // by the user
+	StatusDeleted             Status = "deleted"     // soft-deleted, final
+)
+
+// Statuses lists every status, in lifecycle order
+var Statuses = []Status{
+	StatusPendingVerification,
+	StatusPendingApproval,
+	StatusActive,
+	StatusSuspended,
+	StatusLocked,
+	StatusDeactivated,
+	StatusDeleted,
+}
+
+// ParseStatus returns the status named s
+func ParseStatus(s string) (Status, error) {
+	for _, status := range Statuses {
+		if string(status) == s {
+			return status, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown status %q, expected one of %v", ErrInvalidArgument, s, Statuses)
+}
+
+// Roles granting extra permissions, stored on the user and carried in tokens
+const (
+	RoleAdmin      = "admin"      // manages the users of their organization
+	RoleSuperAdmin = "superadmin" // manages the organizations themselves
+)
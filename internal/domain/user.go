@@ -9,8 +9,33 @@ import (
 // User represents the user entity in our domain
 type User struct {
 	ID        bson.ObjectID `json:"id" bson:"_id,omitempty" jsonschema:"title=ID,description=User ID"`
+	TenantID  bson.ObjectID `json:"-" bson:"tenant_id,omitempty"` // the organization, set by the repository from the context
 	Name      string        `json:"name" bson:"name" jsonschema:"title=Name,description=User Name,minLength=1"`
 	Email     string        `json:"email" bson:"email" jsonschema:"title=Email,description=User Email,format=email"`
+	Username  string        `json:"username,omitempty" bson:"username,omitempty" jsonschema:"title=Username,description=Optional Unique Handle"`
 	Password  string        `json:"-" bson:"password" jsonschema:"title=Password,description=Bcrypt hash,minLength=8"` // "-" means this field won't be included in JSON responses
 	CreatedAt time.Time     `json:"created_at" bson:"created_at" jsonschema:"title=CreatedAt,description=User Created At"`
+	UpdatedAt time.Time     `json:"updated_at" bson:"updated_at" jsonschema:"title=UpdatedAt,description=User Updated At"`
+
+	// Optional profile, empty when not provided, see CanonicalizeProfile
+	DisplayName string `json:"display_name,omitempty" bson:"display_name,omitempty"`
+	Phone       string `json:"phone,omitempty" bson:"phone,omitempty"`         // E.164
+	Locale      string `json:"locale,omitempty" bson:"locale,omitempty"`       // BCP 47
+	TimeZone    string `json:"time_zone,omitempty" bson:"time_zone,omitempty"` // IANA
+	AvatarURL   string `json:"avatar_url,omitempty" bson:"avatar_url,omitempty"`
+	Bio         string `json:"bio,omitempty" bson:"bio,omitempty"`
+	AvatarHash  string `json:"avatar_hash,omitempty" bson:"avatar_hash,omitempty"` // sha256 of the uploaded avatar, see AvatarService
+
+	// Custom attributes, governed by the AttributeSchema
+	Attributes map[string]any `json:"attributes,omitempty" bson:"attributes,omitempty"`
+
+	Status          Status    `json:"status" bson:"status" jsonschema:"title=Status,description=Account Status"`
+	StatusReason    string    `json:"-" bson:"status_reason,omitempty"` // why the account was last suspended, locked or deleted, internal
+	StatusChangedAt time.Time `json:"status_changed_at,omitzero" bson:"status_changed_at,omitempty"`
+	Roles           []string  `json:"-" bson:"roles,omitempty"`
+
+	PendingEmail *EmailChange `json:"-" bson:"pending_email,omitempty"` // nil unless an email change awaits confirmation
+	EmailRevert  *EmailRevert `json:"-" bson:"email_revert,omitempty"`  // nil unless an email change can be reverted
+
+	PreviousUsernames []UsernameRedirect `json:"-" bson:"previous_usernames,omitempty"` // usernames still redirecting to the user
 }
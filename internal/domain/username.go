@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Username limits
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 30
+)
+
+// ReservedUsernames can't be claimed, they name the service, its staff or its routes
+var ReservedUsernames = []string{
+	"about", "account", "admin", "administrator", "anonymous", "api", "auth", "billing",
+	"help", "login", "logout", "me", "moderator", "null", "official", "owner", "register",
+	"root", "search", "security", "self", "settings", "signup", "staff", "support",
+	"system", "undefined", "user", "users", "www",
+}
+
+// UsernamePolicy configures which usernames can be claimed beyond the charset rules
+type UsernamePolicy struct {
+	// Reserved are reserved on top of ReservedUsernames, e.g. brand names
+	Reserved []string
+}
+
+// UsernameRedirect is a previous username of a user, it keeps pointing to the user and
+// can't be claimed by anyone else until it expires.
+type UsernameRedirect struct {
+	Username  string    `bson:"username"`
+	RenamedAt time.Time `bson:"renamed_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// ParseUsername validates a username and returns its canonical, lowercase form. Usernames
+// are ASCII letters, digits, "_" and "-", start with a letter and don't end with "_" or "-",
+// so they read the same in any font and in a URL.
+func ParseUsername(raw string, policy UsernamePolicy) (string, error) {
+	username := strings.ToLower(strings.TrimSpace(raw))
+	if len(username) < MinUsernameLength || len(username) > MaxUsernameLength {
+		return "", fmt.Errorf("%w: username must be %d to %d characters", ErrInvalidArgument, MinUsernameLength, MaxUsernameLength)
+	}
+	if !isUsername(username) {
+		return "", fmt.Errorf("%w: username %q must be letters, digits, _ and -, starting with a letter and ending with a letter or digit",
+			ErrInvalidArgument, raw)
+	}
+	if slices.Contains(ReservedUsernames, username) || slices.ContainsFunc(policy.Reserved, func(r string) bool {
+		return strings.EqualFold(r, username)
+	}) {
+		return "", fmt.Errorf("%w: username %q is reserved", ErrInvalidArgument, username)
+	}
+	return username, nil
+}
+
+// isUsername reports whether s is a lowercase username of the allowed charset
+func isUsername(s string) bool {
+	if s[0] < 'a' || s[0] > 'z' {
+		return false
+	}
+	if last := s[len(s)-1]; last == '_' || last == '-' {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
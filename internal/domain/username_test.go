@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseUsername(t *testing.T) {
+	tests := map[string]string{
+		"jane":                           "jane",
+		" Jane_Doe ":                     "jane_doe",
+		"j-d-2":                          "j-d-2",
+		"abcdefghijklmnopqrstuvwxyz0123": "abcdefghijklmnopqrstuvwxyz0123",
+	}
+	for raw, want := range tests {
+		got, err := ParseUsername(raw, UsernamePolicy{})
+		if err != nil || got != want {
+			t.Fatalf("ParseUsername(%q) = %q, %v, want %q", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{
+		"", "jd", "abcdefghijklmnopqrstuvwxyz01234", // length
+		"1jane", "_jane", "jane_", "jane-", "jane.doe", "jane doe", "jané", "jаne", // charset, the last has a Cyrillic a
+		"admin", "Support", // reserved
+	} {
+		if _, err := ParseUsername(raw, UsernamePolicy{}); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("ParseUsername(%q): expected invalid argument, got %v", raw, err)
+		}
+	}
+}
+
+func TestParseUsername_Reserved(t *testing.T) {
+	policy := UsernamePolicy{Reserved: []string{"Acme"}}
+	if _, err := ParseUsername("acme", policy); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected acme to be reserved, got %v", err)
+	}
+	if _, err := ParseUsername("acme", UsernamePolicy{}); err != nil {
+		t.Fatalf("expected acme to be valid without the policy, got %v", err)
+	}
+}
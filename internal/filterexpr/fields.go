@@ -0,0 +1,74 @@
+package filterexpr
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+)
+
+// Type is the type of a filterable field
+type Type string
+
+const (
+	String Type = "string"
+	Time   Type = "time"
+	ID     Type = "id"
+	Bool   Type = "bool"
+	Number Type = "number"
+	Any    Type = "any" // custom attributes, a literal compares to values of its own type
+)
+
+type field struct {
+	typ Type
+	get func(u *domain.User) any
+}
+
+// fields whitelists the user fields a filter can reference, anything else is a type error
+var fields = map[string]field{
+	"id":         {typ: ID, get: func(u *domain.User) any { return u.ID }},
+	"name":       {typ: String, get: func(u *domain.User) any { return u.Name }},
+	"email":      {typ: String, get: func(u *domain.User) any { return u.Email }},
+	"created_at": {typ: Time, get: func(u *domain.User) any { return u.CreatedAt }},
+	"updated_at": {typ: Time, get: func(u *domain.User) any { return u.UpdatedAt }},
+	"status":     {typ: String, get: func(u *domain.User) any { return string(u.Status) }},
+}
+
+// attributePrefix addresses custom attributes, e.g. attributes.department
+const attributePrefix = "attributes."
+
+// lookupField returns a whitelisted field or a custom attribute
+func lookupField(name string) (field, bool) {
+	if f, ok := fields[name]; ok {
+		return f, true
+	}
+	attr, ok := strings.CutPrefix(name, attributePrefix)
+	if !ok || !domain.ValidAttributeName(attr) {
+		return field{}, false
+	}
+	return field{typ: Any, get: func(u *domain.User) any {
+		value := u.Attributes[attr]
+		if n, ok := domain.AttributeNumberValue(value); ok {
+			return n
+		}
+		return value
+	}}, true
+}
+
+// Fields returns the filterable fields and their types
+func Fields() map[string]Type {
+	out := make(map[string]Type, len(fields))
+	for name, f := range fields {
+		out[name] = f.typ
+	}
+	return out
+}
+
+func fieldNames() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append(names, attributePrefix+"<name>")
+}
@@ -0,0 +1,212 @@
+// Package filterexpr implements a small, safe filter language over whitelisted user fields.
+//
+//	expr       = or
+//	or         = and { "or" and }
+//	and        = unary { "and" unary }
+//	unary      = "not" unary | "(" expr ")" | comparison | field
+//	comparison = field op value
+//	op         = "=" | "!=" | "<" | "<=" | ">" | ">=" | "~"
+//	value      = "quoted string" | 2025-01-01 | 2025-01-01T10:00:00Z | 42 | true | false
+//
+// e.g. `email ~ "@acme.com" and created_at > 2025-01-01`. A bare field is a boolean test.
+// Custom attributes are fields too, e.g. `attributes.department = "sales"`, the type of the
+// literal decides what the attribute is compared as.
+// String comparisons are case-insensitive, "~" tests that a string contains the value.
+// Expressions are parsed into a typed AST, so there is no way to smuggle raw query operators.
+package filterexpr
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Limits keep a single expression cheap to parse and to run
+const (
+	MaxLength = 1024
+	MaxDepth  = 32
+)
+
+// Expr is a type-checked filter expression
+type Expr interface {
+	// Match evaluates the expression in memory, for repositories that can't compile it
+	Match(u *domain.User) bool
+	// String returns the canonical form of the expression
+	String() string
+}
+
+// And matches when both sides match
+type And struct{ Left, Right Expr }
+
+// Or matches when either side matches
+type Or struct{ Left, Right Expr }
+
+// Not matches when the inner expression doesn't
+type Not struct{ Expr Expr }
+
+// Field matches when the boolean field is true
+type Field struct{ Name string }
+
+// Compare matches when the field compares to the value. Value has the Go type of the
+// field: string, time.Time, bson.ObjectID, bool or float64.
+type Compare struct {
+	Field string
+	Op    Op
+	Value any
+}
+
+// Op is a comparison operator
+type Op string
+
+const (
+	Eq       Op = "="
+	Ne       Op = "!="
+	Lt       Op = "<"
+	Le       Op = "<="
+	Gt       Op = ">"
+	Ge       Op = ">="
+	Contains Op = "~"
+)
+
+// Error is a syntax or type error, Pos is the byte offset in the expression.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("filter: %s at position %d", e.Msg, e.Pos+1)
+}
+
+// Unwrap lets transports map filter errors to a bad request
+func (e *Error) Unwrap() error {
+	return domain.ErrInvalidArgument
+}
+
+func (e *And) Match(u *domain.User) bool { return e.Left.Match(u) && e.Right.Match(u) }
+func (e *Or) Match(u *domain.User) bool  { return e.Left.Match(u) || e.Right.Match(u) }
+func (e *Not) Match(u *domain.User) bool { return !e.Expr.Match(u) }
+func (e *Compare) Match(u *domain.User) bool {
+	f, _ := lookupField(e.Field)
+	return compare(f.get(u), e.Op, e.Value)
+}
+
+func (e *Field) Match(u *domain.User) bool {
+	f, _ := lookupField(e.Name)
+	v, _ := f.get(u).(bool)
+	return v
+}
+
+func (e *And) String() string   { return "(" + e.Left.String() + " and " + e.Right.String() + ")" }
+func (e *Or) String() string    { return "(" + e.Left.String() + " or " + e.Right.String() + ")" }
+func (e *Not) String() string   { return "not " + e.Expr.String() }
+func (e *Field) String() string { return e.Name }
+func (e *Compare) String() string {
+	return e.Field + " " + string(e.Op) + " " + formatValue(e.Value)
+}
+
+// FieldNames returns the fields expr references in order of appearance, each once. A
+// nil expression references none.
+func FieldNames(expr Expr) []string {
+	var names []string
+	add := func(name string) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	var walk func(e Expr)
+	walk = func(e Expr) {
+		switch e := e.(type) {
+		case *And:
+			walk(e.Left)
+			walk(e.Right)
+		case *Or:
+			walk(e.Left)
+			walk(e.Right)
+		case *Not:
+			walk(e.Expr)
+		case *Field:
+			add(e.Name)
+		case *Compare:
+			add(e.Field)
+		}
+	}
+	walk(expr)
+	return names
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case bson.ObjectID:
+		return strconv.Quote(v.Hex())
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// compare applies op to a field value and a literal. Values of different types, only
+// possible for custom attributes, are only unequal, like in Mongo.
+func compare(field any, op Op, value any) bool {
+	var c int
+	switch f := field.(type) {
+	case string:
+		v, ok := value.(string)
+		if !ok {
+			return op == Ne
+		}
+		f, v = strings.ToLower(f), strings.ToLower(v)
+		if op == Contains {
+			return strings.Contains(f, v)
+		}
+		c = strings.Compare(f, v)
+	case time.Time:
+		c = f.Compare(value.(time.Time))
+	case bson.ObjectID:
+		c = strings.Compare(f.Hex(), value.(bson.ObjectID).Hex())
+	case float64:
+		v, ok := value.(float64)
+		if !ok {
+			return op == Ne
+		}
+		c = cmp.Compare(f, v)
+	case bool:
+		v, ok := value.(bool)
+		if !ok {
+			return op == Ne
+		}
+		// only = and != type-check for booleans
+		if f != v {
+			c = 1
+		}
+	default:
+		return op == Ne // missing attribute
+	}
+
+	switch op {
+	case Eq:
+		return c == 0
+	case Ne:
+		return c != 0
+	case Lt:
+		return c < 0
+	case Le:
+		return c <= 0
+	case Gt:
+		return c > 0
+	case Ge:
+		return c >= 0
+	}
+	return false
+}
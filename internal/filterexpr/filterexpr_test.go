@@ -0,0 +1,138 @@
+package filterexpr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestParse_Canonical(t *testing.T) {
+	cases := map[string]string{
+		`email ~ "@acme.com" and created_at > 2025-01-01`:                   `(email ~ "@acme.com" and created_at > 2025-01-01T00:00:00Z)`,
+		`name = "John" or name = "Jane" and not email ~ "x"`:                `(name = "John" or (name = "Jane" and not email ~ "x"))`,
+		`(name = "a" OR name = "b") AND created_at <= 2025-01-01T10:00:00Z`: `((name = "a" or name = "b") and created_at <= 2025-01-01T10:00:00Z)`,
+		`id != "6857e9d3699a3ec29bfac36e"`:                                  `id != "6857e9d3699a3ec29bfac36e"`,
+		`name = "say \"hi\""`:                                               `name = "say \"hi\""`,
+	}
+	for src, want := range cases {
+		expr, err := Parse(src)
+		if err != nil {
+			t.Fatalf("Parse(%q): expected no error, got %v", src, err)
+		}
+		if got := expr.String(); got != want {
+			t.Fatalf("Parse(%q): expected %v, got %v", src, want, got)
+		}
+	}
+}
+
+func TestParse_Empty(t *testing.T) {
+	expr, err := Parse("  ")
+	if err != nil || expr != nil {
+		t.Fatalf("expected nil expression and no error, got %v, %v", expr, err)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	cases := map[string]string{
+		`password = "x"`:                 `unknown field "password"`,
+		`name`:                           `expected an operator after string field "name"`,
+		`name = 42`:                      `expected a string value`,
+		`created_at ~ "2025"`:            `operator ~ needs a string field`,
+		`created_at > 2025-13-01`:        `invalid time`,
+		`id = "nope"`:                    `invalid id`,
+		`name = "a" and`:                 `unexpected end of filter`,
+		`(name = "a"`:                    `expected )`,
+		`name = "a" name = "b"`:          `expected and, or or end of filter`,
+		`name = "unterminated`:           `unterminated string`,
+		`name = {"$ne": null}`:           `unexpected character '{'`,
+		`! name = "a"`:                   `use "not"`,
+		strings.Repeat("not ", 40) + "x": `nested deeper`,
+		`attributes.Dept = "x"`:          `unknown field "attributes.Dept"`,
+		`attributes.level ~ 3`:           `operator ~ needs a string value`,
+		`attributes.vip > true`:          `can't compare to a bool`,
+	}
+	for src, want := range cases {
+		_, err := Parse(src)
+		if err == nil {
+			t.Fatalf("Parse(%q): expected error, got nil", src)
+		}
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("Parse(%q): expected error containing %q, got %v", src, want, err)
+		}
+		if !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Fatalf("Parse(%q): expected invalid argument error, got %v", src, err)
+		}
+	}
+}
+
+func TestExpr_Match(t *testing.T) {
+	user := &domain.User{
+		ID:        bson.NewObjectID(),
+		Name:      "John Doe",
+		Email:     "john@acme.com",
+		CreatedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	cases := map[string]bool{
+		`email ~ "@ACME.com" and created_at > 2025-01-01`: true,
+		`email ~ "@acme.com" and created_at < 2025-01-01`: false,
+		`name = "john doe"`:                      true,
+		`not name = "john doe"`:                  false,
+		`name > "a" and name < "k"`:              true,
+		`name = "x" or created_at >= 2025-03-01`: true,
+		`id = "` + user.ID.Hex() + `"`:           true,
+	}
+	for src, want := range cases {
+		expr, err := Parse(src)
+		if err != nil {
+			t.Fatalf("Parse(%q): expected no error, got %v", src, err)
+		}
+		if got := expr.Match(user); got != want {
+			t.Fatalf("Match(%q): expected %v, got %v", src, want, got)
+		}
+	}
+}
+
+func TestExpr_MatchAttributes(t *testing.T) {
+	user := &domain.User{Attributes: map[string]any{"department": "Sales", "level": int32(3), "vip": true}}
+
+	cases := map[string]bool{
+		`attributes.department = "sales"`: true,
+		`attributes.department ~ "ale"`:   true,
+		`attributes.level >= 3`:           true,
+		`attributes.level = "3"`:          false,
+		`attributes.level != "3"`:         true,
+		`attributes.vip`:                  true,
+		`attributes.vip = false`:          false,
+		`attributes.missing = "x"`:        false,
+		`not attributes.missing = "x"`:    true,
+		`attributes.cohort != "beta"`:     true,
+	}
+	for src, want := range cases {
+		expr, err := Parse(src)
+		if err != nil {
+			t.Fatalf("Parse(%q): expected no error, got %v", src, err)
+		}
+		if got := expr.Match(user); got != want {
+			t.Fatalf("Match(%q): expected %v, got %v", src, want, got)
+		}
+	}
+}
+
+func TestFieldNames(t *testing.T) {
+	expr, err := Parse(`name ~ "jo" and not (email ~ "@acme.com" or name = "x") or attributes.vip`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	got, want := FieldNames(expr), []string{"name", "email", "attributes.vip"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if names := FieldNames(nil); names != nil {
+		t.Fatalf("expected no fields for no expression, got %v", names)
+	}
+}
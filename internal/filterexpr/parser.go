@@ -0,0 +1,305 @@
+package filterexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type tokenKind int
+
+const (
+	tEOF    tokenKind = iota
+	tIdent            // field names and keywords
+	tString           // "quoted"
+	tBare             // unquoted dates and numbers
+	tOp
+	tLParen
+	tRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) describe() string {
+	if t.kind == tEOF {
+		return "end of filter"
+	}
+	return strconv.Quote(t.text)
+}
+
+func (t token) is(keyword string) bool {
+	return t.kind == tIdent && strings.EqualFold(t.text, keyword)
+}
+
+func isKeyword(s string) bool {
+	switch strings.ToLower(s) {
+	case "and", "or", "not", "true", "false":
+		return true
+	}
+	return false
+}
+
+// Parse parses and type-checks a filter expression, an empty expression returns nil.
+func Parse(src string) (Expr, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, nil
+	}
+	if len(src) > MaxLength {
+		return nil, &Error{Pos: MaxLength, Msg: fmt.Sprintf("filter is longer than %d characters", MaxLength)}
+	}
+
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tEOF {
+		return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s, expected and, or or end of filter", tok.describe())}
+	}
+	return expr, nil
+}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			toks = append(toks, token{kind: tLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			toks = append(toks, token{kind: tRParen, text: ")", pos: i})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, &Error{Pos: i, Msg: "unterminated string"}
+			}
+			text, err := strconv.Unquote(src[i : end+1])
+			if err != nil {
+				return nil, &Error{Pos: i, Msg: "invalid string escape"}
+			}
+			toks = append(toks, token{kind: tString, text: text, pos: i})
+			i = end + 1
+		case strings.ContainsRune("=!<>~", rune(c)):
+			op := src[i : i+1]
+			if i+1 < len(src) && src[i+1] == '=' && c != '=' && c != '~' {
+				op = src[i : i+2]
+			}
+			if op == "!" {
+				return nil, &Error{Pos: i, Msg: `unexpected "!", use "not" or "!="`}
+			}
+			toks = append(toks, token{kind: tOp, text: op, pos: i})
+			i += len(op)
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			end := i
+			for end < len(src) && (src[end] == '_' || src[end] == '.' || isAlnum(src[end])) {
+				end++
+			}
+			toks = append(toks, token{kind: tIdent, text: src[i:end], pos: i})
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(src) && (isAlnum(src[end]) || strings.IndexByte(":.+-", src[end]) >= 0) {
+				end++
+			}
+			toks = append(toks, token{kind: tBare, text: src[i:end], pos: i})
+			i = end
+		default:
+			return nil, &Error{Pos: i, Msg: fmt.Sprintf("unexpected character %q", c)}
+		}
+	}
+	return append(toks, token{kind: tEOF, pos: len(src)}), nil
+}
+
+func isAlnum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+type parser struct {
+	toks  []token
+	i     int
+	depth int
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *parser) next() token {
+	tok := p.toks[p.i]
+	if tok.kind != tEOF {
+		p.i++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	tok := p.next()
+	if p.depth++; p.depth > MaxDepth {
+		return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("filter is nested deeper than %d levels", MaxDepth)}
+	}
+	defer func() { p.depth-- }()
+
+	switch {
+	case tok.is("not"):
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: expr}, nil
+
+	case tok.kind == tLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tRParen {
+			return nil, &Error{Pos: closing.pos, Msg: fmt.Sprintf("unexpected %s, expected )", closing.describe())}
+		}
+		return expr, nil
+
+	case tok.kind == tIdent && !isKeyword(tok.text):
+		return p.parseField(tok)
+	}
+	return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s, expected a field, not or (", tok.describe())}
+}
+
+func (p *parser) parseField(name token) (Expr, error) {
+	f, ok := lookupField(name.text)
+	if !ok {
+		return nil, &Error{Pos: name.pos, Msg: fmt.Sprintf("unknown field %q, expected one of %s", name.text, strings.Join(fieldNames(), ", "))}
+	}
+
+	opTok := p.peek()
+	if opTok.kind != tOp {
+		if f.typ != Bool && f.typ != Any {
+			return nil, &Error{Pos: opTok.pos, Msg: fmt.Sprintf("unexpected %s, expected an operator after %s field %q", opTok.describe(), f.typ, name.text)}
+		}
+		return &Field{Name: name.text}, nil
+	}
+	p.next()
+
+	op := Op(opTok.text)
+	switch {
+	case op == Contains && f.typ != String && f.typ != Any:
+		return nil, &Error{Pos: opTok.pos, Msg: fmt.Sprintf("operator ~ needs a string field, %q is a %s", name.text, f.typ)}
+	case f.typ == Bool && op != Eq && op != Ne:
+		return nil, &Error{Pos: opTok.pos, Msg: fmt.Sprintf("operator %s can't compare bool field %q", op, name.text)}
+	}
+
+	valueTok := p.next()
+	value, err := parseValue(f.typ, valueTok)
+	if err != nil {
+		return nil, err
+	}
+	if _, isString := value.(string); f.typ == Any && op == Contains && !isString {
+		return nil, &Error{Pos: valueTok.pos, Msg: fmt.Sprintf("operator ~ needs a string value, got %s", valueTok.describe())}
+	}
+	if _, isBool := value.(bool); f.typ == Any && isBool && op != Eq && op != Ne {
+		return nil, &Error{Pos: opTok.pos, Msg: fmt.Sprintf("operator %s can't compare to a bool", op)}
+	}
+	return &Compare{Field: name.text, Op: op, Value: value}, nil
+}
+
+// parseValue converts a literal to the Go type of the field it's compared to
+func parseValue(typ Type, tok token) (any, error) {
+	mismatch := &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s, expected a %s value", tok.describe(), typ)}
+	switch typ {
+	case String:
+		if tok.kind == tString {
+			return tok.text, nil
+		}
+	case ID:
+		if tok.kind == tString {
+			id, err := bson.ObjectIDFromHex(tok.text)
+			if err != nil {
+				return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("invalid id %s", tok.describe())}
+			}
+			return id, nil
+		}
+	case Time:
+		if tok.kind == tBare || tok.kind == tString {
+			for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+				if t, err := time.Parse(layout, tok.text); err == nil {
+					return t, nil
+				}
+			}
+			return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("invalid time %s, expected YYYY-MM-DD or RFC 3339", tok.describe())}
+		}
+	case Number:
+		if tok.kind == tBare {
+			n, err := strconv.ParseFloat(tok.text, 64)
+			if err != nil {
+				return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("invalid number %s", tok.describe())}
+			}
+			return n, nil
+		}
+	case Bool:
+		if tok.is("true") || tok.is("false") {
+			return strings.EqualFold(tok.text, "true"), nil
+		}
+	case Any:
+		switch {
+		case tok.kind == tString:
+			return parseValue(String, tok)
+		case tok.kind == tBare:
+			return parseValue(Number, tok)
+		case tok.is("true") || tok.is("false"):
+			return parseValue(Bool, tok)
+		}
+		mismatch.Msg = fmt.Sprintf("unexpected %s, expected a string, number or bool value", tok.describe())
+	}
+	return nil, mismatch
+}
@@ -0,0 +1,95 @@
+// Package imaging decodes uploaded images and renders square thumbnails of them.
+//
+// Uploads are sniffed from their content rather than trusted by name or declared type, and
+// their dimensions are checked before decoding so a small file can't expand into a huge
+// bitmap. Thumbnails are re-encoded, which also drops metadata like EXIF locations.
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif" // registers the gif decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // registers the webp decoder
+)
+
+// Supported content types of uploads
+const (
+	JPEG = "image/jpeg"
+	PNG  = "image/png"
+	GIF  = "image/gif"
+	WebP = "image/webp"
+)
+
+// MaxDimension is the largest width or height of an image that is decoded
+const MaxDimension = 8192
+
+// Sniff returns the content type of an image from its first bytes, an error wrapping
+// domain.ErrInvalidArgument if it's not a supported image.
+func Sniff(data []byte) (string, error) {
+	switch contentType := http.DetectContentType(data); contentType {
+	case JPEG, PNG, GIF, WebP:
+		return contentType, nil
+	default:
+		return "", fmt.Errorf("%w: image must be JPEG, PNG, GIF or WebP, got %s", domain.ErrInvalidArgument, contentType)
+	}
+}
+
+// Decode sniffs and decodes an image, rejecting images larger than MaxDimension.
+// GIFs decode to their first frame.
+func Decode(data []byte) (image.Image, string, error) {
+	contentType, err := Sniff(data)
+	if err != nil {
+		return nil, "", err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: invalid image: %v", domain.ErrInvalidArgument, err)
+	}
+	if cfg.Width > MaxDimension || cfg.Height > MaxDimension {
+		return nil, "", fmt.Errorf("%w: image is larger than %dx%d pixels", domain.ErrInvalidArgument, MaxDimension, MaxDimension)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: invalid image: %v", domain.ErrInvalidArgument, err)
+	}
+	return img, contentType, nil
+}
+
+// Thumbnail crops the center square of img and scales it to size x size pixels
+func Thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+	crop := image.Rect(x0, y0, x0+side, y0+side)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, xdraw.Src, nil)
+	return dst
+}
+
+// ThumbnailType is the content type thumbnails of an image of contentType are encoded in:
+// photos stay JPEG, anything that may be transparent becomes PNG.
+func ThumbnailType(contentType string) string {
+	if contentType == JPEG {
+		return JPEG
+	}
+	return PNG
+}
+
+// Encode writes img as contentType, JPEG or PNG
+func Encode(w io.Writer, img image.Image, contentType string) error {
+	if contentType == JPEG {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	}
+	return png.Encode(w, img)
+}
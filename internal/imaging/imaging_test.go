@@ -0,0 +1,73 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := range w {
+		for y := range h {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSniff(t *testing.T) {
+	if contentType, err := Sniff(encodePNG(t, 1, 1)); err != nil || contentType != PNG {
+		t.Fatalf("Sniff(png) = %q, %v", contentType, err)
+	}
+	for _, data := range [][]byte{[]byte("<svg xmlns='http://www.w3.org/2000/svg'/>"), []byte("%PDF-1.7"), nil} {
+		if _, err := Sniff(data); !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Fatalf("Sniff(%q): expected invalid argument, got %v", data, err)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	img, contentType, err := Decode(encodePNG(t, 30, 20))
+	if err != nil || contentType != PNG || img.Bounds().Dx() != 30 {
+		t.Fatalf("Decode = %v, %q, %v", img.Bounds(), contentType, err)
+	}
+
+	// a PNG header claiming a huge image, rejected before decoding any pixel
+	huge := encodePNG(t, 1, 1)
+	binary.BigEndian.PutUint32(huge[16:], 16384) // IHDR width
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+	if _, _, err := Decode(huge); !errors.Is(err, domain.ErrInvalidArgument) || !strings.Contains(err.Error(), "larger") {
+		t.Fatalf("expected invalid argument for a huge image, got %v", err)
+	}
+
+	truncated := encodePNG(t, 10, 10)[:40]
+	if _, _, err := Decode(truncated); !errors.Is(err, domain.ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument for a truncated image, got %v", err)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	img, _, err := Decode(encodePNG(t, 300, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{64, 256} {
+		if b := Thumbnail(img, size).Bounds(); b.Dx() != size || b.Dy() != size {
+			t.Fatalf("Thumbnail(%d) is %v", size, b)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/ports/attribute_port.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	domain "github.com/hinphansa/7-solutions-challenge/internal/domain"
+)
+
+// MockAttributeSchemaRepository is a mock of AttributeSchemaRepository interface.
+type MockAttributeSchemaRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAttributeSchemaRepositoryMockRecorder
+}
+
+// MockAttributeSchemaRepositoryMockRecorder is the mock recorder for MockAttributeSchemaRepository.
+type MockAttributeSchemaRepositoryMockRecorder struct {
+	mock *MockAttributeSchemaRepository
+}
+
+// NewMockAttributeSchemaRepository creates a new mock instance.
+func NewMockAttributeSchemaRepository(ctrl *gomock.Controller) *MockAttributeSchemaRepository {
+	mock := &MockAttributeSchemaRepository{ctrl: ctrl}
+	mock.recorder = &MockAttributeSchemaRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAttributeSchemaRepository) EXPECT() *MockAttributeSchemaRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockAttributeSchemaRepository) Delete(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockAttributeSchemaRepositoryMockRecorder) Delete(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAttributeSchemaRepository)(nil).Delete), ctx, name)
+}
+
+// List mocks base method.
+func (m *MockAttributeSchemaRepository) List(ctx context.Context) (domain.AttributeSchema, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].(domain.AttributeSchema)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAttributeSchemaRepositoryMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAttributeSchemaRepository)(nil).List), ctx)
+}
+
+// Put mocks base method.
+func (m *MockAttributeSchemaRepository) Put(ctx context.Context, def *domain.AttributeDefinition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", ctx, def)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockAttributeSchemaRepositoryMockRecorder) Put(ctx, def interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockAttributeSchemaRepository)(nil).Put), ctx, def)
+}
+
+// MockAttributeService is a mock of AttributeService interface.
+type MockAttributeService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAttributeServiceMockRecorder
+}
+
+// MockAttributeServiceMockRecorder is the mock recorder for MockAttributeService.
+type MockAttributeServiceMockRecorder struct {
+	mock *MockAttributeService
+}
+
+// NewMockAttributeService creates a new mock instance.
+func NewMockAttributeService(ctrl *gomock.Controller) *MockAttributeService {
+	mock := &MockAttributeService{ctrl: ctrl}
+	mock.recorder = &MockAttributeServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAttributeService) EXPECT() *MockAttributeServiceMockRecorder {
+	return m.recorder
+}
+
+// DeleteAttribute mocks base method.
+func (m *MockAttributeService) DeleteAttribute(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAttribute", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAttribute indicates an expected call of DeleteAttribute.
+func (mr *MockAttributeServiceMockRecorder) DeleteAttribute(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAttribute", reflect.TypeOf((*MockAttributeService)(nil).DeleteAttribute), ctx, name)
+}
+
+// ListAttributes mocks base method.
+func (m *MockAttributeService) ListAttributes(ctx context.Context) (domain.AttributeSchema, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAttributes", ctx)
+	ret0, _ := ret[0].(domain.AttributeSchema)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAttributes indicates an expected call of ListAttributes.
+func (mr *MockAttributeServiceMockRecorder) ListAttributes(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAttributes", reflect.TypeOf((*MockAttributeService)(nil).ListAttributes), ctx)
+}
+
+// PutAttribute mocks base method.
+func (m *MockAttributeService) PutAttribute(ctx context.Context, def *domain.AttributeDefinition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutAttribute", ctx, def)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutAttribute indicates an expected call of PutAttribute.
+func (mr *MockAttributeServiceMockRecorder) PutAttribute(ctx, def interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutAttribute", reflect.TypeOf((*MockAttributeService)(nil).PutAttribute), ctx, def)
+}
@@ -0,0 +1,353 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/ports/group_port.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	domain "github.com/hinphansa/7-solutions-challenge/internal/domain"
+	ports "github.com/hinphansa/7-solutions-challenge/internal/ports"
+	bson "go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MockGroupRepository is a mock of GroupRepository interface.
+type MockGroupRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockGroupRepositoryMockRecorder
+}
+
+// MockGroupRepositoryMockRecorder is the mock recorder for MockGroupRepository.
+type MockGroupRepositoryMockRecorder struct {
+	mock *MockGroupRepository
+}
+
+// NewMockGroupRepository creates a new mock instance.
+func NewMockGroupRepository(ctrl *gomock.Controller) *MockGroupRepository {
+	mock := &MockGroupRepository{ctrl: ctrl}
+	mock.recorder = &MockGroupRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGroupRepository) EXPECT() *MockGroupRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddMember mocks base method.
+func (m *MockGroupRepository) AddMember(ctx context.Context, groupID bson.ObjectID, member *domain.GroupMember) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddMember", ctx, groupID, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddMember indicates an expected call of AddMember.
+func (mr *MockGroupRepositoryMockRecorder) AddMember(ctx, groupID, member interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMember", reflect.TypeOf((*MockGroupRepository)(nil).AddMember), ctx, groupID, member)
+}
+
+// Create mocks base method.
+func (m *MockGroupRepository) Create(ctx context.Context, group *domain.Group) (*bson.ObjectID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, group)
+	ret0, _ := ret[0].(*bson.ObjectID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockGroupRepositoryMockRecorder) Create(ctx, group interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockGroupRepository)(nil).Create), ctx, group)
+}
+
+// Delete mocks base method.
+func (m *MockGroupRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockGroupRepositoryMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockGroupRepository)(nil).Delete), ctx, id)
+}
+
+// GetByID mocks base method.
+func (m *MockGroupRepository) GetByID(ctx context.Context, id bson.ObjectID) (*domain.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockGroupRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockGroupRepository)(nil).GetByID), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockGroupRepository) List(ctx context.Context, ids []bson.ObjectID, after *bson.ObjectID, limit int64) ([]domain.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, ids, after, limit)
+	ret0, _ := ret[0].([]domain.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockGroupRepositoryMockRecorder) List(ctx, ids, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockGroupRepository)(nil).List), ctx, ids, after, limit)
+}
+
+// ListMembers mocks base method.
+func (m *MockGroupRepository) ListMembers(ctx context.Context, groupID bson.ObjectID, after *bson.ObjectID, limit int64) ([]domain.GroupMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMembers", ctx, groupID, after, limit)
+	ret0, _ := ret[0].([]domain.GroupMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMembers indicates an expected call of ListMembers.
+func (mr *MockGroupRepositoryMockRecorder) ListMembers(ctx, groupID, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMembers", reflect.TypeOf((*MockGroupRepository)(nil).ListMembers), ctx, groupID, after, limit)
+}
+
+// ParentsOf mocks base method.
+func (m *MockGroupRepository) ParentsOf(ctx context.Context, memberIDs []bson.ObjectID) ([]bson.ObjectID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParentsOf", ctx, memberIDs)
+	ret0, _ := ret[0].([]bson.ObjectID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParentsOf indicates an expected call of ParentsOf.
+func (mr *MockGroupRepositoryMockRecorder) ParentsOf(ctx, memberIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParentsOf", reflect.TypeOf((*MockGroupRepository)(nil).ParentsOf), ctx, memberIDs)
+}
+
+// RemoveFromAll mocks base method.
+func (m *MockGroupRepository) RemoveFromAll(ctx context.Context, memberID bson.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveFromAll", ctx, memberID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveFromAll indicates an expected call of RemoveFromAll.
+func (mr *MockGroupRepositoryMockRecorder) RemoveFromAll(ctx, memberID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFromAll", reflect.TypeOf((*MockGroupRepository)(nil).RemoveFromAll), ctx, memberID)
+}
+
+// RemoveMember mocks base method.
+func (m *MockGroupRepository) RemoveMember(ctx context.Context, groupID, memberID bson.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMember", ctx, groupID, memberID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveMember indicates an expected call of RemoveMember.
+func (mr *MockGroupRepositoryMockRecorder) RemoveMember(ctx, groupID, memberID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMember", reflect.TypeOf((*MockGroupRepository)(nil).RemoveMember), ctx, groupID, memberID)
+}
+
+// Update mocks base method.
+func (m *MockGroupRepository) Update(ctx context.Context, id bson.ObjectID, update *ports.GroupUpdate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, id, update)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockGroupRepositoryMockRecorder) Update(ctx, id, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockGroupRepository)(nil).Update), ctx, id, update)
+}
+
+// MockGroupService is a mock of GroupService interface.
+type MockGroupService struct {
+	ctrl     *gomock.Controller
+	recorder *MockGroupServiceMockRecorder
+}
+
+// MockGroupServiceMockRecorder is the mock recorder for MockGroupService.
+type MockGroupServiceMockRecorder struct {
+	mock *MockGroupService
+}
+
+// NewMockGroupService creates a new mock instance.
+func NewMockGroupService(ctrl *gomock.Controller) *MockGroupService {
+	mock := &MockGroupService{ctrl: ctrl}
+	mock.recorder = &MockGroupServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGroupService) EXPECT() *MockGroupServiceMockRecorder {
+	return m.recorder
+}
+
+// AddMember mocks base method.
+func (m *MockGroupService) AddMember(ctx context.Context, groupID bson.ObjectID, kind domain.MemberKind, memberID bson.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddMember", ctx, groupID, kind, memberID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddMember indicates an expected call of AddMember.
+func (mr *MockGroupServiceMockRecorder) AddMember(ctx, groupID, kind, memberID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMember", reflect.TypeOf((*MockGroupService)(nil).AddMember), ctx, groupID, kind, memberID)
+}
+
+// CreateGroup mocks base method.
+func (m *MockGroupService) CreateGroup(ctx context.Context, group *domain.Group) (*domain.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGroup", ctx, group)
+	ret0, _ := ret[0].(*domain.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateGroup indicates an expected call of CreateGroup.
+func (mr *MockGroupServiceMockRecorder) CreateGroup(ctx, group interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroup", reflect.TypeOf((*MockGroupService)(nil).CreateGroup), ctx, group)
+}
+
+// DeleteGroup mocks base method.
+func (m *MockGroupService) DeleteGroup(ctx context.Context, id bson.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGroup", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGroup indicates an expected call of DeleteGroup.
+func (mr *MockGroupServiceMockRecorder) DeleteGroup(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroup", reflect.TypeOf((*MockGroupService)(nil).DeleteGroup), ctx, id)
+}
+
+// GetGroup mocks base method.
+func (m *MockGroupService) GetGroup(ctx context.Context, id bson.ObjectID) (*domain.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroup", ctx, id)
+	ret0, _ := ret[0].(*domain.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroup indicates an expected call of GetGroup.
+func (mr *MockGroupServiceMockRecorder) GetGroup(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockGroupService)(nil).GetGroup), ctx, id)
+}
+
+// ListGroups mocks base method.
+func (m *MockGroupService) ListGroups(ctx context.Context, req *ports.PageRequest) (*ports.GroupPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroups", ctx, req)
+	ret0, _ := ret[0].(*ports.GroupPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroups indicates an expected call of ListGroups.
+func (mr *MockGroupServiceMockRecorder) ListGroups(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroups", reflect.TypeOf((*MockGroupService)(nil).ListGroups), ctx, req)
+}
+
+// ListMembers mocks base method.
+func (m *MockGroupService) ListMembers(ctx context.Context, groupID bson.ObjectID, req *ports.PageRequest) (*ports.GroupMemberPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMembers", ctx, groupID, req)
+	ret0, _ := ret[0].(*ports.GroupMemberPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMembers indicates an expected call of ListMembers.
+func (mr *MockGroupServiceMockRecorder) ListMembers(ctx, groupID, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMembers", reflect.TypeOf((*MockGroupService)(nil).ListMembers), ctx, groupID, req)
+}
+
+// ListUserGroups mocks base method.
+func (m *MockGroupService) ListUserGroups(ctx context.Context, userID bson.ObjectID, req *ports.PageRequest) (*ports.GroupPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserGroups", ctx, userID, req)
+	ret0, _ := ret[0].(*ports.GroupPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserGroups indicates an expected call of ListUserGroups.
+func (mr *MockGroupServiceMockRecorder) ListUserGroups(ctx, userID, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserGroups", reflect.TypeOf((*MockGroupService)(nil).ListUserGroups), ctx, userID, req)
+}
+
+// RemoveMember mocks base method.
+func (m *MockGroupService) RemoveMember(ctx context.Context, groupID, memberID bson.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMember", ctx, groupID, memberID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveMember indicates an expected call of RemoveMember.
+func (mr *MockGroupServiceMockRecorder) RemoveMember(ctx, groupID, memberID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMember", reflect.TypeOf((*MockGroupService)(nil).RemoveMember), ctx, groupID, memberID)
+}
+
+// RolesOf mocks base method.
+func (m *MockGroupService) RolesOf(ctx context.Context, userID bson.ObjectID) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RolesOf", ctx, userID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RolesOf indicates an expected call of RolesOf.
+func (mr *MockGroupServiceMockRecorder) RolesOf(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RolesOf", reflect.TypeOf((*MockGroupService)(nil).RolesOf), ctx, userID)
+}
+
+// UpdateGroup mocks base method.
+func (m *MockGroupService) UpdateGroup(ctx context.Context, id bson.ObjectID, update *ports.GroupUpdate) (*domain.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateGroup", ctx, id, update)
+	ret0, _ := ret[0].(*domain.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateGroup indicates an expected call of UpdateGroup.
+func (mr *MockGroupServiceMockRecorder) UpdateGroup(ctx, id, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGroup", reflect.TypeOf((*MockGroupService)(nil).UpdateGroup), ctx, id, update)
+}
@@ -0,0 +1,208 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/ports/invitation_port.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	domain "github.com/hinphansa/7-solutions-challenge/internal/domain"
+	ports "github.com/hinphansa/7-solutions-challenge/internal/ports"
+	bson "go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MockInvitationRepository is a mock of InvitationRepository interface.
+type MockInvitationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockInvitationRepositoryMockRecorder
+}
+
+// MockInvitationRepositoryMockRecorder is the mock recorder for MockInvitationRepository.
+type MockInvitationRepositoryMockRecorder struct {
+	mock *MockInvitationRepository
+}
+
+// NewMockInvitationRepository creates a new mock instance.
+func NewMockInvitationRepository(ctrl *gomock.Controller) *MockInvitationRepository {
+	mock := &MockInvitationRepository{ctrl: ctrl}
+	mock.recorder = &MockInvitationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInvitationRepository) EXPECT() *MockInvitationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockInvitationRepository) Create(ctx context.Context, invitation *domain.Invitation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, invitation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockInvitationRepositoryMockRecorder) Create(ctx, invitation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockInvitationRepository)(nil).Create), ctx, invitation)
+}
+
+// Delete mocks base method.
+func (m *MockInvitationRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockInvitationRepositoryMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockInvitationRepository)(nil).Delete), ctx, id)
+}
+
+// GetByID mocks base method.
+func (m *MockInvitationRepository) GetByID(ctx context.Context, id bson.ObjectID) (*domain.Invitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Invitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockInvitationRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockInvitationRepository)(nil).GetByID), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockInvitationRepository) List(ctx context.Context, after *bson.ObjectID, limit int64) ([]domain.Invitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, after, limit)
+	ret0, _ := ret[0].([]domain.Invitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockInvitationRepositoryMockRecorder) List(ctx, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockInvitationRepository)(nil).List), ctx, after, limit)
+}
+
+// Renew mocks base method.
+func (m *MockInvitationRepository) Renew(ctx context.Context, id bson.ObjectID, tokenHash string, expiresAt, sentAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Renew", ctx, id, tokenHash, expiresAt, sentAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Renew indicates an expected call of Renew.
+func (mr *MockInvitationRepositoryMockRecorder) Renew(ctx, id, tokenHash, expiresAt, sentAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Renew", reflect.TypeOf((*MockInvitationRepository)(nil).Renew), ctx, id, tokenHash, expiresAt, sentAt)
+}
+
+// MockInvitationService is a mock of InvitationService interface.
+type MockInvitationService struct {
+	ctrl     *gomock.Controller
+	recorder *MockInvitationServiceMockRecorder
+}
+
+// MockInvitationServiceMockRecorder is the mock recorder for MockInvitationService.
+type MockInvitationServiceMockRecorder struct {
+	mock *MockInvitationService
+}
+
+// NewMockInvitationService creates a new mock instance.
+func NewMockInvitationService(ctrl *gomock.Controller) *MockInvitationService {
+	mock := &MockInvitationService{ctrl: ctrl}
+	mock.recorder = &MockInvitationServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInvitationService) EXPECT() *MockInvitationServiceMockRecorder {
+	return m.recorder
+}
+
+// AcceptInvitation mocks base method.
+func (m *MockInvitationService) AcceptInvitation(ctx context.Context, token string, user *domain.User) (*bson.ObjectID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptInvitation", ctx, token, user)
+	ret0, _ := ret[0].(*bson.ObjectID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptInvitation indicates an expected call of AcceptInvitation.
+func (mr *MockInvitationServiceMockRecorder) AcceptInvitation(ctx, token, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptInvitation", reflect.TypeOf((*MockInvitationService)(nil).AcceptInvitation), ctx, token, user)
+}
+
+// Invite mocks base method.
+func (m *MockInvitationService) Invite(ctx context.Context, invitation *domain.Invitation) (*domain.Invitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Invite", ctx, invitation)
+	ret0, _ := ret[0].(*domain.Invitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Invite indicates an expected call of Invite.
+func (mr *MockInvitationServiceMockRecorder) Invite(ctx, invitation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Invite", reflect.TypeOf((*MockInvitationService)(nil).Invite), ctx, invitation)
+}
+
+// ListInvitations mocks base method.
+func (m *MockInvitationService) ListInvitations(ctx context.Context, req *ports.PageRequest) (*ports.InvitationPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInvitations", ctx, req)
+	ret0, _ := ret[0].(*ports.InvitationPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInvitations indicates an expected call of ListInvitations.
+func (mr *MockInvitationServiceMockRecorder) ListInvitations(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInvitations", reflect.TypeOf((*MockInvitationService)(nil).ListInvitations), ctx, req)
+}
+
+// ResendInvitation mocks base method.
+func (m *MockInvitationService) ResendInvitation(ctx context.Context, id bson.ObjectID) (*domain.Invitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResendInvitation", ctx, id)
+	ret0, _ := ret[0].(*domain.Invitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResendInvitation indicates an expected call of ResendInvitation.
+func (mr *MockInvitationServiceMockRecorder) ResendInvitation(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendInvitation", reflect.TypeOf((*MockInvitationService)(nil).ResendInvitation), ctx, id)
+}
+
+// RevokeInvitation mocks base method.
+func (m *MockInvitationService) RevokeInvitation(ctx context.Context, id bson.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeInvitation", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeInvitation indicates an expected call of RevokeInvitation.
+func (mr *MockInvitationServiceMockRecorder) RevokeInvitation(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeInvitation", reflect.TypeOf((*MockInvitationService)(nil).RevokeInvitation), ctx, id)
+}
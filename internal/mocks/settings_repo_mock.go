@@ -0,0 +1,162 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/ports/settings_port.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	domain "github.com/hinphansa/7-solutions-challenge/internal/domain"
+	bson "go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MockSettingsRepository is a mock of SettingsRepository interface.
+type MockSettingsRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSettingsRepositoryMockRecorder
+}
+
+// MockSettingsRepositoryMockRecorder is the mock recorder for MockSettingsRepository.
+type MockSettingsRepositoryMockRecorder struct {
+	mock *MockSettingsRepository
+}
+
+// NewMockSettingsRepository creates a new mock instance.
+func NewMockSettingsRepository(ctrl *gomock.Controller) *MockSettingsRepository {
+	mock := &MockSettingsRepository{ctrl: ctrl}
+	mock.recorder = &MockSettingsRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSettingsRepository) EXPECT() *MockSettingsRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockSettingsRepository) Delete(ctx context.Context, userID bson.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockSettingsRepositoryMockRecorder) Delete(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSettingsRepository)(nil).Delete), ctx, userID)
+}
+
+// Get mocks base method.
+func (m *MockSettingsRepository) Get(ctx context.Context, userID bson.ObjectID) (map[string]any, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, userID)
+	ret0, _ := ret[0].(map[string]any)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockSettingsRepositoryMockRecorder) Get(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockSettingsRepository)(nil).Get), ctx, userID)
+}
+
+// Replace mocks base method.
+func (m *MockSettingsRepository) Replace(ctx context.Context, userID bson.ObjectID, settings map[string]any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Replace", ctx, userID, settings)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Replace indicates an expected call of Replace.
+func (mr *MockSettingsRepositoryMockRecorder) Replace(ctx, userID, settings interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replace", reflect.TypeOf((*MockSettingsRepository)(nil).Replace), ctx, userID, settings)
+}
+
+// Update mocks base method.
+func (m *MockSettingsRepository) Update(ctx context.Context, userID bson.ObjectID, set map[string]any, unset []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, userID, set, unset)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockSettingsRepositoryMockRecorder) Update(ctx, userID, set, unset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockSettingsRepository)(nil).Update), ctx, userID, set, unset)
+}
+
+// MockSettingsService is a mock of SettingsService interface.
+type MockSettingsService struct {
+	ctrl     *gomock.Controller
+	recorder *MockSettingsServiceMockRecorder
+}
+
+// MockSettingsServiceMockRecorder is the mock recorder for MockSettingsService.
+type MockSettingsServiceMockRecorder struct {
+	mock *MockSettingsService
+}
+
+// NewMockSettingsService creates a new mock instance.
+func NewMockSettingsService(ctrl *gomock.Controller) *MockSettingsService {
+	mock := &MockSettingsService{ctrl: ctrl}
+	mock.recorder = &MockSettingsServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSettingsService) EXPECT() *MockSettingsServiceMockRecorder {
+	return m.recorder
+}
+
+// GetSettings mocks base method.
+func (m *MockSettingsService) GetSettings(ctx context.Context, userID bson.ObjectID) (domain.Settings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSettings", ctx, userID)
+	ret0, _ := ret[0].(domain.Settings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSettings indicates an expected call of GetSettings.
+func (mr *MockSettingsServiceMockRecorder) GetSettings(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSettings", reflect.TypeOf((*MockSettingsService)(nil).GetSettings), ctx, userID)
+}
+
+// ReplaceSettings mocks base method.
+func (m *MockSettingsService) ReplaceSettings(ctx context.Context, userID bson.ObjectID, settings map[string]any) (domain.Settings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceSettings", ctx, userID, settings)
+	ret0, _ := ret[0].(domain.Settings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplaceSettings indicates an expected call of ReplaceSettings.
+func (mr *MockSettingsServiceMockRecorder) ReplaceSettings(ctx, userID, settings interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceSettings", reflect.TypeOf((*MockSettingsService)(nil).ReplaceSettings), ctx, userID, settings)
+}
+
+// UpdateSettings mocks base method.
+func (m *MockSettingsService) UpdateSettings(ctx context.Context, userID bson.ObjectID, patch map[string]any) (domain.Settings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSettings", ctx, userID, patch)
+	ret0, _ := ret[0].(domain.Settings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSettings indicates an expected call of UpdateSettings.
+func (mr *MockSettingsServiceMockRecorder) UpdateSettings(ctx, userID, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSettings", reflect.TypeOf((*MockSettingsService)(nil).UpdateSettings), ctx, userID, patch)
+}
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	domain "github.com/hinphansa/7-solutions-challenge/internal/domain"
@@ -37,19 +38,33 @@ func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ConfirmEmailChange mocks base method.
+func (m *MockUserRepository) ConfirmEmailChange(ctx context.Context, id bson.ObjectID, tokenHash, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmailChange", ctx, id, tokenHash, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmEmailChange indicates an expected call of ConfirmEmailChange.
+func (mr *MockUserRepositoryMockRecorder) ConfirmEmailChange(ctx, id, tokenHash, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailChange", reflect.TypeOf((*MockUserRepository)(nil).ConfirmEmailChange), ctx, id, tokenHash, email)
+}
+
 // Count mocks base method.
-func (m *MockUserRepository) Count(ctx context.Context) (int64, error) {
+func (m *MockUserRepository) Count(ctx context.Context, filter *ports.UserFilter) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Count", ctx)
+	ret := m.ctrl.Call(m, "Count", ctx, filter)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Count indicates an expected call of Count.
-func (mr *MockUserRepositoryMockRecorder) Count(ctx interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) Count(ctx, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockUserRepository)(nil).Count), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockUserRepository)(nil).Count), ctx, filter)
 }
 
 // Create mocks base method.
@@ -112,47 +127,152 @@ func (mr *MockUserRepositoryMockRecorder) GetByEmail(ctx, email interface{}) *go
 }
 
 // GetByID mocks base method.
-func (m *MockUserRepository) GetByID(ctx context.Context, id bson.ObjectID) (*domain.User, error) {
+func (m *MockUserRepository) GetByID(ctx context.Context, id bson.ObjectID, fields ...string) (*domain.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	varargs := []interface{}{ctx, id}
+	for _, a := range fields {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetByID", varargs...)
 	ret0, _ := ret[0].(*domain.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByID indicates an expected call of GetByID.
-func (mr *MockUserRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) GetByID(ctx, id interface{}, fields ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, id}, fields...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserRepository)(nil).GetByID), varargs...)
+}
+
+// GetByUsername mocks base method.
+func (m *MockUserRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUsername", ctx, username)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUsername indicates an expected call of GetByUsername.
+func (mr *MockUserRepositoryMockRecorder) GetByUsername(ctx, username interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserRepository)(nil).GetByID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUsername", reflect.TypeOf((*MockUserRepository)(nil).GetByUsername), ctx, username)
 }
 
 // List mocks base method.
-func (m *MockUserRepository) List(ctx context.Context, pagination *ports.Pagination) ([]domain.User, error) {
+func (m *MockUserRepository) List(ctx context.Context, filter *ports.UserFilter, pagination *ports.Pagination) ([]domain.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "List", ctx, pagination)
+	ret := m.ctrl.Call(m, "List", ctx, filter, pagination)
 	ret0, _ := ret[0].([]domain.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // List indicates an expected call of List.
-func (mr *MockUserRepositoryMockRecorder) List(ctx, pagination interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) List(ctx, filter, pagination interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUserRepository)(nil).List), ctx, filter, pagination)
+}
+
+// RevertEmailChange mocks base method.
+func (m *MockUserRepository) RevertEmailChange(ctx context.Context, id bson.ObjectID, tokenHash, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertEmailChange", ctx, id, tokenHash, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevertEmailChange indicates an expected call of RevertEmailChange.
+func (mr *MockUserRepositoryMockRecorder) RevertEmailChange(ctx, id, tokenHash, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertEmailChange", reflect.TypeOf((*MockUserRepository)(nil).RevertEmailChange), ctx, id, tokenHash, email)
+}
+
+// Search mocks base method.
+func (m *MockUserRepository) Search(ctx context.Context, query string, limit int64) ([]domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, query, limit)
+	ret0, _ := ret[0].([]domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockUserRepositoryMockRecorder) Search(ctx, query, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUserRepository)(nil).List), ctx, pagination)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockUserRepository)(nil).Search), ctx, query, limit)
+}
+
+// SetAvatar mocks base method.
+func (m *MockUserRepository) SetAvatar(ctx context.Context, id bson.ObjectID, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAvatar", ctx, id, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAvatar indicates an expected call of SetAvatar.
+func (mr *MockUserRepositoryMockRecorder) SetAvatar(ctx, id, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAvatar", reflect.TypeOf((*MockUserRepository)(nil).SetAvatar), ctx, id, hash)
+}
+
+// SetEmailChange mocks base method.
+func (m *MockUserRepository) SetEmailChange(ctx context.Context, id bson.ObjectID, change *domain.EmailChange, revert *domain.EmailRevert) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEmailChange", ctx, id, change, revert)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEmailChange indicates an expected call of SetEmailChange.
+func (mr *MockUserRepositoryMockRecorder) SetEmailChange(ctx, id, change, revert interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmailChange", reflect.TypeOf((*MockUserRepository)(nil).SetEmailChange), ctx, id, change, revert)
+}
+
+// SetStatus mocks base method.
+func (m *MockUserRepository) SetStatus(ctx context.Context, id bson.ObjectID, change *ports.StatusChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStatus", ctx, id, change)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStatus indicates an expected call of SetStatus.
+func (mr *MockUserRepositoryMockRecorder) SetStatus(ctx, id, change interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatus", reflect.TypeOf((*MockUserRepository)(nil).SetStatus), ctx, id, change)
+}
+
+// SetUsername mocks base method.
+func (m *MockUserRepository) SetUsername(ctx context.Context, id bson.ObjectID, username string, redirectUntil time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUsername", ctx, id, username, redirectUntil)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUsername indicates an expected call of SetUsername.
+func (mr *MockUserRepositoryMockRecorder) SetUsername(ctx, id, username, redirectUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUsername", reflect.TypeOf((*MockUserRepository)(nil).SetUsername), ctx, id, username, redirectUntil)
 }
 
 // Update mocks base method.
-func (m *MockUserRepository) Update(ctx context.Context, id bson.ObjectID, user *domain.User) error {
+func (m *MockUserRepository) Update(ctx context.Context, id bson.ObjectID, update *ports.UserUpdate) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", ctx, id, user)
+	ret := m.ctrl.Call(m, "Update", ctx, id, update)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockUserRepositoryMockRecorder) Update(ctx, id, user interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) Update(ctx, id, update interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserRepository)(nil).Update), ctx, id, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserRepository)(nil).Update), ctx, id, update)
 }
 
 // MockUserService is a mock of UserService interface.
@@ -178,6 +298,65 @@ func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
 	return m.recorder
 }
 
+// ApproveRegistration mocks base method.
+func (m *MockUserService) ApproveRegistration(ctx context.Context, id bson.ObjectID) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveRegistration", ctx, id)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApproveRegistration indicates an expected call of ApproveRegistration.
+func (mr *MockUserServiceMockRecorder) ApproveRegistration(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveRegistration", reflect.TypeOf((*MockUserService)(nil).ApproveRegistration), ctx, id)
+}
+
+// ChangeStatus mocks base method.
+func (m *MockUserService) ChangeStatus(ctx context.Context, id bson.ObjectID, status domain.Status, reason string) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeStatus", ctx, id, status, reason)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeStatus indicates an expected call of ChangeStatus.
+func (mr *MockUserServiceMockRecorder) ChangeStatus(ctx, id, status, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeStatus", reflect.TypeOf((*MockUserService)(nil).ChangeStatus), ctx, id, status, reason)
+}
+
+// CheckUsername mocks base method.
+func (m *MockUserService) CheckUsername(ctx context.Context, username string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckUsername", ctx, username)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckUsername indicates an expected call of CheckUsername.
+func (mr *MockUserServiceMockRecorder) CheckUsername(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckUsername", reflect.TypeOf((*MockUserService)(nil).CheckUsername), ctx, username)
+}
+
+// ConfirmEmailChange mocks base method.
+func (m *MockUserService) ConfirmEmailChange(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmailChange", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmEmailChange indicates an expected call of ConfirmEmailChange.
+func (mr *MockUserServiceMockRecorder) ConfirmEmailChange(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailChange", reflect.TypeOf((*MockUserService)(nil).ConfirmEmailChange), ctx, token)
+}
+
 // Count mocks base method.
 func (m *MockUserService) Count(ctx context.Context) (int64, error) {
 	m.ctrl.T.Helper()
@@ -223,33 +402,74 @@ func (mr *MockUserServiceMockRecorder) GetAll(ctx interface{}) *gomock.Call {
 }
 
 // GetByID mocks base method.
-func (m *MockUserService) GetByID(ctx context.Context, id bson.ObjectID) (*domain.User, error) {
+func (m *MockUserService) GetByID(ctx context.Context, id bson.ObjectID, fields ...string) (*domain.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	varargs := []interface{}{ctx, id}
+	for _, a := range fields {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetByID", varargs...)
 	ret0, _ := ret[0].(*domain.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByID indicates an expected call of GetByID.
-func (mr *MockUserServiceMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) GetByID(ctx, id interface{}, fields ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, id}, fields...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserService)(nil).GetByID), varargs...)
+}
+
+// GetByUsername mocks base method.
+func (m *MockUserService) GetByUsername(ctx context.Context, username string, fields ...string) (*domain.User, string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, username}
+	for _, a := range fields {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetByUsername", varargs...)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetByUsername indicates an expected call of GetByUsername.
+func (mr *MockUserServiceMockRecorder) GetByUsername(ctx, username interface{}, fields ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserService)(nil).GetByID), ctx, id)
+	varargs := append([]interface{}{ctx, username}, fields...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUsername", reflect.TypeOf((*MockUserService)(nil).GetByUsername), varargs...)
 }
 
 // List mocks base method.
-func (m *MockUserService) List(ctx context.Context, pagination *ports.Pagination) ([]domain.User, error) {
+func (m *MockUserService) List(ctx context.Context, req *ports.ListRequest) (*ports.UserPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "List", ctx, pagination)
-	ret0, _ := ret[0].([]domain.User)
+	ret := m.ctrl.Call(m, "List", ctx, req)
+	ret0, _ := ret[0].(*ports.UserPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // List indicates an expected call of List.
-func (mr *MockUserServiceMockRecorder) List(ctx, pagination interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) List(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUserService)(nil).List), ctx, req)
+}
+
+// ListPendingRegistrations mocks base method.
+func (m *MockUserService) ListPendingRegistrations(ctx context.Context, req *ports.PageRequest) (*ports.UserPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingRegistrations", ctx, req)
+	ret0, _ := ret[0].(*ports.UserPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingRegistrations indicates an expected call of ListPendingRegistrations.
+func (mr *MockUserServiceMockRecorder) ListPendingRegistrations(ctx, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUserService)(nil).List), ctx, pagination)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingRegistrations", reflect.TypeOf((*MockUserService)(nil).ListPendingRegistrations), ctx, req)
 }
 
 // Register mocks base method.
@@ -267,18 +487,75 @@ func (mr *MockUserServiceMockRecorder) Register(ctx, user interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockUserService)(nil).Register), ctx, user)
 }
 
+// RejectRegistration mocks base method.
+func (m *MockUserService) RejectRegistration(ctx context.Context, id bson.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectRegistration", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RejectRegistration indicates an expected call of RejectRegistration.
+func (mr *MockUserServiceMockRecorder) RejectRegistration(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectRegistration", reflect.TypeOf((*MockUserService)(nil).RejectRegistration), ctx, id)
+}
+
+// RequestEmailChange mocks base method.
+func (m *MockUserService) RequestEmailChange(ctx context.Context, id bson.ObjectID, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestEmailChange", ctx, id, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestEmailChange indicates an expected call of RequestEmailChange.
+func (mr *MockUserServiceMockRecorder) RequestEmailChange(ctx, id, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestEmailChange", reflect.TypeOf((*MockUserService)(nil).RequestEmailChange), ctx, id, email)
+}
+
+// RevertEmailChange mocks base method.
+func (m *MockUserService) RevertEmailChange(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertEmailChange", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevertEmailChange indicates an expected call of RevertEmailChange.
+func (mr *MockUserServiceMockRecorder) RevertEmailChange(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertEmailChange", reflect.TypeOf((*MockUserService)(nil).RevertEmailChange), ctx, token)
+}
+
+// Search mocks base method.
+func (m *MockUserService) Search(ctx context.Context, req *ports.SearchRequest) ([]ports.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, req)
+	ret0, _ := ret[0].([]ports.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockUserServiceMockRecorder) Search(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockUserService)(nil).Search), ctx, req)
+}
+
 // Update mocks base method.
-func (m *MockUserService) Update(ctx context.Context, id bson.ObjectID, user *domain.User) error {
+func (m *MockUserService) Update(ctx context.Context, id bson.ObjectID, update *ports.UserUpdate) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", ctx, id, user)
+	ret := m.ctrl.Call(m, "Update", ctx, id, update)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockUserServiceMockRecorder) Update(ctx, id, user interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) Update(ctx, id, update interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserService)(nil).Update), ctx, id, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserService)(nil).Update), ctx, id, update)
 }
 
 // MockAuthService is a mock of AuthService interface.
@@ -304,6 +581,20 @@ func (m *MockAuthService) EXPECT() *MockAuthServiceMockRecorder {
 	return m.recorder
 }
 
+// CheckActive mocks base method.
+func (m *MockAuthService) CheckActive(ctx context.Context, id bson.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckActive", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckActive indicates an expected call of CheckActive.
+func (mr *MockAuthServiceMockRecorder) CheckActive(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckActive", reflect.TypeOf((*MockAuthService)(nil).CheckActive), ctx, id)
+}
+
 // Login mocks base method.
 func (m *MockAuthService) Login(ctx context.Context, email, password string) (string, error) {
 	m.ctrl.T.Helper()
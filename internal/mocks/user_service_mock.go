@@ -87,16 +87,16 @@ func (m *MockTokenGenerator) EXPECT() *MockTokenGeneratorMockRecorder {
 }
 
 // Generate mocks base method.
-func (m *MockTokenGenerator) Generate(id bson.ObjectID, email string) (string, error) {
+func (m *MockTokenGenerator) Generate(id, tenantID bson.ObjectID, email string, roles []string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Generate", id, email)
+	ret := m.ctrl.Call(m, "Generate", id, tenantID, email, roles)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Generate indicates an expected call of Generate.
-func (mr *MockTokenGeneratorMockRecorder) Generate(id, email interface{}) *gomock.Call {
+func (mr *MockTokenGeneratorMockRecorder) Generate(id, tenantID, email, roles interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Generate", reflect.TypeOf((*MockTokenGenerator)(nil).Generate), id, email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Generate", reflect.TypeOf((*MockTokenGenerator)(nil).Generate), id, tenantID, email, roles)
 }
@@ -0,0 +1,24 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+)
+
+type AttributeSchemaRepository interface {
+	// List returns every attribute definition, sorted by name
+	List(ctx context.Context) (domain.AttributeSchema, error)
+	// Put creates or replaces the definition with the same name
+	Put(ctx context.Context, def *domain.AttributeDefinition) error
+	// Delete removes a definition, domain.ErrNotFound if there's none
+	Delete(ctx context.Context, name string) error
+}
+
+// AttributeService manages the schema of custom user attributes, for admins
+type AttributeService interface {
+	ListAttributes(ctx context.Context) (domain.AttributeSchema, error)
+	PutAttribute(ctx context.Context, def *domain.AttributeDefinition) error
+	// DeleteAttribute removes a definition, users keep their values until they clear them
+	DeleteAttribute(ctx context.Context, name string) error
+}
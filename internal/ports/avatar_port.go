@@ -0,0 +1,24 @@
+package ports
+
+import (
+	"context"
+	"io"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Avatar is a thumbnail of the avatar of a user being read
+type Avatar struct {
+	Blob
+	ETag string // changes with the image and the size
+}
+
+// AvatarService stores uploaded avatars as thumbnails of standard sizes
+type AvatarService interface {
+	// UploadAvatar sniffs, validates and resizes the image in r and makes it the avatar of
+	// the user. It returns the content hash identifying the image.
+	UploadAvatar(ctx context.Context, userID bson.ObjectID, r io.Reader) (string, error)
+	// OpenAvatar opens the thumbnail of the avatar of the user of the smallest standard size
+	// at least size, the largest if size is bigger, domain.ErrNotFound without an avatar.
+	OpenAvatar(ctx context.Context, userID bson.ObjectID, size int) (*Avatar, error)
+}
@@ -0,0 +1,27 @@
+package ports
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Blob is a stored object being read, the caller closes Body
+type Blob struct {
+	Body        io.ReadCloser
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// BlobStore stores opaque objects by key, keys are slash separated paths like "avatars/ab/cd.png"
+type BlobStore interface {
+	// Put stores size bytes of r under key, replacing any object with the same key
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key, domain.ErrNotFound if there's none
+	Get(ctx context.Context, key string) (*Blob, error)
+	// Exists reports whether an object is stored under key
+	Exists(ctx context.Context, key string) (bool, error)
+	// Delete removes the object under key, it's not an error if there's none
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,96 @@
+package ports
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+)
+
+// Fields clients can select with sparse fieldsets and read masks, named as in responses
+const (
+	FieldID        = "id"
+	FieldName      = "name"
+	FieldEmail     = "email"
+	FieldUsername  = "username"
+	FieldCreatedAt = "created_at"
+	FieldStatus    = "status"
+	FieldUpdatedAt = "updated_at"
+
+	FieldDisplayName = "display_name"
+	FieldPhone       = "phone"
+	FieldLocale      = "locale"
+	FieldTimeZone    = "time_zone"
+	FieldAvatarURL   = "avatar_url"
+	FieldBio         = "bio"
+	FieldAvatarHash  = "avatar_hash"
+
+	FieldAttributes = "attributes" // a single attribute is addressed as attributes.<name>
+)
+
+var selectableFields = []string{
+	FieldID, FieldName, FieldEmail, FieldUsername, FieldCreatedAt, FieldStatus, FieldUpdatedAt,
+	FieldDisplayName, FieldPhone, FieldLocale, FieldTimeZone, FieldAvatarURL, FieldBio,
+	FieldAvatarHash, FieldAttributes,
+}
+
+// ParseFields validates a list of selected fields and removes duplicates, an empty list
+// selects every field and returns nil.
+func ParseFields(paths []string) ([]string, error) {
+	var fields []string
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if !slices.Contains(selectableFields, path) {
+			return nil, fmt.Errorf("%w: unknown field %q, expected one of %s",
+				domain.ErrInvalidArgument, path, strings.Join(selectableFields, ", "))
+		}
+		if !slices.Contains(fields, path) {
+			fields = append(fields, path)
+		}
+	}
+	return fields, nil
+}
+
+// MaskUser clears the fields of user that aren't selected, nil fields selects every field.
+func MaskUser(user *domain.User, fields []string) {
+	if fields == nil {
+		return
+	}
+	masked := domain.User{}
+	for _, field := range fields {
+		switch field {
+		case FieldID:
+			masked.ID = user.ID
+		case FieldName:
+			masked.Name = user.Name
+		case FieldEmail:
+			masked.Email = user.Email
+		case FieldUsername:
+			masked.Username = user.Username
+		case FieldCreatedAt:
+			masked.CreatedAt = user.CreatedAt
+		case FieldStatus:
+			masked.Status = user.Status
+		case FieldUpdatedAt:
+			masked.UpdatedAt = user.UpdatedAt
+		case FieldDisplayName:
+			masked.DisplayName = user.DisplayName
+		case FieldPhone:
+			masked.Phone = user.Phone
+		case FieldLocale:
+			masked.Locale = user.Locale
+		case FieldTimeZone:
+			masked.TimeZone = user.TimeZone
+		case FieldAvatarURL:
+			masked.AvatarURL = user.AvatarURL
+		case FieldBio:
+			masked.Bio = user.Bio
+		case FieldAvatarHash:
+			masked.AvatarHash = user.AvatarHash
+		case FieldAttributes:
+			masked.Attributes = user.Attributes
+		}
+	}
+	*user = masked
+}
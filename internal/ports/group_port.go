@@ -0,0 +1,81 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// GroupRepository stores the groups of the organization of the context and their direct
+// members, nesting is resolved by services.
+type GroupRepository interface {
+	// Create stores a new group, domain.ErrGroupTaken when another one has the name
+	Create(ctx context.Context, group *domain.Group) (*bson.ObjectID, error)
+	GetByID(ctx context.Context, id bson.ObjectID) (*domain.Group, error)
+	// List returns up to limit groups with an id greater than after, sorted by id. Nil ids
+	// lists every group, otherwise only those with the ids. A zero limit returns every group.
+	List(ctx context.Context, ids []bson.ObjectID, after *bson.ObjectID, limit int64) ([]domain.Group, error)
+	// Update applies a partial update, domain.ErrNotFound if there's no such group
+	Update(ctx context.Context, id bson.ObjectID, update *GroupUpdate) error
+	// Delete removes a group along with its memberships, as a group and as a member,
+	// domain.ErrNotFound if there's none
+	Delete(ctx context.Context, id bson.ObjectID) error
+
+	// AddMember adds a direct member, domain.ErrConflict if it already is one. Repositories
+	// serializing the additions refuse a group closing a cycle with domain.ErrGroupCycle.
+	AddMember(ctx context.Context, groupID bson.ObjectID, member *domain.GroupMember) error
+	// RemoveMember removes a direct member, domain.ErrNotFound if it isn't one
+	RemoveMember(ctx context.Context, groupID, memberID bson.ObjectID) error
+	// RemoveFromAll removes a member from every group it directly belongs to
+	RemoveFromAll(ctx context.Context, memberID bson.ObjectID) error
+	// ListMembers returns up to limit direct members with an id greater than after, sorted by id
+	ListMembers(ctx context.Context, groupID bson.ObjectID, after *bson.ObjectID, limit int64) ([]domain.GroupMember, error)
+	// ParentsOf returns the groups having any of memberIDs as a direct member
+	ParentsOf(ctx context.Context, memberIDs []bson.ObjectID) ([]bson.ObjectID, error)
+}
+
+// GroupUpdate is a partial update of a group, the name can't be cleared
+type GroupUpdate struct {
+	Name        FieldUpdate[string]
+	Description FieldUpdate[string]
+	Roles       FieldUpdate[[]string]
+}
+
+// PageRequest is a page of a list ordered by id
+type PageRequest struct {
+	PageSize  int64  // 0 means the server default
+	PageToken string // opaque token from a previous page, empty for the first page
+}
+
+// GroupPage is a single page of groups
+type GroupPage struct {
+	Groups        []domain.Group `json:"groups"`
+	NextPageToken string         `json:"next_page_token,omitempty"` // empty on the last page
+}
+
+// GroupMemberPage is a single page of the direct members of a group
+type GroupMemberPage struct {
+	Members       []domain.GroupMember `json:"members"`
+	NextPageToken string               `json:"next_page_token,omitempty"` // empty on the last page
+}
+
+// GroupService manages groups and their members, for admins
+type GroupService interface {
+	CreateGroup(ctx context.Context, group *domain.Group) (*domain.Group, error)
+	GetGroup(ctx context.Context, id bson.ObjectID) (*domain.Group, error)
+	ListGroups(ctx context.Context, req *PageRequest) (*GroupPage, error)
+	UpdateGroup(ctx context.Context, id bson.ObjectID, update *GroupUpdate) (*domain.Group, error)
+	DeleteGroup(ctx context.Context, id bson.ObjectID) error
+
+	// AddMember adds a user or a group to a group, domain.ErrGroupCycle when the group would
+	// end up a member of itself
+	AddMember(ctx context.Context, groupID bson.ObjectID, kind domain.MemberKind, memberID bson.ObjectID) error
+	RemoveMember(ctx context.Context, groupID, memberID bson.ObjectID) error
+	// ListMembers returns the direct members of a group, users and groups
+	ListMembers(ctx context.Context, groupID bson.ObjectID, req *PageRequest) (*GroupMemberPage, error)
+	// ListUserGroups returns the groups a user belongs to, directly or through nested groups
+	ListUserGroups(ctx context.Context, userID bson.ObjectID, req *PageRequest) (*GroupPage, error)
+	// RolesOf returns the roles a user gets from its groups, sorted
+	RolesOf(ctx context.Context, userID bson.ObjectID) ([]string, error)
+}
@@ -0,0 +1,43 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// InvitationRepository stores the pending invitations of the organization of the context
+type InvitationRepository interface {
+	// Create stores an invitation with the id it already has, as its token embeds the id.
+	// domain.ErrInvited when the email already has one.
+	Create(ctx context.Context, invitation *domain.Invitation) error
+	GetByID(ctx context.Context, id bson.ObjectID) (*domain.Invitation, error)
+	// List returns up to limit invitations with an id greater than after, sorted by id
+	List(ctx context.Context, after *bson.ObjectID, limit int64) ([]domain.Invitation, error)
+	// Renew replaces the token of an invitation and extends it, domain.ErrNotFound if there's none
+	Renew(ctx context.Context, id bson.ObjectID, tokenHash string, expiresAt, sentAt time.Time) error
+	// Delete removes an invitation, domain.ErrNotFound if there's none
+	Delete(ctx context.Context, id bson.ObjectID) error
+}
+
+// InvitationPage is a single page of pending invitations
+type InvitationPage struct {
+	Invitations   []domain.Invitation `json:"invitations"`
+	NextPageToken string              `json:"next_page_token,omitempty"` // empty on the last page
+}
+
+// InvitationService invites people to register, for admins, and lets them accept
+type InvitationService interface {
+	// Invite mails an invitation to invitation.Email with the roles and groups of invitation
+	Invite(ctx context.Context, invitation *domain.Invitation) (*domain.Invitation, error)
+	// ListInvitations returns the pending invitations, expired ones included so they can be resent
+	ListInvitations(ctx context.Context, req *PageRequest) (*InvitationPage, error)
+	// ResendInvitation mails a new token, the previous one stops working, and extends the invitation
+	ResendInvitation(ctx context.Context, id bson.ObjectID) (*domain.Invitation, error)
+	RevokeInvitation(ctx context.Context, id bson.ObjectID) error
+	// AcceptInvitation registers user with the email, roles and groups of the invitation the
+	// token was mailed for, and returns the id of the new user
+	AcceptInvitation(ctx context.Context, token string, user *domain.User) (*bson.ObjectID, error)
+}
@@ -0,0 +1,44 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// OrganizationRepository stores the organizations, it isn't scoped to a tenant
+type OrganizationRepository interface {
+	// Create stores a new organization, domain.ErrSlugTaken when another one has the slug
+	Create(ctx context.Context, org *domain.Organization) (*bson.ObjectID, error)
+	GetByID(ctx context.Context, id bson.ObjectID) (*domain.Organization, error)
+	GetBySlug(ctx context.Context, slug string) (*domain.Organization, error)
+	// List returns every organization, sorted by slug
+	List(ctx context.Context) ([]domain.Organization, error)
+	// Update applies the non-empty fields of update, domain.ErrNotFound if there's no such
+	// organization and domain.ErrSlugTaken when another one has the new slug
+	Update(ctx context.Context, id bson.ObjectID, update *OrganizationUpdate) error
+	// Delete removes an organization, domain.ErrNotFound if there's none
+	Delete(ctx context.Context, id bson.ObjectID) error
+}
+
+// OrganizationUpdate changes the name or slug of an organization, empty fields are left as is
+type OrganizationUpdate struct {
+	Name string
+	Slug string
+}
+
+// OrganizationService manages organizations, for superadmins, and resolves the organization
+// of requests for transports
+type OrganizationService interface {
+	CreateOrganization(ctx context.Context, org *domain.Organization) (*domain.Organization, error)
+	GetOrganization(ctx context.Context, id bson.ObjectID) (*domain.Organization, error)
+	// ResolveOrganization returns the organization with the slug, domain.ErrNotFound if there's
+	// none. Slugs are matched case-insensitively, like hostnames.
+	ResolveOrganization(ctx context.Context, slug string) (*domain.Organization, error)
+	ListOrganizations(ctx context.Context) ([]domain.Organization, error)
+	// UpdateOrganization renames an organization, a new slug moves it to another subdomain
+	UpdateOrganization(ctx context.Context, id bson.ObjectID, update *OrganizationUpdate) (*domain.Organization, error)
+	// DeleteOrganization removes an organization without users, domain.ErrPrecondition otherwise
+	DeleteOrganization(ctx context.Context, id bson.ObjectID) error
+}
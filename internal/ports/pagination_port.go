@@ -1,6 +1,44 @@
 package ports
 
+import (
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Pagination is a keyset window over users ordered by Sort, then by id.
 type Pagination struct {
-	Limit  int64 `json:"limit"`
-	Offset int64 `json:"offset"`
+	Limit int64       `json:"limit"`
+	Sort  []SortField `json:"sort"`
+	After *Cursor     `json:"after,omitempty"` // nil means the first page
+
+	// Fields to load, nil loads every field. The id is always loaded.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Cursor is the position of the last user of a page: its id and the values
+// of the fields the page is sorted by, other fields are left empty.
+type Cursor struct {
+	ID        bson.ObjectID `json:"id"`
+	Name      string        `json:"name,omitempty"`
+	Email     string        `json:"email,omitempty"`
+	CreatedAt time.Time     `json:"created_at,omitzero"`
+}
+
+// ListRequest is a filtered and sorted page of users requested by a client.
+type ListRequest struct {
+	Filter       UserFilter
+	Sort         []SortField // empty means oldest first
+	PageSize     int64       // 0 means the server default
+	PageToken    string      // opaque token from a previous UserPage, empty for the first page
+	IncludeTotal bool        // total count costs an extra query, so it's opt-in
+	Fields       []string    // sparse fieldset from ParseFields, nil returns every field
+}
+
+// UserPage is a single page of users.
+type UserPage struct {
+	Users         []domain.User `json:"users"`
+	NextPageToken string        `json:"next_page_token,omitempty"` // empty on the last page
+	TotalCount    *int64        `json:"total_count,omitempty"`     // matching the filter
 }
@@ -0,0 +1,69 @@
+package porttest
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// TestAttributeSchemaRepository runs the ports.AttributeSchemaRepository contract against
+// the repository returned by newRepo. The schema isn't scoped, so the test uses attribute
+// names of its own and only looks at those.
+func TestAttributeSchemaRepository(t *testing.T, newRepo func(t *testing.T) ports.AttributeSchemaRepository) {
+	repo := newRepo(t)
+	ctx := context.Background()
+	prefix := "a" + bson.NewObjectID().Hex() + "_"
+
+	team := &domain.AttributeDefinition{
+		Name:        prefix + "team",
+		Type:        domain.AttributeString,
+		Required:    true,
+		Enum:        []string{"red", "blue"},
+		Description: "Team of the user",
+	}
+	level := &domain.AttributeDefinition{Name: prefix + "level", Type: domain.AttributeNumber}
+	for _, def := range []*domain.AttributeDefinition{team, level} {
+		if err := repo.Put(ctx, def); err != nil {
+			t.Fatalf("put %s: %v", def.Name, err)
+		}
+	}
+
+	ours := func() domain.AttributeSchema {
+		t.Helper()
+		schema, err := repo.List(ctx)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		return slices.DeleteFunc(schema, func(def domain.AttributeDefinition) bool { return !strings.HasPrefix(def.Name, prefix) })
+	}
+	schema := ours()
+	if len(schema) != 2 || schema[0].Name != level.Name || schema[1].Name != team.Name {
+		t.Fatalf("expected the definitions sorted by name, got %+v", schema)
+	}
+	if got := schema[1]; got.Type != team.Type || !got.Required || !slices.Equal(got.Enum, team.Enum) || got.Description != team.Description {
+		t.Fatalf("expected %+v, got %+v", team, got)
+	}
+
+	// putting a definition replaces it whole
+	if err := repo.Put(ctx, &domain.AttributeDefinition{Name: team.Name, Type: domain.AttributeString, Pattern: "[a-z]+"}); err != nil {
+		t.Fatalf("put again: %v", err)
+	}
+	if got := ours()[1]; got.Required || len(got.Enum) != 0 || got.Pattern != "[a-z]+" || got.Description != "" {
+		t.Fatalf("expected the replaced definition, got %+v", got)
+	}
+
+	for _, def := range []*domain.AttributeDefinition{team, level} {
+		if err := repo.Delete(ctx, def.Name); err != nil {
+			t.Fatalf("delete %s: %v", def.Name, err)
+		}
+	}
+	if schema := ours(); len(schema) != 0 {
+		t.Fatalf("expected the definitions deleted, got %+v", schema)
+	}
+	expectErr(t, "delete a missing definition", repo.Delete(ctx, team.Name), domain.ErrNotFound)
+}
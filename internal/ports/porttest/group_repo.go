@@ -0,0 +1,254 @@
+package porttest
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// TestGroupRepository runs the ports.GroupRepository contract against the repositories
+// returned by newRepo, each subtest in a new organization like TestUserRepository
+func TestGroupRepository(t *testing.T, newRepo func(t *testing.T) ports.GroupRepository) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, repo ports.GroupRepository)
+	}{
+		{"CreateAndGet", testGroupCreateAndGet},
+		{"List", testGroupList},
+		{"Update", testGroupUpdate},
+		{"Members", testGroupMembers},
+		{"Delete", testGroupDelete},
+		{"Tenancy", testGroupTenancy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newTenant(), newRepo(t))
+		})
+	}
+}
+
+func newGroup(name string, roles ...string) *domain.Group {
+	at := now()
+	return &domain.Group{Name: name, Roles: roles, CreatedAt: at, UpdatedAt: at}
+}
+
+// mustCreateGroup stores group and sets its id
+func mustCreateGroup(t *testing.T, ctx context.Context, repo ports.GroupRepository, group *domain.Group) *domain.Group {
+	t.Helper()
+	id, err := repo.Create(ctx, group)
+	if err != nil {
+		t.Fatalf("create %s: %v", group.Name, err)
+	}
+	group.ID = *id
+	return group
+}
+
+func groupIDs(groups []domain.Group) []bson.ObjectID {
+	out := make([]bson.ObjectID, len(groups))
+	for i, group := range groups {
+		out[i] = group.ID
+	}
+	return out
+}
+
+func compareIDs(a, b bson.ObjectID) int {
+	return slices.Compare(a[:], b[:])
+}
+
+func testGroupCreateAndGet(t *testing.T, ctx context.Context, repo ports.GroupRepository) {
+	group := newGroup("Support", "support", "on_call")
+	group.Description = "First line"
+	mustCreateGroup(t, ctx, repo, group)
+	if group.ID.IsZero() {
+		t.Fatal("expected an id to be generated")
+	}
+
+	got, err := repo.GetByID(ctx, group.ID)
+	if err != nil {
+		t.Fatalf("get by id: %v", err)
+	}
+	tenantID, _ := ports.TenantOf(ctx)
+	if got.ID != group.ID || got.TenantID != tenantID || got.Name != group.Name || got.Description != group.Description ||
+		!slices.Equal(got.Roles, group.Roles) || !got.CreatedAt.Equal(group.CreatedAt) {
+		t.Fatalf("expected %+v, got %+v", group, got)
+	}
+
+	// names are unique case-insensitively
+	_, err = repo.Create(ctx, newGroup("support"))
+	expectErr(t, "create with a taken name", err, domain.ErrGroupTaken)
+
+	_, err = repo.GetByID(ctx, bson.NewObjectID())
+	expectErr(t, "get a missing group", err, domain.ErrNotFound)
+}
+
+func testGroupList(t *testing.T, ctx context.Context, repo ports.GroupRepository) {
+	var created []bson.ObjectID
+	for _, name := range []string{"A", "B", "C"} {
+		created = append(created, mustCreateGroup(t, ctx, repo, newGroup(name)).ID)
+	}
+	slices.SortFunc(created, compareIDs)
+
+	all, err := repo.List(ctx, nil, nil, 0)
+	if err != nil || !slices.Equal(groupIDs(all), created) {
+		t.Fatalf("expected every group in id order %v, got %v, %v", created, groupIDs(all), err)
+	}
+	page, err := repo.List(ctx, nil, &created[0], 1)
+	if err != nil || !slices.Equal(groupIDs(page), created[1:2]) {
+		t.Fatalf("expected the page %v, got %v, %v", created[1:2], groupIDs(page), err)
+	}
+	some, err := repo.List(ctx, []bson.ObjectID{created[2], created[0], bson.NewObjectID()}, nil, 0)
+	if err != nil || !slices.Equal(groupIDs(some), []bson.ObjectID{created[0], created[2]}) {
+		t.Fatalf("expected the groups with the ids, got %v, %v", groupIDs(some), err)
+	}
+	none, err := repo.List(ctx, []bson.ObjectID{}, nil, 0)
+	if err != nil || len(none) != 0 {
+		t.Fatalf("expected no groups for no ids, got %v, %v", groupIDs(none), err)
+	}
+}
+
+func testGroupUpdate(t *testing.T, ctx context.Context, repo ports.GroupRepository) {
+	group := newGroup("Support", "support")
+	group.Description = "First line"
+	mustCreateGroup(t, ctx, repo, group)
+	mustCreateGroup(t, ctx, repo, newGroup("Sales"))
+
+	err := repo.Update(ctx, group.ID, &ports.GroupUpdate{
+		Name:        ports.SetTo("Help Desk"),
+		Description: ports.Cleared[string](),
+		Roles:       ports.Cleared[[]string](),
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got, err := repo.GetByID(ctx, group.ID)
+	if err != nil || got.Name != "Help Desk" || got.Description != "" || len(got.Roles) != 0 {
+		t.Fatalf("expected the renamed group without description and roles, got %+v, %v", got, err)
+	}
+
+	err = repo.Update(ctx, group.ID, &ports.GroupUpdate{Roles: ports.SetTo([]string{"support"})})
+	if err != nil {
+		t.Fatalf("update the roles: %v", err)
+	}
+	if got, err := repo.GetByID(ctx, group.ID); err != nil || got.Name != "Help Desk" || !slices.Equal(got.Roles, []string{"support"}) {
+		t.Fatalf("expected only the roles to change, got %+v, %v", got, err)
+	}
+
+	err = repo.Update(ctx, group.ID, &ports.GroupUpdate{Name: ports.SetTo("SALES")})
+	expectErr(t, "rename to a taken name", err, domain.ErrGroupTaken)
+	err = repo.Update(ctx, bson.NewObjectID(), &ports.GroupUpdate{Name: ports.SetTo("Nobody")})
+	expectErr(t, "update a missing group", err, domain.ErrNotFound)
+}
+
+func testGroupMembers(t *testing.T, ctx context.Context, repo ports.GroupRepository) {
+	engineering := mustCreateGroup(t, ctx, repo, newGroup("Engineering"))
+	backend := mustCreateGroup(t, ctx, repo, newGroup("Backend"))
+	jane, john := bson.NewObjectID(), bson.NewObjectID()
+
+	add := func(group bson.ObjectID, kind domain.MemberKind, id bson.ObjectID) error {
+		return repo.AddMember(ctx, group, &domain.GroupMember{Kind: kind, ID: id, AddedAt: now()})
+	}
+	for _, member := range []struct {
+		group bson.ObjectID
+		kind  domain.MemberKind
+		id    bson.ObjectID
+	}{
+		{engineering.ID, domain.MemberGroup, backend.ID},
+		{engineering.ID, domain.MemberUser, jane},
+		{backend.ID, domain.MemberUser, jane},
+		{backend.ID, domain.MemberUser, john},
+	} {
+		if err := add(member.group, member.kind, member.id); err != nil {
+			t.Fatalf("add member %v: %v", member.id, err)
+		}
+	}
+	expectErr(t, "add a member twice", add(backend.ID, domain.MemberUser, jane), domain.ErrConflict)
+
+	members, err := repo.ListMembers(ctx, backend.ID, nil, 0)
+	want := []bson.ObjectID{jane, john}
+	if err != nil || len(members) != 2 || members[0].ID != want[0] || members[1].ID != want[1] || members[0].Kind != domain.MemberUser {
+		t.Fatalf("expected the members %v in id order, got %+v, %v", want, members, err)
+	}
+	page, err := repo.ListMembers(ctx, backend.ID, &jane, 1)
+	if err != nil || len(page) != 1 || page[0].ID != john {
+		t.Fatalf("expected the page after jane, got %+v, %v", page, err)
+	}
+
+	parents, err := repo.ParentsOf(ctx, []bson.ObjectID{jane, backend.ID})
+	slices.SortFunc(parents, compareIDs)
+	want = []bson.ObjectID{engineering.ID, backend.ID}
+	slices.SortFunc(want, compareIDs)
+	if err != nil || !slices.Equal(parents, want) {
+		t.Fatalf("expected the parents %v once each, got %v, %v", want, parents, err)
+	}
+
+	if err := repo.RemoveMember(ctx, backend.ID, john); err != nil {
+		t.Fatalf("remove member: %v", err)
+	}
+	expectErr(t, "remove a missing member", repo.RemoveMember(ctx, backend.ID, john), domain.ErrNotFound)
+
+	if err := repo.RemoveFromAll(ctx, jane); err != nil {
+		t.Fatalf("remove from all: %v", err)
+	}
+	if parents, err := repo.ParentsOf(ctx, []bson.ObjectID{jane}); err != nil || len(parents) != 0 {
+		t.Fatalf("expected jane in no group, got %v, %v", parents, err)
+	}
+}
+
+func testGroupDelete(t *testing.T, ctx context.Context, repo ports.GroupRepository) {
+	engineering := mustCreateGroup(t, ctx, repo, newGroup("Engineering"))
+	backend := mustCreateGroup(t, ctx, repo, newGroup("Backend"))
+	jane := bson.NewObjectID()
+	for _, err := range []error{
+		repo.AddMember(ctx, engineering.ID, &domain.GroupMember{Kind: domain.MemberGroup, ID: backend.ID, AddedAt: now()}),
+		repo.AddMember(ctx, backend.ID, &domain.GroupMember{Kind: domain.MemberUser, ID: jane, AddedAt: now()}),
+	} {
+		if err != nil {
+			t.Fatalf("add member: %v", err)
+		}
+	}
+
+	// its memberships go with it, as a group and as a member
+	if err := repo.Delete(ctx, backend.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_, err := repo.GetByID(ctx, backend.ID)
+	expectErr(t, "get a deleted group", err, domain.ErrNotFound)
+	if members, err := repo.ListMembers(ctx, engineering.ID, nil, 0); err != nil || len(members) != 0 {
+		t.Fatalf("expected no members left, got %+v, %v", members, err)
+	}
+	if parents, err := repo.ParentsOf(ctx, []bson.ObjectID{jane}); err != nil || len(parents) != 0 {
+		t.Fatalf("expected jane in no group, got %v, %v", parents, err)
+	}
+
+	expectErr(t, "delete a missing group", repo.Delete(ctx, backend.ID), domain.ErrNotFound)
+}
+
+func testGroupTenancy(t *testing.T, ctx context.Context, repo ports.GroupRepository) {
+	group := mustCreateGroup(t, ctx, repo, newGroup("Support"))
+	jane := bson.NewObjectID()
+	if err := repo.AddMember(ctx, group.ID, &domain.GroupMember{Kind: domain.MemberUser, ID: jane, AddedAt: now()}); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+
+	// another organization neither sees the group nor changes it, and can use its name
+	other := newTenant()
+	_, err := repo.GetByID(other, group.ID)
+	expectErr(t, "get from another organization", err, domain.ErrNotFound)
+	if groups, err := repo.List(other, nil, nil, 0); err != nil || len(groups) != 0 {
+		t.Fatalf("expected no groups in another organization, got %v, %v", groupIDs(groups), err)
+	}
+	if parents, err := repo.ParentsOf(other, []bson.ObjectID{jane}); err != nil || len(parents) != 0 {
+		t.Fatalf("expected no parents in another organization, got %v, %v", parents, err)
+	}
+	expectErr(t, "update from another organization", repo.Update(other, group.ID, &ports.GroupUpdate{Name: ports.SetTo("Taken")}), domain.ErrNotFound)
+	expectErr(t, "delete from another organization", repo.Delete(other, group.ID), domain.ErrNotFound)
+	expectErr(t, "remove member from another organization", repo.RemoveMember(other, group.ID, jane), domain.ErrNotFound)
+	mustCreateGroup(t, other, repo, newGroup("Support"))
+
+	_, err = repo.GetByID(context.Background(), group.ID)
+	expectErr(t, "get without an organization", err, ports.ErrNoTenant)
+}
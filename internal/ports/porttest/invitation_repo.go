@@ -0,0 +1,91 @@
+package porttest
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// TestInvitationRepository runs the ports.InvitationRepository contract against the
+// repository returned by newRepo, in new organizations
+func TestInvitationRepository(t *testing.T, newRepo func(t *testing.T) ports.InvitationRepository) {
+	repo := newRepo(t)
+	ctx := newTenant()
+	newInvitation := func(email string) *domain.Invitation {
+		at := now()
+		return &domain.Invitation{
+			ID:        bson.NewObjectID(),
+			Email:     email,
+			InvitedBy: bson.NewObjectID(),
+			TokenHash: "hash",
+			ExpiresAt: at.Add(time.Hour),
+			SentAt:    at,
+			CreatedAt: at,
+		}
+	}
+
+	jane := newInvitation("jane@example.com")
+	jane.Roles = []string{domain.RoleAdmin}
+	jane.GroupIDs = []bson.ObjectID{bson.NewObjectID()}
+	john := newInvitation("john@example.com")
+	for _, invitation := range []*domain.Invitation{jane, john} {
+		if err := repo.Create(ctx, invitation); err != nil {
+			t.Fatalf("create %s: %v", invitation.Email, err)
+		}
+	}
+	expectErr(t, "invite an email twice", repo.Create(ctx, newInvitation("jane@example.com")), domain.ErrInvited)
+
+	got, err := repo.GetByID(ctx, jane.ID)
+	tenantID, _ := ports.TenantOf(ctx)
+	if err != nil || got.TenantID != tenantID || got.Email != jane.Email || got.InvitedBy != jane.InvitedBy ||
+		got.TokenHash != jane.TokenHash || !got.ExpiresAt.Equal(jane.ExpiresAt) ||
+		!slices.Equal(got.Roles, jane.Roles) || !slices.Equal(got.GroupIDs, jane.GroupIDs) {
+		t.Fatalf("expected %+v, got %+v, %v", jane, got, err)
+	}
+
+	first, second := jane, john
+	if compareIDs(first.ID, second.ID) > 0 {
+		first, second = second, first
+	}
+	page, err := repo.List(ctx, nil, 1)
+	if err != nil || len(page) != 1 || page[0].ID != first.ID {
+		t.Fatalf("expected the first invitation, got %+v, %v", page, err)
+	}
+	page, err = repo.List(ctx, &first.ID, 10)
+	if err != nil || len(page) != 1 || page[0].ID != second.ID {
+		t.Fatalf("expected the second invitation, got %+v, %v", page, err)
+	}
+
+	expiresAt := now().Add(2 * time.Hour)
+	if err := repo.Renew(ctx, jane.ID, "new hash", expiresAt, now()); err != nil {
+		t.Fatalf("renew: %v", err)
+	}
+	if got, err := repo.GetByID(ctx, jane.ID); err != nil || got.TokenHash != "new hash" || !got.ExpiresAt.Equal(expiresAt) {
+		t.Fatalf("expected the new token, got %+v, %v", got, err)
+	}
+
+	// another organization neither sees the invitations nor changes them, and can invite the email
+	other := newTenant()
+	_, err = repo.GetByID(other, jane.ID)
+	expectErr(t, "get from another organization", err, domain.ErrNotFound)
+	if invitations, err := repo.List(other, nil, 10); err != nil || len(invitations) != 0 {
+		t.Fatalf("expected no invitations in another organization, got %+v, %v", invitations, err)
+	}
+	expectErr(t, "renew from another organization", repo.Renew(other, jane.ID, "hash", expiresAt, now()), domain.ErrNotFound)
+	expectErr(t, "delete from another organization", repo.Delete(other, jane.ID), domain.ErrNotFound)
+	if err := repo.Create(other, newInvitation("jane@example.com")); err != nil {
+		t.Fatalf("invite in another organization: %v", err)
+	}
+
+	if err := repo.Delete(ctx, jane.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_, err = repo.GetByID(ctx, jane.ID)
+	expectErr(t, "get a deleted invitation", err, domain.ErrNotFound)
+	expectErr(t, "delete a missing invitation", repo.Delete(ctx, jane.ID), domain.ErrNotFound)
+	expectErr(t, "renew a missing invitation", repo.Renew(ctx, jane.ID, "hash", expiresAt, now()), domain.ErrNotFound)
+}
@@ -0,0 +1,85 @@
+package porttest
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// TestOrganizationRepository runs the ports.OrganizationRepository contract against the
+// repository returned by newRepo. Organizations aren't scoped, so the subtests use slugs
+// of their own and only look at their organizations.
+func TestOrganizationRepository(t *testing.T, newRepo func(t *testing.T) ports.OrganizationRepository) {
+	repo := newRepo(t)
+	ctx := context.Background()
+	suffix := bson.NewObjectID().Hex()
+	newOrganization := func(slug string) *domain.Organization {
+		at := now()
+		return &domain.Organization{Slug: slug + "-" + suffix, Name: slug, CreatedAt: at, UpdatedAt: at}
+	}
+
+	acme := newOrganization("acme")
+	id, err := repo.Create(ctx, acme)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	acme.ID = *id
+	globex := newOrganization("globex")
+	if id, err = repo.Create(ctx, globex); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	globex.ID = *id
+
+	for what, get := range map[string]func() (*domain.Organization, error){
+		"id":   func() (*domain.Organization, error) { return repo.GetByID(ctx, acme.ID) },
+		"slug": func() (*domain.Organization, error) { return repo.GetBySlug(ctx, acme.Slug) },
+	} {
+		got, err := get()
+		if err != nil || got.ID != acme.ID || got.Slug != acme.Slug || got.Name != acme.Name || !got.CreatedAt.Equal(acme.CreatedAt) {
+			t.Fatalf("get by %s: expected %+v, got %+v, %v", what, acme, got, err)
+		}
+	}
+	_, err = repo.GetBySlug(ctx, "missing-"+suffix)
+	expectErr(t, "get a missing slug", err, domain.ErrNotFound)
+
+	all, err := repo.List(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var slugs []string
+	for _, org := range all {
+		if org.ID == acme.ID || org.ID == globex.ID {
+			slugs = append(slugs, org.Slug)
+		}
+	}
+	if !slices.Equal(slugs, []string{acme.Slug, globex.Slug}) {
+		t.Fatalf("expected the organizations in slug order, got %v", slugs)
+	}
+
+	_, err = repo.Create(ctx, newOrganization("acme"))
+	expectErr(t, "create with a taken slug", err, domain.ErrSlugTaken)
+	err = repo.Update(ctx, globex.ID, &ports.OrganizationUpdate{Slug: acme.Slug})
+	expectErr(t, "update to a taken slug", err, domain.ErrSlugTaken)
+
+	if err := repo.Update(ctx, acme.ID, &ports.OrganizationUpdate{Name: "Acme Corp"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if got, err := repo.GetByID(ctx, acme.ID); err != nil || got.Name != "Acme Corp" || got.Slug != acme.Slug {
+		t.Fatalf("expected only the name to change, got %+v, %v", got, err)
+	}
+	err = repo.Update(ctx, bson.NewObjectID(), &ports.OrganizationUpdate{Name: "Nobody"})
+	expectErr(t, "update a missing organization", err, domain.ErrNotFound)
+
+	for _, org := range []*domain.Organization{acme, globex} {
+		if err := repo.Delete(ctx, org.ID); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+	}
+	_, err = repo.GetByID(ctx, acme.ID)
+	expectErr(t, "get a deleted organization", err, domain.ErrNotFound)
+	expectErr(t, "delete a missing organization", repo.Delete(ctx, acme.ID), domain.ErrNotFound)
+}
@@ -0,0 +1,98 @@
+package porttest
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// TestSettingsRepository runs the ports.SettingsRepository contract against the
+// repositories returned by newRepo, each subtest in a new organization
+func TestSettingsRepository(t *testing.T, newRepo func(t *testing.T) ports.SettingsRepository) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, repo ports.SettingsRepository)
+	}{
+		{"ReplaceAndGet", testSettingsReplaceAndGet},
+		{"Update", testSettingsUpdate},
+		{"Tenancy", testSettingsTenancy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newTenant(), newRepo(t))
+		})
+	}
+}
+
+func expectSettings(t *testing.T, ctx context.Context, repo ports.SettingsRepository, userID bson.ObjectID, want map[string]any) {
+	t.Helper()
+	got, err := repo.Get(ctx, userID)
+	if err != nil || !maps.Equal(got, want) {
+		t.Fatalf("expected the settings %v, got %v, %v", want, got, err)
+	}
+}
+
+func testSettingsReplaceAndGet(t *testing.T, ctx context.Context, repo ports.SettingsRepository) {
+	userID := bson.NewObjectID()
+	expectSettings(t, ctx, repo, userID, nil)
+
+	settings := map[string]any{"theme": "dark", "page_size": float64(50), "newsletter": true}
+	if err := repo.Replace(ctx, userID, settings); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	expectSettings(t, ctx, repo, userID, settings)
+
+	// replacing drops the settings left out
+	if err := repo.Replace(ctx, userID, map[string]any{"theme": "light"}); err != nil {
+		t.Fatalf("replace again: %v", err)
+	}
+	expectSettings(t, ctx, repo, userID, map[string]any{"theme": "light"})
+
+	if err := repo.Delete(ctx, userID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	expectSettings(t, ctx, repo, userID, nil)
+	if err := repo.Delete(ctx, userID); err != nil {
+		t.Fatalf("expected deleting missing settings to succeed, got %v", err)
+	}
+}
+
+func testSettingsUpdate(t *testing.T, ctx context.Context, repo ports.SettingsRepository) {
+	userID := bson.NewObjectID()
+
+	// updating stores settings for a user without any
+	if err := repo.Update(ctx, userID, map[string]any{"theme": "dark", "newsletter": true}, nil); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	expectSettings(t, ctx, repo, userID, map[string]any{"theme": "dark", "newsletter": true})
+
+	// and keeps the others
+	if err := repo.Update(ctx, userID, map[string]any{"page_size": float64(20)}, []string{"newsletter"}); err != nil {
+		t.Fatalf("update again: %v", err)
+	}
+	expectSettings(t, ctx, repo, userID, map[string]any{"theme": "dark", "page_size": float64(20)})
+}
+
+func testSettingsTenancy(t *testing.T, ctx context.Context, repo ports.SettingsRepository) {
+	userID := bson.NewObjectID()
+	if err := repo.Replace(ctx, userID, map[string]any{"theme": "dark"}); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+
+	// another organization neither sees the settings nor takes them over
+	other := newTenant()
+	expectSettings(t, other, repo, userID, nil)
+	if err := repo.Replace(other, userID, map[string]any{"theme": "light"}); err == nil {
+		t.Fatal("expected replacing the settings of another organization to fail")
+	}
+	if err := repo.Delete(other, userID); err != nil {
+		t.Fatalf("delete from another organization: %v", err)
+	}
+	expectSettings(t, ctx, repo, userID, map[string]any{"theme": "dark"})
+
+	_, err := repo.Get(context.Background(), userID)
+	expectErr(t, "get without an organization", err, ports.ErrNoTenant)
+}
@@ -0,0 +1,555 @@
+// Package porttest checks implementations of the ports against the behavior the services
+// rely on. Adapters run the checks from their own tests.
+package porttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// TestUserRepository runs the ports.UserRepository contract against the repositories
+// returned by newRepo. Every subtest scopes its context to a new organization, so the
+// repositories can share their storage, e.g. a test database.
+func TestUserRepository(t *testing.T, newRepo func(t *testing.T) ports.UserRepository) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, repo ports.UserRepository)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"NotFound", testNotFound},
+		{"UniqueEmail", testUniqueEmail},
+		{"UniqueUsername", testUniqueUsername},
+		{"Tenancy", testTenancy},
+		{"Pagination", testPagination},
+		{"Filter", testFilter},
+		{"Update", testUpdate},
+		{"Delete", testDelete},
+		{"SetStatus", testSetStatus},
+		{"SetUsername", testSetUsername},
+		{"EmailChange", testEmailChange},
+		{"Search", testSearch},
+		{"ConcurrentCreate", testConcurrentCreate},
+		{"ConcurrentSetStatus", testConcurrentSetStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newTenant(), newRepo(t))
+		})
+	}
+}
+
+// newTenant returns a context scoped to a new organization
+func newTenant() context.Context {
+	return ports.WithTenant(context.Background(), bson.NewObjectID())
+}
+
+// now is truncated to the millisecond precision of BSON dates, so times round-trip exactly
+func now() time.Time {
+	return time.Now().Truncate(time.Millisecond)
+}
+
+func newUser(name, email string) *domain.User {
+	at := now()
+	return &domain.User{
+		Name:            name,
+		Email:           email,
+		Password:        "hash",
+		Status:          domain.StatusActive,
+		CreatedAt:       at,
+		UpdatedAt:       at,
+		StatusChangedAt: at,
+	}
+}
+
+// mustCreate stores user and sets its id
+func mustCreate(t *testing.T, ctx context.Context, repo ports.UserRepository, user *domain.User) *domain.User {
+	t.Helper()
+	id, err := repo.Create(ctx, user)
+	if err != nil {
+		t.Fatalf("create %s: %v", user.Email, err)
+	}
+	user.ID = *id
+	return user
+}
+
+func expectErr(t *testing.T, what string, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("%s: expected %v, got %v", what, want, err)
+	}
+}
+
+func ids(users []domain.User) []bson.ObjectID {
+	out := make([]bson.ObjectID, len(users))
+	for i, user := range users {
+		out[i] = user.ID
+	}
+	return out
+}
+
+func testCreateAndGet(t *testing.T, ctx context.Context, repo ports.UserRepository) {
+	user := newUser("Jane Doe", "jane@example.com")
+	user.Username = "jane"
+	user.Roles = []string{domain.RoleAdmin}
+	user.Attributes = map[string]any{"department": "support"}
+	mustCreate(t, ctx, repo, user)
+	if user.ID.IsZero() {
+		t.Fatal("expected an id to be generated")
+	}
+
+	got, err := repo.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("get by id: %v", err)
+	}
+	if got.ID != user.ID || got.Name != user.Name || got.Email != user.Email || got.Username != user.Username ||
+		got.Password != user.Password || got.Status != user.Status || !got.CreatedAt.Equal(user.CreatedAt) ||
+		!slices.Equal(got.Roles, user.Roles) || got.Attributes["department"] != "support" {
+		t.Fatalf("expected %+v, got %+v", user, got)
+	}
+
+	// selected fields only, and the id
+	got, err = repo.GetByID(ctx, user.ID, ports.FieldName)
+	if err != nil {
+		t.Fatalf("get by id with fields: %v", err)
+	}
+	if got.ID != user.ID || got.Name != user.Name || got.Email != "" {
+		t.Fatalf("expected only the id and name, got %+v", got)
+	}
+
+	for what, get := range map[string]func() (*domain.User, error){
+		"email":    func() (*domain.User, error) { return repo.GetByEmail(ctx, "jane@example.com") },
+		"username": func() (*domain.User, error) { return repo.GetByUsername(ctx, "jane") },
+	} {
+		got, err := get()
+		if err != nil || got.ID != user.ID {
+			t.Fatalf("get by %s: expected %v, got %+v, %v", what, user.ID, got, err)
+		}
+	}
+
+	// a preset id is kept, and can't be reused
+	preset := newUser("John Doe", "john@example.com")
+	preset.ID = bson.NewObjectID()
+	id, err := repo.Create(ctx, preset)
+	if err != nil || *id != preset.ID {
+		t.Fatalf("expected the preset id %v, got %v, %v", preset.ID, id, err)
+	}
+	_, err = repo.Create(ctx, &domain.User{ID: preset.ID, Email: "other@example.com"})
+	expectErr(t, "create with a taken id", err, domain.ErrConflict)
+
+	all, err := repo.GetAll(ctx)
+	if err != nil || len(all) != 2 {
+		t.Fatalf("expected 2 users, got %d, %v", len(all), err)
+	}
+	if count, err := repo.Count(ctx, nil); err != nil || count != 2 {
+		t.Fatalf("expected a count of 2, got %d, %v", count, err)
+	}
+}
+
+func testNotFound(t *testing.T, ctx context.Context, repo ports.UserRepository) {
+	missing := bson.NewObjectID()
+
+	_, err := repo.GetByID(ctx, missing)
+	expectErr(t, "get by id", err, domain.ErrNotFound)
+	_, err = repo.GetByEmail(ctx, "nobody@example.com")
+	expectErr(t, "get by email", err, domain.ErrNotFound)
+	_, err = repo.GetByUsername(ctx, "nobody")
+	expectErr(t, "get by username", err, domain.ErrNotFound)
+
+	expectErr(t, "update", repo.Update(ctx, missing, &ports.UserUpdate{Name: ports.SetTo("Nobody")}), domain.ErrNotFound)
+	expectErr(t, "set status", repo.SetStatus(ctx, missing, &ports.StatusChange{
+		From: domain.StatusActive, To: domain.StatusSuspended, Reason: "spam", At: now(),
+	}), domain.ErrNotFound)
+	expectErr(t, "set username", repo.SetUsername(ctx, missing, "nobody", now().Add(time.Hour)), domain.ErrNotFound)
+	expectErr(t, "set avatar", repo.SetAvatar(ctx, missing, "hash"), domain.ErrNotFound)
+	expectErr(t, "set email change", repo.SetEmailChange(ctx, missing, &domain.EmailChange{Email: "a@example.com"}, nil), domain.ErrNotFound)
+	expectErr(t, "confirm email change", repo.ConfirmEmailChange(ctx, missing, "hash", "a@example.com"), domain.ErrNotFound)
+
+	if err := repo.Delete(ctx, missing); err != nil {
+		t.Fatalf("expected deleting a missing user to succeed, got %v", err)
+	}
+	if users, err := repo.GetAll(ctx); err != nil || len(users) != 0 {
+		t.Fatalf("expected no users, got %d, %v", len(users), err)
+	}
+}
+
+func testUniqueEmail(t *testing.T, ctx context.Context, repo ports.UserRepository) {
+	jane := mustCreate(t, ctx, repo, newUser("Jane", "jane@example.com"))
+	john := mustCreate(t, ctx, repo, newUser("John", "john@example.com"))
+
+	_, err := repo.Create(ctx, newUser("Jane Again", "jane@example.com"))
+	expectErr(t, "create with a taken email", err, domain.ErrEmailTaken)
+	err = repo.Update(ctx, john.ID, &ports.UserUpdate{Email: ports.SetTo(jane.Email)})
+	expectErr(t, "update to a taken email", err, domain.ErrEmailTaken)
+
+	// keeping one's own email isn't a clash
+	if err := repo.Update(ctx, jane.ID, &ports.UserUpdate{Email: ports.SetTo(jane.Email), Name: ports.SetTo("Jane D")}); err != nil {
+		t.Fatalf("update keeping the email: %v", err)
+	}
+}
+
+func testUniqueUsername(t *testing.T, ctx context.Context, repo ports.UserRepository) {
+	jane := newUser("Jane", "jane@example.com")
+	jane.Username = "jane"
+	mustCreate(t, ctx, repo, jane)
+
+	taken := newUser("Jane 2", "jane2@example.com")
+	taken.Username = "jane"
+	_, err := repo.Create(ctx, taken)
+	expectErr(t, "create with a taken username", err, domain.ErrUsernameTaken)
+
+	// usernames are optional, users without one don't clash
+	mustCreate(t, ctx, repo, newUser("John", "john@example.com"))
+	john := mustCreate(t, ctx, repo, newUser("Jim", "jim@example.com"))
+	expectErr(t, "rename to a taken username", repo.SetUsername(ctx, john.ID, "jane", now().Add(time.Hour)), domain.ErrUsernameTaken)
+}
+
+func testTenancy(t *testing.T, ctx context.Context, repo ports.UserRepository) {
+	jane := newUser("Jane", "jane@example.com")
+	jane.Username = "jane"
+	mustCreate(t, ctx, repo, jane)
+
+	// another organization can reuse the email and username, and doesn't see the user
+	other := newTenant()
+	twin := newUser("Jane", "jane@example.com")
+	twin.Username = "jane"
+	mustCreate(t, other, repo, twin)
+	_, err := repo.GetByID(other, jane.ID)
+	expectErr(t, "get a user of another organization", err, domain.ErrNotFound)
+	if got, err := repo.GetByEmail(other, jane.Email); err != nil || got.ID != twin.ID {
+		t.Fatalf("expected the user of the organization %v, got %+v, %v", twin.ID, got, err)
+	}
+	expectErr(t, "update a user of another organization", repo.Update(other, jane.ID, &ports.UserUpdate{Name: ports.SetTo("Mallory")}), domain.ErrNotFound)
+	if err := repo.Delete(other, jane.ID); err != nil {
+		t.Fatalf("delete from another organization: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, jane.ID); err != nil {
+		t.Fatalf("expected the user to survive a delete from another organization, got %v", err)
+	}
+
+	// no organization is an error rather than every organization
+	_, err = repo.Create(context.Background(), newUser("Nobody", "nobody@example.com"))
+	expectErr(t, "create without organization", err, ports.ErrNoTenant)
+	_, err = repo.GetByID(context.Background(), jane.ID)
+	expectErr(t, "get without organization", err, ports.ErrNoTenant)
+	_, err = repo.List(context.Background(), nil, &ports.Pagination{Limit: 10})
+	expectErr(t, "list without organization", err, ports.ErrNoTenant)
+
+	if got, err := repo.GetByID(ports.AllTenants(context.Background()), jane.ID); err != nil || got.ID != jane.ID {
+		t.Fatalf("expected every organization to see the user, got %+v, %v", got, err)
+	}
+}
+
+func testPagination(t *testing.T, ctx context.Context, repo ports.UserRepository) {
+	start := now()
+	names := []string{"delta", "Alpha", "charlie", "Bravo", "echo"}
+	var created []*domain.User
+	for i, name := range names {
+		user := newUser(name, fmt.Sprintf("%s@example.com", name))
+		user.CreatedAt = start.Add(time.Duration(i) * time.Second)
+		created = append(created, mustCreate(t, ctx, repo, user))
+	}
+	// same creation time as the first, ties are broken by id
+	tie := newUser("foxtrot", "foxtrot@example.com")
+	tie.CreatedAt = start
+	created = append(created, mustCreate(t, ctx, repo, tie))
+
+	byCreation := []bson.ObjectID{created[0].ID, created[5].ID, created[1].ID, created[2].ID, created[3].ID, created[4].ID}
+	if slices.Compare(created[5].ID[:], created[0].ID[:]) < 0 {
+		byCreation[0], byCreation[1] = byCreation[1], byCreation[0]
+	}
+	byNameDesc := []bson.ObjectID{created[5].ID, created[4].ID, created[0].ID, created[2].ID, created[3].ID, created[1].ID}
+
+	for _, tt := range []struct {
+		name string
+		sort []ports.SortField
+		want []bson.ObjectID
+	}{
+		{"created_at", []ports.SortField{{Field: ports.SortByCreatedAt}}, byCreation},
+		{"-name", []ports.SortField{{Field: ports.SortByName, Desc: true}}, byNameDesc},
+	} {
+		var got []bson.ObjectID
+		pagination := &ports.Pagination{Limit: 4, Sort: tt.sort}
+		for page := 0; ; page++ {
+			users, err := repo.List(ctx, nil, pagination)
+			if err != nil {
+				t.Fatalf("list by %s: %v", tt.name, err)
+			}
+			if page > len(tt.want) {
+				t.Fatalf("list by %s doesn't end", tt.name)
+			}
+			got = append(got, ids(users)...)
+			if int64(len(users)) < pagination.Limit {
+				break
+			}
+			last := users[len(users)-1]
+			pagination.After = &ports.Cursor{ID: last.ID, Name: last.Name, Email: last.Email, CreatedAt: last.CreatedAt}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Fatalf("list by %s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	// sparse fieldsets keep the id
+	users, err := repo.List(ctx, nil, &ports.Pagination{Limit: 1, Fields: []string{ports.FieldEmail}})
+	if err != nil || len(users) != 1 || users[0].ID.IsZero() || users[0].Email == "" || users[0].Name != "" {
+		t.Fatalf("expected the id and email only, got %+v, %v", users, err)
+	}
+}
+
+func testFilter(t *testing.T, ctx context.Context, repo ports.UserRepository) {
+	start := now()
+	jane := newUser("Jane Doe", "jane@example.com")
+	john := newUser("John Roe", "john@corp.example")
+	john.CreatedAt = start.Add(time.Hour)
+	john.Status = domain.StatusSuspended
+	jim := newUser("jim", "jim@example.com")
+	for _, user := range []*domain.User{jane, john, jim} {
+		mustCreate(t, ctx, repo, user)
+	}
+	after := start.Add(time.Minute)
+
+	for name, tt := range map[string]struct {
+		filter *ports.UserFilter
+		want   []bson.ObjectID
+	}{
+		"name prefix, any case": {&ports.UserFilter{NamePrefix: "J"}, []bson.ObjectID{jane.ID, john.ID, jim.ID}},
+		"email domain":          {&ports.UserFilter{EmailDomain: "example.com"}, []bson.ObjectID{jane.ID, jim.ID}},
+		"created after":         {&ports.UserFilter{CreatedAfter: &after}, []bson.ObjectID{john.ID}},
+		"created before":        {&ports.UserFilter{CreatedBefore: &after}, []bson.ObjectID{jane.ID, jim.ID}},
+		"status":                {&ports.UserFilter{Status: string(domain.StatusSuspended)}, []bson.ObjectID{john.ID}},
+		"search name or email":  {&ports.UserFilter{Search: "JI"}, []bson.ObjectID{jim.ID}},
+	} {
+		users, err := repo.List(ctx, tt.filter, &ports.Pagination{Limit: 10})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		got := ids(users)
+		slices.SortFunc(got, func(a, b bson.ObjectID) int { return slices.Compare(a[:], b[:]) })
+		want := slices.Clone(tt.want)
+		slices.SortFunc(want, func(a, b bson.ObjectID) int { return slices.Compare(a[:], b[:]) })
+		if !slices.Equal(got, want) {
+			t.Fatalf("%s: expected %v, got %v", name, want, got)
+		}
+		if count, err := repo.Count(ctx, tt.filter); err != nil || count != int64(len(want)) {
+			t.Fatalf("%s: expected a count of %d, got %d, %v", name, len(want), count, err)
+		}
+	}
+}
+
+func testUpdate(t *testing.T, ctx context.Context, repo ports.UserRepository) {
+	user := newUser("Jane", "jane@example.com")
+	user.Bio = "Hello"
+	user.Attributes = map[string]any{"floor": "3", "desk": "12"}
+	mustCreate(t, ctx, repo, user)
+
+	err := repo.Update(ctx, user.ID, &ports.UserUpdate{
+		Name:       ports.SetTo("Jane Doe"),
+		Locale:     ports.SetTo("en-US"),
+		Bio:        ports.Cleared[string](),
+		Attributes: map[string]ports.FieldUpdate[any]{"floor": ports.SetTo[any]("4"), "desk": ports.Cleared[any]()},
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got, err := repo.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Name != "Jane Doe" || got.Locale != "en-US" || got.Bio != "" || got.Email != user.Email ||
+		got.Attributes["floor"] != "4" || got.Attributes["desk"] != nil || got.UpdatedAt.Before(user.UpdatedAt) {
+		t.Fatalf("unexpected user after update %+v", got)
+	}
+
+	if err := repo.SetAvatar(ctx, user.ID, "avatar-hash"); err != nil {
+		t.Fatalf("set avatar: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, user.ID); got.AvatarHash != "avatar-hash" {
+		t.Fatalf("expected the avatar hash to be set, got %q", got.AvatarHash)
+	}
+
+	// users returned aren't the stored ones
+	got.Name = "Mallory"
+	if again, _ := repo.GetByID(ctx, user.ID); again.Name != "Jane Doe" {
+		t.Fatalf("expected the stored user to be unaffected, got %q", again.Name)
+	}
+}
+
+func testDelete(t *testing.T, ctx context.Context, repo ports.UserRepository) {
+	user := mustCreate(t, ctx, repo, newUser("Jane", "jane@example.com"))
+	if err := repo.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_, err := repo.GetByID(ctx, user.ID)
+	expectErr(t, "get after delete", err, domain.ErrNotFound)
+
+	// the email is free again
+	mustCreate(t, ctx, repo, newUser("Jane", "jane@example.com"))
+}
+
+func testSetStatus(t *testing.T, ctx context.Context, repo ports.UserRepository) {
+	user := mustCreate(t, ctx, repo, newUser("Jane", "jane@example.com"))
+	at := now()
+
+	err := repo.SetStatus(ctx, user.ID, &ports.StatusChange{From: domain.StatusActive, To: domain.StatusSuspended, Reason: "spam", At: at})
+	if err != nil {
+		t.Fatalf("set status: %v", err)
+	}
+	got, _ := repo.GetByID(ctx, user.ID)
+	if got.Status != domain.StatusSuspended || got.StatusReason != "spam" || !got.StatusChangedAt.Equal(at) {
+		t.Fatalf("unexpected status %s, %q, %v", got.Status, got.StatusReason, got.StatusChangedAt)
+	}
+
+	// the change only applies from the expected status
+	err = repo.SetStatus(ctx, user.ID, &ports.StatusChange{From: domain.StatusActive, To: domain.StatusLocked, Reason: "brute force", At: at})
+	expectErr(t, "set status from a stale status", err, domain.ErrNotFound)
+
+	// an empty reason clears the previous one
+	if err := repo.SetStatus(ctx, user.ID, &ports.StatusChange{From: domain.StatusSuspended, To: domain.StatusActive, At: at}); err != nil {
+		t.Fatalf("reactivate: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, user.ID); got.Status != domain.StatusActive || got.StatusReason != "" {
+		t.Fatalf("expected an active user without reason, got %s, %q", got.Status, got.StatusReason)
+	}
+}
+
+func testSetUsername(t *testing.T, ctx context.Context, repo ports.UserRepository) {
+	user := newUser("Jane", "jane@example.com")
+	user.Username = "jane"
+	mustCreate(t, ctx, repo, user)
+
+	if err := repo.SetUsername(ctx, user.ID, "jane_doe", now().Add(time.Hour)); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	for _, username := range []string{"jane_doe", "jane"} {
+		if got, err := repo.GetByUsername(ctx, username); err != nil || got.ID != user.ID || got.Username != "jane_doe" {
+			t.Fatalf("get by username %s: expected the renamed user, got %+v, %v", username, got, err)
+		}
+	}
+
+	// a current username wins over a redirect
+	other := newUser("Other Jane", "other@example.com")
+	other.Username = "jane"
+	mustCreate(t, ctx, repo, other)
+	if got, err := repo.GetByUsername(ctx, "jane"); err != nil || got.ID != other.ID {
+		t.Fatalf("expected the current owner of jane, got %+v, %v", got, err)
+	}
+
+	// expired redirects don't resolve
+	if err := repo.SetUsername(ctx, user.ID, "", now().Add(-time.Second)); err != nil {
+		t.Fatalf("remove username: %v", err)
+	}
+	_, err := repo.GetByUsername(ctx, "jane_doe")
+	expectErr(t, "get by expired redirect", err, domain.ErrNotFound)
+}
+
+func testEmailChange(t *testing.T, ctx context.Context, repo ports.UserRepository) {
+	user := mustCreate(t, ctx, repo, newUser("Jane", "jane@example.com"))
+	expires := now().Add(time.Hour)
+	change := &domain.EmailChange{Email: "jane@new.example", TokenHash: "confirm", ExpiresAt: expires}
+	revert := &domain.EmailRevert{Email: "jane@example.com", TokenHash: "revert", ExpiresAt: expires}
+	if err := repo.SetEmailChange(ctx, user.ID, change, revert); err != nil {
+		t.Fatalf("set email change: %v", err)
+	}
+
+	expectErr(t, "confirm with a wrong token", repo.ConfirmEmailChange(ctx, user.ID, "wrong", change.Email), domain.ErrNotFound)
+	if err := repo.ConfirmEmailChange(ctx, user.ID, "confirm", change.Email); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	got, _ := repo.GetByEmail(ctx, change.Email)
+	if got == nil || got.ID != user.ID || got.PendingEmail != nil || got.EmailRevert == nil {
+		t.Fatalf("expected the new email with a revert, got %+v", got)
+	}
+	expectErr(t, "confirm twice", repo.ConfirmEmailChange(ctx, user.ID, "confirm", change.Email), domain.ErrNotFound)
+
+	if err := repo.RevertEmailChange(ctx, user.ID, "revert", revert.Email); err != nil {
+		t.Fatalf("revert: %v", err)
+	}
+	got, _ = repo.GetByID(ctx, user.ID)
+	if got.Email != revert.Email || got.EmailRevert != nil {
+		t.Fatalf("expected the email to be restored, got %+v", got)
+	}
+}
+
+func testSearch(t *testing.T, ctx context.Context, repo ports.UserRepository) {
+	jonathan := mustCreate(t, ctx, repo, newUser("Jonathan Smith", "jsmith@example.com"))
+	mustCreate(t, ctx, repo, newUser("Maria Garcia", "maria@example.com"))
+
+	// a typo still finds candidates
+	users, err := repo.Search(ctx, "jonathon", 10)
+	if err != nil || len(users) == 0 || users[0].ID != jonathan.ID {
+		t.Fatalf("expected %v first, got %v, %v", jonathan.ID, ids(users), err)
+	}
+	if users, err := repo.Search(ctx, "jonathon", 1); err != nil || len(users) != 1 {
+		t.Fatalf("expected the limit to apply, got %d, %v", len(users), err)
+	}
+	if users, err := repo.Search(newTenant(), "jonathon", 10); err != nil || len(users) != 0 {
+		t.Fatalf("expected no candidates in another organization, got %d, %v", len(users), err)
+	}
+}
+
+func testConcurrentCreate(t *testing.T, ctx context.Context, repo ports.UserRepository) {
+	const writers = 8
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int
+	)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.Create(ctx, newUser(fmt.Sprintf("Jane %d", i), "jane@example.com"))
+			switch {
+			case err == nil:
+				mu.Lock()
+				created++
+				mu.Unlock()
+			case !errors.Is(err, domain.ErrEmailTaken):
+				t.Errorf("concurrent create: expected email taken, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if created != 1 {
+		t.Fatalf("expected exactly one of the concurrent creates to win, %d did", created)
+	}
+}
+
+func testConcurrentSetStatus(t *testing.T, ctx context.Context, repo ports.UserRepository) {
+	user := mustCreate(t, ctx, repo, newUser("Jane", "jane@example.com"))
+	targets := []domain.Status{domain.StatusSuspended, domain.StatusLocked, domain.StatusDeactivated, domain.StatusDeleted}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		applied int
+	)
+	for _, to := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := repo.SetStatus(ctx, user.ID, &ports.StatusChange{From: domain.StatusActive, To: to, Reason: "test", At: now()})
+			switch {
+			case err == nil:
+				mu.Lock()
+				applied++
+				mu.Unlock()
+			case !errors.Is(err, domain.ErrNotFound):
+				t.Errorf("concurrent set status: expected not found, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if applied != 1 {
+		t.Fatalf("expected exactly one of the concurrent transitions to apply, %d did", applied)
+	}
+}
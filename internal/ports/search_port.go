@@ -0,0 +1,17 @@
+package ports
+
+import "github.com/hinphansa/7-solutions-challenge/internal/domain"
+
+// SearchRequest is a free-text, typo tolerant search over user names and emails.
+type SearchRequest struct {
+	Query  string
+	Limit  int64    // 0 means the server default
+	Fields []string // fields matched, FieldName and FieldEmail, nil for both, see View.SearchFields
+}
+
+// SearchResult is a user matching a search, most relevant first.
+type SearchResult struct {
+	User       domain.User       `json:"user"`
+	Score      float64           `json:"score"`                // relevance between 0 and 1
+	Highlights map[string]string `json:"highlights,omitempty"` // field to HTML-escaped text with matches in <em>
+}
@@ -0,0 +1,30 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// SettingsRepository stores the settings users changed from their defaults, by user id
+type SettingsRepository interface {
+	// Get returns the stored settings of a user, nil if none are stored
+	Get(ctx context.Context, userID bson.ObjectID) (map[string]any, error)
+	// Replace stores exactly settings for the user
+	Replace(ctx context.Context, userID bson.ObjectID, settings map[string]any) error
+	// Update sets and removes stored settings of the user, others are kept
+	Update(ctx context.Context, userID bson.ObjectID, set map[string]any, unset []string) error
+	// Delete removes the settings of a user, it's not an error if there are none
+	Delete(ctx context.Context, userID bson.ObjectID) error
+}
+
+// SettingsService manages the settings of users. Settings returned always include every
+// setting, with the default of those the user didn't change.
+type SettingsService interface {
+	GetSettings(ctx context.Context, userID bson.ObjectID) (domain.Settings, error)
+	// ReplaceSettings sets every setting, those left out go back to their default
+	ReplaceSettings(ctx context.Context, userID bson.ObjectID, settings map[string]any) (domain.Settings, error)
+	// UpdateSettings changes the settings in patch, a nil value resets a setting to its default
+	UpdateSettings(ctx context.Context, userID bson.ObjectID, patch map[string]any) (domain.Settings, error)
+}
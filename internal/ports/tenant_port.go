@@ -0,0 +1,44 @@
+package ports
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ErrNoTenant is a repository call with a context scoped to no organization, a request
+// that named none when there's no default organization
+var ErrNoTenant = fmt.Errorf("%w: organization required", domain.ErrInvalidArgument)
+
+// TenantKey is the context key of the organization a context is scoped to. Use WithTenant,
+// or set it directly where the context can't be wrapped, like fiber's locals.
+type TenantKey struct{}
+
+// allTenantsKey marks a context spanning every organization, see AllTenants
+type allTenantsKey struct{}
+
+// WithTenant returns a context scoped to the organization with id, repositories only read
+// and write the users of that organization.
+func WithTenant(ctx context.Context, id bson.ObjectID) context.Context {
+	return context.WithValue(ctx, TenantKey{}, id)
+}
+
+// TenantOf returns the organization ctx is scoped to
+func TenantOf(ctx context.Context) (bson.ObjectID, bool) {
+	id, ok := ctx.Value(TenantKey{}).(bson.ObjectID)
+	return id, ok && !id.IsZero()
+}
+
+// AllTenants returns a context reading across every organization, for system jobs like
+// metrics, never for requests. Creating users still needs an organization.
+func AllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantsKey{}, true)
+}
+
+// IsAllTenants reports whether ctx spans every organization, see AllTenants
+func IsAllTenants(ctx context.Context) bool {
+	all, _ := ctx.Value(allTenantsKey{}).(bool)
+	return all
+}
@@ -0,0 +1,63 @@
+package ports
+
+import (
+	"context"
+	"sync"
+)
+
+// TxManager runs units of work, the repository calls made with the context it passes on
+// commit or roll back together
+type TxManager interface {
+	// WithinTx runs fn in a transaction, committed when fn returns nil and rolled back
+	// otherwise. fn may run more than once when the transaction is retried, so it should
+	// only have effects through repositories, see AfterCommit for the others. Calls with a
+	// context already in a transaction join it.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// UnitOfWork is a transaction attempt, it runs the callbacks registered with AfterCommit
+// once the transaction commits
+type UnitOfWork struct {
+	mu          sync.Mutex
+	afterCommit []func()
+}
+
+// unitOfWorkKey is the context key of the unit of work a context runs in
+type unitOfWorkKey struct{}
+
+// NewUnitOfWork returns ctx running in a new unit of work, for TxManager implementations
+// to call for each attempt of a transaction
+func NewUnitOfWork(ctx context.Context) (context.Context, *UnitOfWork) {
+	uow := &UnitOfWork{}
+	return context.WithValue(ctx, unitOfWorkKey{}, uow), uow
+}
+
+// Committed runs the callbacks registered for the unit of work, in registration order
+func (u *UnitOfWork) Committed() {
+	u.mu.Lock()
+	callbacks := u.afterCommit
+	u.afterCommit = nil
+	u.mu.Unlock()
+	for _, fn := range callbacks {
+		fn()
+	}
+}
+
+// InTx reports whether ctx runs in a transaction, whose writes others can't see yet
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(unitOfWorkKey{}).(*UnitOfWork)
+	return ok
+}
+
+// AfterCommit runs fn once the transaction ctx runs in commits, never if it rolls back,
+// and right away outside a transaction
+func AfterCommit(ctx context.Context, fn func()) {
+	uow, ok := ctx.Value(unitOfWorkKey{}).(*UnitOfWork)
+	if !ok {
+		fn()
+		return
+	}
+	uow.mu.Lock()
+	defer uow.mu.Unlock()
+	uow.afterCommit = append(uow.afterCommit, fn)
+}
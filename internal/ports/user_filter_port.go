@@ -0,0 +1,76 @@
+package ports
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/filterexpr"
+)
+
+// UserFilter narrows down the users returned by List, zero values don't filter.
+type UserFilter struct {
+	NamePrefix    string     `json:"name_prefix,omitempty"`    // case-insensitive
+	EmailDomain   string     `json:"email_domain,omitempty"`   // e.g. "example.com"
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`  // inclusive
+	CreatedBefore *time.Time `json:"created_before,omitempty"` // exclusive
+	Search        string     `json:"search,omitempty"`         // case-insensitive prefix of the name or the email
+	Status        string     `json:"status,omitempty"`         // a domain.Status
+
+	// Expr is an ad-hoc filter expression, ANDed with the fields above
+	Expr filterexpr.Expr `json:"-"`
+}
+
+// Fields users can be sorted by
+const (
+	SortByName      = "name"
+	SortByEmail     = "email"
+	SortByCreatedAt = "created_at"
+)
+
+var sortableFields = map[string]bool{
+	SortByName:      true,
+	SortByEmail:     true,
+	SortByCreatedAt: true,
+}
+
+// SortField is one key of a multi-field sort, ties are always broken by id.
+type SortField struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc,omitempty"`
+}
+
+// ParseSort parses a comma separated list of fields, a leading "-" sorts descending,
+// e.g. "-created_at,name".
+func ParseSort(s string) ([]SortField, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var (
+		fields []SortField
+		seen   = map[string]bool{}
+	)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		field := SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if err := field.Validate(); err != nil {
+			return nil, err
+		}
+		if seen[field.Field] {
+			return nil, fmt.Errorf("%w: duplicate sort field %q", domain.ErrInvalidArgument, field.Field)
+		}
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// Validate checks the field is sortable
+func (f SortField) Validate() error {
+	if !sortableFields[f.Field] {
+		return fmt.Errorf("%w: unknown sort field %q", domain.ErrInvalidArgument, f.Field)
+	}
+	return nil
+}
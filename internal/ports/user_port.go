@@ -2,32 +2,104 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/hinphansa/7-solutions-challenge/internal/domain"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+// UserRepository stores the users of the organization of the context, every method fails
+// with ErrNoTenant when the context names none. Emails, and usernames when set, are unique
+// within an organization. porttest.TestUserRepository checks an implementation against it.
 type UserRepository interface {
+	// Create stores a user and returns its id, generated unless the user has one.
+	// domain.ErrEmailTaken or domain.ErrUsernameTaken when another user has them.
 	Create(ctx context.Context, user *domain.User) (*bson.ObjectID, error)
-	GetByID(ctx context.Context, id bson.ObjectID) (*domain.User, error)
+	// GetByID loads the selected fields of a user, every field when none is given
+	GetByID(ctx context.Context, id bson.ObjectID, fields ...string) (*domain.User, error)
+	// GetByEmail loads the user with the canonical email, domain.ErrNotFound otherwise
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
+	// GetByUsername loads the user with the username, or the user it still redirects to,
+	// domain.ErrNotFound otherwise. A current username wins over a previous one.
+	GetByUsername(ctx context.Context, username string) (*domain.User, error)
 	GetAll(ctx context.Context) ([]domain.User, error)
-	List(ctx context.Context, pagination *Pagination) ([]domain.User, error)
-	Update(ctx context.Context, id bson.ObjectID, user *domain.User) error
+	// List returns up to pagination.Limit users matching filter, after the cursor in the
+	// order of pagination.Sort then id. Strings filter and sort case-insensitively.
+	List(ctx context.Context, filter *UserFilter, pagination *Pagination) ([]domain.User, error)
+	// Update applies a partial update, the username excepted, see SetUsername.
+	// domain.ErrNotFound if there's no such user, domain.ErrEmailTaken when another user has the email.
+	Update(ctx context.Context, id bson.ObjectID, update *UserUpdate) error
+	// Delete removes a user, deleting a missing user isn't an error
 	Delete(ctx context.Context, id bson.ObjectID) error
-	Count(ctx context.Context) (int64, error)
+	Count(ctx context.Context, filter *UserFilter) (int64, error) // nil filter counts every user
+	// SetEmailChange stores a pending email change and how to revert it, replacing earlier ones
+	SetEmailChange(ctx context.Context, id bson.ObjectID, change *domain.EmailChange, revert *domain.EmailRevert) error
+	// ConfirmEmailChange atomically swaps in the pending email when it's still email with the
+	// token hash, domain.ErrNotFound otherwise.
+	ConfirmEmailChange(ctx context.Context, id bson.ObjectID, tokenHash string, email string) error
+	// RevertEmailChange atomically restores email when the revert still has the token hash and
+	// drops any pending change, domain.ErrNotFound otherwise.
+	RevertEmailChange(ctx context.Context, id bson.ObjectID, tokenHash string, email string) error
+	// SetStatus atomically moves a user to change.To when its status is still change.From,
+	// domain.ErrNotFound otherwise.
+	SetStatus(ctx context.Context, id bson.ObjectID, change *StatusChange) error
+	// SetUsername sets the username of a user, empty removes it, domain.ErrUsernameTaken when
+	// another user has it. The replaced username redirects to the user until redirectUntil,
+	// expired redirects are dropped.
+	SetUsername(ctx context.Context, id bson.ObjectID, username string, redirectUntil time.Time) error
+	// SetAvatar sets the content hash of the uploaded avatar of a user, domain.ErrNotFound if
+	// there's no such user
+	SetAvatar(ctx context.Context, id bson.ObjectID, hash string) error
+	// Search returns up to limit candidates sharing trigrams with the query, most shared
+	// first. Candidates are only roughly ordered, services rank them.
+	Search(ctx context.Context, query string, limit int64) ([]domain.User, error)
+}
+
+// StatusChange is a status transition of a user
+type StatusChange struct {
+	From   domain.Status
+	To     domain.Status
+	Reason string // empty clears the previous reason
+	At     time.Time
 }
 
 type UserService interface {
 	Register(ctx context.Context, user *domain.User) (*bson.ObjectID, error)
-	GetByID(ctx context.Context, id bson.ObjectID) (*domain.User, error)
+	GetByID(ctx context.Context, id bson.ObjectID, fields ...string) (*domain.User, error)
+	// GetByUsername returns the user with the username, or the user it still redirects to, and
+	// the current username of the user, which differs from the one asked for after a rename.
+	GetByUsername(ctx context.Context, username string, fields ...string) (*domain.User, string, error)
+	// CheckUsername returns the canonical form of a username when it can be claimed, an
+	// invalid argument or domain.ErrUsernameTaken error otherwise.
+	CheckUsername(ctx context.Context, username string) (string, error)
 	GetAll(ctx context.Context) ([]domain.User, error)
-	List(ctx context.Context, pagination *Pagination) ([]domain.User, error)
-	Update(ctx context.Context, id bson.ObjectID, user *domain.User) error
+	List(ctx context.Context, req *ListRequest) (*UserPage, error)
+	Search(ctx context.Context, req *SearchRequest) ([]SearchResult, error)
+	// Update validates and applies a partial update, see UserUpdate. A new email isn't
+	// applied, it starts an email change instead.
+	Update(ctx context.Context, id bson.ObjectID, update *UserUpdate) error
+	// RequestEmailChange sends a confirmation token to the new email and a revert link to the
+	// current one, the email only changes once the token is confirmed.
+	RequestEmailChange(ctx context.Context, id bson.ObjectID, email string) error
+	ConfirmEmailChange(ctx context.Context, token string) error
+	RevertEmailChange(ctx context.Context, token string) error
+	// ChangeStatus moves a user to another account status, for reasons and allowed
+	// transitions see the services package. It returns the updated user.
+	ChangeStatus(ctx context.Context, id bson.ObjectID, status domain.Status, reason string) (*domain.User, error)
 	Delete(ctx context.Context, id bson.ObjectID) error
 	Count(ctx context.Context) (int64, error)
+
+	// ListPendingRegistrations returns the accounts waiting for an admin to approve them, oldest first
+	ListPendingRegistrations(ctx context.Context, req *PageRequest) (*UserPage, error)
+	// ApproveRegistration activates an account waiting for approval and returns it
+	ApproveRegistration(ctx context.Context, id bson.ObjectID) (*domain.User, error)
+	// RejectRegistration deletes an account waiting for approval
+	RejectRegistration(ctx context.Context, id bson.ObjectID) error
 }
 
 type AuthService interface {
 	Login(ctx context.Context, email string, password string) (string, error)
+	// CheckActive fails with domain.ErrForbidden unless the user with id is still active,
+	// so tokens of users suspended or deleted since they logged in are refused
+	CheckActive(ctx context.Context, id bson.ObjectID) error
 }
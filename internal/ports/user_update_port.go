@@ -0,0 +1,273 @@
+package ports
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+)
+
+// UpdateOp is what an update does to a field
+type UpdateOp int
+
+const (
+	Keep  UpdateOp = iota // leave the field as it is
+	Set                   // set the field to the value
+	Clear                 // remove the value of the field
+)
+
+// FieldUpdate is the change to a single field. The zero value keeps the field, so an
+// empty string is a value to set rather than "not provided".
+type FieldUpdate[T any] struct {
+	Op    UpdateOp
+	Value T
+}
+
+// SetTo returns an update setting the field to value
+func SetTo[T any](value T) FieldUpdate[T] {
+	return FieldUpdate[T]{Op: Set, Value: value}
+}
+
+// Cleared returns an update clearing the field
+func Cleared[T any]() FieldUpdate[T] {
+	return FieldUpdate[T]{Op: Clear}
+}
+
+// UserUpdate is a partial update of a user, fields are kept unless their update says otherwise.
+type UserUpdate struct {
+	Name  FieldUpdate[string]
+	Email FieldUpdate[string]
+
+	// Username is optional, the previous username redirects to the user for a while
+	Username FieldUpdate[string]
+
+	// optional profile fields, they can be cleared
+	DisplayName FieldUpdate[string]
+	Phone       FieldUpdate[string]
+	Locale      FieldUpdate[string]
+	TimeZone    FieldUpdate[string]
+	AvatarURL   FieldUpdate[string]
+	Bio         FieldUpdate[string]
+
+	// Attributes updates custom attributes by name, attributes left out are kept
+	Attributes map[string]FieldUpdate[any]
+}
+
+// Updatable user fields, named as in responses
+var updatableFields = []string{
+	FieldName, FieldEmail, FieldUsername,
+	FieldDisplayName, FieldPhone, FieldLocale, FieldTimeZone, FieldAvatarURL, FieldBio,
+}
+
+// UpdatableFields returns the names of the fields an update can change
+func UpdatableFields() []string {
+	return slices.Clone(updatableFields)
+}
+
+// fields returns the updates of the fields by name
+func (u *UserUpdate) fields() map[string]*FieldUpdate[string] {
+	return map[string]*FieldUpdate[string]{
+		FieldName:        &u.Name,
+		FieldEmail:       &u.Email,
+		FieldUsername:    &u.Username,
+		FieldDisplayName: &u.DisplayName,
+		FieldPhone:       &u.Phone,
+		FieldLocale:      &u.Locale,
+		FieldTimeZone:    &u.TimeZone,
+		FieldAvatarURL:   &u.AvatarURL,
+		FieldBio:         &u.Bio,
+	}
+}
+
+// profileParsers validate and canonicalize the optional profile fields
+var profileParsers = map[string]func(string) (string, error){
+	FieldDisplayName: domain.ParseDisplayName,
+	FieldPhone:       domain.ParsePhone,
+	FieldLocale:      domain.ParseLocale,
+	FieldTimeZone:    domain.ParseTimeZone,
+	FieldAvatarURL:   domain.ParseAvatarURL,
+	FieldBio:         domain.ParseBio,
+}
+
+// Empty reports whether the update changes nothing
+func (u *UserUpdate) Empty() bool {
+	for _, f := range u.fields() {
+		if f.Op != Keep {
+			return false
+		}
+	}
+	for _, f := range u.Attributes {
+		if f.Op != Keep {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalize trims the values of the update. Setting an optional field to an empty
+// value clears it.
+func (u *UserUpdate) Normalize() {
+	for name, f := range u.fields() {
+		f.Value = strings.TrimSpace(f.Value)
+		if _, optional := profileParsers[name]; (optional || name == FieldUsername) && f.Op == Set && f.Value == "" {
+			*f = Cleared[string]()
+		}
+	}
+}
+
+// CanonicalizeProfile puts the profile values of the update in canonical form, e.g. phone
+// numbers in E.164, and fails on invalid values.
+func (u *UserUpdate) CanonicalizeProfile() error {
+	fields := u.fields()
+	for name, parse := range profileParsers {
+		f := fields[name]
+		if f.Op != Set {
+			continue
+		}
+		value, err := parse(f.Value)
+		if err != nil {
+			return err
+		}
+		f.Value = value
+	}
+	return nil
+}
+
+// Validate checks each changed field, required fields can be set but not cleared.
+func (u *UserUpdate) Validate() error {
+	if u.Empty() {
+		return fmt.Errorf("%w: update has no fields", domain.ErrInvalidArgument)
+	}
+
+	switch u.Name.Op {
+	case Clear:
+		return fmt.Errorf("%w: name is required and can't be cleared", domain.ErrInvalidArgument)
+	case Set:
+		if len([]rune(u.Name.Value)) < 3 {
+			return fmt.Errorf("%w: name must be at least 3 characters", domain.ErrInvalidArgument)
+		}
+	}
+
+	switch u.Email.Op {
+	case Clear:
+		return fmt.Errorf("%w: email is required and can't be cleared", domain.ErrInvalidArgument)
+	case Set:
+		if _, err := domain.ParseEmail(u.Email.Value, domain.EmailPolicy{}); err != nil {
+			return err
+		}
+	}
+
+	if u.Username.Op == Set {
+		if _, err := domain.ParseUsername(u.Username.Value, domain.UsernamePolicy{}); err != nil {
+			return err
+		}
+	}
+
+	fields := u.fields()
+	for name, parse := range profileParsers {
+		if f := fields[name]; f.Op == Set {
+			if _, err := parse(f.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SetField sets a field by its name, for transports carrying field names like field masks and patches.
+func (u *UserUpdate) SetField(field string, value string) error {
+	f, ok := u.fields()[field]
+	if !ok {
+		return unknownUpdateField(field)
+	}
+	*f = SetTo(value)
+	return nil
+}
+
+// ClearField clears a field by its name
+func (u *UserUpdate) ClearField(field string) error {
+	f, ok := u.fields()[field]
+	if !ok {
+		return unknownUpdateField(field)
+	}
+	*f = Cleared[string]()
+	return nil
+}
+
+// AttributePath returns the attribute name of a path like "attributes.department"
+func AttributePath(path string) (string, bool) {
+	name, ok := strings.CutPrefix(path, FieldAttributes+".")
+	return name, ok
+}
+
+// SetAttribute sets a custom attribute, the value is checked against the schema by the service
+func (u *UserUpdate) SetAttribute(name string, value any) error {
+	return u.updateAttribute(name, SetTo(value))
+}
+
+// ClearAttribute removes a custom attribute
+func (u *UserUpdate) ClearAttribute(name string) error {
+	return u.updateAttribute(name, Cleared[any]())
+}
+
+func (u *UserUpdate) updateAttribute(name string, update FieldUpdate[any]) error {
+	if !domain.ValidAttributeName(name) {
+		return fmt.Errorf("%w: invalid attribute name %q", domain.ErrInvalidArgument, name)
+	}
+	if u.Attributes == nil {
+		u.Attributes = map[string]FieldUpdate[any]{}
+	}
+	u.Attributes[name] = update
+	return nil
+}
+
+// ValidateAttributes checks the attribute updates against the schema and normalizes
+// their values, see domain.AttributeSchema.
+func (u *UserUpdate) ValidateAttributes(schema domain.AttributeSchema) error {
+	for name, f := range u.Attributes {
+		switch f.Op {
+		case Set:
+			value, err := schema.CheckValue(name, f.Value)
+			if err != nil {
+				return err
+			}
+			u.Attributes[name] = SetTo(value)
+		case Clear:
+			if err := schema.CheckClear(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// UpdatableValues returns the current values of the updatable fields of user by name,
+// empty optional fields are left out.
+func UpdatableValues(user *domain.User) map[string]string {
+	values := map[string]string{
+		FieldName:        user.Name,
+		FieldEmail:       user.Email,
+		FieldUsername:    user.Username,
+		FieldDisplayName: user.DisplayName,
+		FieldPhone:       user.Phone,
+		FieldLocale:      user.Locale,
+		FieldTimeZone:    user.TimeZone,
+		FieldAvatarURL:   user.AvatarURL,
+		FieldBio:         user.Bio,
+	}
+	for name := range profileParsers {
+		if values[name] == "" {
+			delete(values, name)
+		}
+	}
+	if user.Username == "" {
+		delete(values, FieldUsername)
+	}
+	return values
+}
+
+func unknownUpdateField(field string) error {
+	return fmt.Errorf("%w: field %q can't be updated, expected one of %s or attributes.<name>",
+		domain.ErrInvalidArgument, field, strings.Join(updatableFields, ", "))
+}
@@ -0,0 +1,132 @@
+package ports
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/filterexpr"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// View is the representation of a user a caller is allowed to see
+type View int
+
+const (
+	ViewPublic View = iota // anonymous callers and other users, see PublicFields
+	ViewSelf               // the user themselves, every field but internal ones
+	ViewAdmin              // admins, every field including the status reason and roles
+)
+
+// publicFields are the fields anyone can see, enough for a profile page without contact details
+var publicFields = []string{
+	FieldID, FieldName, FieldUsername, FieldDisplayName, FieldAvatarURL, FieldAvatarHash, FieldBio,
+}
+
+// PublicFields returns the fields of the public view
+func PublicFields() []string {
+	return slices.Clone(publicFields)
+}
+
+// Caller is the authenticated principal of a request, the zero value is an anonymous caller
+type Caller struct {
+	ID    bson.ObjectID
+	Roles []string
+}
+
+// Anonymous reports whether the request isn't authenticated
+func (c Caller) Anonymous() bool {
+	return c.ID.IsZero()
+}
+
+// ViewOf returns the view the caller has of the user with id, pass a zero id for a list
+// of users, where only admins see more than the public view.
+func (c Caller) ViewOf(id bson.ObjectID) View {
+	switch {
+	case slices.Contains(c.Roles, domain.RoleAdmin):
+		return ViewAdmin
+	case !c.Anonymous() && c.ID == id:
+		return ViewSelf
+	default:
+		return ViewPublic
+	}
+}
+
+// Fields restricts a sparse fieldset to the fields the view can see. Nil fields select
+// every visible field, so the result is nil only when the view sees every field.
+func (v View) Fields(fields []string) []string {
+	if v != ViewPublic {
+		return fields
+	}
+	if fields == nil {
+		return PublicFields()
+	}
+	return slices.DeleteFunc(slices.Clone(fields), func(field string) bool {
+		return !slices.Contains(publicFields, field)
+	})
+}
+
+// CheckSort fails when the public view sorts by email, the next page token carries the
+// sort values of the last user of a page.
+func (v View) CheckSort(sort []SortField) error {
+	if v == ViewPublic && slices.ContainsFunc(sort, func(f SortField) bool { return f.Field == SortByEmail }) {
+		return fmt.Errorf("%w: sorting by email requires authentication", domain.ErrForbidden)
+	}
+	return nil
+}
+
+// publicFilterFields are the fields the public view can use in a filter expression, the
+// visible ones and the timestamps it can already page by
+var publicFilterFields = []string{FieldID, FieldName, FieldCreatedAt, FieldUpdatedAt}
+
+// CheckFilter fails when the public view filters on fields it can't see, the email, the
+// status or custom attributes. Narrowing such a filter a character at a time would recover
+// them from the users it matches, whatever fields the response hides.
+func (v View) CheckFilter(filter *UserFilter) error {
+	if v != ViewPublic {
+		return nil
+	}
+	forbidden := func(what string) error {
+		return fmt.Errorf("%w: filtering by %s requires an admin", domain.ErrForbidden, what)
+	}
+	switch {
+	case filter.EmailDomain != "":
+		return forbidden("email domain")
+	case filter.Search != "":
+		return forbidden("name or email prefix, filter by name prefix instead")
+	case filter.Status != "":
+		return forbidden("status")
+	}
+	for _, name := range filterexpr.FieldNames(filter.Expr) {
+		if !slices.Contains(publicFilterFields, name) {
+			return forbidden(name)
+		}
+	}
+	return nil
+}
+
+// SearchFields returns the fields a search in the view matches, the public view only
+// matches names so results can't be probed for an email
+func (v View) SearchFields() []string {
+	if v == ViewPublic {
+		return []string{FieldName}
+	}
+	return []string{FieldName, FieldEmail}
+}
+
+// SearchResult returns result as seen in the view, the user is masked to the visible fields
+// and highlights of other fields are left out.
+func (v View) SearchResult(result SearchResult) SearchResult {
+	visible := v.Fields(nil)
+	if visible == nil {
+		return result
+	}
+	seen := SearchResult{User: result.User, Score: result.Score, Highlights: map[string]string{}}
+	MaskUser(&seen.User, visible)
+	for field, highlight := range result.Highlights {
+		if slices.Contains(visible, field) {
+			seen.Highlights[field] = highlight
+		}
+	}
+	return seen
+}
@@ -0,0 +1,180 @@
+// Package search implements the typo-tolerant matching behind user search: text is split
+// into lowercase tokens, tokens into trigrams, and candidates are ranked by how well their
+// tokens match the query tokens.
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Minimum relevance of a result, below that a trigram overlap is a coincidence
+const MinScore = 0.3
+
+type span struct {
+	start, end int // byte offsets
+}
+
+type tokenSpan struct {
+	text string // lowercase
+	span
+}
+
+// tokenize splits text on anything that isn't a letter or a digit, so
+// "Jon-Paul <jp@gmail.com>" gives jon, paul, jp, gmail, com.
+func tokenize(text string) []tokenSpan {
+	var (
+		tokens []tokenSpan
+		start  = -1
+	)
+	for i, r := range text + " " {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			tokens = append(tokens, tokenSpan{text: strings.ToLower(text[start:i]), span: span{start, i}})
+			start = -1
+		}
+	}
+	return tokens
+}
+
+// Tokens returns the lowercase tokens of text
+func Tokens(text string) []string {
+	spans := tokenize(text)
+	tokens := make([]string, len(spans))
+	for i, t := range spans {
+		tokens[i] = t.text
+	}
+	return tokens
+}
+
+// Trigrams returns the distinct trigrams of the tokens of text. Tokens are padded with a
+// space on both sides, so short tokens still produce grams and prefixes weigh more.
+func Trigrams(text string) []string {
+	seen := map[string]bool{}
+	var grams []string
+	for _, token := range Tokens(text) {
+		for _, g := range tokenTrigrams(token) {
+			if !seen[g] {
+				seen[g] = true
+				grams = append(grams, g)
+			}
+		}
+	}
+	sort.Strings(grams)
+	return grams
+}
+
+func tokenTrigrams(token string) []string {
+	runes := []rune(" " + token + " ")
+	grams := make([]string, 0, len(runes)-2)
+	for i := 0; i+3 <= len(runes); i++ {
+		grams = append(grams, string(runes[i:i+3]))
+	}
+	return grams
+}
+
+// similarity is the Dice coefficient of the trigrams of two tokens, 1 for equal tokens
+func similarity(a, b string) float64 {
+	ga, gb := tokenTrigrams(a), tokenTrigrams(b)
+	set := make(map[string]int, len(ga))
+	for _, g := range ga {
+		set[g]++
+	}
+	common := 0
+	for _, g := range gb {
+		if set[g] > 0 {
+			set[g]--
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(ga)+len(gb))
+}
+
+// tokenScore rates how well a document token matches a query token: 1 when equal,
+// 0.9 when the query is a prefix of the token, the trigram similarity otherwise.
+func tokenScore(query, token string) (score float64, prefix bool) {
+	switch {
+	case query == token:
+		return 1, false
+	case strings.HasPrefix(token, query):
+		return 0.9, true
+	default:
+		return similarity(query, token), false
+	}
+}
+
+// Field is a named text to search in
+type Field struct {
+	Name string
+	Text string
+}
+
+// Match is the relevance of a document to a query, with the spans of the matched tokens per field
+type Match struct {
+	Score float64
+	spans map[string][]span
+}
+
+// Score rates fields against the query: the average over query tokens of their best
+// matching token in any field. Fields earlier in the list win ties.
+func Score(query string, fields ...Field) Match {
+	queryTokens := Tokens(query)
+	match := Match{spans: map[string][]span{}}
+	if len(queryTokens) == 0 {
+		return match
+	}
+
+	total := 0.0
+	for _, q := range queryTokens {
+		best, bestField, bestSpan := 0.0, "", span{}
+		for _, field := range fields {
+			for _, token := range tokenize(field.Text) {
+				score, prefix := tokenScore(q, token.text)
+				if score <= best {
+					continue
+				}
+				best, bestField, bestSpan = score, field.Name, token.span
+				if prefix {
+					// only highlight the matched prefix
+					bestSpan.end = min(bestSpan.start+len(q), token.end)
+				}
+			}
+		}
+		if best >= MinScore {
+			match.spans[bestField] = append(match.spans[bestField], bestSpan)
+		}
+		total += best
+	}
+	match.Score = total / float64(len(queryTokens))
+	return match
+}
+
+// Highlight returns the HTML-escaped text of a field with matched tokens wrapped in <em>,
+// and false when nothing matched in that field.
+func (m Match) Highlight(field, text string) (string, bool) {
+	spans := m.spans[field]
+	if len(spans) == 0 {
+		return "", false
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s.start < last {
+			continue // same token matched by two query tokens
+		}
+		b.WriteString(html.EscapeString(text[last:s.start]))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(text[s.start:s.end]))
+		b.WriteString("</em>")
+		last = s.end
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String(), true
+}
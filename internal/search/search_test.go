@@ -0,0 +1,57 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	got := Tokens("Jon-Paul <JP@gmail.com>")
+	want := []string{"jon", "paul", "jp", "gmail", "com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestTrigrams(t *testing.T) {
+	got := Trigrams("Jon jon")
+	want := []string{" jo", "jon", "on "}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestScore_Ranking(t *testing.T) {
+	query := "jonh gmail"
+	exact := Score(query, Field{"name", "Jonh Smith"}, Field{"email", "jonh@gmail.com"})
+	typo := Score(query, Field{"name", "John Smith"}, Field{"email", "john@gmail.com"})
+	prefix := Score("jon gmail", Field{"name", "Jonathan Smith"}, Field{"email", "jonathan@gmail.com"})
+	unrelated := Score(query, Field{"name", "Alice Martin"}, Field{"email", "alice@example.com"})
+
+	if exact.Score != 1 {
+		t.Fatalf("expected exact match to score 1, got %v", exact.Score)
+	}
+	if typo.Score < MinScore || typo.Score >= exact.Score {
+		t.Fatalf("expected typo to score between %v and %v, got %v", MinScore, exact.Score, typo.Score)
+	}
+	if prefix.Score < 0.9 {
+		t.Fatalf("expected prefix match to score at least 0.9, got %v", prefix.Score)
+	}
+	if unrelated.Score >= MinScore {
+		t.Fatalf("expected unrelated user to score below %v, got %v", MinScore, unrelated.Score)
+	}
+}
+
+func TestMatch_Highlight(t *testing.T) {
+	match := Score("jon gmail", Field{"name", "Jonathan <Doe>"}, Field{"email", "jonathan@gmail.com"})
+
+	if got, _ := match.Highlight("name", "Jonathan <Doe>"); got != "<em>Jon</em>athan &lt;Doe&gt;" {
+		t.Fatalf("unexpected name highlight %q", got)
+	}
+	if got, _ := match.Highlight("email", "jonathan@gmail.com"); got != "jonathan@<em>gmail</em>.com" {
+		t.Fatalf("unexpected email highlight %q", got)
+	}
+	if _, ok := Score("zzz", Field{"name", "Jonathan"}).Highlight("name", "Jonathan"); ok {
+		t.Fatalf("expected no highlight")
+	}
+}
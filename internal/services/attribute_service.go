@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+)
+
+var _ ports.AttributeService = &attrsvc{}
+
+type attrsvc struct {
+	attributeRepo ports.AttributeSchemaRepository
+}
+
+func NewAttributeService(attributeRepo ports.AttributeSchemaRepository) *attrsvc {
+	return &attrsvc{attributeRepo: attributeRepo}
+}
+
+func (s *attrsvc) ListAttributes(ctx context.Context) (domain.AttributeSchema, error) {
+	return s.attributeRepo.List(ctx)
+}
+
+func (s *attrsvc) PutAttribute(ctx context.Context, def *domain.AttributeDefinition) error {
+	def.Description = strings.TrimSpace(def.Description)
+	if err := def.Validate(); err != nil {
+		return err
+	}
+	return s.attributeRepo.Put(ctx, def)
+}
+
+func (s *attrsvc) DeleteAttribute(ctx context.Context, name string) error {
+	return s.attributeRepo.Delete(ctx, name)
+}
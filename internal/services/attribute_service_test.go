@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/mocks"
+)
+
+func TestAttributeService_PutAttribute_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	attributeRepo := mocks.NewMockAttributeSchemaRepository(ctrl)
+	attributeService := NewAttributeService(attributeRepo)
+
+	want := &domain.AttributeDefinition{Name: "department", Type: domain.AttributeString, Description: "Team of the user"}
+	attributeRepo.EXPECT().Put(gomock.Any(), gomock.Eq(want)).Return(nil)
+
+	err := attributeService.PutAttribute(context.Background(), &domain.AttributeDefinition{
+		Name: "department", Type: domain.AttributeString, Description: " Team of the user ",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestAttributeService_PutAttribute_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	attributeService := NewAttributeService(mocks.NewMockAttributeSchemaRepository(ctrl))
+
+	err := attributeService.PutAttribute(context.Background(), &domain.AttributeDefinition{Name: "department", Type: "date"})
+	if !errors.Is(err, domain.ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument error, got %v", err)
+	}
+}
@@ -2,8 +2,13 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
 	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 var _ ports.AuthService = &authsvc{}
@@ -12,30 +17,103 @@ type authsvc struct {
 	userRepo       ports.UserRepository
 	passwordHasher PasswordHasher
 	tokenGenerator TokenGenerator
+	emailPolicy    domain.EmailPolicy
+	groupService   ports.GroupService
 }
 
-func NewAuthService(userRepo ports.UserRepository, passwordHasher PasswordHasher, tokenGenerator TokenGenerator) *authsvc {
-	return &authsvc{
+// AuthServiceOption configures optional behaviour of the auth service
+type AuthServiceOption func(*authsvc)
+
+// WithLoginEmailPolicy sets how login emails are canonicalized, it must match the
+// policy of the user service so users are found by the email they registered with.
+func WithLoginEmailPolicy(policy domain.EmailPolicy) AuthServiceOption {
+	return func(s *authsvc) {
+		s.emailPolicy = policy
+	}
+}
+
+// WithGroupRoles adds the roles users get from their groups to their tokens, group changes
+// apply from the next login.
+func WithGroupRoles(groupService ports.GroupService) AuthServiceOption {
+	return func(s *authsvc) {
+		s.groupService = groupService
+	}
+}
+
+func NewAuthService(userRepo ports.UserRepository, passwordHasher PasswordHasher, tokenGenerator TokenGenerator, opts ...AuthServiceOption) *authsvc {
+	s := &authsvc{
 		userRepo:       userRepo,
 		passwordHasher: passwordHasher,
 		tokenGenerator: tokenGenerator,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *authsvc) Login(ctx context.Context, email string, password string) (string, error) {
-	user, err := s.userRepo.GetByEmail(ctx, email)
+	canonical, err := domain.ParseEmail(email, s.emailPolicy)
 	if err != nil {
 		return "", errUserNotFound
 	}
 
+	// emails are unique per organization, the context is scoped to the one logged into
+	user, err := s.userRepo.GetByEmail(ctx, canonical.String())
+	if err != nil {
+		return "", lookupError(err, errUserNotFound)
+	}
+
 	if err := s.passwordHasher.Compare(password, user.Password); err != nil {
 		return "", errInvalidPassword
 	}
 
-	token, err := s.tokenGenerator.Generate(user.ID, user.Email)
+	// checked after the password so the status isn't revealed to anyone guessing emails
+	if user.Status != domain.StatusActive {
+		return "", fmt.Errorf("%w: account is %s", domain.ErrForbidden, user.Status)
+	}
+
+	roles := user.Roles
+	if s.groupService != nil {
+		groupRoles, err := s.groupService.RolesOf(ctx, user.ID)
+		if err != nil {
+			return "", err
+		}
+		roles = mergeRoles(roles, groupRoles)
+	}
+
+	token, err := s.tokenGenerator.Generate(user.ID, user.TenantID, user.Email, roles)
 	if err != nil {
 		return "", errUnableToGenerateToken
 	}
 
 	return token, nil
 }
+
+// CheckActive fails with domain.ErrForbidden unless the user with id is still active. It
+// runs on every authenticated request, the lookup goes through the user cache when there's
+// one, which the status change drops the user from.
+func (s *authsvc) CheckActive(ctx context.Context, id bson.ObjectID) error {
+	user, err := s.userRepo.GetByID(ctx, id, ports.FieldStatus)
+	if errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("%w: account is %s", domain.ErrForbidden, domain.StatusDeleted)
+	}
+	if err != nil {
+		return err
+	}
+	if user.Status != domain.StatusActive {
+		return fmt.Errorf("%w: account is %s", domain.ErrForbidden, user.Status)
+	}
+	return nil
+}
+
+// mergeRoles returns the roles of a user followed by the group roles it doesn't have yet
+func mergeRoles(roles, groupRoles []string) []string {
+	merged := slices.Clone(roles)
+	for _, role := range groupRoles {
+		if !slices.Contains(merged, role) {
+			merged = append(merged, role)
+		}
+	}
+	return merged
+}
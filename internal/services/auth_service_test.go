@@ -8,6 +8,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/hinphansa/7-solutions-challenge/internal/domain"
 	"github.com/hinphansa/7-solutions-challenge/internal/mocks"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
@@ -22,13 +23,15 @@ func TestAuthService_Login_Success(t *testing.T) {
 
 	user := &domain.User{
 		ID:       bson.ObjectID{},
+		TenantID: bson.NewObjectID(),
 		Email:    "test@example.com",
 		Password: "password",
+		Status:   domain.StatusActive,
 	}
 
 	userRepo.EXPECT().GetByEmail(gomock.Any(), gomock.Eq(user.Email)).Return(user, nil).AnyTimes()
 	passwordHasher.EXPECT().Compare(gomock.Eq(user.Password), gomock.Eq(user.Password)).Return(nil).AnyTimes()
-	tokenGenerator.EXPECT().Generate(gomock.Eq(user.ID), gomock.Eq(user.Email)).Return("token", nil).AnyTimes()
+	tokenGenerator.EXPECT().Generate(gomock.Eq(user.ID), gomock.Eq(user.TenantID), gomock.Eq(user.Email), gomock.Nil()).Return("token", nil).AnyTimes()
 
 	token, err := authService.Login(context.Background(), user.Email, user.Password)
 	if err != nil {
@@ -40,6 +43,47 @@ func TestAuthService_Login_Success(t *testing.T) {
 	}
 }
 
+func TestAuthService_Login_CanonicalEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	passwordHasher := mocks.NewMockPasswordHasher(ctrl)
+	tokenGenerator := mocks.NewMockTokenGenerator(ctrl)
+	authService := NewAuthService(userRepo, passwordHasher, tokenGenerator,
+		WithLoginEmailPolicy(domain.EmailPolicy{FoldPlusAddressing: true}))
+
+	user := &domain.User{Email: "test@example.com", Password: "hash", Status: domain.StatusActive}
+	userRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(user, nil)
+	passwordHasher.EXPECT().Compare("password", "hash").Return(nil)
+	tokenGenerator.EXPECT().Generate(gomock.Any(), gomock.Any(), "test@example.com", gomock.Any()).Return("token", nil)
+
+	if _, err := authService.Login(context.Background(), " Test+Login@EXAMPLE.com", "password"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestAuthService_Login_GroupRoles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	passwordHasher := mocks.NewMockPasswordHasher(ctrl)
+	tokenGenerator := mocks.NewMockTokenGenerator(ctrl)
+	groupService := mocks.NewMockGroupService(ctrl)
+	authService := NewAuthService(userRepo, passwordHasher, tokenGenerator, WithGroupRoles(groupService))
+
+	user := &domain.User{ID: bson.NewObjectID(), Email: "test@example.com", Password: "hash", Status: domain.StatusActive, Roles: []string{"admin"}}
+	userRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(user, nil)
+	passwordHasher.EXPECT().Compare("password", "hash").Return(nil)
+	groupService.EXPECT().RolesOf(gomock.Any(), user.ID).Return([]string{"admin", "support"}, nil)
+	tokenGenerator.EXPECT().Generate(user.ID, gomock.Any(), user.Email, []string{"admin", "support"}).Return("token", nil)
+
+	if _, err := authService.Login(context.Background(), "test@example.com", "password"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
 func TestAuthService_Login_UserNotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -74,6 +118,7 @@ func TestAuthService_Login_PasswordCompareError(t *testing.T) {
 		ID:       bson.ObjectID{},
 		Email:    "test@example.com",
 		Password: "password",
+		Status:   domain.StatusActive,
 	}
 
 	userRepo.EXPECT().GetByEmail(gomock.Any(), gomock.Eq(user.Email)).Return(user, nil).AnyTimes()
@@ -102,11 +147,12 @@ func TestAuthService_Login_TokenGeneratorError(t *testing.T) {
 		ID:       bson.ObjectID{},
 		Email:    "test@example.com",
 		Password: "password",
+		Status:   domain.StatusActive,
 	}
 
 	userRepo.EXPECT().GetByEmail(gomock.Any(), gomock.Eq(user.Email)).Return(user, nil).AnyTimes()
 	passwordHasher.EXPECT().Compare(gomock.Eq(user.Password), gomock.Eq(user.Password)).Return(nil).AnyTimes()
-	tokenGenerator.EXPECT().Generate(gomock.Eq(user.ID), gomock.Eq(user.Email)).Return("", errors.New("token generator error")).AnyTimes()
+	tokenGenerator.EXPECT().Generate(gomock.Eq(user.ID), gomock.Any(), gomock.Eq(user.Email), gomock.Nil()).Return("", errors.New("token generator error")).AnyTimes()
 
 	_, err := authService.Login(context.Background(), user.Email, user.Password)
 	if err == nil {
@@ -117,3 +163,67 @@ func TestAuthService_Login_TokenGeneratorError(t *testing.T) {
 		t.Fatalf("expected error %v, got %v", "token generator error", err)
 	}
 }
+
+func TestAuthService_Login_InactiveAccount(t *testing.T) {
+	for _, status := range []domain.Status{domain.StatusPendingVerification, domain.StatusSuspended, domain.StatusLocked, domain.StatusDeactivated, domain.StatusDeleted} {
+		t.Run(string(status), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := mocks.NewMockUserRepository(ctrl)
+			passwordHasher := mocks.NewMockPasswordHasher(ctrl)
+			tokenGenerator := mocks.NewMockTokenGenerator(ctrl)
+			authService := NewAuthService(userRepo, passwordHasher, tokenGenerator)
+
+			user := &domain.User{Email: "test@example.com", Password: "hash", Status: status}
+			userRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(user, nil)
+			passwordHasher.EXPECT().Compare("password", "hash").Return(nil)
+
+			_, err := authService.Login(context.Background(), "test@example.com", "password")
+			if !errors.Is(err, domain.ErrForbidden) {
+				t.Fatalf("expected forbidden error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAuthService_Login_InactiveAccountWrongPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	passwordHasher := mocks.NewMockPasswordHasher(ctrl)
+	tokenGenerator := mocks.NewMockTokenGenerator(ctrl)
+	authService := NewAuthService(userRepo, passwordHasher, tokenGenerator)
+
+	user := &domain.User{Email: "test@example.com", Password: "hash", Status: domain.StatusSuspended}
+	userRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(user, nil)
+	passwordHasher.EXPECT().Compare("wrong", "hash").Return(errors.New("mismatch"))
+
+	// the status is only revealed to the account owner
+	if _, err := authService.Login(context.Background(), "test@example.com", "wrong"); !errors.Is(err, errInvalidPassword) {
+		t.Fatalf("expected invalid password, got %v", err)
+	}
+}
+
+func TestAuthService_CheckActive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	authService := NewAuthService(userRepo, mocks.NewMockPasswordHasher(ctrl), mocks.NewMockTokenGenerator(ctrl))
+
+	active, suspended, deleted := bson.NewObjectID(), bson.NewObjectID(), bson.NewObjectID()
+	userRepo.EXPECT().GetByID(gomock.Any(), active, ports.FieldStatus).Return(&domain.User{ID: active, Status: domain.StatusActive}, nil)
+	userRepo.EXPECT().GetByID(gomock.Any(), suspended, ports.FieldStatus).Return(&domain.User{ID: suspended, Status: domain.StatusSuspended}, nil)
+	userRepo.EXPECT().GetByID(gomock.Any(), deleted, ports.FieldStatus).Return(nil, domain.ErrNotFound)
+
+	if err := authService.CheckActive(context.Background(), active); err != nil {
+		t.Fatalf("expected an active user to pass, got %v", err)
+	}
+	for _, id := range []bson.ObjectID{suspended, deleted} {
+		if err := authService.CheckActive(context.Background(), id); !errors.Is(err, domain.ErrForbidden) {
+			t.Fatalf("expected forbidden, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/imaging"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var _ ports.AvatarService = &avatarsvc{}
+
+// Avatar defaults
+const (
+	defaultAvatarMaxBytes = 5 << 20
+)
+
+var defaultAvatarSizes = []int{64, 128, 256, 512}
+
+// avatarsvc stores avatars content addressed: thumbnails are keyed by the sha256 of the
+// upload, so the same image is stored once and a key never changes content. Thumbnails of
+// replaced avatars are kept, other users may share them.
+type avatarsvc struct {
+	userRepo ports.UserRepository
+	blobs    ports.BlobStore
+	maxBytes int64
+	sizes    []int // ascending
+}
+
+// AvatarServiceOption configures optional behaviour of the avatar service
+type AvatarServiceOption func(*avatarsvc)
+
+// WithAvatarLimits sets the largest upload accepted, in bytes, and the thumbnail sizes in
+// pixels. Empty sizes keep the default sizes.
+func WithAvatarLimits(maxBytes int64, sizes []int) AvatarServiceOption {
+	return func(s *avatarsvc) {
+		s.maxBytes = maxBytes
+		if len(sizes) > 0 {
+			s.sizes = slices.Sorted(slices.Values(sizes))
+		}
+	}
+}
+
+func NewAvatarService(userRepo ports.UserRepository, blobs ports.BlobStore, opts ...AvatarServiceOption) *avatarsvc {
+	s := &avatarsvc{
+		userRepo: userRepo,
+		blobs:    blobs,
+		maxBytes: defaultAvatarMaxBytes,
+		sizes:    defaultAvatarSizes,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *avatarsvc) UploadAvatar(ctx context.Context, userID bson.ObjectID, r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.maxBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > s.maxBytes {
+		return "", fmt.Errorf("%w: avatar is larger than %d bytes", domain.ErrInvalidArgument, s.maxBytes)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	// the largest thumbnail is written last, once it exists every size does
+	exists, err := s.blobs.Exists(ctx, s.key(hash, s.sizes[len(s.sizes)-1]))
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		if err := s.storeThumbnails(ctx, hash, data); err != nil {
+			return "", err
+		}
+	}
+
+	if err := s.userRepo.SetAvatar(ctx, userID, hash); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+func (s *avatarsvc) storeThumbnails(ctx context.Context, hash string, data []byte) error {
+	img, contentType, err := imaging.Decode(data)
+	if err != nil {
+		return err
+	}
+	contentType = imaging.ThumbnailType(contentType)
+
+	var buf bytes.Buffer
+	for _, size := range s.sizes {
+		buf.Reset()
+		if err := imaging.Encode(&buf, imaging.Thumbnail(img, size), contentType); err != nil {
+			return err
+		}
+		if err := s.blobs.Put(ctx, s.key(hash, size), &buf, int64(buf.Len()), contentType); err != nil {
+			return fmt.Errorf("store %dpx avatar: %w", size, err)
+		}
+	}
+	return nil
+}
+
+func (s *avatarsvc) OpenAvatar(ctx context.Context, userID bson.ObjectID, size int) (*ports.Avatar, error) {
+	user, err := s.userRepo.GetByID(ctx, userID, ports.FieldAvatarHash)
+	if err != nil {
+		return nil, err
+	}
+	if user.AvatarHash == "" {
+		return nil, fmt.Errorf("%w: user has no avatar", domain.ErrNotFound)
+	}
+
+	size = s.standardSize(size)
+	blob, err := s.blobs.Get(ctx, s.key(user.AvatarHash, size))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("%w: avatar %s is missing from storage", domain.ErrNotFound, user.AvatarHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ports.Avatar{Blob: *blob, ETag: `"` + user.AvatarHash + "-" + strconv.Itoa(size) + `"`}, nil
+}
+
+// standardSize returns the smallest thumbnail size at least size, or the largest
+func (s *avatarsvc) standardSize(size int) int {
+	for _, standard := range s.sizes {
+		if standard >= size {
+			return standard
+		}
+	}
+	return s.sizes[len(s.sizes)-1]
+}
+
+// key returns the blob key of a thumbnail, the hash is split so no directory grows too large
+func (s *avatarsvc) key(hash string, size int) string {
+	return fmt.Sprintf("avatars/%s/%s/%d", hash[:2], hash[2:], size)
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/mocks"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func testPNG(t *testing.T) ([]byte, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 30))); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return buf.Bytes(), hex.EncodeToString(sum[:])
+}
+
+func TestAvatarService_UploadAvatar_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	blobs := mocks.NewMockBlobStore(ctrl)
+	avatarService := NewAvatarService(userRepo, blobs, WithAvatarLimits(1<<20, []int{128, 32}))
+
+	data, hash := testPNG(t)
+	id := bson.NewObjectID()
+	prefix := "avatars/" + hash[:2] + "/" + hash[2:] + "/"
+	blobs.EXPECT().Exists(gomock.Any(), prefix+"128").Return(false, nil)
+	gomock.InOrder(
+		blobs.EXPECT().Put(gomock.Any(), prefix+"32", gomock.Any(), gomock.Any(), "image/png").Return(nil),
+		blobs.EXPECT().Put(gomock.Any(), prefix+"128", gomock.Any(), gomock.Any(), "image/png").Return(nil),
+	)
+	userRepo.EXPECT().SetAvatar(gomock.Any(), gomock.Eq(id), hash).Return(nil)
+
+	got, err := avatarService.UploadAvatar(context.Background(), id, bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got != hash {
+		t.Fatalf("expected hash %s, got %s", hash, got)
+	}
+}
+
+func TestAvatarService_UploadAvatar_AlreadyStored(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	blobs := mocks.NewMockBlobStore(ctrl)
+	avatarService := NewAvatarService(userRepo, blobs)
+
+	data, hash := testPNG(t)
+	blobs.EXPECT().Exists(gomock.Any(), gomock.Any()).Return(true, nil)
+	userRepo.EXPECT().SetAvatar(gomock.Any(), gomock.Any(), hash).Return(nil)
+
+	if _, err := avatarService.UploadAvatar(context.Background(), bson.NewObjectID(), bytes.NewReader(data)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestAvatarService_UploadAvatar_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	blobs := mocks.NewMockBlobStore(ctrl)
+	blobs.EXPECT().Exists(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	avatarService := NewAvatarService(mocks.NewMockUserRepository(ctrl), blobs, WithAvatarLimits(100, []int{64}))
+
+	uploads := map[string]io.Reader{
+		"too large":    bytes.NewReader(make([]byte, 101)),
+		"not an image": strings.NewReader("<svg xmlns='http://www.w3.org/2000/svg'/>"),
+	}
+	for name, upload := range uploads {
+		_, err := avatarService.UploadAvatar(context.Background(), bson.NewObjectID(), upload)
+		if !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Fatalf("%s: expected invalid argument error, got %v", name, err)
+		}
+	}
+}
+
+func TestAvatarService_OpenAvatar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	blobs := mocks.NewMockBlobStore(ctrl)
+	avatarService := NewAvatarService(userRepo, blobs, WithAvatarLimits(1<<20, []int{64, 128}))
+
+	id := bson.NewObjectID()
+	hash := strings.Repeat("ab", 32)
+	userRepo.EXPECT().GetByID(gomock.Any(), gomock.Eq(id), ports.FieldAvatarHash).Return(&domain.User{AvatarHash: hash}, nil).Times(2)
+	blobs.EXPECT().Get(gomock.Any(), "avatars/ab/"+hash[2:]+"/128").Return(&ports.Blob{Body: io.NopCloser(strings.NewReader("")), ContentType: "image/png"}, nil).Times(2)
+
+	// sizes round up to the next standard size, or down to the largest
+	for _, size := range []int{100, 1000} {
+		avatar, err := avatarService.OpenAvatar(context.Background(), id, size)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if avatar.ETag != `"`+hash+`-128"` {
+			t.Fatalf("unexpected ETag %s", avatar.ETag)
+		}
+	}
+}
+
+func TestAvatarService_OpenAvatar_None(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	avatarService := NewAvatarService(userRepo, mocks.NewMockBlobStore(ctrl))
+
+	userRepo.EXPECT().GetByID(gomock.Any(), gomock.Any(), gomock.Any()).Return(&domain.User{}, nil)
+
+	_, err := avatarService.OpenAvatar(context.Background(), bson.NewObjectID(), 64)
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
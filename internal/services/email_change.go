@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var (
+	errEmailChangeDisabled = errors.New("email change is not configured")
+	errInvalidEmailToken   = fmt.Errorf("%w: invalid or expired token", domain.ErrInvalidArgument)
+)
+
+// EmailChangeConfig configures the links and lifetimes of email change tokens.
+// "{token}" in the URLs is replaced by the token.
+type EmailChangeConfig struct {
+	ConfirmURL string
+	RevertURL  string
+	ConfirmTTL time.Duration
+	RevertTTL  time.Duration // counted from the request, so a confirmed change can still be reverted
+}
+
+// WithEmailChange enables email changes, confirmation and revert links are sent through mailer.
+func WithEmailChange(mailer ports.Mailer, cfg EmailChangeConfig) UserServiceOption {
+	return func(s *usersvc) {
+		s.mailer = mailer
+		s.emailChange = cfg
+	}
+}
+
+func (s *usersvc) RequestEmailChange(ctx context.Context, id bson.ObjectID, email string) error {
+	canonical, err := domain.ParseEmail(email, s.emailPolicy)
+	if err != nil {
+		return err
+	}
+	var notify func(ctx context.Context) error
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		user, err := s.userRepo.GetByID(ctx, id)
+		if err != nil {
+			return lookupError(err, errUserNotFound)
+		}
+		if canonical.String() == user.Email {
+			return fmt.Errorf("%w: new email is the current email", domain.ErrInvalidArgument)
+		}
+		notify, err = s.startEmailChange(ctx, user, canonical)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return notify(ctx)
+}
+
+// startEmailChange stores the pending change, and returns the mailing of the confirmation
+// to the new address and of the revert link to the current one, to run once it's stored.
+func (s *usersvc) startEmailChange(ctx context.Context, user *domain.User, email domain.Email) (func(ctx context.Context) error, error) {
+	if s.mailer == nil {
+		return nil, errEmailChangeDisabled
+	}
+
+	switch _, err := s.userRepo.GetByEmail(ctx, email.String()); {
+	case err == nil:
+		return nil, domain.ErrEmailTaken
+	case !errors.Is(err, domain.ErrNotFound):
+		return nil, err
+	}
+
+	confirmToken, confirmHash := newEmailToken(user.ID)
+	revertToken, revertHash := newEmailToken(user.ID)
+	now := time.Now()
+	change := &domain.EmailChange{Email: email.String(), TokenHash: confirmHash, ExpiresAt: now.Add(s.emailChange.ConfirmTTL)}
+	revert := &domain.EmailRevert{Email: user.Email, TokenHash: revertHash, ExpiresAt: now.Add(s.emailChange.RevertTTL)}
+	if err := s.userRepo.SetEmailChange(ctx, user.ID, change, revert); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		return s.mailEmailChange(ctx, user, change, revert, confirmToken, revertToken)
+	}, nil
+}
+
+// mailEmailChange mails the confirmation of a change to the new address and the revert
+// link to the current one
+func (s *usersvc) mailEmailChange(ctx context.Context, user *domain.User, change *domain.EmailChange, revert *domain.EmailRevert, confirmToken, revertToken string) error {
+	if err := s.mailer.Send(ctx, &ports.Message{
+		To:      change.Email,
+		Subject: "Confirm your new email",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm %s as the email of your account by opening %s\n\nThe link expires on %s.\n",
+			user.Name, change.Email, emailLink(s.emailChange.ConfirmURL, confirmToken), change.ExpiresAt.UTC().Format(time.RFC1123)),
+	}); err != nil {
+		return fmt.Errorf("unable to send confirmation email: %w", err)
+	}
+	if err := s.mailer.Send(ctx, &ports.Message{
+		To:      user.Email,
+		Subject: "Your email is being changed",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to change the email of your account to %s. "+
+			"If it wasn't you, keep %s by opening %s\n\nThe link works until %s, even after the change is confirmed.\n",
+			user.Name, change.Email, user.Email, emailLink(s.emailChange.RevertURL, revertToken), revert.ExpiresAt.UTC().Format(time.RFC1123)),
+	}); err != nil {
+		return fmt.Errorf("unable to send email change notification: %w", err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange swaps in the new email of the pending change the token was issued for.
+func (s *usersvc) ConfirmEmailChange(ctx context.Context, token string) error {
+	id, hash, ok := parseEmailToken(token)
+	if !ok {
+		return errInvalidEmailToken
+	}
+	return s.inTx(ctx, func(ctx context.Context) error {
+		user, err := s.userRepo.GetByID(ctx, id)
+		if err != nil {
+			return lookupError(err, errInvalidEmailToken)
+		}
+		change := user.PendingEmail
+		if change == nil || !hashEqual(change.TokenHash, hash) || time.Now().After(change.ExpiresAt) {
+			return errInvalidEmailToken
+		}
+
+		err = s.userRepo.ConfirmEmailChange(ctx, id, hash, change.Email)
+		if errors.Is(err, domain.ErrNotFound) {
+			return errInvalidEmailToken // confirmed or replaced concurrently
+		}
+		return err
+	})
+}
+
+// RevertEmailChange restores the email the token was issued for and cancels any pending change.
+func (s *usersvc) RevertEmailChange(ctx context.Context, token string) error {
+	id, hash, ok := parseEmailToken(token)
+	if !ok {
+		return errInvalidEmailToken
+	}
+	return s.inTx(ctx, func(ctx context.Context) error {
+		user, err := s.userRepo.GetByID(ctx, id)
+		if err != nil {
+			return lookupError(err, errInvalidEmailToken)
+		}
+		revert := user.EmailRevert
+		if revert == nil || !hashEqual(revert.TokenHash, hash) || time.Now().After(revert.ExpiresAt) {
+			return errInvalidEmailToken
+		}
+
+		err = s.userRepo.RevertEmailChange(ctx, id, hash, revert.Email)
+		if errors.Is(err, domain.ErrNotFound) {
+			return errInvalidEmailToken
+		}
+		return err
+	})
+}
+
+// newEmailToken returns a random token prefixed with id, of the user or invitation it was
+// issued for, and its hash to store.
+func newEmailToken(id bson.ObjectID) (token, hash string) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Errorf("unable to generate email token: %w", err))
+	}
+	token = id.Hex() + "." + base64.RawURLEncoding.EncodeToString(secret)
+	return token, hashToken(token)
+}
+
+func parseEmailToken(token string) (id bson.ObjectID, hash string, ok bool) {
+	idHex, _, found := strings.Cut(token, ".")
+	if !found {
+		return id, "", false
+	}
+	id, err := bson.ObjectIDFromHex(idHex)
+	if err != nil {
+		return id, "", false
+	}
+	return id, hashToken(token), true
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func hashEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func emailLink(url, token string) string {
+	return strings.ReplaceAll(url, "{token}", token)
+}
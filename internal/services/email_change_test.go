@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/mocks"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var testEmailChangeConfig = EmailChangeConfig{
+	ConfirmURL: "https://example.com/confirm?token={token}",
+	RevertURL:  "https://example.com/revert?token={token}",
+	ConfirmTTL: time.Hour,
+	RevertTTL:  24 * time.Hour,
+}
+
+// mailedToken extracts the token of a link mailed with testEmailChangeConfig
+func mailedToken(t *testing.T, body string) string {
+	_, rest, ok := strings.Cut(body, "?token=")
+	if !ok {
+		t.Fatalf("expected a token link in %q", body)
+	}
+	return strings.Fields(rest)[0]
+}
+
+func TestUserService_RequestEmailChange_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	mailer := mocks.NewMockMailer(ctrl)
+	userService := NewUserService(userRepo, nil, nil, WithEmailChange(mailer, testEmailChangeConfig))
+
+	user := &domain.User{ID: bson.NewObjectID(), Name: "John", Email: "old@example.com"}
+	userRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+	userRepo.EXPECT().GetByEmail(gomock.Any(), "new@example.com").Return(nil, domain.ErrNotFound)
+
+	var (
+		change *domain.EmailChange
+		revert *domain.EmailRevert
+	)
+	userRepo.EXPECT().SetEmailChange(gomock.Any(), user.ID, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ bson.ObjectID, c *domain.EmailChange, r *domain.EmailRevert) error {
+			change, revert = c, r
+			return nil
+		})
+
+	var sent []*ports.Message
+	mailer.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, msg *ports.Message) error {
+		sent = append(sent, msg)
+		return nil
+	}).Times(2)
+
+	if err := userService.RequestEmailChange(context.Background(), user.ID, "New@Example.com"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if change.Email != "new@example.com" || revert.Email != "old@example.com" {
+		t.Fatalf("expected change to new@example.com revertible to old@example.com, got %+v and %+v", change, revert)
+	}
+	if sent[0].To != "new@example.com" || hashToken(mailedToken(t, sent[0].Body)) != change.TokenHash {
+		t.Fatalf("expected the confirmation token mailed to the new email, got %+v", sent[0])
+	}
+	if sent[1].To != "old@example.com" || hashToken(mailedToken(t, sent[1].Body)) != revert.TokenHash {
+		t.Fatalf("expected the revert token mailed to the old email, got %+v", sent[1])
+	}
+}
+
+// The mails go out once the change commits, so a failed commit doesn't mail tokens that
+// were never stored
+func TestUserService_RequestEmailChange_CommitFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	mailer := mocks.NewMockMailer(ctrl)
+	txManager := mocks.NewMockTxManager(ctrl)
+	userService := NewUserService(userRepo, nil, nil, WithEmailChange(mailer, testEmailChangeConfig), WithTransactions(txManager))
+
+	user := &domain.User{ID: bson.NewObjectID(), Email: "old@example.com"}
+	userRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+	userRepo.EXPECT().GetByEmail(gomock.Any(), "new@example.com").Return(nil, domain.ErrNotFound)
+	userRepo.EXPECT().SetEmailChange(gomock.Any(), user.ID, gomock.Any(), gomock.Any()).Return(nil)
+	commitErr := errors.New("commit error")
+	txManager.EXPECT().WithinTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+			if err := fn(ctx); err != nil {
+				t.Fatalf("expected no error in the transaction, got %v", err)
+			}
+			return commitErr
+		})
+
+	err := userService.RequestEmailChange(context.Background(), user.ID, "new@example.com")
+	if !errors.Is(err, commitErr) {
+		t.Fatalf("expected the commit error, got %v", err)
+	}
+}
+
+func TestUserService_RequestEmailChange_EmailTaken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	mailer := mocks.NewMockMailer(ctrl)
+	userService := NewUserService(userRepo, nil, nil, WithEmailChange(mailer, testEmailChangeConfig))
+
+	user := &domain.User{ID: bson.NewObjectID(), Email: "old@example.com"}
+	userRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+	userRepo.EXPECT().GetByEmail(gomock.Any(), "new@example.com").Return(&domain.User{}, nil)
+
+	err := userService.RequestEmailChange(context.Background(), user.ID, "new@example.com")
+	if !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+}
+
+func TestUserService_Update_EmailStartsChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	mailer := mocks.NewMockMailer(ctrl)
+	userService := NewUserService(userRepo, nil, nil, WithEmailChange(mailer, testEmailChangeConfig))
+
+	user := &domain.User{ID: bson.NewObjectID(), Email: "old@example.com"}
+	userRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+	userRepo.EXPECT().GetByEmail(gomock.Any(), "new@example.com").Return(nil, domain.ErrNotFound)
+	userRepo.EXPECT().SetEmailChange(gomock.Any(), user.ID, gomock.Any(), gomock.Any()).Return(nil)
+	mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	// only the name is applied right away
+	userRepo.EXPECT().Update(gomock.Any(), user.ID, gomock.Eq(&ports.UserUpdate{Name: ports.SetTo("John Doe")})).Return(nil)
+
+	err := userService.Update(context.Background(), user.ID, &ports.UserUpdate{
+		Name:  ports.SetTo("John Doe"),
+		Email: ports.SetTo("new@example.com"),
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUserService_ConfirmEmailChange_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	id := bson.NewObjectID()
+	token, hash := newEmailToken(id)
+	user := &domain.User{ID: id, Email: "old@example.com", PendingEmail: &domain.EmailChange{
+		Email: "new@example.com", TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour),
+	}}
+	userRepo.EXPECT().GetByID(gomock.Any(), id).Return(user, nil)
+	userRepo.EXPECT().ConfirmEmailChange(gomock.Any(), id, hash, "new@example.com").Return(nil)
+
+	if err := userService.ConfirmEmailChange(context.Background(), token); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUserService_ConfirmEmailChange_InvalidToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	id := bson.NewObjectID()
+	token, hash := newEmailToken(id)
+	otherToken, _ := newEmailToken(id)
+	expired := &domain.User{ID: id, PendingEmail: &domain.EmailChange{
+		Email: "new@example.com", TokenHash: hash, ExpiresAt: time.Now().Add(-time.Minute),
+	}}
+	userRepo.EXPECT().GetByID(gomock.Any(), id).Return(expired, nil).Times(2)
+
+	for _, token := range []string{"", "garbage", token, otherToken} {
+		err := userService.ConfirmEmailChange(context.Background(), token)
+		if !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Fatalf("expected invalid argument error for token %q, got %v", token, err)
+		}
+	}
+}
+
+func TestUserService_RevertEmailChange_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	id := bson.NewObjectID()
+	token, hash := newEmailToken(id)
+	user := &domain.User{ID: id, Email: "new@example.com", EmailRevert: &domain.EmailRevert{
+		Email: "old@example.com", TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour),
+	}}
+	userRepo.EXPECT().GetByID(gomock.Any(), id).Return(user, nil)
+	userRepo.EXPECT().RevertEmailChange(gomock.Any(), id, hash, "old@example.com").Return(nil)
+
+	if err := userService.RevertEmailChange(context.Background(), token); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var _ ports.GroupService = &groupsvc{}
+
+var (
+	errGroupNotFound  = fmt.Errorf("group %w", domain.ErrNotFound)
+	errMemberNotFound = fmt.Errorf("group member %w", domain.ErrNotFound)
+)
+
+type groupsvc struct {
+	groupRepo ports.GroupRepository
+	userRepo  ports.UserRepository
+
+	pageTokens      pageTokens
+	defaultPageSize int64
+	maxPageSize     int64
+
+	txManager ports.TxManager
+}
+
+// GroupServiceOption configures optional behaviour of the group service
+type GroupServiceOption func(*groupsvc)
+
+// WithGroupPagination sets the default and maximum page sizes and the key signing page
+// tokens, see WithPageSize and WithPageTokenSecret of the user service.
+func WithGroupPagination(defaultSize, maxSize int64, secret string) GroupServiceOption {
+	return func(s *groupsvc) {
+		s.defaultPageSize = defaultSize
+		s.maxPageSize = maxSize
+		if secret != "" {
+			s.pageTokens = pageTokens{key: []byte(secret)}
+		}
+	}
+}
+
+// WithGroupTransactions checks nested groups for cycles and adds them in one transaction
+// of tx, so concurrent additions can't close a cycle between them. Without it the
+// repository has to serialize them.
+func WithGroupTransactions(tx ports.TxManager) GroupServiceOption {
+	return func(s *groupsvc) {
+		s.txManager = tx
+	}
+}
+
+// NewGroupService returns a group service, users are looked up in userRepo before they're
+// added to a group.
+func NewGroupService(groupRepo ports.GroupRepository, userRepo ports.UserRepository, opts ...GroupServiceOption) *groupsvc {
+	s := &groupsvc{
+		groupRepo:       groupRepo,
+		userRepo:        userRepo,
+		pageTokens:      newRandomPageTokens(),
+		defaultPageSize: defaultPageSize,
+		maxPageSize:     defaultMaxPage,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// inTx runs fn in a transaction when the service has a TxManager, and as is otherwise
+func (s *groupsvc) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.txManager == nil {
+		return fn(ctx)
+	}
+	return s.txManager.WithinTx(ctx, fn)
+}
+
+func (s *groupsvc) CreateGroup(ctx context.Context, group *domain.Group) (*domain.Group, error) {
+	created := &domain.Group{Name: group.Name, Description: group.Description, Roles: group.Roles}
+	if err := created.Canonicalize(); err != nil {
+		return nil, err
+	}
+	created.CreatedAt = time.Now()
+	created.UpdatedAt = created.CreatedAt
+
+	id, err := s.groupRepo.Create(ctx, created)
+	if err != nil {
+		return nil, err
+	}
+	created.ID = *id
+	return created, nil
+}
+
+func (s *groupsvc) GetGroup(ctx context.Context, id bson.ObjectID) (*domain.Group, error) {
+	group, err := s.groupRepo.GetByID(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, errGroupNotFound
+	}
+	return group, err
+}
+
+func (s *groupsvc) ListGroups(ctx context.Context, req *ports.PageRequest) (*ports.GroupPage, error) {
+	return s.groupPage(ctx, nil, req, "groups")
+}
+
+func (s *groupsvc) UpdateGroup(ctx context.Context, id bson.ObjectID, update *ports.GroupUpdate) (*domain.Group, error) {
+	if update.Name.Op == ports.Keep && update.Description.Op == ports.Keep && update.Roles.Op == ports.Keep {
+		return nil, fmt.Errorf("%w: update has no fields", domain.ErrInvalidArgument)
+	}
+	if update.Name.Op == ports.Clear {
+		return nil, fmt.Errorf("%w: group name is required and can't be cleared", domain.ErrInvalidArgument)
+	}
+
+	// the values are checked as a group, kept fields get valid placeholders
+	canonical := *update
+	group := &domain.Group{Name: "-", Description: update.Description.Value, Roles: update.Roles.Value}
+	if update.Name.Op == ports.Set {
+		group.Name = update.Name.Value
+	}
+	if err := group.Canonicalize(); err != nil {
+		return nil, err
+	}
+	if canonical.Name.Op == ports.Set {
+		canonical.Name.Value = group.Name
+	}
+	if canonical.Description.Op == ports.Set {
+		canonical.Description.Value = group.Description
+		if group.Description == "" {
+			canonical.Description = ports.Cleared[string]()
+		}
+	}
+	if canonical.Roles.Op == ports.Set {
+		canonical.Roles.Value = group.Roles
+		if len(group.Roles) == 0 {
+			canonical.Roles = ports.Cleared[[]string]()
+		}
+	}
+
+	if err := s.groupRepo.Update(ctx, id, &canonical); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, errGroupNotFound
+		}
+		return nil, err
+	}
+	return s.GetGroup(ctx, id)
+}
+
+func (s *groupsvc) DeleteGroup(ctx context.Context, id bson.ObjectID) error {
+	if err := s.groupRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return errGroupNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// AddMember checks the member exists in the organization. A group can't join one of its
+// own members, directly or nested, as it would then contain itself. The check and the
+// addition run in one transaction, see WithGroupTransactions.
+func (s *groupsvc) AddMember(ctx context.Context, groupID bson.ObjectID, kind domain.MemberKind, memberID bson.ObjectID) error {
+	if _, err := domain.ParseMemberKind(string(kind)); err != nil {
+		return err
+	}
+	return s.inTx(ctx, func(ctx context.Context) error {
+		if _, err := s.GetGroup(ctx, groupID); err != nil {
+			return err
+		}
+
+		switch kind {
+		case domain.MemberUser:
+			if _, err := s.userRepo.GetByID(ctx, memberID); err != nil {
+				return lookupError(err, errUserNotFound)
+			}
+		case domain.MemberGroup:
+			if _, err := s.GetGroup(ctx, memberID); err != nil {
+				return err
+			}
+			ancestors, err := s.ancestors(ctx, groupID)
+			if err != nil {
+				return err
+			}
+			if memberID == groupID || slices.Contains(ancestors, memberID) {
+				return domain.ErrGroupCycle
+			}
+		}
+
+		return s.groupRepo.AddMember(ctx, groupID, &domain.GroupMember{Kind: kind, ID: memberID, AddedAt: time.Now()})
+	})
+}
+
+func (s *groupsvc) RemoveMember(ctx context.Context, groupID, memberID bson.ObjectID) error {
+	if err := s.groupRepo.RemoveMember(ctx, groupID, memberID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return errMemberNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *groupsvc) ListMembers(ctx context.Context, groupID bson.ObjectID, req *ports.PageRequest) (*ports.GroupMemberPage, error) {
+	if _, err := s.GetGroup(ctx, groupID); err != nil {
+		return nil, err
+	}
+
+	query := "members:" + groupID.Hex()
+	size, after, err := s.window(req, query)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.groupRepo.ListMembers(ctx, groupID, after, size+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ports.GroupMemberPage{Members: members}
+	if int64(len(members)) > size {
+		page.Members = members[:size]
+		page.NextPageToken = s.pageTokens.encode(ports.Cursor{ID: page.Members[size-1].ID}, query)
+	}
+	return page, nil
+}
+
+func (s *groupsvc) ListUserGroups(ctx context.Context, userID bson.ObjectID, req *ports.PageRequest) (*ports.GroupPage, error) {
+	ids, err := s.ancestors(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return &ports.GroupPage{Groups: []domain.Group{}}, nil
+	}
+	return s.groupPage(ctx, ids, req, "user-groups:"+userID.Hex())
+}
+
+// RolesOf merges the roles of every group the user belongs to, directly or nested
+func (s *groupsvc) RolesOf(ctx context.Context, userID bson.ObjectID) ([]string, error) {
+	ids, err := s.ancestors(ctx, userID)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	groups, err := s.groupRepo.List(ctx, ids, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var roles []string
+	for _, group := range groups {
+		roles = append(roles, group.Roles...)
+	}
+	slices.Sort(roles)
+	return slices.Compact(roles), nil
+}
+
+// ancestors returns the groups containing memberID, directly or through nested groups,
+// walking up one level of nesting per query.
+func (s *groupsvc) ancestors(ctx context.Context, memberID bson.ObjectID) ([]bson.ObjectID, error) {
+	var ancestors []bson.ObjectID
+	seen := map[bson.ObjectID]bool{memberID: true}
+	for level := []bson.ObjectID{memberID}; len(level) > 0; {
+		parents, err := s.groupRepo.ParentsOf(ctx, level)
+		if err != nil {
+			return nil, err
+		}
+		level = nil
+		for _, id := range parents {
+			if !seen[id] {
+				seen[id] = true
+				ancestors = append(ancestors, id)
+				level = append(level, id)
+			}
+		}
+	}
+	return ancestors, nil
+}
+
+// groupPage returns a page of the groups with ids, nil for every group
+func (s *groupsvc) groupPage(ctx context.Context, ids []bson.ObjectID, req *ports.PageRequest, query string) (*ports.GroupPage, error) {
+	size, after, err := s.window(req, query)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := s.groupRepo.List(ctx, ids, after, size+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ports.GroupPage{Groups: groups}
+	if int64(len(groups)) > size {
+		page.Groups = groups[:size]
+		page.NextPageToken = s.pageTokens.encode(ports.Cursor{ID: page.Groups[size-1].ID}, query)
+	}
+	return page, nil
+}
+
+// window returns the clamped page size and the id the page starts after, nil for the first page
+func (s *groupsvc) window(req *ports.PageRequest, query string) (int64, *bson.ObjectID, error) {
+	size := req.PageSize
+	if size <= 0 {
+		size = s.defaultPageSize
+	}
+	size = min(size, s.maxPageSize)
+
+	if req.PageToken == "" {
+		return size, nil, nil
+	}
+	cursor, err := s.pageTokens.decode(req.PageToken, query)
+	if err != nil {
+		return 0, nil, err
+	}
+	return size, &cursor.ID, nil
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/mocks"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// parentsOf fakes ParentsOf with a map from members to the groups they directly belong to
+func parentsOf(parents map[bson.ObjectID][]bson.ObjectID) func(context.Context, []bson.ObjectID) ([]bson.ObjectID, error) {
+	return func(_ context.Context, ids []bson.ObjectID) ([]bson.ObjectID, error) {
+		var found []bson.ObjectID
+		for _, id := range ids {
+			found = append(found, parents[id]...)
+		}
+		return found, nil
+	}
+}
+
+func TestGroupService_CreateGroup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	groupRepo := mocks.NewMockGroupRepository(ctrl)
+	groupService := NewGroupService(groupRepo, nil)
+
+	id := bson.NewObjectID()
+	groupRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, group *domain.Group) (*bson.ObjectID, error) {
+		if group.Name != "Support" || !slices.Equal(group.Roles, []string{"support"}) || group.CreatedAt.IsZero() {
+			t.Fatalf("unexpected group %+v", group)
+		}
+		return &id, nil
+	})
+
+	group, err := groupService.CreateGroup(context.Background(), &domain.Group{Name: " Support ", Roles: []string{"Support"}})
+	if err != nil || group.ID != id {
+		t.Fatalf("expected the created group, got %+v, %v", group, err)
+	}
+
+	if _, err := groupService.CreateGroup(context.Background(), &domain.Group{Name: "Root", Roles: []string{"superadmin"}}); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected forbidden error, got %v", err)
+	}
+}
+
+func TestGroupService_UpdateGroup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	groupRepo := mocks.NewMockGroupRepository(ctrl)
+	groupService := NewGroupService(groupRepo, nil)
+
+	id := bson.NewObjectID()
+	groupRepo.EXPECT().Update(gomock.Any(), id, gomock.Eq(&ports.GroupUpdate{
+		Description: ports.Cleared[string](),
+		Roles:       ports.SetTo([]string{"billing"}),
+	})).Return(nil)
+	groupRepo.EXPECT().GetByID(gomock.Any(), id).Return(&domain.Group{ID: id, Name: "Finance", Roles: []string{"billing"}}, nil)
+
+	update := &ports.GroupUpdate{Description: ports.SetTo(" "), Roles: ports.SetTo([]string{"Billing", "billing"})}
+	if _, err := groupService.UpdateGroup(context.Background(), id, update); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, invalid := range []*ports.GroupUpdate{{}, {Name: ports.Cleared[string]()}, {Roles: ports.SetTo([]string{"on call"})}} {
+		if _, err := groupService.UpdateGroup(context.Background(), id, invalid); !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Fatalf("%+v: expected invalid argument error, got %v", invalid, err)
+		}
+	}
+}
+
+func TestGroupService_AddMember_Cycle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	groupRepo := mocks.NewMockGroupRepository(ctrl)
+	groupService := NewGroupService(groupRepo, nil)
+
+	// engineering contains backend, which contains oncall
+	engineering, backend, oncall := bson.NewObjectID(), bson.NewObjectID(), bson.NewObjectID()
+	groupRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id bson.ObjectID) (*domain.Group, error) {
+		return &domain.Group{ID: id}, nil
+	}).AnyTimes()
+	groupRepo.EXPECT().ParentsOf(gomock.Any(), gomock.Any()).DoAndReturn(parentsOf(map[bson.ObjectID][]bson.ObjectID{
+		backend: {engineering},
+		oncall:  {backend},
+	})).AnyTimes()
+
+	for _, member := range []bson.ObjectID{engineering, backend, oncall} {
+		if err := groupService.AddMember(context.Background(), oncall, domain.MemberGroup, member); !errors.Is(err, domain.ErrGroupCycle) {
+			t.Fatalf("expected cycle error, got %v", err)
+		}
+	}
+
+	other := bson.NewObjectID()
+	groupRepo.EXPECT().AddMember(gomock.Any(), oncall, gomock.Any()).DoAndReturn(func(_ context.Context, _ bson.ObjectID, member *domain.GroupMember) error {
+		if member.Kind != domain.MemberGroup || member.ID != other {
+			t.Fatalf("unexpected member %+v", member)
+		}
+		return nil
+	})
+	if err := groupService.AddMember(context.Background(), oncall, domain.MemberGroup, other); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestGroupService_AddMember_Transaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	groupRepo := mocks.NewMockGroupRepository(ctrl)
+	txManager := mocks.NewMockTxManager(ctrl)
+	groupService := NewGroupService(groupRepo, nil, WithGroupTransactions(txManager))
+
+	// backend joins oncall while oncall joins backend concurrently, the first attempt conflicts
+	// and the retry sees backend as a parent of oncall
+	backend, oncall := bson.NewObjectID(), bson.NewObjectID()
+	conflict := errors.New("write conflict")
+	txManager.EXPECT().WithinTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+			txCtx, _ := ports.NewUnitOfWork(ctx)
+			if err := fn(txCtx); !errors.Is(err, conflict) {
+				t.Fatalf("expected the conflict in the first attempt, got %v", err)
+			}
+			return fn(txCtx)
+		})
+	inTx := func(ctx context.Context) {
+		if !ports.InTx(ctx) {
+			t.Fatalf("expected the check and the addition in the transaction")
+		}
+	}
+	groupRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, id bson.ObjectID) (*domain.Group, error) {
+		inTx(ctx)
+		return &domain.Group{ID: id}, nil
+	}).AnyTimes()
+	gomock.InOrder(
+		groupRepo.EXPECT().ParentsOf(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, ids []bson.ObjectID) ([]bson.ObjectID, error) {
+			inTx(ctx)
+			return nil, nil
+		}),
+		groupRepo.EXPECT().AddMember(gomock.Any(), oncall, gomock.Any()).DoAndReturn(func(ctx context.Context, _ bson.ObjectID, _ *domain.GroupMember) error {
+			inTx(ctx)
+			return conflict
+		}),
+		groupRepo.EXPECT().ParentsOf(gomock.Any(), gomock.Any()).DoAndReturn(parentsOf(map[bson.ObjectID][]bson.ObjectID{
+			oncall: {backend},
+		})).AnyTimes(),
+	)
+
+	if err := groupService.AddMember(context.Background(), oncall, domain.MemberGroup, backend); !errors.Is(err, domain.ErrGroupCycle) {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
+
+func TestGroupService_AddMember_UnknownUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	groupRepo := mocks.NewMockGroupRepository(ctrl)
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	groupService := NewGroupService(groupRepo, userRepo)
+
+	groupID, userID := bson.NewObjectID(), bson.NewObjectID()
+	groupRepo.EXPECT().GetByID(gomock.Any(), groupID).Return(&domain.Group{ID: groupID}, nil)
+	userRepo.EXPECT().GetByID(gomock.Any(), userID).Return(nil, domain.ErrNotFound)
+
+	if err := groupService.AddMember(context.Background(), groupID, domain.MemberUser, userID); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestGroupService_RolesOf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	groupRepo := mocks.NewMockGroupRepository(ctrl)
+	groupService := NewGroupService(groupRepo, nil)
+
+	// the user is in backend and oncall, both nested in engineering, so engineering is only walked once
+	userID, engineering, backend, oncall := bson.NewObjectID(), bson.NewObjectID(), bson.NewObjectID(), bson.NewObjectID()
+	groupRepo.EXPECT().ParentsOf(gomock.Any(), gomock.Any()).DoAndReturn(parentsOf(map[bson.ObjectID][]bson.ObjectID{
+		userID:  {backend, oncall},
+		backend: {engineering},
+		oncall:  {engineering},
+	})).Times(3)
+	groupRepo.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Nil(), int64(0)).DoAndReturn(func(_ context.Context, ids []bson.ObjectID, _ *bson.ObjectID, _ int64) ([]domain.Group, error) {
+		if len(ids) != 3 {
+			t.Fatalf("expected 3 groups, got %v", ids)
+		}
+		return []domain.Group{
+			{ID: engineering, Roles: []string{"engineer"}},
+			{ID: backend, Roles: []string{"deployer", "engineer"}},
+			{ID: oncall, Roles: []string{"admin"}},
+		}, nil
+	})
+
+	roles, err := groupService.RolesOf(context.Background(), userID)
+	if err != nil || !slices.Equal(roles, []string{"admin", "deployer", "engineer"}) {
+		t.Fatalf("unexpected roles %v, %v", roles, err)
+	}
+}
+
+func TestGroupService_ListUserGroups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	groupRepo := mocks.NewMockGroupRepository(ctrl)
+	groupService := NewGroupService(groupRepo, nil)
+
+	userID, first, second := bson.NewObjectID(), bson.NewObjectID(), bson.NewObjectID()
+	groupRepo.EXPECT().ParentsOf(gomock.Any(), gomock.Any()).DoAndReturn(parentsOf(map[bson.ObjectID][]bson.ObjectID{
+		userID: {first, second},
+	})).AnyTimes()
+	groupRepo.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Nil(), int64(2)).Return([]domain.Group{{ID: first}, {ID: second}}, nil)
+	groupRepo.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Eq(&first), int64(2)).Return([]domain.Group{{ID: second}}, nil)
+
+	page, err := groupService.ListUserGroups(context.Background(), userID, &ports.PageRequest{PageSize: 1})
+	if err != nil || len(page.Groups) != 1 || page.NextPageToken == "" {
+		t.Fatalf("expected a first page with a next page token, got %+v, %v", page, err)
+	}
+	token := page.NextPageToken
+
+	page, err = groupService.ListUserGroups(context.Background(), userID, &ports.PageRequest{PageSize: 1, PageToken: token})
+	if err != nil || len(page.Groups) != 1 || page.Groups[0].ID != second || page.NextPageToken != "" {
+		t.Fatalf("expected the last page, got %+v, %v", page, err)
+	}
+
+	// tokens are bound to the list they were issued for
+	if _, err := groupService.ListGroups(context.Background(), &ports.PageRequest{PageToken: token}); !errors.Is(err, domain.ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument error, got %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var _ ports.InvitationService = &invitesvc{}
+
+var (
+	errInvitationNotFound = fmt.Errorf("invitation %w", domain.ErrNotFound)
+	errInvalidInvitation  = fmt.Errorf("%w: invalid or expired invitation", domain.ErrInvalidArgument)
+)
+
+// InvitationConfig configures the link and lifetime of invitations, "{token}" in the URL
+// is replaced by the token.
+type InvitationConfig struct {
+	AcceptURL string
+	TTL       time.Duration
+}
+
+type invitesvc struct {
+	inviteRepo   ports.InvitationRepository
+	userRepo     ports.UserRepository
+	userService  ports.UserService
+	groupService ports.GroupService
+	mailer       ports.Mailer
+	cfg          InvitationConfig
+
+	emailPolicy     domain.EmailPolicy
+	pageTokens      pageTokens
+	defaultPageSize int64
+	maxPageSize     int64
+}
+
+// InvitationServiceOption configures optional behaviour of the invitation service
+type InvitationServiceOption func(*invitesvc)
+
+// WithInvitationEmailPolicy sets how invited emails are canonicalized, it must match the
+// policy of the user service so invitations are found for registered emails.
+func WithInvitationEmailPolicy(policy domain.EmailPolicy) InvitationServiceOption {
+	return func(s *invitesvc) {
+		s.emailPolicy = policy
+	}
+}
+
+// WithInvitationPagination sets the default and maximum page sizes and the key signing
+// page tokens, see WithGroupPagination.
+func WithInvitationPagination(defaultSize, maxSize int64, secret string) InvitationServiceOption {
+	return func(s *invitesvc) {
+		s.defaultPageSize = defaultSize
+		s.maxPageSize = maxSize
+		if secret != "" {
+			s.pageTokens = pageTokens{key: []byte(secret)}
+		}
+	}
+}
+
+// NewInvitationService returns an invitation service mailing invitations through mailer.
+// Invitees are registered through userService and added to groups through groupService.
+func NewInvitationService(inviteRepo ports.InvitationRepository, userRepo ports.UserRepository, userService ports.UserService, groupService ports.GroupService, mailer ports.Mailer, cfg InvitationConfig, opts ...InvitationServiceOption) *invitesvc {
+	s := &invitesvc{
+		inviteRepo:      inviteRepo,
+		userRepo:        userRepo,
+		userService:     userService,
+		groupService:    groupService,
+		mailer:          mailer,
+		cfg:             cfg,
+		pageTokens:      newRandomPageTokens(),
+		defaultPageSize: defaultPageSize,
+		maxPageSize:     defaultMaxPage,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Invite checks the email isn't registered yet and the groups exist, then stores and mails
+// the invitation. It's deleted again when it can't be mailed, so it can be retried.
+func (s *invitesvc) Invite(ctx context.Context, invitation *domain.Invitation) (*domain.Invitation, error) {
+	email, err := domain.ParseEmail(invitation.Email, s.emailPolicy)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := domain.ParseRoles(invitation.Roles)
+	if err != nil {
+		return nil, err
+	}
+	switch _, err := s.userRepo.GetByEmail(ctx, email.String()); {
+	case err == nil:
+		return nil, domain.ErrEmailTaken
+	case !errors.Is(err, domain.ErrNotFound):
+		return nil, err
+	}
+	for _, id := range invitation.GroupIDs {
+		if _, err := s.groupService.GetGroup(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	created := &domain.Invitation{
+		ID:        bson.NewObjectID(),
+		Email:     email.String(),
+		Roles:     roles,
+		GroupIDs:  invitation.GroupIDs,
+		InvitedBy: invitation.InvitedBy,
+		ExpiresAt: now.Add(s.cfg.TTL),
+		SentAt:    now,
+		CreatedAt: now,
+	}
+	token, hash := newEmailToken(created.ID)
+	created.TokenHash = hash
+	if err := s.inviteRepo.Create(ctx, created); err != nil {
+		return nil, err
+	}
+
+	if err := s.send(ctx, created, token); err != nil {
+		if err := s.inviteRepo.Delete(ctx, created.ID); err != nil {
+			return nil, fmt.Errorf("unable to delete unsent invitation: %w", err)
+		}
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *invitesvc) ListInvitations(ctx context.Context, req *ports.PageRequest) (*ports.InvitationPage, error) {
+	size := req.PageSize
+	if size <= 0 {
+		size = s.defaultPageSize
+	}
+	size = min(size, s.maxPageSize)
+
+	var after *bson.ObjectID
+	if req.PageToken != "" {
+		cursor, err := s.pageTokens.decode(req.PageToken, "invitations")
+		if err != nil {
+			return nil, err
+		}
+		after = &cursor.ID
+	}
+
+	invitations, err := s.inviteRepo.List(ctx, after, size+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &ports.InvitationPage{Invitations: invitations}
+	if int64(len(invitations)) > size {
+		page.Invitations = invitations[:size]
+		page.NextPageToken = s.pageTokens.encode(ports.Cursor{ID: page.Invitations[size-1].ID}, "invitations")
+	}
+	return page, nil
+}
+
+func (s *invitesvc) ResendInvitation(ctx context.Context, id bson.ObjectID) (*domain.Invitation, error) {
+	invitation, err := s.inviteRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, errInvitationNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	token, hash := newEmailToken(id)
+	invitation.TokenHash, invitation.ExpiresAt, invitation.SentAt = hash, now.Add(s.cfg.TTL), now
+	if err := s.inviteRepo.Renew(ctx, id, hash, invitation.ExpiresAt, now); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, errInvitationNotFound
+		}
+		return nil, err
+	}
+	if err := s.send(ctx, invitation, token); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+func (s *invitesvc) RevokeInvitation(ctx context.Context, id bson.ObjectID) error {
+	if err := s.inviteRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return errInvitationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// AcceptInvitation keeps the invitation when registering fails, e.g. on a weak password, so
+// the invitee can try again. Invitees skip invite-only mode, the domain lists and approval. A second concurrent accept fails as the email is then taken.
+func (s *invitesvc) AcceptInvitation(ctx context.Context, token string, user *domain.User) (*bson.ObjectID, error) {
+	id, hash, ok := parseEmailToken(token)
+	if !ok {
+		return nil, errInvalidInvitation
+	}
+	invitation, err := s.inviteRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, errInvalidInvitation
+		}
+		return nil, err
+	}
+	if !hashEqual(invitation.TokenHash, hash) || invitation.Expired(time.Now()) {
+		return nil, errInvalidInvitation
+	}
+
+	invitee := *user
+	invitee.Email = invitation.Email
+	invitee.Roles = invitation.Roles
+	userID, err := s.userService.Register(withInvitation(ctx), &invitee)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, groupID := range invitation.GroupIDs {
+		// groups deleted since the invitation are skipped
+		err := s.groupService.AddMember(ctx, groupID, domain.MemberUser, *userID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return nil, fmt.Errorf("add to group %s: %w", groupID.Hex(), err)
+		}
+	}
+	if err := s.inviteRepo.Delete(ctx, id); err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("delete accepted invitation: %w", err)
+	}
+	return userID, nil
+}
+
+func (s *invitesvc) send(ctx context.Context, invitation *domain.Invitation, token string) error {
+	if err := s.mailer.Send(ctx, &ports.Message{
+		To:      invitation.Email,
+		Subject: "You're invited",
+		Body: fmt.Sprintf("Hi,\n\nYou're invited to create an account with %s. Choose your password by opening %s\n\nThe link expires on %s.\n",
+			invitation.Email, emailLink(s.cfg.AcceptURL, token), invitation.ExpiresAt.UTC().Format(time.RFC1123)),
+	}); err != nil {
+		return fmt.Errorf("unable to send invitation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/mocks"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var testInvitationConfig = InvitationConfig{AcceptURL: "https://app.example.com/accept?token={token}", TTL: time.Hour}
+
+func TestInvitationService_Invite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inviteRepo := mocks.NewMockInvitationRepository(ctrl)
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	groupService := mocks.NewMockGroupService(ctrl)
+	mailer := mocks.NewMockMailer(ctrl)
+	inviteService := NewInvitationService(inviteRepo, userRepo, nil, groupService, mailer, testInvitationConfig)
+
+	groupID, adminID := bson.NewObjectID(), bson.NewObjectID()
+	var stored *domain.Invitation
+	userRepo.EXPECT().GetByEmail(gomock.Any(), "jane@example.com").Return(nil, domain.ErrNotFound)
+	groupService.EXPECT().GetGroup(gomock.Any(), groupID).Return(&domain.Group{ID: groupID}, nil)
+	inviteRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, invitation *domain.Invitation) error {
+		stored = invitation
+		return nil
+	})
+	mailer.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, msg *ports.Message) error {
+		if msg.To != "jane@example.com" {
+			t.Fatalf("expected the invitation to be mailed to jane@example.com, got %s", msg.To)
+		}
+		if id, hash, ok := parseEmailToken(mailedToken(t, msg.Body)); !ok || id != stored.ID || hash != stored.TokenHash {
+			t.Fatalf("mailed token doesn't match the stored invitation")
+		}
+		return nil
+	})
+
+	invitation, err := inviteService.Invite(context.Background(), &domain.Invitation{
+		Email:     " Jane@Example.com ",
+		Roles:     []string{"Support"},
+		GroupIDs:  []bson.ObjectID{groupID},
+		InvitedBy: adminID,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if invitation.Email != "jane@example.com" || !slices.Equal(invitation.Roles, []string{"support"}) || invitation.InvitedBy != adminID {
+		t.Fatalf("unexpected invitation %+v", invitation)
+	}
+	if !invitation.ExpiresAt.After(time.Now().Add(59 * time.Minute)) {
+		t.Fatalf("expected the invitation to expire in an hour, got %v", invitation.ExpiresAt)
+	}
+}
+
+func TestInvitationService_Invite_Registered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	inviteService := NewInvitationService(nil, userRepo, nil, nil, nil, testInvitationConfig)
+
+	userRepo.EXPECT().GetByEmail(gomock.Any(), "jane@example.com").Return(&domain.User{}, nil)
+
+	if _, err := inviteService.Invite(context.Background(), &domain.Invitation{Email: "jane@example.com"}); !errors.Is(err, domain.ErrEmailTaken) {
+		t.Fatalf("expected email taken error, got %v", err)
+	}
+}
+
+func TestInvitationService_Invite_MailError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inviteRepo := mocks.NewMockInvitationRepository(ctrl)
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	mailer := mocks.NewMockMailer(ctrl)
+	inviteService := NewInvitationService(inviteRepo, userRepo, nil, nil, mailer, testInvitationConfig)
+
+	var id bson.ObjectID
+	userRepo.EXPECT().GetByEmail(gomock.Any(), gomock.Any()).Return(nil, domain.ErrNotFound)
+	inviteRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, invitation *domain.Invitation) error {
+		id = invitation.ID
+		return nil
+	})
+	mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Return(errors.New("smtp down"))
+	// the unsent invitation is removed so it can be retried
+	inviteRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, deleted bson.ObjectID) error {
+		if deleted != id {
+			t.Fatalf("expected invitation %v to be deleted, got %v", id, deleted)
+		}
+		return nil
+	})
+
+	if _, err := inviteService.Invite(context.Background(), &domain.Invitation{Email: "jane@example.com"}); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func TestInvitationService_AcceptInvitation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inviteRepo := mocks.NewMockInvitationRepository(ctrl)
+	userService := mocks.NewMockUserService(ctrl)
+	groupService := mocks.NewMockGroupService(ctrl)
+	inviteService := NewInvitationService(inviteRepo, nil, userService, groupService, nil, testInvitationConfig)
+
+	id, groupID, deletedGroupID, userID := bson.NewObjectID(), bson.NewObjectID(), bson.NewObjectID(), bson.NewObjectID()
+	token, hash := newEmailToken(id)
+	inviteRepo.EXPECT().GetByID(gomock.Any(), id).Return(&domain.Invitation{
+		ID:        id,
+		Email:     "jane@example.com",
+		Roles:     []string{"support"},
+		GroupIDs:  []bson.ObjectID{groupID, deletedGroupID},
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, nil)
+	userService.EXPECT().Register(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user *domain.User) (*bson.ObjectID, error) {
+		// the invitation decides the email and roles, whatever the invitee sent
+		if user.Email != "jane@example.com" || !slices.Equal(user.Roles, []string{"support"}) || user.Name != "Jane Doe" {
+			t.Fatalf("unexpected user %+v", user)
+		}
+		return &userID, nil
+	})
+	groupService.EXPECT().AddMember(gomock.Any(), groupID, domain.MemberUser, userID).Return(nil)
+	groupService.EXPECT().AddMember(gomock.Any(), deletedGroupID, domain.MemberUser, userID).Return(domain.ErrNotFound)
+	inviteRepo.EXPECT().Delete(gomock.Any(), id).Return(nil)
+
+	got, err := inviteService.AcceptInvitation(context.Background(), token, &domain.User{
+		Name:     "Jane Doe",
+		Email:    "mallory@example.com",
+		Password: "password",
+		Roles:    []string{domain.RoleAdmin},
+	})
+	if err != nil || *got != userID {
+		t.Fatalf("expected user %v, got %v, %v", userID, got, err)
+	}
+}
+
+func TestInvitationService_AcceptInvitation_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inviteRepo := mocks.NewMockInvitationRepository(ctrl)
+	inviteService := NewInvitationService(inviteRepo, nil, nil, nil, nil, testInvitationConfig)
+
+	expired, resent := bson.NewObjectID(), bson.NewObjectID()
+	expiredToken, expiredHash := newEmailToken(expired)
+	staleToken, _ := newEmailToken(resent)
+	_, currentHash := newEmailToken(resent)
+	inviteRepo.EXPECT().GetByID(gomock.Any(), expired).Return(&domain.Invitation{ID: expired, TokenHash: expiredHash, ExpiresAt: time.Now().Add(-time.Minute)}, nil)
+	inviteRepo.EXPECT().GetByID(gomock.Any(), resent).Return(&domain.Invitation{ID: resent, TokenHash: currentHash, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+
+	for _, token := range []string{"garbage", expiredToken, staleToken} {
+		if _, err := inviteService.AcceptInvitation(context.Background(), token, &domain.User{}); !errors.Is(err, errInvalidInvitation) {
+			t.Fatalf("expected invalid invitation error, got %v", err)
+		}
+	}
+}
+
+func TestInvitationService_ResendInvitation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inviteRepo := mocks.NewMockInvitationRepository(ctrl)
+	mailer := mocks.NewMockMailer(ctrl)
+	inviteService := NewInvitationService(inviteRepo, nil, nil, nil, mailer, testInvitationConfig)
+
+	id := bson.NewObjectID()
+	_, oldHash := newEmailToken(id)
+	var newHash string
+	inviteRepo.EXPECT().GetByID(gomock.Any(), id).Return(&domain.Invitation{ID: id, Email: "jane@example.com", TokenHash: oldHash, ExpiresAt: time.Now().Add(-time.Hour)}, nil)
+	inviteRepo.EXPECT().Renew(gomock.Any(), id, gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ bson.ObjectID, hash string, expiresAt, _ time.Time) error {
+		if hash == oldHash || !expiresAt.After(time.Now()) {
+			t.Fatalf("expected a new token and expiry, got %s, %v", hash, expiresAt)
+		}
+		newHash = hash
+		return nil
+	})
+	mailer.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, msg *ports.Message) error {
+		if _, hash, _ := parseEmailToken(mailedToken(t, msg.Body)); hash != newHash {
+			t.Fatalf("expected the new token to be mailed")
+		}
+		return nil
+	})
+
+	if _, err := inviteService.ResendInvitation(context.Background(), id); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	inviteRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).Return(nil, domain.ErrNotFound)
+	if _, err := inviteService.ResendInvitation(context.Background(), bson.NewObjectID()); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var _ ports.OrganizationService = &orgsvc{}
+
+var errOrganizationNotFound = fmt.Errorf("organization %w", domain.ErrNotFound)
+
+type orgsvc struct {
+	orgRepo  ports.OrganizationRepository
+	userRepo ports.UserRepository
+}
+
+// NewOrganizationService returns an organization service, users are counted through
+// userRepo before an organization is deleted.
+func NewOrganizationService(orgRepo ports.OrganizationRepository, userRepo ports.UserRepository) *orgsvc {
+	return &orgsvc{orgRepo: orgRepo, userRepo: userRepo}
+}
+
+func (s *orgsvc) CreateOrganization(ctx context.Context, org *domain.Organization) (*domain.Organization, error) {
+	slug, err := domain.ParseSlug(org.Slug)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(org.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", domain.ErrInvalidArgument)
+	}
+
+	now := time.Now()
+	created := &domain.Organization{Slug: slug, Name: name, CreatedAt: now, UpdatedAt: now}
+	id, err := s.orgRepo.Create(ctx, created)
+	if err != nil {
+		return nil, err
+	}
+	created.ID = *id
+	return created, nil
+}
+
+func (s *orgsvc) GetOrganization(ctx context.Context, id bson.ObjectID) (*domain.Organization, error) {
+	org, err := s.orgRepo.GetByID(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, errOrganizationNotFound
+	}
+	return org, err
+}
+
+func (s *orgsvc) ResolveOrganization(ctx context.Context, slug string) (*domain.Organization, error) {
+	org, err := s.orgRepo.GetBySlug(ctx, strings.ToLower(strings.TrimSpace(slug)))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, errOrganizationNotFound
+	}
+	return org, err
+}
+
+func (s *orgsvc) ListOrganizations(ctx context.Context) ([]domain.Organization, error) {
+	return s.orgRepo.List(ctx)
+}
+
+func (s *orgsvc) UpdateOrganization(ctx context.Context, id bson.ObjectID, update *ports.OrganizationUpdate) (*domain.Organization, error) {
+	canonical := &ports.OrganizationUpdate{Name: strings.TrimSpace(update.Name)}
+	if update.Slug != "" {
+		slug, err := domain.ParseSlug(update.Slug)
+		if err != nil {
+			return nil, err
+		}
+		canonical.Slug = slug
+	}
+	if canonical.Name == "" && canonical.Slug == "" {
+		return nil, fmt.Errorf("%w: update has no fields", domain.ErrInvalidArgument)
+	}
+
+	if err := s.orgRepo.Update(ctx, id, canonical); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, errOrganizationNotFound
+		}
+		return nil, err
+	}
+	return s.GetOrganization(ctx, id)
+}
+
+// DeleteOrganization refuses to orphan users, they have to be deleted first. A user
+// registering concurrently can still slip through, its organization then no longer resolves.
+func (s *orgsvc) DeleteOrganization(ctx context.Context, id bson.ObjectID) error {
+	count, err := s.userRepo.Count(ports.WithTenant(ctx, id), nil)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: organization still has %d users", domain.ErrPrecondition, count)
+	}
+	if err := s.orgRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return errOrganizationNotFound
+		}
+		return err
+	}
+	return nil
+}
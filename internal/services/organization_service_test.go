@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/mocks"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestOrganizationService_CreateOrganization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orgRepo := mocks.NewMockOrganizationRepository(ctrl)
+	orgService := NewOrganizationService(orgRepo, nil)
+
+	id := bson.NewObjectID()
+	orgRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, org *domain.Organization) (*bson.ObjectID, error) {
+		if org.Slug != "acme" || org.Name != "Acme Corp" || org.CreatedAt.IsZero() {
+			t.Fatalf("unexpected organization %+v", org)
+		}
+		return &id, nil
+	})
+
+	org, err := orgService.CreateOrganization(context.Background(), &domain.Organization{Slug: " ACME ", Name: " Acme Corp "})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if org.ID != id {
+		t.Fatalf("expected id %v, got %v", id, org.ID)
+	}
+
+	for _, invalid := range []*domain.Organization{{Slug: "www", Name: "Acme"}, {Slug: "acme", Name: " "}} {
+		if _, err := orgService.CreateOrganization(context.Background(), invalid); !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Fatalf("%+v: expected invalid argument error, got %v", invalid, err)
+		}
+	}
+}
+
+func TestOrganizationService_UpdateOrganization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orgRepo := mocks.NewMockOrganizationRepository(ctrl)
+	orgService := NewOrganizationService(orgRepo, nil)
+
+	id := bson.NewObjectID()
+	orgRepo.EXPECT().Update(gomock.Any(), id, gomock.Eq(&ports.OrganizationUpdate{Slug: "acme-corp"})).Return(nil)
+	orgRepo.EXPECT().GetByID(gomock.Any(), id).Return(&domain.Organization{ID: id, Slug: "acme-corp"}, nil)
+
+	org, err := orgService.UpdateOrganization(context.Background(), id, &ports.OrganizationUpdate{Slug: "Acme-Corp", Name: " "})
+	if err != nil || org.Slug != "acme-corp" {
+		t.Fatalf("expected the updated organization, got %+v, %v", org, err)
+	}
+
+	if _, err := orgService.UpdateOrganization(context.Background(), id, &ports.OrganizationUpdate{}); !errors.Is(err, domain.ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument error, got %v", err)
+	}
+}
+
+func TestOrganizationService_DeleteOrganization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orgRepo := mocks.NewMockOrganizationRepository(ctrl)
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	orgService := NewOrganizationService(orgRepo, userRepo)
+
+	withUsers, empty := bson.NewObjectID(), bson.NewObjectID()
+	// users are counted in the organization being deleted, not the one of the caller
+	userRepo.EXPECT().Count(gomock.Any(), gomock.Nil()).DoAndReturn(func(ctx context.Context, _ *ports.UserFilter) (int64, error) {
+		if tenantID, _ := ports.TenantOf(ctx); tenantID == withUsers {
+			return 2, nil
+		}
+		return 0, nil
+	}).Times(2)
+	orgRepo.EXPECT().Delete(gomock.Any(), empty).Return(nil)
+
+	ctx := ports.WithTenant(context.Background(), bson.NewObjectID())
+	if err := orgService.DeleteOrganization(ctx, withUsers); !errors.Is(err, domain.ErrPrecondition) {
+		t.Fatalf("expected failed precondition error, got %v", err)
+	}
+	if err := orgService.DeleteOrganization(ctx, empty); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestOrganizationService_ResolveOrganization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orgRepo := mocks.NewMockOrganizationRepository(ctrl)
+	orgService := NewOrganizationService(orgRepo, nil)
+
+	orgRepo.EXPECT().GetBySlug(gomock.Any(), "acme").Return(&domain.Organization{Slug: "acme"}, nil)
+	orgRepo.EXPECT().GetBySlug(gomock.Any(), "nobody").Return(nil, domain.ErrNotFound)
+
+	if _, err := orgService.ResolveOrganization(context.Background(), "Acme"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := orgService.ResolveOrganization(context.Background(), "nobody"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
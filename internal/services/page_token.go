@@ -0,0 +1,93 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+)
+
+var errInvalidPageToken = fmt.Errorf("%w: invalid page token", domain.ErrInvalidArgument)
+
+// pageTokens encodes keyset cursors into opaque tokens sealed with AES-256-GCM under a
+// key derived from the secret, so clients can neither forge a cursor nor read the sort
+// values of the last row it carries.
+type pageTokens struct {
+	key []byte
+}
+
+type pageTokenPayload struct {
+	Cursor ports.Cursor `json:"c"`
+	Query  string       `json:"q"` // fingerprint of the filter and sort the token was issued for
+}
+
+// newRandomPageTokens returns a codec with a per-process key, tokens won't survive a restart.
+func newRandomPageTokens() pageTokens {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Errorf("unable to generate page token key: %w", err))
+	}
+	return pageTokens{key: key}
+}
+
+// encode returns a token resuming after cursor, only valid for the same query.
+func (p pageTokens) encode(cursor ports.Cursor, query string) string {
+	payload, _ := json.Marshal(pageTokenPayload{Cursor: cursor, Query: query})
+	aead := p.aead()
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(fmt.Errorf("unable to generate page token nonce: %w", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, payload, nil))
+}
+
+func (p pageTokens) decode(token string, query string) (*ports.Cursor, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(token)
+	aead := p.aead()
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, errInvalidPageToken
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	raw, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errInvalidPageToken
+	}
+	var payload pageTokenPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, errInvalidPageToken
+	}
+	if payload.Query != query {
+		return nil, fmt.Errorf("%w: page token was issued for another filter or sort", domain.ErrInvalidArgument)
+	}
+	return &payload.Cursor, nil
+}
+
+// aead is the cipher sealing the tokens, keyed by the SHA-256 of the secret so secrets of
+// any length give a 256-bit key
+func (p pageTokens) aead() cipher.AEAD {
+	key := sha256.Sum256(p.key)
+	block, _ := aes.NewCipher(key[:]) // a 32 byte key is always valid
+	aead, _ := cipher.NewGCM(block)
+	return aead
+}
+
+// queryFingerprint identifies a filter and sort, so a page token can't be replayed against another query.
+func queryFingerprint(filter ports.UserFilter, sort []ports.SortField) string {
+	var expr string
+	if filter.Expr != nil {
+		expr = filter.Expr.String()
+	}
+	raw, _ := json.Marshal(struct {
+		Filter ports.UserFilter  `json:"f"`
+		Expr   string            `json:"e"`
+		Sort   []ports.SortField `json:"s"`
+	}{filter, expr, sort})
+	sum := sha256.Sum256(raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
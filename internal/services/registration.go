@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var errNotPendingApproval = fmt.Errorf("%w: user isn't waiting for approval", domain.ErrPrecondition)
+
+// WithRegistration sets who can register and whether new accounts wait for an admin to
+// approve them, see domain.RegistrationPolicy. Without it anyone can register.
+func WithRegistration(policy domain.RegistrationPolicy) UserServiceOption {
+	return func(s *usersvc) {
+		s.registration = policy
+	}
+}
+
+type invitedKey struct{}
+
+// withInvitation marks a registration as the acceptance of an invitation. An admin chose
+// the invitee, so it passes invite-only mode and the domain lists and is approved already.
+func withInvitation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, invitedKey{}, true)
+}
+
+func invited(ctx context.Context) bool {
+	ok, _ := ctx.Value(invitedKey{}).(bool)
+	return ok
+}
+
+// checkRegistration returns an error unless email can register under the registration policy
+func (s *usersvc) checkRegistration(ctx context.Context, email domain.Email) error {
+	if err := s.registration.CheckMode(invited(ctx)); err != nil {
+		return err
+	}
+	if invited(ctx) {
+		return nil
+	}
+	return s.registration.CheckEmail(email)
+}
+
+// registrationStatus is the status of a new account
+func (s *usersvc) registrationStatus(ctx context.Context) domain.Status {
+	if s.registration.RequireApproval && !invited(ctx) {
+		return domain.StatusPendingApproval
+	}
+	return domain.StatusActive // no email verification yet, see domain.StatusPendingVerification
+}
+
+// ListPendingRegistrations returns the accounts waiting for approval, oldest first
+func (s *usersvc) ListPendingRegistrations(ctx context.Context, req *ports.PageRequest) (*ports.UserPage, error) {
+	return s.List(ctx, &ports.ListRequest{
+		Filter:    ports.UserFilter{Status: string(domain.StatusPendingApproval)},
+		PageSize:  req.PageSize,
+		PageToken: req.PageToken,
+	})
+}
+
+// ApproveRegistration activates an account waiting for approval, so it can log in
+func (s *usersvc) ApproveRegistration(ctx context.Context, id bson.ObjectID) (*domain.User, error) {
+	user, err := s.pendingApproval(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &ports.StatusChange{From: domain.StatusPendingApproval, To: domain.StatusActive, At: time.Now()}
+	if err := s.userRepo.SetStatus(ctx, id, change); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, errStatusChanged
+		}
+		return nil, err
+	}
+	user.Status, user.StatusReason, user.StatusChangedAt = change.To, "", change.At
+	return user, nil
+}
+
+// RejectRegistration deletes an account waiting for approval, which frees its email and
+// username. It's marked deleted first so a concurrent approval can't win, and both happen
+// in one transaction so a failed delete doesn't leave it marked.
+func (s *usersvc) RejectRegistration(ctx context.Context, id bson.ObjectID) error {
+	return s.inTx(ctx, func(ctx context.Context) error {
+		if _, err := s.pendingApproval(ctx, id); err != nil {
+			return err
+		}
+
+		change := &ports.StatusChange{From: domain.StatusPendingApproval, To: domain.StatusDeleted, Reason: "registration rejected", At: time.Now()}
+		if err := s.userRepo.SetStatus(ctx, id, change); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return errStatusChanged
+			}
+			return err
+		}
+		return s.Delete(ctx, id)
+	})
+}
+
+// pendingApproval returns the user with id if it's waiting for approval
+func (s *usersvc) pendingApproval(ctx context.Context, id bson.ObjectID) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, lookupError(err, errUserNotFound)
+	}
+	if user.Status != domain.StatusPendingApproval {
+		return nil, errNotPendingApproval
+	}
+	return user, nil
+}
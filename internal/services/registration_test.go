@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/mocks"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestUserService_Register_Policy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  domain.RegistrationPolicy
+		email   string
+		invited bool
+		want    error
+	}{
+		{"closed", domain.RegistrationPolicy{Mode: domain.RegistrationClosed}, "jane@example.com", false, domain.ErrRegistrationClosed},
+		{"closed to invitees", domain.RegistrationPolicy{Mode: domain.RegistrationClosed}, "jane@example.com", true, domain.ErrRegistrationClosed},
+		{"invite only", domain.RegistrationPolicy{Mode: domain.RegistrationInviteOnly}, "jane@example.com", false, domain.ErrInviteOnly},
+		{"allowlist", domain.RegistrationPolicy{AllowedDomains: []string{"example.com"}}, "jane@example.net", false, domain.ErrEmailDomainNotAllowed},
+		{"denylist", domain.RegistrationPolicy{DeniedDomains: []string{"example.net"}}, "jane@example.net", false, domain.ErrEmailDomainNotAllowed},
+		{"disposable", domain.RegistrationPolicy{BlockDisposable: true}, "jane@yopmail.com", false, domain.ErrEmailDomainNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// refused before anything is stored
+			userService := NewUserService(mocks.NewMockUserRepository(ctrl), nil, nil, WithRegistration(tt.policy))
+			ctx := context.Background()
+			if tt.invited {
+				ctx = withInvitation(ctx)
+			}
+			if _, err := userService.Register(ctx, &domain.User{Email: tt.email, Password: "password"}); !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestUserService_Register_Invited(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	passwordHasher := mocks.NewMockPasswordHasher(ctrl)
+	userService := NewUserService(userRepo, passwordHasher, nil, WithRegistration(domain.RegistrationPolicy{
+		Mode:            domain.RegistrationInviteOnly,
+		AllowedDomains:  []string{"example.com"},
+		RequireApproval: true,
+	}))
+
+	id := bson.NewObjectID()
+	passwordHasher.EXPECT().Hash("password").Return("hash", nil)
+	userRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(&id, nil)
+
+	// the admin chose the invitee, so the domain lists and the approval don't apply
+	user := &domain.User{Email: "contractor@example.net", Password: "password"}
+	if _, err := userService.Register(withInvitation(context.Background()), user); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if user.Status != domain.StatusActive {
+		t.Fatalf("expected status %v, got %v", domain.StatusActive, user.Status)
+	}
+}
+
+func TestUserService_Register_RequireApproval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	passwordHasher := mocks.NewMockPasswordHasher(ctrl)
+	userService := NewUserService(userRepo, passwordHasher, nil, WithRegistration(domain.RegistrationPolicy{RequireApproval: true}))
+
+	id := bson.NewObjectID()
+	passwordHasher.EXPECT().Hash("password").Return("hash", nil)
+	userRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(&id, nil)
+
+	user := &domain.User{Email: "jane@example.com", Password: "password"}
+	if _, err := userService.Register(context.Background(), user); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if user.Status != domain.StatusPendingApproval {
+		t.Fatalf("expected status %v, got %v", domain.StatusPendingApproval, user.Status)
+	}
+}
+
+func TestUserService_ApproveRegistration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	id := bson.NewObjectID()
+	userRepo.EXPECT().GetByID(gomock.Any(), id).Return(&domain.User{ID: id, Status: domain.StatusPendingApproval}, nil)
+	userRepo.EXPECT().SetStatus(gomock.Any(), id, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ bson.ObjectID, change *ports.StatusChange) error {
+			if change.From != domain.StatusPendingApproval || change.To != domain.StatusActive {
+				t.Fatalf("unexpected change %+v", change)
+			}
+			return nil
+		})
+
+	user, err := userService.ApproveRegistration(context.Background(), id)
+	if err != nil || user.Status != domain.StatusActive {
+		t.Fatalf("expected an active user, got %+v, %v", user, err)
+	}
+
+	// only accounts waiting for approval can be approved, not e.g. suspended ones
+	userRepo.EXPECT().GetByID(gomock.Any(), id).Return(&domain.User{ID: id, Status: domain.StatusSuspended}, nil)
+	if _, err := userService.ApproveRegistration(context.Background(), id); !errors.Is(err, domain.ErrPrecondition) {
+		t.Fatalf("expected failed precondition, got %v", err)
+	}
+}
+
+func TestUserService_RejectRegistration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	id := bson.NewObjectID()
+	userRepo.EXPECT().GetByID(gomock.Any(), id).Return(&domain.User{ID: id, Status: domain.StatusPendingApproval}, nil)
+	gomock.InOrder(
+		userRepo.EXPECT().SetStatus(gomock.Any(), id, gomock.Any()).Return(nil),
+		userRepo.EXPECT().Delete(gomock.Any(), id).Return(nil),
+	)
+	if err := userService.RejectRegistration(context.Background(), id); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// approved meanwhile, the account is kept
+	userRepo.EXPECT().GetByID(gomock.Any(), id).Return(&domain.User{ID: id, Status: domain.StatusPendingApproval}, nil)
+	userRepo.EXPECT().SetStatus(gomock.Any(), id, gomock.Any()).Return(domain.ErrNotFound)
+	if err := userService.RejectRegistration(context.Background(), id); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var _ ports.SettingsService = &setsvc{}
+
+type setsvc struct {
+	settingsRepo ports.SettingsRepository
+	schema       domain.SettingsSchema
+}
+
+// NewSettingsService returns a settings service for the settings of schema, see
+// config.Config.SettingsSchema.
+func NewSettingsService(settingsRepo ports.SettingsRepository, schema domain.SettingsSchema) *setsvc {
+	return &setsvc{settingsRepo: settingsRepo, schema: schema}
+}
+
+func (s *setsvc) GetSettings(ctx context.Context, userID bson.ObjectID) (domain.Settings, error) {
+	stored, err := s.settingsRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.schema.Resolve(stored), nil
+}
+
+func (s *setsvc) ReplaceSettings(ctx context.Context, userID bson.ObjectID, settings map[string]any) (domain.Settings, error) {
+	stored := make(map[string]any, len(settings))
+	for name, value := range settings {
+		if value == nil {
+			continue // back to the default
+		}
+		value, err := s.schema.CheckValue(name, value)
+		if err != nil {
+			return nil, err
+		}
+		stored[name] = value
+	}
+
+	if err := s.settingsRepo.Replace(ctx, userID, stored); err != nil {
+		return nil, err
+	}
+	return s.schema.Resolve(stored), nil
+}
+
+func (s *setsvc) UpdateSettings(ctx context.Context, userID bson.ObjectID, patch map[string]any) (domain.Settings, error) {
+	if len(patch) == 0 {
+		return nil, fmt.Errorf("%w: update has no settings", domain.ErrInvalidArgument)
+	}
+
+	set := map[string]any{}
+	var unset []string
+	for name, value := range patch {
+		if value == nil {
+			if err := s.schema.CheckName(name); err != nil {
+				return nil, err
+			}
+			unset = append(unset, name)
+			continue
+		}
+		value, err := s.schema.CheckValue(name, value)
+		if err != nil {
+			return nil, err
+		}
+		set[name] = value
+	}
+
+	if err := s.settingsRepo.Update(ctx, userID, set, unset); err != nil {
+		return nil, err
+	}
+	return s.GetSettings(ctx, userID)
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/mocks"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var testSettings = domain.SettingsSchema{
+	{Name: "notify_email", Type: domain.AttributeBool, Default: true},
+	{Name: "theme", Type: domain.AttributeString, Default: "system", Enum: []string{"light", "dark", "system"}},
+	{Name: "page_size", Type: domain.AttributeNumber, Default: float64(20)},
+}
+
+func TestSettingsService_GetSettings_Defaults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	settingsRepo := mocks.NewMockSettingsRepository(ctrl)
+	settingsService := NewSettingsService(settingsRepo, testSettings)
+
+	id := bson.NewObjectID()
+	settingsRepo.EXPECT().Get(gomock.Any(), gomock.Eq(id)).Return(map[string]any{"theme": "dark", "removed": 1}, nil)
+
+	settings, err := settingsService.GetSettings(context.Background(), id)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := domain.Settings{"notify_email": true, "theme": "dark", "page_size": float64(20)}
+	if !reflect.DeepEqual(settings, want) {
+		t.Fatalf("expected %v, got %v", want, settings)
+	}
+}
+
+func TestSettingsService_ReplaceSettings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	settingsRepo := mocks.NewMockSettingsRepository(ctrl)
+	settingsService := NewSettingsService(settingsRepo, testSettings)
+
+	id := bson.NewObjectID()
+	settingsRepo.EXPECT().Replace(gomock.Any(), gomock.Eq(id), gomock.Eq(map[string]any{"page_size": float64(50)})).Return(nil)
+
+	settings, err := settingsService.ReplaceSettings(context.Background(), id, map[string]any{"page_size": 50, "theme": nil})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if settings["theme"] != "system" || settings["page_size"] != float64(50) {
+		t.Fatalf("expected the default theme and page size 50, got %v", settings)
+	}
+}
+
+func TestSettingsService_UpdateSettings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	settingsRepo := mocks.NewMockSettingsRepository(ctrl)
+	settingsService := NewSettingsService(settingsRepo, testSettings)
+
+	id := bson.NewObjectID()
+	settingsRepo.EXPECT().Update(gomock.Any(), gomock.Eq(id), gomock.Eq(map[string]any{"notify_email": false}), gomock.Eq([]string{"theme"})).Return(nil)
+	settingsRepo.EXPECT().Get(gomock.Any(), gomock.Eq(id)).Return(map[string]any{"notify_email": false}, nil)
+
+	settings, err := settingsService.UpdateSettings(context.Background(), id, map[string]any{"notify_email": false, "theme": nil})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if settings["notify_email"] != false || settings["theme"] != "system" {
+		t.Fatalf("unexpected settings %v", settings)
+	}
+}
+
+func TestSettingsService_UpdateSettings_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	settingsService := NewSettingsService(mocks.NewMockSettingsRepository(ctrl), testSettings)
+
+	patches := map[string]map[string]any{
+		"empty":         {},
+		"unknown":       {"font": "serif"},
+		"reset unknown": {"font": nil},
+		"type":          {"notify_email": "yes"},
+		"enum":          {"theme": "blue"},
+	}
+	for name, patch := range patches {
+		_, err := settingsService.UpdateSettings(context.Background(), bson.NewObjectID(), patch)
+		if !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Fatalf("%s: expected invalid argument error, got %v", name, err)
+		}
+	}
+}
@@ -3,11 +3,16 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/hinphansa/7-solutions-challenge/internal/domain"
 	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"github.com/hinphansa/7-solutions-challenge/internal/search"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
@@ -15,11 +20,20 @@ import (
 var _ ports.UserService = &usersvc{}
 
 var (
-	errUserNotFound          = errors.New("user not found")
+	errUserNotFound          = fmt.Errorf("user %w", domain.ErrNotFound)
 	errInvalidPassword       = errors.New("invalid password")
 	errUnableToGenerateToken = errors.New("unable to generate token")
 )
 
+// lookupError is the error of a failed user lookup, replaced by notFound unless the storage
+// is unavailable, which callers should retry rather than take for a missing user
+func lookupError(err, notFound error) error {
+	if errors.Is(err, domain.ErrUnavailable) {
+		return err
+	}
+	return notFound
+}
+
 // PasswordHasher is an interface that defines the methods for hashing and comparing passwords
 type PasswordHasher interface {
 	Hash(password string) (string, error)
@@ -28,26 +42,159 @@ type PasswordHasher interface {
 
 // TokenGenerator is an interface that defines the methods for generating and verifying tokens
 type TokenGenerator interface {
-	Generate(id bson.ObjectID, email string) (string, error)
+	// Generate issues a token for the user with id in the organization with tenantID
+	Generate(id bson.ObjectID, tenantID bson.ObjectID, email string, roles []string) (string, error)
 }
 
+const (
+	defaultPageSize = 20
+	defaultMaxPage  = 100
+)
+
 type usersvc struct {
 	userRepo       ports.UserRepository
 	passwordHasher PasswordHasher
 	tokenGenerator TokenGenerator
+
+	pageTokens      pageTokens
+	defaultPageSize int64
+	maxPageSize     int64
+	emailPolicy     domain.EmailPolicy
+	mailer          ports.Mailer
+	emailChange     EmailChangeConfig
+	attributeRepo   ports.AttributeSchemaRepository
+	settingsRepo    ports.SettingsRepository
+	groupRepo       ports.GroupRepository
+
+	usernamePolicy      domain.UsernamePolicy
+	usernameRedirectTTL time.Duration
+	registration        domain.RegistrationPolicy
+
+	txManager ports.TxManager
+}
+
+// UserServiceOption configures optional behaviour of the user service
+type UserServiceOption func(*usersvc)
+
+// WithPageSize sets the page size used when a client doesn't ask for one,
+// and the maximum page size a client can ask for.
+func WithPageSize(defaultSize, maxSize int64) UserServiceOption {
+	return func(s *usersvc) {
+		s.defaultPageSize = defaultSize
+		s.maxPageSize = maxSize
+	}
+}
+
+// WithPageTokenSecret sets the key used to sign page tokens.
+// Without it a random key is used, so tokens are only valid for the lifetime of the process.
+func WithPageTokenSecret(secret string) UserServiceOption {
+	return func(s *usersvc) {
+		s.pageTokens = pageTokens{key: []byte(secret)}
+	}
+}
+
+// WithEmailPolicy sets how emails are canonicalized, see domain.EmailPolicy.
+func WithEmailPolicy(policy domain.EmailPolicy) UserServiceOption {
+	return func(s *usersvc) {
+		s.emailPolicy = policy
+	}
+}
+
+// WithAttributeSchema validates custom attributes against the schema in repo. Without it
+// no attributes are defined, so users can't carry any.
+func WithAttributeSchema(repo ports.AttributeSchemaRepository) UserServiceOption {
+	return func(s *usersvc) {
+		s.attributeRepo = repo
+	}
+}
+
+// WithSettings deletes the settings of users along with them
+func WithSettings(repo ports.SettingsRepository) UserServiceOption {
+	return func(s *usersvc) {
+		s.settingsRepo = repo
+	}
+}
+
+// WithGroups removes users from their groups along with them
+func WithGroups(repo ports.GroupRepository) UserServiceOption {
+	return func(s *usersvc) {
+		s.groupRepo = repo
+	}
+}
+
+// WithTransactions runs the operations writing more than once in transactions of tx, so
+// they apply whole or not at all. Without it each write applies on its own.
+func WithTransactions(tx ports.TxManager) UserServiceOption {
+	return func(s *usersvc) {
+		s.txManager = tx
+	}
+}
+
+// inTx runs fn in a transaction when the service has a TxManager, and as is otherwise.
+// fn may be retried, mails and other side effects go after it.
+func (s *usersvc) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.txManager == nil {
+		return fn(ctx)
+	}
+	return s.txManager.WithinTx(ctx, fn)
 }
 
-func NewUserService(userRepo ports.UserRepository, passwordHasher PasswordHasher, tokenGenerator TokenGenerator) *usersvc {
-	return &usersvc{
-		userRepo:       userRepo,
-		passwordHasher: passwordHasher,
-		tokenGenerator: tokenGenerator,
+// attributeSchema loads the current attribute schema
+func (s *usersvc) attributeSchema(ctx context.Context) (domain.AttributeSchema, error) {
+	if s.attributeRepo == nil {
+		return nil, nil
 	}
+	return s.attributeRepo.List(ctx)
+}
+
+func NewUserService(userRepo ports.UserRepository, passwordHasher PasswordHasher, tokenGenerator TokenGenerator, opts ...UserServiceOption) *usersvc {
+	s := &usersvc{
+		userRepo:        userRepo,
+		passwordHasher:  passwordHasher,
+		tokenGenerator:  tokenGenerator,
+		pageTokens:      newRandomPageTokens(),
+		defaultPageSize: defaultPageSize,
+		maxPageSize:     defaultMaxPage,
+
+		usernameRedirectTTL: defaultUsernameRedirectTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *usersvc) Register(ctx context.Context, user *domain.User) (*bson.ObjectID, error) {
-	user.Email = strings.ToLower(strings.TrimSpace(user.Email))
+	email, err := domain.ParseEmail(user.Email, s.emailPolicy)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRegistration(ctx, email); err != nil {
+		return nil, err
+	}
+	user.Email = email.String()
+	if user.Username = strings.TrimSpace(user.Username); user.Username != "" {
+		if user.Username, err = domain.ParseUsername(user.Username, s.usernamePolicy); err != nil {
+			return nil, err
+		}
+		if err := s.checkUsernameFree(ctx, user.Username, bson.ObjectID{}); err != nil {
+			return nil, err
+		}
+	}
+	if err := user.CanonicalizeProfile(); err != nil {
+		return nil, err
+	}
+	schema, err := s.attributeSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.ValidateAttributes(user.Attributes); err != nil {
+		return nil, err
+	}
 	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+	user.Status = s.registrationStatus(ctx)
+	user.StatusChangedAt = user.CreatedAt
 
 	hash, err := s.passwordHasher.Hash(user.Password)
 	if err != nil {
@@ -55,18 +202,24 @@ func (s *usersvc) Register(ctx context.Context, user *domain.User) (*bson.Object
 	}
 	user.Password = hash
 
-	id, err := s.userRepo.Create(ctx, user)
+	// records written along with the user join this transaction
+	var id *bson.ObjectID
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		id, err = s.userRepo.Create(ctx, user)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	return id, nil
 }
 
-func (s *usersvc) GetByID(ctx context.Context, id bson.ObjectID) (*domain.User, error) {
-	user, err := s.userRepo.GetByID(ctx, id)
+func (s *usersvc) GetByID(ctx context.Context, id bson.ObjectID, fields ...string) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id, fields...)
 	if err != nil {
-		return nil, errUserNotFound
+		return nil, lookupError(err, errUserNotFound)
 	}
+	ports.MaskUser(user, fields) // Mongo returns the id even when it's not selected
 	return user, nil
 }
 
@@ -74,18 +227,270 @@ func (s *usersvc) GetAll(ctx context.Context) ([]domain.User, error) {
 	return s.userRepo.GetAll(ctx)
 }
 
-func (s *usersvc) List(ctx context.Context, pagination *ports.Pagination) ([]domain.User, error) {
-	return s.userRepo.List(ctx, pagination)
+// List returns a page of users matching the filter. Page size is clamped to the
+// server maximum, and one extra user is fetched to know whether a next page exists.
+func (s *usersvc) List(ctx context.Context, req *ports.ListRequest) (*ports.UserPage, error) {
+	filter := normalizeFilter(req.Filter)
+	if filter.Status != "" {
+		if _, err := domain.ParseStatus(filter.Status); err != nil {
+			return nil, err
+		}
+	}
+	sort := req.Sort
+	if len(sort) == 0 {
+		sort = []ports.SortField{{Field: ports.SortByCreatedAt}}
+	}
+	for _, field := range sort {
+		if err := field.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	size := req.PageSize
+	if size <= 0 {
+		size = s.defaultPageSize
+	}
+	size = min(size, s.maxPageSize)
+
+	query := queryFingerprint(filter, sort)
+	pagination := &ports.Pagination{Limit: size + 1, Sort: sort, Fields: withSortFields(req.Fields, sort)}
+	if req.PageToken != "" {
+		after, err := s.pageTokens.decode(req.PageToken, query)
+		if err != nil {
+			return nil, err
+		}
+		pagination.After = after
+	}
+
+	users, err := s.userRepo.List(ctx, &filter, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ports.UserPage{Users: users}
+	if int64(len(users)) > size {
+		page.Users = users[:size]
+		page.NextPageToken = s.pageTokens.encode(cursorOf(&page.Users[size-1], sort), query)
+	}
+	for i := range page.Users {
+		// sort fields were only loaded for the cursor
+		ports.MaskUser(&page.Users[i], req.Fields)
+	}
+
+	if req.IncludeTotal {
+		total, err := s.userRepo.Count(ctx, &filter)
+		if err != nil {
+			return nil, err
+		}
+		page.TotalCount = &total
+	}
+	return page, nil
+}
+
+// normalizeFilter trims the filter, names and emails are matched case-insensitively
+// and emails are stored canonical, so the email domain is canonicalized too.
+func normalizeFilter(filter ports.UserFilter) ports.UserFilter {
+	filter.NamePrefix = strings.TrimSpace(filter.NamePrefix)
+	filter.EmailDomain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(filter.EmailDomain), "@"))
+	if host, err := domain.CanonicalDomain(filter.EmailDomain); err == nil {
+		filter.EmailDomain = host
+	}
+	filter.Search = strings.TrimSpace(filter.Search)
+	filter.Status = strings.ToLower(strings.TrimSpace(filter.Status))
+	return filter
+}
+
+// withSortFields adds the sort fields to a sparse fieldset, the next page cursor needs them.
+func withSortFields(fields []string, sort []ports.SortField) []string {
+	if fields == nil {
+		return nil
+	}
+	loaded := slices.Clone(fields)
+	for _, field := range sort {
+		if !slices.Contains(loaded, field.Field) {
+			loaded = append(loaded, field.Field)
+		}
+	}
+	return loaded
+}
+
+// cursorOf returns the position of user in a page sorted by sort.
+func cursorOf(user *domain.User, sort []ports.SortField) ports.Cursor {
+	cursor := ports.Cursor{ID: user.ID}
+	for _, field := range sort {
+		switch field.Field {
+		case ports.SortByName:
+			cursor.Name = user.Name
+		case ports.SortByEmail:
+			cursor.Email = user.Email
+		case ports.SortByCreatedAt:
+			cursor.CreatedAt = user.CreatedAt
+		}
+	}
+	return cursor
+}
+
+const (
+	maxSearchQueryLength = 100
+	searchCandidates     = 5 // candidates fetched per requested result, ranking happens here
+)
+
+// Search ranks users by how well their name and email match the query, tolerating typos
+// and partial words, and highlights the matches. Only the requested fields are matched, a
+// candidate matching in another field is left out.
+func (s *usersvc) Search(ctx context.Context, req *ports.SearchRequest) ([]ports.SearchResult, error) {
+	query := strings.TrimSpace(req.Query)
+	if len(search.Tokens(query)) == 0 {
+		return nil, fmt.Errorf("%w: search query is required", domain.ErrInvalidArgument)
+	}
+	if len(query) > maxSearchQueryLength {
+		return nil, fmt.Errorf("%w: search query is longer than %d characters", domain.ErrInvalidArgument, maxSearchQueryLength)
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = s.defaultPageSize
+	}
+	limit = min(limit, s.maxPageSize)
+
+	candidates, err := s.userRepo.Search(ctx, query, limit*searchCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := req.Fields
+	if matched == nil {
+		matched = []string{ports.FieldName, ports.FieldEmail}
+	}
+	results := make([]ports.SearchResult, 0, len(candidates))
+	for _, u := range candidates {
+		fields := make([]search.Field, 0, len(matched))
+		for _, name := range matched {
+			switch name {
+			case ports.FieldName:
+				fields = append(fields, search.Field{Name: name, Text: u.Name})
+			case ports.FieldEmail:
+				fields = append(fields, search.Field{Name: name, Text: u.Email})
+			}
+		}
+		match := search.Score(query, fields...)
+		if match.Score < search.MinScore {
+			continue
+		}
+
+		result := ports.SearchResult{User: u, Score: match.Score, Highlights: map[string]string{}}
+		for _, field := range fields {
+			if h, ok := match.Highlight(field.Name, field.Text); ok {
+				result.Highlights[field.Name] = h
+			}
+		}
+		results = append(results, result)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if int64(len(results)) > limit {
+		results = results[:limit]
+	}
+	return results, nil
 }
 
-func (s *usersvc) Update(ctx context.Context, id bson.ObjectID, user *domain.User) error {
-	return s.userRepo.Update(ctx, id, user)
+func (s *usersvc) Update(ctx context.Context, id bson.ObjectID, update *ports.UserUpdate) error {
+	normalized := *update
+	normalized.Normalize()
+	var email domain.Email
+	if normalized.Email.Op == ports.Set {
+		var err error
+		if email, err = domain.ParseEmail(normalized.Email.Value, s.emailPolicy); err != nil {
+			return err
+		}
+		normalized.Email.Value = email.String()
+	}
+	if normalized.Username.Op == ports.Set {
+		username, err := domain.ParseUsername(normalized.Username.Value, s.usernamePolicy)
+		if err != nil {
+			return err
+		}
+		normalized.Username.Value = username
+	}
+	if err := normalized.CanonicalizeProfile(); err != nil {
+		return err
+	}
+	if err := normalized.Validate(); err != nil {
+		return err
+	}
+	if len(normalized.Attributes) > 0 {
+		schema, err := s.attributeSchema(ctx)
+		if err != nil {
+			return err
+		}
+		normalized.Attributes = maps.Clone(normalized.Attributes) // values are normalized in place
+		if err := normalized.ValidateAttributes(schema); err != nil {
+			return err
+		}
+	}
+
+	// the username, email change and other fields apply together, the email change is
+	// mailed once they did
+	var notify func(ctx context.Context) error
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		notify = nil
+		update := normalized
+
+		// usernames are checked against the redirects of other users and keep a redirect themselves
+		if update.Username.Op != ports.Keep {
+			if err := s.changeUsername(ctx, id, update.Username); err != nil {
+				return err
+			}
+			update.Username = ports.FieldUpdate[string]{}
+			if update.Empty() {
+				return nil
+			}
+		}
+
+		// a new email only applies once confirmed, see RequestEmailChange
+		if update.Email.Op == ports.Set {
+			user, err := s.userRepo.GetByID(ctx, id)
+			if err != nil {
+				return lookupError(err, errUserNotFound)
+			}
+			if email.String() != user.Email {
+				if notify, err = s.startEmailChange(ctx, user, email); err != nil {
+					return err
+				}
+			}
+			update.Email = ports.FieldUpdate[string]{}
+			if update.Empty() {
+				return nil
+			}
+		}
+		return s.userRepo.Update(ctx, id, &update)
+	})
+	if err != nil || notify == nil {
+		return err
+	}
+	return notify(ctx)
 }
 
+// Delete removes a user with its settings and group memberships, in one transaction
 func (s *usersvc) Delete(ctx context.Context, id bson.ObjectID) error {
-	return s.userRepo.Delete(ctx, id)
+	return s.inTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if s.settingsRepo != nil {
+			if err := s.settingsRepo.Delete(ctx, id); err != nil {
+				return fmt.Errorf("delete settings: %w", err)
+			}
+		}
+		if s.groupRepo != nil {
+			if err := s.groupRepo.RemoveFromAll(ctx, id); err != nil {
+				return fmt.Errorf("remove from groups: %w", err)
+			}
+		}
+		return nil
+	})
 }
 
 func (s *usersvc) Count(ctx context.Context) (int64, error) {
-	return s.userRepo.Count(ctx)
+	return s.userRepo.Count(ctx, nil)
 }
@@ -1,9 +1,15 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/hinphansa/7-solutions-challenge/internal/domain"
@@ -44,6 +50,111 @@ func TestUserService_Register_Success(t *testing.T) {
 	if newID.Hex() != id.Hex() {
 		t.Fatalf("expected id %v, got %v", id, newID)
 	}
+
+	if user.Status != domain.StatusActive {
+		t.Fatalf("expected status %v, got %v", domain.StatusActive, user.Status)
+	}
+}
+
+func TestUserService_Register_CanonicalEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	passwordHasher := mocks.NewMockPasswordHasher(ctrl)
+	userService := NewUserService(userRepo, passwordHasher, nil,
+		WithEmailPolicy(domain.EmailPolicy{FoldPlusAddressing: true, PlusAddressingDomains: []string{"gmail.com"}}))
+
+	id := bson.NewObjectID()
+	passwordHasher.EXPECT().Hash("password").Return("hash", nil)
+	userRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user *domain.User) (*bson.ObjectID, error) {
+		if user.Email != "john.doe@gmail.com" {
+			t.Fatalf("expected canonical email john.doe@gmail.com, got %q", user.Email)
+		}
+		return &id, nil
+	})
+
+	_, err := userService.Register(context.Background(), &domain.User{Email: " John.Doe+Shop@GMail.com ", Password: "password"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUserService_Register_InvalidEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	passwordHasher := mocks.NewMockPasswordHasher(ctrl)
+	userService := NewUserService(userRepo, passwordHasher, nil)
+
+	_, err := userService.Register(context.Background(), &domain.User{Email: "not an email", Password: "password"})
+	if !errors.Is(err, domain.ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument error, got %v", err)
+	}
+}
+
+func TestUserService_Register_InvalidProfile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	_, err := userService.Register(context.Background(), &domain.User{Email: "test@example.com", Password: "password", Phone: "555-2671"})
+	if !errors.Is(err, domain.ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument error, got %v", err)
+	}
+}
+
+func TestUserService_Register_Attributes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	passwordHasher := mocks.NewMockPasswordHasher(ctrl)
+	attributeRepo := mocks.NewMockAttributeSchemaRepository(ctrl)
+	userService := NewUserService(userRepo, passwordHasher, nil, WithAttributeSchema(attributeRepo))
+
+	attributeRepo.EXPECT().List(gomock.Any()).Return(domain.AttributeSchema{
+		{Name: "department", Type: domain.AttributeString, Required: true},
+		{Name: "floor", Type: domain.AttributeNumber},
+	}, nil).Times(3)
+	passwordHasher.EXPECT().Hash(gomock.Any()).Return("hashed_password", nil)
+	id := bson.NewObjectID()
+	userRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(&id, nil)
+
+	user := &domain.User{Email: "test@example.com", Password: "password", Attributes: map[string]any{"department": "sales", "floor": 3}}
+	if _, err := userService.Register(context.Background(), user); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if user.Attributes["floor"] != float64(3) {
+		t.Fatalf("expected floor normalized to float64, got %T", user.Attributes["floor"])
+	}
+
+	for _, attributes := range []map[string]any{{"floor": 3}, {"department": "sales", "team": "a"}} {
+		_, err := userService.Register(context.Background(), &domain.User{Email: "test@example.com", Password: "password", Attributes: attributes})
+		if !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Fatalf("attributes %v: expected invalid argument error, got %v", attributes, err)
+		}
+	}
+}
+
+func TestUserService_Register_EmailTaken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	passwordHasher := mocks.NewMockPasswordHasher(ctrl)
+	userService := NewUserService(userRepo, passwordHasher, nil)
+
+	passwordHasher.EXPECT().Hash(gomock.Any()).Return("hash", nil)
+	userRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, domain.ErrEmailTaken)
+
+	_, err := userService.Register(context.Background(), &domain.User{Email: "test@example.com", Password: "password"})
+	if !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
 }
 
 func TestUserService_Register_Hash_Error(t *testing.T) {
@@ -149,6 +260,42 @@ func TestUserService_GetByID_UserNotFound(t *testing.T) {
 	}
 }
 
+// A storage that's unavailable isn't reported as a missing user
+func TestUserService_GetByID_Unavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	unavailable := fmt.Errorf("%w: user storage is failing", domain.ErrUnavailable)
+	userRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).Return(nil, unavailable)
+
+	if _, err := userService.GetByID(context.Background(), bson.NewObjectID()); !errors.Is(err, domain.ErrUnavailable) {
+		t.Fatalf("expected unavailable, got %v", err)
+	}
+}
+
+func TestUserService_GetByID_Fields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil) // passwordHasher and tokenGenerator are nil because we don't need them for this test
+
+	id := bson.NewObjectID()
+	userRepo.EXPECT().GetByID(gomock.Any(), id, ports.FieldName).Return(&domain.User{ID: id, Name: "John"}, nil)
+
+	user, err := userService.GetByID(context.Background(), id, ports.FieldName)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(*user, domain.User{Name: "John"}) {
+		t.Fatalf("expected only the name, got %+v", user)
+	}
+}
+
 func TestUserService_GetAll_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -205,7 +352,9 @@ func TestUserService_List_Success(t *testing.T) {
 	userRepo := mocks.NewMockUserRepository(ctrl)
 	userService := NewUserService(userRepo, nil, nil) // passwordHasher and tokenGenerator are nil because we don't need them for this test
 
-	userRepo.EXPECT().List(gomock.Any(), gomock.Eq(&ports.Pagination{})).Return([]domain.User{
+	// one extra user is requested to know whether there is a next page, oldest first by default
+	pagination := &ports.Pagination{Limit: defaultPageSize + 1, Sort: []ports.SortField{{Field: ports.SortByCreatedAt}}}
+	userRepo.EXPECT().List(gomock.Any(), gomock.Eq(&ports.UserFilter{}), gomock.Eq(pagination)).Return([]domain.User{
 		{
 			ID:       bson.ObjectID{},
 			Email:    "test@example.com",
@@ -213,13 +362,217 @@ func TestUserService_List_Success(t *testing.T) {
 		},
 	}, nil).AnyTimes()
 
-	users, err := userService.List(context.Background(), &ports.Pagination{})
+	page, err := userService.List(context.Background(), &ports.ListRequest{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(page.Users) != 1 {
+		t.Fatalf("expected 1 user, got %v", len(page.Users))
+	}
+
+	if page.NextPageToken != "" {
+		t.Fatalf("expected no next page token, got %v", page.NextPageToken)
+	}
+
+	if page.TotalCount != nil {
+		t.Fatalf("expected no total count, got %v", *page.TotalCount)
+	}
+}
+
+func TestUserService_List_NextPageToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil, WithPageTokenSecret("secret"))
+
+	createdAt := time.Date(2025, 6, 22, 10, 49, 12, 930000000, time.UTC)
+	users := []domain.User{
+		{ID: bson.NewObjectID(), Email: "a@example.com", CreatedAt: createdAt},
+		{ID: bson.NewObjectID(), Email: "b@example.com", CreatedAt: createdAt},
+		{ID: bson.NewObjectID(), Email: "c@example.com", CreatedAt: createdAt.Add(time.Second)},
+	}
+
+	userRepo.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).Return(users, nil)
+
+	page, err := userService.List(context.Background(), &ports.ListRequest{PageSize: 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(page.Users) != 2 {
+		t.Fatalf("expected 2 users, got %v", len(page.Users))
+	}
+
+	if page.NextPageToken == "" {
+		t.Fatalf("expected next page token, got empty")
+	}
+
+	// the token resumes after the last user of the page
+	var got *ports.Pagination
+	userRepo.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *ports.UserFilter, p *ports.Pagination) ([]domain.User, error) {
+		got = p
+		return users[2:], nil
+	})
+
+	page, err = userService.List(context.Background(), &ports.ListRequest{PageSize: 2, PageToken: page.NextPageToken})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if got.After == nil || got.After.ID != users[1].ID || !got.After.CreatedAt.Equal(createdAt) {
+		t.Fatalf("expected cursor after %v, got %+v", users[1].ID, got.After)
+	}
+
+	if page.NextPageToken != "" {
+		t.Fatalf("expected no next page token, got %v", page.NextPageToken)
+	}
+}
+
+func TestPageTokens_Sealed(t *testing.T) {
+	tokens := pageTokens{key: []byte("secret")}
+	token := tokens.encode(ports.Cursor{ID: bson.NewObjectID(), Email: "jane@example.com"}, "query")
+
+	// the cursor can't be read from the token
+	raw, _ := base64.RawURLEncoding.DecodeString(token)
+	if strings.Contains(token, "jane") || bytes.Contains(raw, []byte("jane@example.com")) {
+		t.Fatalf("expected the cursor sealed, got %q", raw)
+	}
+	if cursor, err := tokens.decode(token, "query"); err != nil || cursor.Email != "jane@example.com" {
+		t.Fatalf("expected the cursor back, got %+v, %v", cursor, err)
+	}
+
+	// nor changed
+	tampered := []byte(token)
+	tampered[len(tampered)/2] ^= 1
+	if _, err := tokens.decode(string(tampered), "query"); !errors.Is(err, domain.ErrInvalidArgument) {
+		t.Fatalf("expected a tampered token to be invalid, got %v", err)
+	}
+}
+
+func TestUserService_List_InvalidPageToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil, WithPageTokenSecret("secret"))
+
+	// a token sealed with another key must be rejected
+	sort := []ports.SortField{{Field: ports.SortByCreatedAt}}
+	forged := pageTokens{key: []byte("other")}.encode(ports.Cursor{ID: bson.NewObjectID()}, queryFingerprint(ports.UserFilter{}, sort))
+
+	// a token issued for another filter must be rejected too
+	otherQuery := pageTokens{key: []byte("secret")}.encode(ports.Cursor{ID: bson.NewObjectID()}, queryFingerprint(ports.UserFilter{NamePrefix: "jo"}, sort))
+
+	for _, token := range []string{"garbage", forged, otherQuery} {
+		_, err := userService.List(context.Background(), &ports.ListRequest{PageToken: token})
+		if !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Fatalf("expected invalid argument error for %q, got %v", token, err)
+		}
+	}
+}
+
+func TestUserService_List_MaxPageSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil, WithPageSize(10, 50))
+
+	userRepo.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Eq(&ports.Pagination{Limit: 51, Sort: []ports.SortField{{Field: ports.SortByCreatedAt}}})).Return(nil, nil)
+
+	if _, err := userService.List(context.Background(), &ports.ListRequest{PageSize: 1000}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUserService_List_IncludeTotal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	userRepo.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+	userRepo.EXPECT().Count(gomock.Any(), gomock.Eq(&ports.UserFilter{EmailDomain: "example.com"})).Return(int64(42), nil)
+
+	page, err := userService.List(context.Background(), &ports.ListRequest{
+		Filter:       ports.UserFilter{EmailDomain: "example.com"},
+		IncludeTotal: true,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if page.TotalCount == nil || *page.TotalCount != 42 {
+		t.Fatalf("expected total count 42, got %v", page.TotalCount)
+	}
+}
+
+func TestUserService_List_FilterAndSort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	sort := []ports.SortField{{Field: ports.SortByName}, {Field: ports.SortByCreatedAt, Desc: true}}
+	wantFilter := &ports.UserFilter{NamePrefix: "jo", EmailDomain: "example.com", Search: "doe"}
+	wantPagination := &ports.Pagination{Limit: defaultPageSize + 1, Sort: sort}
+	userRepo.EXPECT().List(gomock.Any(), gomock.Eq(wantFilter), gomock.Eq(wantPagination)).Return(nil, nil)
+
+	_, err := userService.List(context.Background(), &ports.ListRequest{
+		Filter: ports.UserFilter{NamePrefix: " jo ", EmailDomain: "@Example.COM", Search: "doe "},
+		Sort:   sort,
+	})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
+}
+
+func TestUserService_List_Fields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
 
-	if len(users) != 1 {
-		t.Fatalf("expected 1 user, got %v", len(users))
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	sort := []ports.SortField{{Field: ports.SortByEmail}}
+	wantPagination := &ports.Pagination{Limit: 2, Sort: sort, Fields: []string{ports.FieldName, ports.SortByEmail}}
+	users := []domain.User{
+		{ID: bson.NewObjectID(), Name: "A", Email: "a@example.com"},
+		{ID: bson.NewObjectID(), Name: "B", Email: "b@example.com"},
+	}
+	userRepo.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Eq(wantPagination)).Return(users, nil)
+
+	page, err := userService.List(context.Background(), &ports.ListRequest{
+		Sort:     sort,
+		PageSize: 1,
+		Fields:   []string{ports.FieldName},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(page.Users) != 1 || !reflect.DeepEqual(page.Users[0], domain.User{Name: "A"}) {
+		t.Fatalf("expected only the name of the first user, got %+v", page.Users)
+	}
+	if page.NextPageToken == "" {
+		t.Fatalf("expected a next page token")
+	}
+}
+
+func TestUserService_List_InvalidSort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	_, err := userService.List(context.Background(), &ports.ListRequest{Sort: []ports.SortField{{Field: "password"}}})
+	if !errors.Is(err, domain.ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument error, got %v", err)
 	}
 }
 
@@ -230,9 +583,9 @@ func TestUserService_List_Error(t *testing.T) {
 	userRepo := mocks.NewMockUserRepository(ctrl)
 	userService := NewUserService(userRepo, nil, nil) // passwordHasher and tokenGenerator are nil because we don't need them for this test
 
-	userRepo.EXPECT().List(gomock.Any(), gomock.Eq(&ports.Pagination{})).Return(nil, errors.New("list error"))
+	userRepo.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("list error"))
 
-	_, err := userService.List(context.Background(), &ports.Pagination{})
+	_, err := userService.List(context.Background(), &ports.ListRequest{})
 	if err == nil {
 		t.Fatalf("expected error, got nil")
 	}
@@ -242,6 +595,114 @@ func TestUserService_List_Error(t *testing.T) {
 	}
 }
 
+func TestUserService_Search_Ranking(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil) // passwordHasher and tokenGenerator are nil because we don't need them for this test
+
+	candidates := []domain.User{
+		{Name: "Jonathan Smith", Email: "jsmith@example.com"},
+		{Name: "Alice Brown", Email: "alice@example.com"},
+		{Name: "Jon Snow", Email: "jon@example.com"},
+	}
+	userRepo.EXPECT().Search(gomock.Any(), "jon", int64(2*searchCandidates)).Return(candidates, nil)
+
+	results, err := userService.Search(context.Background(), &ports.SearchRequest{Query: " jon ", Limit: 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].User.Name != "Jon Snow" || results[1].User.Name != "Jonathan Smith" {
+		t.Fatalf("expected Jon Snow then Jonathan Smith, got %v then %v", results[0].User.Name, results[1].User.Name)
+	}
+	if results[0].Score <= results[1].Score {
+		t.Fatalf("expected scores in decreasing order, got %v and %v", results[0].Score, results[1].Score)
+	}
+	if got := results[1].Highlights["name"]; got != "<em>Jon</em>athan Smith" {
+		t.Fatalf("expected name highlight %q, got %q", "<em>Jon</em>athan Smith", got)
+	}
+}
+
+func TestUserService_Search_Typo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil) // passwordHasher and tokenGenerator are nil because we don't need them for this test
+
+	candidates := []domain.User{{Name: "Katherine Lee", Email: "kate@example.com"}}
+	userRepo.EXPECT().Search(gomock.Any(), gomock.Any(), gomock.Any()).Return(candidates, nil)
+
+	results, err := userService.Search(context.Background(), &ports.SearchRequest{Query: "katherin"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+}
+
+func TestUserService_Search_NameOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil) // passwordHasher and tokenGenerator are nil because we don't need them for this test
+
+	candidates := []domain.User{
+		{Name: "Alice Brown", Email: "jon@example.com"},
+		{Name: "Jon Snow", Email: "snow@example.com"},
+	}
+	userRepo.EXPECT().Search(gomock.Any(), gomock.Any(), gomock.Any()).Return(candidates, nil)
+
+	results, err := userService.Search(context.Background(), &ports.SearchRequest{Query: "jon", Fields: []string{ports.FieldName}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(results) != 1 || results[0].User.Name != "Jon Snow" {
+		t.Fatalf("expected only the name match, got %+v", results)
+	}
+	if _, ok := results[0].Highlights["email"]; ok {
+		t.Fatalf("expected no email highlight, got %v", results[0].Highlights)
+	}
+}
+
+func TestUserService_Search_InvalidQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil) // passwordHasher and tokenGenerator are nil because we don't need them for this test
+
+	for _, query := range []string{"", "  ", "--", strings.Repeat("a", maxSearchQueryLength+1)} {
+		_, err := userService.Search(context.Background(), &ports.SearchRequest{Query: query})
+		if !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Fatalf("expected invalid argument for query %q, got %v", query, err)
+		}
+	}
+}
+
+func TestUserService_Search_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil) // passwordHasher and tokenGenerator are nil because we don't need them for this test
+
+	userRepo.EXPECT().Search(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("search error"))
+
+	_, err := userService.Search(context.Background(), &ports.SearchRequest{Query: "jon"})
+	if err == nil || err.Error() != "search error" {
+		t.Fatalf("expected error %v, got %v", "search error", err)
+	}
+}
+
 func TestUserService_Update_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -249,36 +710,111 @@ func TestUserService_Update_Success(t *testing.T) {
 	userRepo := mocks.NewMockUserRepository(ctrl)
 	userService := NewUserService(userRepo, nil, nil) // passwordHasher and tokenGenerator are nil because we don't need them for this test
 
-	user := &domain.User{
-		ID:       bson.ObjectID{},
-		Email:    "test@example.com",
-		Password: "password",
+	id := bson.NewObjectID()
+	want := &ports.UserUpdate{Name: ports.SetTo("John Doe")}
+	userRepo.EXPECT().Update(gomock.Any(), gomock.Eq(id), gomock.Eq(want)).Return(nil)
+
+	err := userService.Update(context.Background(), id, &ports.UserUpdate{Name: ports.SetTo(" John Doe ")})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
 	}
+}
 
-	userRepo.EXPECT().Update(gomock.Any(), gomock.Eq(user.ID), gomock.Eq(user)).Return(nil).AnyTimes()
+func TestUserService_Update_Profile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil) // passwordHasher and tokenGenerator are nil because we don't need them for this test
 
-	err := userService.Update(context.Background(), user.ID, user)
+	id := bson.NewObjectID()
+	want := &ports.UserUpdate{
+		Phone:  ports.SetTo("+66812345678"),
+		Locale: ports.SetTo("th-TH"),
+		Bio:    ports.Cleared[string](),
+	}
+	userRepo.EXPECT().Update(gomock.Any(), gomock.Eq(id), gomock.Eq(want)).Return(nil)
+
+	err := userService.Update(context.Background(), id, &ports.UserUpdate{
+		Phone:  ports.SetTo("+66 81 234 5678"),
+		Locale: ports.SetTo("th_th"),
+		Bio:    ports.SetTo("  "), // an empty optional field is cleared
+	})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 }
 
-func TestUserService_Update_Error(t *testing.T) {
+func TestUserService_Update_Invalid(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	userRepo := mocks.NewMockUserRepository(ctrl)
 	userService := NewUserService(userRepo, nil, nil) // passwordHasher and tokenGenerator are nil because we don't need them for this test
 
-	user := &domain.User{
-		ID:       bson.ObjectID{},
-		Email:    "test@example.com",
-		Password: "password",
+	updates := map[string]*ports.UserUpdate{
+		"empty":         {},
+		"clear name":    {Name: ports.Cleared[string]()},
+		"clear email":   {Email: ports.Cleared[string]()},
+		"short name":    {Name: ports.SetTo("Jo")},
+		"invalid email": {Email: ports.SetTo("not an email")},
+		"invalid phone": {Phone: ports.SetTo("0812345678")},
+		"invalid tz":    {TimeZone: ports.SetTo("Mars/Olympus")},
+	}
+	for name, update := range updates {
+		err := userService.Update(context.Background(), bson.NewObjectID(), update)
+		if !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Fatalf("%s: expected invalid argument error, got %v", name, err)
+		}
+	}
+}
+
+func TestUserService_Update_Attributes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	attributeRepo := mocks.NewMockAttributeSchemaRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil, WithAttributeSchema(attributeRepo))
+
+	attributeRepo.EXPECT().List(gomock.Any()).Return(domain.AttributeSchema{
+		{Name: "department", Type: domain.AttributeString, Required: true},
+		{Name: "floor", Type: domain.AttributeNumber},
+	}, nil).Times(2)
+
+	id := bson.NewObjectID()
+	want := &ports.UserUpdate{Attributes: map[string]ports.FieldUpdate[any]{
+		"floor":   ports.SetTo[any](float64(4)),
+		"retired": ports.Cleared[any](), // no longer in the schema, clearing it cleans up
+	}}
+	userRepo.EXPECT().Update(gomock.Any(), gomock.Eq(id), gomock.Eq(want)).Return(nil)
+
+	err := userService.Update(context.Background(), id, &ports.UserUpdate{Attributes: map[string]ports.FieldUpdate[any]{
+		"floor":   ports.SetTo[any](int64(4)),
+		"retired": ports.Cleared[any](),
+	}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = userService.Update(context.Background(), id, &ports.UserUpdate{Attributes: map[string]ports.FieldUpdate[any]{
+		"department": ports.Cleared[any](),
+	}})
+	if !errors.Is(err, domain.ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument error clearing a required attribute, got %v", err)
 	}
+}
+
+func TestUserService_Update_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
 
-	userRepo.EXPECT().Update(gomock.Any(), gomock.Eq(user.ID), gomock.Eq(user)).Return(errors.New("update error")).AnyTimes()
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil) // passwordHasher and tokenGenerator are nil because we don't need them for this test
 
-	err := userService.Update(context.Background(), user.ID, user)
+	userRepo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("update error"))
+
+	err := userService.Update(context.Background(), bson.NewObjectID(), &ports.UserUpdate{Name: ports.SetTo("John Doe")})
 	if err == nil {
 		t.Fatalf("expected error, got nil")
 	}
@@ -303,6 +839,77 @@ func TestUserService_Delete_Success(t *testing.T) {
 	}
 }
 
+func TestUserService_Delete_Settings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	settingsRepo := mocks.NewMockSettingsRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil, WithSettings(settingsRepo))
+
+	id := bson.NewObjectID()
+	gomock.InOrder(
+		userRepo.EXPECT().Delete(gomock.Any(), gomock.Eq(id)).Return(nil),
+		settingsRepo.EXPECT().Delete(gomock.Any(), gomock.Eq(id)).Return(nil),
+	)
+
+	if err := userService.Delete(context.Background(), id); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUserService_Delete_Groups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	groupRepo := mocks.NewMockGroupRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil, WithGroups(groupRepo))
+
+	id := bson.NewObjectID()
+	gomock.InOrder(
+		userRepo.EXPECT().Delete(gomock.Any(), gomock.Eq(id)).Return(nil),
+		groupRepo.EXPECT().RemoveFromAll(gomock.Any(), gomock.Eq(id)).Return(nil),
+	)
+
+	if err := userService.Delete(context.Background(), id); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUserService_Delete_Transaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	settingsRepo := mocks.NewMockSettingsRepository(ctrl)
+	txManager := mocks.NewMockTxManager(ctrl)
+	userService := NewUserService(userRepo, nil, nil, WithSettings(settingsRepo), WithTransactions(txManager))
+
+	// the settings fail after the user is deleted, the transaction gets the error to roll back
+	id := bson.NewObjectID()
+	settingsErr := errors.New("settings error")
+	txManager.EXPECT().WithinTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+			txCtx, _ := ports.NewUnitOfWork(ctx)
+			if err := fn(txCtx); !errors.Is(err, settingsErr) {
+				t.Fatalf("expected the settings error in the transaction, got %v", err)
+			}
+			return errors.New("rolled back")
+		})
+	inTx := func(ctx context.Context, _ bson.ObjectID) {
+		if !ports.InTx(ctx) {
+			t.Fatalf("expected the delete in the transaction")
+		}
+	}
+	userRepo.EXPECT().Delete(gomock.Any(), id).Do(inTx).Return(nil)
+	settingsRepo.EXPECT().Delete(gomock.Any(), id).Do(inTx).Return(settingsErr)
+
+	if err := userService.Delete(context.Background(), id); err == nil || err.Error() != "rolled back" {
+		t.Fatalf("expected the transaction error, got %v", err)
+	}
+}
+
 func TestUserService_Delete_Error(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
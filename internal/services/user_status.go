@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// statusTransitions lists the statuses each status can move to, deleted is final
+var statusTransitions = map[domain.Status][]domain.Status{
+	domain.StatusPendingVerification: {domain.StatusActive, domain.StatusSuspended, domain.StatusDeleted},
+	domain.StatusPendingApproval:     {domain.StatusActive, domain.StatusDeleted},
+	domain.StatusActive:              {domain.StatusSuspended, domain.StatusLocked, domain.StatusDeactivated, domain.StatusDeleted},
+	domain.StatusSuspended:           {domain.StatusActive, domain.StatusDeleted},
+	domain.StatusLocked:              {domain.StatusActive, domain.StatusSuspended, domain.StatusDeleted},
+	domain.StatusDeactivated:         {domain.StatusActive, domain.StatusDeleted},
+	domain.StatusDeleted:             {},
+}
+
+// reasonRequired lists the statuses that need a reason, so support can tell the user why
+var reasonRequired = map[domain.Status]bool{
+	domain.StatusSuspended: true,
+	domain.StatusLocked:    true,
+	domain.StatusDeleted:   true,
+}
+
+const maxStatusReasonLength = 500
+
+var errStatusChanged = fmt.Errorf("%w: status changed concurrently, retry", domain.ErrConflict)
+
+// checkTransition returns an error unless a user can move from one status to another for reason
+func checkTransition(from, to domain.Status, reason string) error {
+	if _, ok := statusTransitions[to]; !ok {
+		return fmt.Errorf("%w: unknown status %q", domain.ErrInvalidArgument, to)
+	}
+	if from == to {
+		return fmt.Errorf("%w: user is already %s", domain.ErrPrecondition, to)
+	}
+	if !slices.Contains(statusTransitions[from], to) {
+		return fmt.Errorf("%w: user can't go from %s to %s", domain.ErrPrecondition, from, to)
+	}
+	if reasonRequired[to] && reason == "" {
+		return fmt.Errorf("%w: a reason is required to make a user %s", domain.ErrInvalidArgument, to)
+	}
+	if len(reason) > maxStatusReasonLength {
+		return fmt.Errorf("%w: reason is longer than %d characters", domain.ErrInvalidArgument, maxStatusReasonLength)
+	}
+	return nil
+}
+
+// ChangeStatus moves a user to another status if the transition is allowed, see
+// statusTransitions. The change only applies if the status didn't change meanwhile.
+func (s *usersvc) ChangeStatus(ctx context.Context, id bson.ObjectID, to domain.Status, reason string) (*domain.User, error) {
+	reason = strings.TrimSpace(reason)
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, lookupError(err, errUserNotFound)
+	}
+	if err := checkTransition(user.Status, to, reason); err != nil {
+		return nil, err
+	}
+
+	change := &ports.StatusChange{From: user.Status, To: to, Reason: reason, At: time.Now()}
+	if err := s.userRepo.SetStatus(ctx, id, change); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, errStatusChanged
+		}
+		return nil, err
+	}
+
+	user.Status, user.StatusReason, user.StatusChangedAt = to, reason, change.At
+	return user, nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/mocks"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		from, to domain.Status
+		reason   string
+		want     error
+	}{
+		{domain.StatusActive, domain.StatusSuspended, "spam", nil},
+		{domain.StatusActive, domain.StatusSuspended, "", domain.ErrInvalidArgument},
+		{domain.StatusSuspended, domain.StatusActive, "", nil},
+		{domain.StatusLocked, domain.StatusActive, "", nil},
+		{domain.StatusPendingVerification, domain.StatusActive, "", nil},
+		{domain.StatusActive, domain.StatusActive, "", domain.ErrPrecondition},
+		{domain.StatusDeleted, domain.StatusActive, "", domain.ErrPrecondition},
+		{domain.StatusSuspended, domain.StatusLocked, "brute force", domain.ErrPrecondition},
+		{domain.StatusActive, "banned", "spam", domain.ErrInvalidArgument},
+	}
+	for _, tt := range tests {
+		err := checkTransition(tt.from, tt.to, tt.reason)
+		if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s -> %s: expected %v, got %v", tt.from, tt.to, tt.want, err)
+		}
+	}
+}
+
+func TestUserService_ChangeStatus_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	id := bson.NewObjectID()
+	userRepo.EXPECT().GetByID(gomock.Any(), id).Return(&domain.User{ID: id, Status: domain.StatusActive}, nil)
+	userRepo.EXPECT().SetStatus(gomock.Any(), id, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ bson.ObjectID, change *ports.StatusChange) error {
+			if change.From != domain.StatusActive || change.To != domain.StatusSuspended || change.Reason != "spam" {
+				t.Fatalf("unexpected change %+v", change)
+			}
+			return nil
+		})
+
+	user, err := userService.ChangeStatus(context.Background(), id, domain.StatusSuspended, " spam ")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if user.Status != domain.StatusSuspended || user.StatusReason != "spam" || user.StatusChangedAt.IsZero() {
+		t.Fatalf("expected a suspended user, got %+v", user)
+	}
+}
+
+func TestUserService_ChangeStatus_NotAllowed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	id := bson.NewObjectID()
+	userRepo.EXPECT().GetByID(gomock.Any(), id).Return(&domain.User{ID: id, Status: domain.StatusDeleted}, nil)
+
+	if _, err := userService.ChangeStatus(context.Background(), id, domain.StatusActive, ""); !errors.Is(err, domain.ErrPrecondition) {
+		t.Fatalf("expected failed precondition, got %v", err)
+	}
+}
+
+func TestUserService_ChangeStatus_Concurrent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	id := bson.NewObjectID()
+	userRepo.EXPECT().GetByID(gomock.Any(), id).Return(&domain.User{ID: id, Status: domain.StatusSuspended}, nil)
+	userRepo.EXPECT().SetStatus(gomock.Any(), id, gomock.Any()).Return(domain.ErrNotFound)
+
+	if _, err := userService.ChangeStatus(context.Background(), id, domain.StatusActive, ""); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+}
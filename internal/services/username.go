@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// defaultUsernameRedirectTTL is how long a previous username keeps pointing to its user
+const defaultUsernameRedirectTTL = 30 * 24 * time.Hour
+
+// WithUsernames sets the usernames that can't be claimed on top of domain.ReservedUsernames,
+// and how long a replaced username redirects to its user before anyone can claim it.
+func WithUsernames(policy domain.UsernamePolicy, redirectTTL time.Duration) UserServiceOption {
+	return func(s *usersvc) {
+		s.usernamePolicy = policy
+		s.usernameRedirectTTL = redirectTTL
+	}
+}
+
+func (s *usersvc) CheckUsername(ctx context.Context, username string) (string, error) {
+	canonical, err := domain.ParseUsername(username, s.usernamePolicy)
+	if err != nil {
+		return "", err
+	}
+	if err := s.checkUsernameFree(ctx, canonical, bson.ObjectID{}); err != nil {
+		return "", err
+	}
+	return canonical, nil
+}
+
+// checkUsernameFree fails unless username is unused or already belongs to the user with
+// id, whether as its current username or one still redirecting to it. The unique index
+// still has the last word on concurrent claims.
+func (s *usersvc) checkUsernameFree(ctx context.Context, username string, id bson.ObjectID) error {
+	owner, err := s.userRepo.GetByUsername(ctx, username)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return nil
+	case err != nil:
+		return err
+	case owner.ID != id:
+		return domain.ErrUsernameTaken
+	}
+	return nil
+}
+
+// GetByUsername doesn't check the charset of username, reserved or invalid usernames are
+// simply not found.
+func (s *usersvc) GetByUsername(ctx context.Context, username string, fields ...string) (*domain.User, string, error) {
+	user, err := s.userRepo.GetByUsername(ctx, strings.ToLower(strings.TrimSpace(username)))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, "", errUserNotFound
+		}
+		return nil, "", err
+	}
+	current := user.Username
+	ports.MaskUser(user, fields)
+	return user, current, nil
+}
+
+// changeUsername applies a username update, the replaced username redirects to the user
+// until the redirect TTL passes.
+func (s *usersvc) changeUsername(ctx context.Context, id bson.ObjectID, update ports.FieldUpdate[string]) error {
+	var username string
+	if update.Op == ports.Set {
+		username = update.Value
+		if err := s.checkUsernameFree(ctx, username, id); err != nil {
+			return err
+		}
+	}
+	return s.userRepo.SetUsername(ctx, id, username, time.Now().Add(s.usernameRedirectTTL))
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hinphansa/7-solutions-challenge/internal/domain"
+	"github.com/hinphansa/7-solutions-challenge/internal/mocks"
+	"github.com/hinphansa/7-solutions-challenge/internal/ports"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestUserService_Register_Username(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	passwordHasher := mocks.NewMockPasswordHasher(ctrl)
+	userService := NewUserService(userRepo, passwordHasher, nil)
+
+	id := bson.NewObjectID()
+	userRepo.EXPECT().GetByUsername(gomock.Any(), "jane_doe").Return(nil, domain.ErrNotFound)
+	passwordHasher.EXPECT().Hash("password").Return("hash", nil)
+	userRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user *domain.User) (*bson.ObjectID, error) {
+		if user.Username != "jane_doe" {
+			t.Fatalf("expected canonical username jane_doe, got %q", user.Username)
+		}
+		return &id, nil
+	})
+
+	_, err := userService.Register(context.Background(), &domain.User{Email: "jane@example.com", Username: " Jane_Doe ", Password: "password"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUserService_Register_UsernameTaken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	// the username still redirects to another user
+	userRepo.EXPECT().GetByUsername(gomock.Any(), "jane").Return(&domain.User{ID: bson.NewObjectID(), Username: "jane_doe"}, nil)
+
+	_, err := userService.Register(context.Background(), &domain.User{Email: "jane@example.com", Username: "jane", Password: "password"})
+	if !errors.Is(err, domain.ErrUsernameTaken) {
+		t.Fatalf("expected username taken error, got %v", err)
+	}
+}
+
+func TestUserService_CheckUsername(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil, WithUsernames(domain.UsernamePolicy{Reserved: []string{"acme"}}, time.Hour))
+
+	userRepo.EXPECT().GetByUsername(gomock.Any(), "jane").Return(nil, domain.ErrNotFound)
+	userRepo.EXPECT().GetByUsername(gomock.Any(), "john").Return(&domain.User{ID: bson.NewObjectID(), Username: "john"}, nil)
+
+	username, err := userService.CheckUsername(context.Background(), "Jane")
+	if err != nil || username != "jane" {
+		t.Fatalf("expected jane to be available, got %q, %v", username, err)
+	}
+	if _, err := userService.CheckUsername(context.Background(), "john"); !errors.Is(err, domain.ErrUsernameTaken) {
+		t.Fatalf("expected username taken error, got %v", err)
+	}
+	for _, reserved := range []string{"admin", "ACME"} {
+		if _, err := userService.CheckUsername(context.Background(), reserved); !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Fatalf("%s: expected invalid argument error, got %v", reserved, err)
+		}
+	}
+}
+
+func TestUserService_Update_Username(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil, WithUsernames(domain.UsernamePolicy{}, time.Hour))
+
+	id := bson.NewObjectID()
+	// reclaiming a previous username of the same user is allowed
+	userRepo.EXPECT().GetByUsername(gomock.Any(), "jane").Return(&domain.User{ID: id, Username: "jane_doe"}, nil)
+	userRepo.EXPECT().SetUsername(gomock.Any(), id, "jane", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ bson.ObjectID, _ string, until time.Time) error {
+			if d := time.Until(until); d < 59*time.Minute || d > time.Hour {
+				t.Fatalf("expected the redirect to last an hour, got %v", d)
+			}
+			return nil
+		})
+	userRepo.EXPECT().Update(gomock.Any(), id, gomock.Eq(&ports.UserUpdate{Name: ports.SetTo("Jane Doe")})).Return(nil)
+
+	err := userService.Update(context.Background(), id, &ports.UserUpdate{
+		Name:     ports.SetTo("Jane Doe"),
+		Username: ports.SetTo("JANE"),
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUserService_Update_ClearUsername(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	id := bson.NewObjectID()
+	userRepo.EXPECT().SetUsername(gomock.Any(), id, "", gomock.Any()).Return(nil)
+
+	// an empty username clears it, nothing else changes
+	if err := userService.Update(context.Background(), id, &ports.UserUpdate{Username: ports.SetTo(" ")}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUserService_Update_UsernameTaken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	userRepo.EXPECT().GetByUsername(gomock.Any(), "john").Return(&domain.User{ID: bson.NewObjectID(), Username: "john"}, nil)
+
+	err := userService.Update(context.Background(), bson.NewObjectID(), &ports.UserUpdate{Username: ports.SetTo("john")})
+	if !errors.Is(err, domain.ErrUsernameTaken) {
+		t.Fatalf("expected username taken error, got %v", err)
+	}
+}
+
+func TestUserService_GetByUsername(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(userRepo, nil, nil)
+
+	id := bson.NewObjectID()
+	userRepo.EXPECT().GetByUsername(gomock.Any(), "jane").Return(&domain.User{ID: id, Name: "Jane", Username: "jane_doe"}, nil)
+	userRepo.EXPECT().GetByUsername(gomock.Any(), "nobody").Return(nil, domain.ErrNotFound)
+
+	// found by a previous username, the current one is returned even when not selected
+	user, current, err := userService.GetByUsername(context.Background(), " Jane ", ports.FieldName)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if current != "jane_doe" || user.Name != "Jane" || user.Username != "" || !user.ID.IsZero() {
+		t.Fatalf("unexpected user %+v, current %q", user, current)
+	}
+
+	if _, _, err := userService.GetByUsername(context.Background(), "nobody"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}